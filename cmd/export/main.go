@@ -0,0 +1,93 @@
+// export 离线导出用户，筛选与排序参数与 GET /api/v1/users/export 相同。
+//
+// 用法：
+//
+//	go run ./cmd/export -format csv -columns id,name,email -query 'status=all&sort=-created_at' -o users.csv
+package main
+
+import (
+	"base-gin/internal/domain/user/repository"
+	"base-gin/internal/domain/user/vo"
+	"base-gin/internal/interfaces/handler/user"
+	"base-gin/pkg/dataformat"
+	"base-gin/wire"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	format := flag.String("format", dataformat.FormatCSV, "导出格式：csv、ndjson 或 json")
+	columns := flag.String("columns", "", "导出的列，逗号分隔，默认 id,name,email,created_at")
+	query := flag.String("query", "", "筛选与排序条件，格式同接口查询参数，例如 'status=all&filter=name:like:张&sort=-created_at'")
+	output := flag.String("o", "", "输出文件，默认为 users-<时间>.<格式>")
+	flag.Parse()
+
+	exportColumns, err := vo.ParseExportColumns(*columns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	values, err := url.ParseQuery(*query)
+	if err != nil {
+		log.Fatalf("query 格式错误: %v", err)
+	}
+	// 命令行直接访问数据库，可以导出已删除的用户，status 不经过接口的校验
+	status := values.Get("status")
+	values.Del("status")
+	criteria, err := user.ParseSearchCriteria(values)
+	if err != nil {
+		log.Fatalf("筛选条件错误: %v", err)
+	}
+	if status != "" {
+		if !repository.IsValidStatus(status) {
+			log.Fatalf("不支持的状态: %s", status)
+		}
+		criteria.Status = status
+	}
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("users-%s.%s", time.Now().Format("20060102-150405"), *format)
+	}
+
+	userService, cleanup, err := wire.InitializeUserService()
+	if err != nil {
+		log.Fatalf("初始化失败: %v", err)
+	}
+	defer cleanup()
+
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("创建输出文件失败: %v", err)
+	}
+	buffered := bufio.NewWriter(file)
+
+	writer, err := dataformat.NewWriter(*format, buffered, exportColumns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	count, err := userService.ExportUsers(ctx, criteria, exportColumns, writer)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("导出失败，已写出 %d 行到 %s: %v", count, path, err)
+	}
+
+	log.Printf("已导出 %d 个用户到 %s", count, path)
+}
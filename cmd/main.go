@@ -2,6 +2,8 @@ package main
 
 import (
 	"base-gin/wire"
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -18,6 +20,13 @@ func main() {
 	}
 	defer cleanup()
 
+	// 启动定时任务
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go app.PurgeJob.Start(jobCtx)
+	go app.OutboxRelay.Start(jobCtx)
+	go app.WebhookJob.Start(jobCtx)
+
 	// 启动 HTTP 服务器
 	server := &http.Server{
 		Addr:    ":8080",
@@ -32,6 +41,15 @@ func main() {
 		}
 	}()
 
+	// 管理端口与业务端口分开监听，pprof 等调试接口不对外暴露
+	if app.Admin != nil {
+		go func() {
+			if err := app.Admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("启动管理端口失败: %v", err)
+			}
+		}()
+	}
+
 	// 等待中断信号以优雅地关闭服务器
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -39,13 +57,25 @@ func main() {
 
 	app.Logger.Info("正在关闭服务器...")
 
-	// 给服务器 5 秒的时间来完成现有的请求
-	// ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	// defer cancel()
+	// 先让就绪检查失败，等待负载均衡摘除实例后再停止接收请求
+	app.Health.SetShuttingDown()
+	if delay := time.Duration(app.Config.Health.ShutdownDelaySeconds) * time.Second; delay > 0 {
+		time.Sleep(delay)
+	}
+	stopJobs()
+
+	// 停止接收新连接并等待处理中的请求完成，SSE 长连接在关闭开始时主动断开
+	server.RegisterOnShutdown(app.EventStream.Close)
+	timeout := time.Duration(app.Config.Server.ShutdownTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		app.Logger.Warn(fmt.Sprintf("服务器未能在超时前完成关闭，强制断开剩余连接: %v", err))
+		server.Close()
+	}
 
-	// 关闭服务器
-	if err := server.Close(); err != nil {
-		log.Fatalf("服务器关闭失败: %v", err)
+	if app.Admin != nil {
+		app.Admin.Close()
 	}
 
 	// 等待一秒钟让所有进程结束
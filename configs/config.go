@@ -6,15 +6,35 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Cache    CacheConfig
-	Log      LogConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Cache       CacheConfig
+	Log         LogConfig
+	Paging      PagingConfig
+	HTTPCache   HTTPCacheConfig
+	Retention   RetentionConfig
+	Outbox      OutboxConfig
+	Webhook     WebhookConfig
+	EventStream EventStreamConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	Health      HealthConfig
+	Admin       AdminConfig
+	Audit       AuditConfig
+	ErrorReport ErrorReportConfig
+	CORS        CORSConfig
+	Security    SecurityConfig
+	ClientIP    ClientIPConfig
+	Compression CompressionConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Mode string
+	// RequireIfMatch 为 true 时修改和删除请求必须携带 If-Match 请求头
+	RequireIfMatch bool
+	// ShutdownTimeoutSeconds 关闭时等待处理中请求完成的最长时间，超时后强制断开
+	ShutdownTimeoutSeconds int
 }
 
 type DatabaseConfig struct {
@@ -37,11 +57,250 @@ type LogConfig struct {
 	File  string
 }
 
+type PagingConfig struct {
+	// CursorSecret 游标签名密钥，为空时每次启动随机生成，多实例部署需显式配置
+	CursorSecret string
+}
+
+type HTTPCacheConfig struct {
+	// UserMaxAge、ListMaxAge 单个用户和用户列表响应的 Cache-Control max-age（秒），0 表示每次都需向服务端验证
+	UserMaxAge int
+	ListMaxAge int
+	// Vary 响应的 Vary 头
+	Vary string
+	// StoreEnabled 是否在服务端缓存完整响应，用户修改时按标签失效
+	StoreEnabled    bool
+	StoreTTL        int
+	StoreMaxEntries int
+}
+
+type RetentionConfig struct {
+	// DeletedUserDays 软删除的用户保留天数，超过后由定时任务永久删除，0 表示不启用
+	DeletedUserDays int
+	// PurgeIntervalHours 定时任务执行间隔（小时）
+	PurgeIntervalHours int
+	// PurgeDryRun 为 true 时只输出将被删除的用户，不实际删除
+	PurgeDryRun bool
+}
+
+type OutboxConfig struct {
+	// Enabled 为 true 时用户变更在同一事务中写入发件箱，并由中继投递到 Sink
+	Enabled bool
+	// Sink 投递目标：memory、file、http 或 nats
+	Sink        string
+	FilePath    string
+	HTTPURL     string
+	HTTPTimeout int
+	NATSURL     string
+	// NATSSubject 主题前缀，实际主题为 <前缀>.<事件名>
+	NATSSubject string
+	// NATSTimeout 连接和发布确认的超时时间（秒）
+	NATSTimeout int
+	// PollIntervalMs 中继轮询间隔（毫秒），BatchSize 每次领取的消息数，启用时都必须大于 0
+	PollIntervalMs int
+	BatchSize      int
+	// LeaseSeconds 领取消息的租约时长，中继崩溃后租约过期的消息由其他中继重新领取，必须大于 0
+	LeaseSeconds int
+	// MaxAttempts 最大投递次数，超过后消息进入 dead 状态不再重试
+	MaxAttempts int
+	// RetryBackoffSeconds、RetryMaxBackoffSeconds 失败重试的初始间隔和最大间隔，间隔按次数指数增长
+	RetryBackoffSeconds    int
+	RetryMaxBackoffSeconds int
+}
+
+type WebhookConfig struct {
+	// TimeoutSeconds 单次投递请求的超时时间
+	TimeoutSeconds int
+	// MaxAttempts 每个投递的最大尝试次数，之后标记为失败，可手动重新投递
+	MaxAttempts int
+	// RetryBackoffSeconds、RetryMaxBackoffSeconds 失败重试的初始间隔和最大间隔，间隔按次数指数增长并加入随机抖动
+	RetryBackoffSeconds    int
+	RetryMaxBackoffSeconds int
+	// DisableAfterFailures 订阅连续失败次数达到该值后自动停用，0 表示不自动停用
+	DisableAfterFailures int
+	// PollIntervalMs 投递任务轮询间隔（毫秒），BatchSize 每次领取的投递数，LeaseSeconds 领取的租约时长
+	PollIntervalMs int
+	BatchSize      int
+	LeaseSeconds   int
+	// AllowedNetworks 允许投递的内网 CIDR，逗号分隔；默认只投递到公网地址，回环、内网、链路本地地址（包括云元数据服务）一律拒绝
+	AllowedNetworks string
+}
+
+type EventStreamConfig struct {
+	// ReplayBufferSize 保留的最近事件数，客户端携带 Last-Event-ID 重连时从中补发
+	ReplayBufferSize int
+	// ClientBufferSize 每个连接的发送缓冲，写满时断开连接，由客户端重连补发
+	ClientBufferSize int
+	// HeartbeatSeconds 心跳间隔，防止代理因连接空闲而断开
+	HeartbeatSeconds int
+	// RetryMs 建议客户端断线后的重连间隔（毫秒）
+	RetryMs int
+	// Tokens 访问令牌及其可读取的事件类型，格式为 令牌:类型|类型，多个令牌以逗号分隔，* 表示全部；
+	// 为空时拒绝所有连接
+	Tokens string
+}
+
+type MetricsConfig struct {
+	// Enabled 为 true 时在 Path 暴露 Prometheus 文本格式的指标
+	Enabled bool
+	Path    string
+}
+
+type TracingConfig struct {
+	// Enabled 为 true 时记录请求、用户服务、用户仓储和 SQL 的 span
+	Enabled     bool
+	ServiceName string
+	// Exporter 导出方式：stdout、otlp 或 file
+	Exporter string
+	// OTLPEndpoint OTLP/HTTP 接收地址，OTLPHeaders 附加的请求头，格式为 键=值，多个以逗号分隔
+	OTLPEndpoint       string
+	OTLPHeaders        string
+	OTLPTimeoutSeconds int
+	FilePath           string
+	// SampleRatio 没有上游链路时的采样比例（0 到 1），有上游链路时沿用 traceparent 的采样标记
+	SampleRatio float64
+	// BatchSize 每批导出的 span 数，BatchTimeoutMs 未满一批时的导出间隔（毫秒），QueueSize 等待导出的 span 上限
+	BatchSize      int
+	BatchTimeoutMs int
+	QueueSize      int
+}
+
+type HealthConfig struct {
+	// CheckTimeoutMs 单项检查的超时（毫秒），CacheTTLMs 检查结果的缓存时间（毫秒），避免探针频繁访问依赖
+	CheckTimeoutMs int
+	CacheTTLMs     int
+	// MinFreeDiskMB SQLite 数据目录所在磁盘的最小可用空间（MB），0 表示不检查
+	MinFreeDiskMB int
+	// HeartbeatTimeoutSeconds 后台任务超过该时间（且超过 3 个轮询间隔）没有心跳时存活检查失败
+	HeartbeatTimeoutSeconds int
+	// RedisCheckEnabled 为 true 时检查 Redis 连通性，失败只降级不影响就绪
+	RedisCheckEnabled bool
+	// ShutdownDelaySeconds 收到退出信号后先让就绪检查失败，等待该时间让负载均衡摘除实例再关闭服务
+	ShutdownDelaySeconds int
+}
+
+type AdminConfig struct {
+	// Enabled 为 true 时在 Addr 启动独立的管理 HTTP 服务（pprof、运行信息、日志级别、维护模式），不经过业务端口
+	Enabled bool
+	Addr    string
+	// Token 访问令牌，通过 Authorization: Bearer 传递；为空时 Addr 必须是回环地址，且只接受本机请求。
+	// 业务端口上的管理接口（/api/v1/admin、/api/v1/audit、/api/v1/webhooks）同样使用该令牌，
+	// 为空时只有配置了 IP_FILTER_ADMIN_ALLOW 才允许访问
+	Token string
+}
+
+type AuditConfig struct {
+	// ActorHeader 携带操作者的请求头，由认证网关设置，审计记录以此作为操作者；只有对端为可信代理时视为已认证
+	ActorHeader string
+}
+
+type ErrorReportConfig struct {
+	// DSN Sentry 协议的上报地址，为空时不上报，panic 仍记录到日志
+	DSN         string
+	Environment string
+	Release     string
+	// RateLimit 每分钟最多上报的事件数，超过后丢弃
+	RateLimit int
+	// BatchSize 每批发送的事件数，FlushIntervalMs 未满一批时的发送间隔（毫秒），同一批中相同的错误合并发送
+	BatchSize       int
+	FlushIntervalMs int
+	QueueSize       int
+	TimeoutSeconds  int
+}
+
+type CORSConfig struct {
+	// AllowedOrigins 允许的来源，逗号分隔：* 表示任意来源，https://*.example.com 匹配子域名，
+	// regex: 开头的按正则表达式匹配（表达式中的逗号写作 \x2c）。为空时拒绝所有跨域请求
+	AllowedOrigins string
+	AllowedMethods string
+	AllowedHeaders string
+	ExposedHeaders string
+	// AllowCredentials 为 true 时允许携带 Cookie 和 Authorization，此时 AllowedOrigins 不能为 *
+	AllowCredentials bool
+	// MaxAgeSeconds 浏览器缓存预检结果的时间（秒）
+	MaxAgeSeconds int
+	// AdminAllowedOrigins 管理接口（/api/v1/admin、/api/v1/audit、/api/v1/webhooks）允许的来源，为空时与 AllowedOrigins 相同
+	AdminAllowedOrigins string
+}
+
+type SecurityConfig struct {
+	// Profile 安全配置档：production 或 development，决定以下各项的默认值，
+	// GIN_MODE=release 时默认为 production
+	Profile string
+	// HSTSMaxAgeSeconds Strict-Transport-Security 的有效期（秒），0 表示不发送，production 默认一年
+	HSTSMaxAgeSeconds     int
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// ContentSecurityPolicy 内容安全策略，{nonce} 替换为每个请求生成的随机值，为空时不发送
+	ContentSecurityPolicy string
+	// CSPReportOnly 为 true 时只报告不拦截，development 默认开启
+	CSPReportOnly     bool
+	ReferrerPolicy    string
+	PermissionsPolicy string
+	FrameOptions      string
+	// MaxBodyBytes 请求体大小上限，ImportMaxBodyBytes 为导入接口单独的上限
+	MaxBodyBytes       int
+	ImportMaxBodyBytes int
+	// MaxURLLength 请求地址的最大字节数，MaxHeaderBytes 请求头的最大总字节数，0 表示不限制
+	MaxURLLength   int
+	MaxHeaderBytes int
+	// RejectUnknownFields 为 true 时 JSON 请求体包含请求结构中没有的字段返回 400，production 默认开启
+	RejectUnknownFields bool
+}
+
+type ClientIPConfig struct {
+	// TrustedProxies 可信代理的 CIDR，逗号分隔；只有直接连接的对端在其中时才读取转发请求头，为空时客户端 IP 即对端地址
+	TrustedProxies string
+	// Headers 按顺序尝试的转发请求头：X-Forwarded-For、X-Real-IP、Forwarded
+	Headers string
+	// APIAllow、APIDeny 业务接口（/api/v1）允许和拒绝的 CIDR，逗号分隔，拒绝优先，允许列表为空时不限制
+	APIAllow string
+	APIDeny  string
+	// AdminAllow、AdminDeny 管理接口（/api/v1/admin、/api/v1/audit、/api/v1/webhooks）允许和拒绝的 CIDR
+	AdminAllow string
+	AdminDeny  string
+	// MetricsAllow、MetricsDeny 指标接口允许和拒绝的 CIDR
+	MetricsAllow string
+	MetricsDeny  string
+}
+
+type CompressionConfig struct {
+	// Enabled 为 false 时不压缩响应，请求体解压不受影响
+	Enabled bool
+	// Level 压缩级别，-1 为默认级别，1 最快，9 压缩率最高
+	Level int
+	// MinSize 响应体达到该字节数才压缩，流式响应不受限制
+	MinSize int
+	// Encodings 按偏好排列的编码，逗号分隔，内置 gzip、deflate
+	Encodings string
+	// ContentTypes 压缩的响应类型，逗号分隔，text/* 匹配所有 text 类型
+	ContentTypes string
+	// MaxDecompressedBytes gzip 请求体解压后的大小上限，防止压缩炸弹
+	MaxDecompressedBytes int
+}
+
+// SecurityProfileProduction 生产环境的安全配置档
+const SecurityProfileProduction = "production"
+
 func LoadConfig() *Config {
+	defaultProfile := "development"
+	if getEnv("GIN_MODE", "debug") == "release" {
+		defaultProfile = SecurityProfileProduction
+	}
+	profile := getEnv("SECURITY_PROFILE", defaultProfile)
+	production := profile == SecurityProfileProduction
+	hstsMaxAge := 0
+	if production {
+		hstsMaxAge = 365 * 24 * 3600
+	}
+
 	return &Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
 			Mode: getEnv("GIN_MODE", "debug"),
+
+			RequireIfMatch:         getEnvAsBool("REQUIRE_IF_MATCH", false),
+			ShutdownTimeoutSeconds: getEnvAsInt("SERVER_SHUTDOWN_TIMEOUT_SECONDS", 10),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -60,6 +319,142 @@ func LoadConfig() *Config {
 			Level: getEnv("LOG_LEVEL", "info"),
 			File:  getEnv("LOG_FILE", "app.log"),
 		},
+		Paging: PagingConfig{
+			CursorSecret: getEnv("CURSOR_SECRET", ""),
+		},
+		HTTPCache: HTTPCacheConfig{
+			UserMaxAge:      getEnvAsInt("HTTP_CACHE_USER_MAX_AGE", 0),
+			ListMaxAge:      getEnvAsInt("HTTP_CACHE_LIST_MAX_AGE", 0),
+			Vary:            getEnv("HTTP_CACHE_VARY", "Accept, Accept-Encoding"),
+			StoreEnabled:    getEnvAsBool("HTTP_CACHE_STORE", false),
+			StoreTTL:        getEnvAsInt("HTTP_CACHE_STORE_TTL", 60),
+			StoreMaxEntries: getEnvAsInt("HTTP_CACHE_STORE_MAX_ENTRIES", 1000),
+		},
+		Retention: RetentionConfig{
+			DeletedUserDays:    getEnvAsInt("DELETED_USER_RETENTION_DAYS", 0),
+			PurgeIntervalHours: getEnvAsInt("USER_PURGE_INTERVAL_HOURS", 24),
+			PurgeDryRun:        getEnvAsBool("USER_PURGE_DRY_RUN", false),
+		},
+		Outbox: OutboxConfig{
+			Enabled:                getEnvAsBool("OUTBOX_ENABLED", false),
+			Sink:                   getEnv("OUTBOX_SINK", "file"),
+			FilePath:               getEnv("OUTBOX_FILE_PATH", "data/outbox.jsonl"),
+			HTTPURL:                getEnv("OUTBOX_HTTP_URL", ""),
+			HTTPTimeout:            getEnvAsInt("OUTBOX_HTTP_TIMEOUT_SECONDS", 10),
+			NATSURL:                getEnv("OUTBOX_NATS_URL", "nats://localhost:4222"),
+			NATSSubject:            getEnv("OUTBOX_NATS_SUBJECT", "events"),
+			NATSTimeout:            getEnvAsInt("OUTBOX_NATS_TIMEOUT_SECONDS", 5),
+			PollIntervalMs:         getEnvAsInt("OUTBOX_POLL_INTERVAL_MS", 1000),
+			BatchSize:              getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			LeaseSeconds:           getEnvAsInt("OUTBOX_LEASE_SECONDS", 30),
+			MaxAttempts:            getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryBackoffSeconds:    getEnvAsInt("OUTBOX_RETRY_BACKOFF_SECONDS", 1),
+			RetryMaxBackoffSeconds: getEnvAsInt("OUTBOX_RETRY_MAX_BACKOFF_SECONDS", 600),
+		},
+		Webhook: WebhookConfig{
+			TimeoutSeconds:         getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			MaxAttempts:            getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoffSeconds:    getEnvAsInt("WEBHOOK_RETRY_BACKOFF_SECONDS", 10),
+			RetryMaxBackoffSeconds: getEnvAsInt("WEBHOOK_RETRY_MAX_BACKOFF_SECONDS", 3600),
+			DisableAfterFailures:   getEnvAsInt("WEBHOOK_DISABLE_AFTER_FAILURES", 20),
+			PollIntervalMs:         getEnvAsInt("WEBHOOK_POLL_INTERVAL_MS", 1000),
+			BatchSize:              getEnvAsInt("WEBHOOK_BATCH_SIZE", 50),
+			LeaseSeconds:           getEnvAsInt("WEBHOOK_LEASE_SECONDS", 60),
+			AllowedNetworks:        getEnv("WEBHOOK_ALLOWED_NETWORKS", ""),
+		},
+		EventStream: EventStreamConfig{
+			ReplayBufferSize: getEnvAsInt("EVENT_STREAM_REPLAY_BUFFER", 1000),
+			ClientBufferSize: getEnvAsInt("EVENT_STREAM_CLIENT_BUFFER", 64),
+			HeartbeatSeconds: getEnvAsInt("EVENT_STREAM_HEARTBEAT_SECONDS", 15),
+			RetryMs:          getEnvAsInt("EVENT_STREAM_RETRY_MS", 3000),
+			Tokens:           getEnv("EVENT_STREAM_TOKENS", ""),
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvAsBool("METRICS_ENABLED", true),
+			Path:    getEnv("METRICS_PATH", "/metrics"),
+		},
+		Tracing: TracingConfig{
+			Enabled:            getEnvAsBool("TRACING_ENABLED", false),
+			ServiceName:        getEnv("TRACING_SERVICE_NAME", "base-gin"),
+			Exporter:           getEnv("TRACING_EXPORTER", "stdout"),
+			OTLPEndpoint:       getEnv("TRACING_OTLP_ENDPOINT", "http://localhost:4318/v1/traces"),
+			OTLPHeaders:        getEnv("TRACING_OTLP_HEADERS", ""),
+			OTLPTimeoutSeconds: getEnvAsInt("TRACING_OTLP_TIMEOUT_SECONDS", 10),
+			FilePath:           getEnv("TRACING_FILE_PATH", "data/traces.jsonl"),
+			SampleRatio:        getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+			BatchSize:          getEnvAsInt("TRACING_BATCH_SIZE", 512),
+			BatchTimeoutMs:     getEnvAsInt("TRACING_BATCH_TIMEOUT_MS", 5000),
+			QueueSize:          getEnvAsInt("TRACING_QUEUE_SIZE", 2048),
+		},
+		Health: HealthConfig{
+			CheckTimeoutMs:          getEnvAsInt("HEALTH_CHECK_TIMEOUT_MS", 2000),
+			CacheTTLMs:              getEnvAsInt("HEALTH_CACHE_TTL_MS", 1000),
+			MinFreeDiskMB:           getEnvAsInt("HEALTH_MIN_FREE_DISK_MB", 100),
+			HeartbeatTimeoutSeconds: getEnvAsInt("HEALTH_HEARTBEAT_TIMEOUT_SECONDS", 300),
+			RedisCheckEnabled:       getEnvAsBool("HEALTH_REDIS_CHECK_ENABLED", false),
+			ShutdownDelaySeconds:    getEnvAsInt("HEALTH_SHUTDOWN_DELAY_SECONDS", 5),
+		},
+		Admin: AdminConfig{
+			Enabled: getEnvAsBool("ADMIN_ENABLED", false),
+			Addr:    getEnv("ADMIN_ADDR", "127.0.0.1:6060"),
+			Token:   getEnv("ADMIN_TOKEN", ""),
+		},
+		Audit: AuditConfig{
+			ActorHeader: getEnv("AUDIT_ACTOR_HEADER", "X-Actor"),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:      getEnv("CORS_ALLOWED_ORIGINS", ""),
+			AllowedMethods:      getEnv("CORS_ALLOWED_METHODS", "GET, POST, PUT, PATCH, DELETE"),
+			AllowedHeaders:      getEnv("CORS_ALLOWED_HEADERS", "Origin, Content-Type, Accept, Authorization, If-Match, If-None-Match, Last-Event-ID, traceparent, tracestate, X-Request-ID"),
+			ExposedHeaders:      getEnv("CORS_EXPOSED_HEADERS", "ETag, Link, Retry-After, X-Request-ID"),
+			AllowCredentials:    getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAgeSeconds:       getEnvAsInt("CORS_MAX_AGE_SECONDS", 600),
+			AdminAllowedOrigins: getEnv("CORS_ADMIN_ALLOWED_ORIGINS", ""),
+		},
+		Security: SecurityConfig{
+			Profile:               profile,
+			HSTSMaxAgeSeconds:     getEnvAsInt("SECURITY_HSTS_MAX_AGE_SECONDS", hstsMaxAge),
+			HSTSIncludeSubdomains: getEnvAsBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", production),
+			HSTSPreload:           getEnvAsBool("SECURITY_HSTS_PRELOAD", false),
+			ContentSecurityPolicy: getEnv("SECURITY_CSP", "default-src 'none'; script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'"),
+			CSPReportOnly:         getEnvAsBool("SECURITY_CSP_REPORT_ONLY", !production),
+			ReferrerPolicy:        getEnv("SECURITY_REFERRER_POLICY", "no-referrer"),
+			PermissionsPolicy:     getEnv("SECURITY_PERMISSIONS_POLICY", "camera=(), microphone=(), geolocation=(), payment=(), usb=()"),
+			FrameOptions:          getEnv("SECURITY_FRAME_OPTIONS", "DENY"),
+			MaxBodyBytes:          getEnvAsInt("SECURITY_MAX_BODY_BYTES", 1<<20),
+			ImportMaxBodyBytes:    getEnvAsInt("SECURITY_IMPORT_MAX_BODY_BYTES", 32<<20),
+			MaxURLLength:          getEnvAsInt("SECURITY_MAX_URL_LENGTH", 8<<10),
+			MaxHeaderBytes:        getEnvAsInt("SECURITY_MAX_HEADER_BYTES", 32<<10),
+			RejectUnknownFields:   getEnvAsBool("SECURITY_REJECT_UNKNOWN_FIELDS", production),
+		},
+		ClientIP: ClientIPConfig{
+			TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+			Headers:        getEnv("CLIENT_IP_HEADERS", "X-Forwarded-For, X-Real-IP"),
+			APIAllow:       getEnv("IP_FILTER_API_ALLOW", ""),
+			APIDeny:        getEnv("IP_FILTER_API_DENY", ""),
+			AdminAllow:     getEnv("IP_FILTER_ADMIN_ALLOW", ""),
+			AdminDeny:      getEnv("IP_FILTER_ADMIN_DENY", ""),
+			MetricsAllow:   getEnv("IP_FILTER_METRICS_ALLOW", ""),
+			MetricsDeny:    getEnv("IP_FILTER_METRICS_DENY", ""),
+		},
+		Compression: CompressionConfig{
+			Enabled:              getEnvAsBool("COMPRESSION_ENABLED", true),
+			Level:                getEnvAsInt("COMPRESSION_LEVEL", -1),
+			MinSize:              getEnvAsInt("COMPRESSION_MIN_SIZE", 1024),
+			Encodings:            getEnv("COMPRESSION_ENCODINGS", "gzip, deflate"),
+			ContentTypes:         getEnv("COMPRESSION_CONTENT_TYPES", "application/json, application/problem+json, application/x-ndjson, text/*"),
+			MaxDecompressedBytes: getEnvAsInt("COMPRESSION_MAX_DECOMPRESSED_BYTES", 32<<20),
+		},
+		ErrorReport: ErrorReportConfig{
+			DSN:             getEnv("ERROR_REPORT_DSN", ""),
+			Environment:     getEnv("ERROR_REPORT_ENVIRONMENT", getEnv("GIN_MODE", "debug")),
+			Release:         getEnv("ERROR_REPORT_RELEASE", ""),
+			RateLimit:       getEnvAsInt("ERROR_REPORT_RATE_LIMIT", 60),
+			BatchSize:       getEnvAsInt("ERROR_REPORT_BATCH_SIZE", 20),
+			FlushIntervalMs: getEnvAsInt("ERROR_REPORT_FLUSH_INTERVAL_MS", 1000),
+			QueueSize:       getEnvAsInt("ERROR_REPORT_QUEUE_SIZE", 100),
+			TimeoutSeconds:  getEnvAsInt("ERROR_REPORT_TIMEOUT_SECONDS", 5),
+		},
 	}
 }
 
@@ -77,3 +472,19 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,65 @@
+package configs
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redactedValue 替换敏感配置的值
+const redactedValue = "[REDACTED]"
+
+// sensitiveFieldMarkers 字段名包含这些词时视为敏感配置
+var sensitiveFieldMarkers = []string{"Password", "Secret", "Token", "Headers", "Key", "DSN"}
+
+// Redacted 返回用于展示的配置，密码、密钥、令牌、请求头和 DSN 替换为 [REDACTED]，URL 中的密码同样隐藏
+func (c *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		switch {
+		case value.Kind() == reflect.Struct:
+			out[field.Name] = redactStruct(value)
+		case isSensitive(field.Name):
+			if value.IsZero() {
+				out[field.Name] = value.Interface()
+			} else {
+				out[field.Name] = redactedValue
+			}
+		case value.Kind() == reflect.String && (strings.HasSuffix(field.Name, "URL") || strings.HasSuffix(field.Name, "Endpoint")):
+			out[field.Name] = redactURL(value.String())
+		default:
+			out[field.Name] = value.Interface()
+		}
+	}
+	return out
+}
+
+func isSensitive(name string) bool {
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL 隐藏 URL 中的密码，无法解析时整体隐藏
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	return u.Redacted()
+}
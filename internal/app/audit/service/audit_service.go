@@ -0,0 +1,126 @@
+package service
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"base-gin/internal/domain/audit/repository"
+	"base-gin/internal/domain/audit/vo"
+	"base-gin/pkg/requestmeta"
+	"context"
+	"time"
+)
+
+// verifyBatchSize 校验哈希链时每批读取的记录数
+const verifyBatchSize = 500
+
+// systemActor context 中没有操作者时记录的操作者
+const systemActor = "system"
+
+// Record 待写入的一条审计记录
+type Record struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Changes    map[string]entity.Change
+}
+
+type AuditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record 记录一次操作，操作者及其来源、请求 ID、IP 和 User-Agent 取自 context。
+// ctx 中有数据库事务时在该事务中写入，与业务变更一起提交或回滚。
+// s 为 nil 时不记录，便于不需要审计的命令行工具复用业务服务
+func (s *AuditService) Record(ctx context.Context, records ...Record) error {
+	if s == nil || len(records) == 0 {
+		return nil
+	}
+
+	meta := requestmeta.FromContext(ctx)
+	actor, source := meta.Actor, meta.ActorSource
+	if actor == "" {
+		actor, source = systemActor, requestmeta.ActorSourceSystem
+	}
+	now := time.Now()
+	entries := make([]*entity.Entry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, &entity.Entry{
+			Actor:       actor,
+			ActorSource: source,
+			Action:      r.Action,
+			TargetType:  r.TargetType,
+			TargetID:    r.TargetID,
+			Changes:     r.Changes,
+			RequestID:   meta.RequestID,
+			IP:          meta.IP,
+			UserAgent:   meta.UserAgent,
+			CreatedAt:   now,
+		})
+	}
+	return s.repo.Append(ctx, entries...)
+}
+
+// ListEntries 分页查询审计记录，最新的在前
+func (s *AuditService) ListEntries(ctx context.Context, criteria repository.AuditCriteria) ([]*vo.AuditEntryResponse, int64, error) {
+	entries, total, err := s.repo.List(ctx, criteria)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*vo.AuditEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		responses = append(responses, toEntryResponse(e))
+	}
+	return responses, total, nil
+}
+
+// Verify 从第一条记录开始逐条校验哈希链，遇到第一条不连续或被修改的记录时停止
+func (s *AuditService) Verify(ctx context.Context) (*vo.AuditVerifyResponse, error) {
+	result := &vo.AuditVerifyResponse{Valid: true}
+	var prev *entity.Entry
+	for {
+		var afterID int64
+		if prev != nil {
+			afterID = prev.ID
+		}
+		entries, err := s.repo.ListAfter(ctx, afterID, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			result.Checked++
+			if err := e.Follows(prev); err != nil {
+				result.Valid = false
+				result.BrokenAt = e.ID
+				result.Error = err.Error()
+				return result, nil
+			}
+			prev = e
+			result.HeadID, result.HeadHash = e.ID, e.Hash
+		}
+		if len(entries) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func toEntryResponse(e *entity.Entry) *vo.AuditEntryResponse {
+	return &vo.AuditEntryResponse{
+		ID:          e.ID,
+		Actor:       e.Actor,
+		ActorSource: e.ActorSource,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Changes:     e.Changes,
+		RequestID:   e.RequestID,
+		IP:          e.IP,
+		UserAgent:   e.UserAgent,
+		CreatedAt:   e.CreatedAt,
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}
+}
@@ -0,0 +1,91 @@
+package job
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/app/user/service"
+	"base-gin/internal/domain/user/vo"
+	"base-gin/pkg/health"
+	"base-gin/pkg/requestmeta"
+	"context"
+	"log"
+	"time"
+)
+
+// PurgeJob 定时永久删除超过保留期的软删除用户
+type PurgeJob struct {
+	userService *service.UserService
+	retention   time.Duration
+	interval    time.Duration
+	dryRun      bool
+	health      *health.Checker
+}
+
+// NewPurgeJob checker 不为 nil 时运行期间注册心跳检查
+func NewPurgeJob(config *configs.Config, userService *service.UserService, checker *health.Checker) *PurgeJob {
+	return &PurgeJob{
+		userService: userService,
+		health:      checker,
+		retention:   time.Duration(config.Retention.DeletedUserDays) * 24 * time.Hour,
+		interval:    time.Duration(config.Retention.PurgeIntervalHours) * time.Hour,
+		dryRun:      config.Retention.PurgeDryRun,
+	}
+}
+
+// Enabled 是否配置了保留期
+func (j *PurgeJob) Enabled() bool {
+	return j.retention > 0
+}
+
+// Start 立即执行一次，之后按间隔执行，直到 ctx 取消
+func (j *PurgeJob) Start(ctx context.Context) {
+	if !j.Enabled() {
+		log.Println("未配置 DELETED_USER_RETENTION_DAYS，不自动清理已删除用户")
+		return
+	}
+
+	interval := j.interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	heartbeat := j.health.Heartbeat("job:purge", interval)
+	defer heartbeat.Stop()
+
+	for {
+		heartbeat.Beat()
+		if _, err := j.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("清理已删除用户失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeActor 清理任务在审计记录中的操作者
+const purgeActor = "system:purge-job"
+
+// Run 执行一次清理并输出结果
+func (j *PurgeJob) Run(ctx context.Context) (*vo.PurgeReport, error) {
+	ctx = requestmeta.WithActor(ctx, purgeActor, requestmeta.ActorSourceSystem)
+	before := time.Now().Add(-j.retention)
+	report, err := j.userService.PurgeDeletedUsers(ctx, before, j.dryRun)
+	if report == nil {
+		return nil, err
+	}
+
+	action := "已永久删除"
+	if report.DryRun {
+		action = "[dry-run] 将永久删除"
+	}
+	for _, user := range report.Users {
+		log.Printf("%s用户 id=%d email=%s deleted_at=%s", action, user.ID, user.Email, user.DeletedAt.Format(time.RFC3339))
+	}
+	log.Printf("%s %d 个在 %s 之前删除的用户", action, report.Count, before.Format(time.RFC3339))
+
+	return report, err
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	auditService "base-gin/internal/app/audit/service"
+	auditEntity "base-gin/internal/domain/audit/entity"
+	"base-gin/internal/domain/user/entity"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// userSnapshot 审计比较的用户字段。密码只保存 SHA-256 摘要，用于判断是否修改，
+// 快照中不出现密码原文；Diff 按字段名将其标记为已脱敏，摘要也不会写入审计记录
+func userSnapshot(user *entity.User) map[string]any {
+	return map[string]any{
+		entity.FieldName:     user.Name.String(),
+		entity.FieldEmail:    user.Email.String(),
+		entity.FieldPassword: passwordDigest(user),
+		"deleted_at":         user.DeletedAt,
+	}
+}
+
+// passwordDigest 返回密码的 SHA-256 摘要，未设置密码时为空字符串
+func passwordDigest(user *entity.User) string {
+	if user.Password.IsZero() {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(user.Password.Value()))
+	return hex.EncodeToString(sum[:])
+}
+
+// userRecord 生成一条用户审计记录，before、after 为 nil 时不记录字段变更
+func userRecord(action string, id int, before, after map[string]any) auditService.Record {
+	record := auditService.Record{
+		Action:     action,
+		TargetType: auditEntity.TargetTypeUser,
+		TargetID:   strconv.Itoa(id),
+	}
+	if before != nil || after != nil {
+		record.Changes = auditEntity.Diff(before, after)
+	}
+	return record
+}
+
+// audit 在 ctx 的事务中写入审计记录，失败时调用方应回滚本次修改
+func (s *UserService) audit(ctx context.Context, records ...auditService.Record) error {
+	if err := s.auditor.Record(ctx, records...); err != nil {
+		return fmt.Errorf("写入审计记录失败: %w", err)
+	}
+	return nil
+}
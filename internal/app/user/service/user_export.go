@@ -0,0 +1,47 @@
+package service
+
+import (
+	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/repository"
+	"base-gin/pkg/dataformat"
+	"context"
+)
+
+// ExportUsers 按条件流式导出用户到 w，columns 需已通过 vo.ParseExportColumns 校验，返回导出的行数。
+// 写出失败（如客户端断开）时立即停止读取数据库
+func (s *UserService) ExportUsers(ctx context.Context, criteria repository.UserSearchCriteria, columns []string, w dataformat.Writer) (int, error) {
+	ctx, span := s.startSpan(ctx, "ExportUsers")
+	defer span.End()
+
+	count := 0
+	values := make([]interface{}, len(columns))
+	err := s.userRepo.Stream(ctx, criteria, func(user *entity.User) error {
+		for i, column := range columns {
+			values[i] = userExportValue(user, column)
+		}
+		count++
+		return w.Write(values)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, w.Close()
+}
+
+func userExportValue(user *entity.User, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID
+	case "name":
+		return user.Name.String()
+	case "email":
+		return user.Email.String()
+	case "created_at":
+		return user.CreatedAt
+	case "updated_at":
+		return user.UpdatedAt
+	case "deleted_at":
+		return user.DeletedAt
+	}
+	return nil
+}
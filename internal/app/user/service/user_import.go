@@ -0,0 +1,328 @@
+package service
+
+import (
+	auditService "base-gin/internal/app/audit/service"
+	auditEntity "base-gin/internal/domain/audit/entity"
+	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/vo"
+	"base-gin/pkg/dataformat"
+	"context"
+	"errors"
+	"io"
+)
+
+// defaultImportBatchSize 默认每个事务写入的行数
+const defaultImportBatchSize = 500
+
+// errImportAborted fail-fast 导入遇到无效行，用于回滚整个导入的事务
+var errImportAborted = errors.New("导入已停止，存在无效行")
+
+// importRow 已通过字段校验、等待写入的行
+type importRow struct {
+	result   *vo.ImportRowResult
+	name     vo.UserName
+	email    vo.Email
+	password string
+	user     *entity.User
+	// before 更新前的审计快照，新建的行为 nil
+	before map[string]any
+}
+
+// userImporter 单次导入的状态
+type userImporter struct {
+	service *UserService
+	opts    vo.ImportOptions
+	report  *vo.ImportReport
+	// seen 文件中已出现的邮箱
+	seen  map[string]bool
+	batch []*importRow
+	// pending 不为 nil 时写入后暂不发布事件，由调用方在外层事务提交后发布
+	pending []*entity.User
+}
+
+// ImportUsers 逐行读取并导入用户，校验规则与 CreateUser 一致。
+// 每 BatchSize 行查询一次已存在的邮箱并在同一事务中写入；dry-run 时只返回每行的预期结果。
+// fail-fast 模式下整个导入在一个事务中执行，遇到无效行时回滚之前写入的所有批次，事件在提交后才发布
+func (s *UserService) ImportUsers(ctx context.Context, reader dataformat.Reader, opts vo.ImportOptions) (*vo.ImportReport, error) {
+	ctx, span := s.startSpan(ctx, "ImportUsers")
+	defer span.End()
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatchSize
+	}
+
+	im := &userImporter{
+		service: s,
+		opts:    opts,
+		report: &vo.ImportReport{
+			Mode:   opts.Mode,
+			DryRun: opts.DryRun,
+			Rows:   []*vo.ImportRowResult{},
+		},
+		seen: make(map[string]bool),
+	}
+
+	// 没有 transactor 时无法回滚，fail-fast 与其他模式一样按批次提交
+	if opts.Mode != vo.ImportModeFailFast || opts.DryRun || s.transactor == nil {
+		return im.report, im.run(ctx, reader)
+	}
+
+	im.pending = []*entity.User{}
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		if err := im.run(ctx, reader); err != nil {
+			return err
+		}
+		if im.report.Aborted {
+			return errImportAborted
+		}
+		return nil
+	})
+	if err != nil {
+		im.rollback()
+		if errors.Is(err, errImportAborted) {
+			return im.report, nil
+		}
+		return im.report, err
+	}
+	s.publishEvents(ctx, im.pending...)
+	return im.report, nil
+}
+
+// run 读取全部输入并按批次写入
+func (im *userImporter) run(ctx context.Context, reader dataformat.Reader) error {
+	for !im.report.Aborted {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		im.add(record)
+		if len(im.batch) >= im.opts.BatchSize || im.report.Aborted {
+			if err := im.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return im.flush(ctx)
+}
+
+// rollback 外层事务回滚后，将已标记为创建或更新的行改为未写入
+func (im *userImporter) rollback() {
+	for _, result := range im.report.Rows {
+		if result.Status != vo.ImportStatusCreated && result.Status != vo.ImportStatusUpdated {
+			continue
+		}
+		if result.Status == vo.ImportStatusCreated {
+			result.ID = 0
+		}
+		result.Status = vo.ImportStatusSkipped
+		result.Code = vo.ImportCodeAborted
+		result.Message = "导入已停止，已回滚"
+		im.report.Skipped++
+	}
+	im.report.Created = 0
+	im.report.Updated = 0
+	im.pending = nil
+}
+
+// add 校验单行字段，合法的行加入当前批次
+func (im *userImporter) add(record *dataformat.Record) {
+	result := &vo.ImportRowResult{Row: record.Line}
+	im.report.Total++
+	im.report.Rows = append(im.report.Rows, result)
+
+	if record.Err != nil {
+		im.reject(result, vo.ImportCodeInvalidRow, record.Err.Error())
+		return
+	}
+	result.Email = record.Fields["email"]
+
+	email, err := vo.NewEmail(record.Fields["email"])
+	if err != nil {
+		im.reject(result, vo.ImportCodeInvalidEmail, err.Error())
+		return
+	}
+	result.Email = email.String()
+
+	name, err := vo.NewUserName(record.Fields["name"])
+	if err != nil {
+		im.reject(result, vo.ImportCodeInvalidName, err.Error())
+		return
+	}
+
+	// upsert 模式下已存在的用户可以不提供密码，是否存在要到写入批次时才知道
+	password := record.Fields["password"]
+	if password != "" || im.opts.Mode != vo.ImportModeUpsert {
+		if _, err := vo.NewPassword(password); err != nil {
+			im.reject(result, vo.ImportCodeInvalidPassword, err.Error())
+			return
+		}
+	}
+
+	if im.seen[email.String()] {
+		im.reject(result, vo.ImportCodeDuplicateInFile, "邮箱在文件中重复出现")
+		return
+	}
+	im.seen[email.String()] = true
+
+	im.batch = append(im.batch, &importRow{result: result, name: name, email: email, password: password})
+}
+
+// reject 标记无效行：fail-fast 模式下为失败并停止导入，其他模式下跳过
+func (im *userImporter) reject(result *vo.ImportRowResult, code, message string) {
+	result.Code = code
+	result.Message = message
+	if im.opts.Mode == vo.ImportModeFailFast {
+		result.Status = vo.ImportStatusFailed
+		im.report.Failed++
+		im.report.Aborted = true
+		return
+	}
+	result.Status = vo.ImportStatusSkipped
+	im.report.Skipped++
+}
+
+// flush 查询当前批次中已存在的邮箱，决定每行创建、更新或跳过，并在一个事务中写入
+func (im *userImporter) flush(ctx context.Context) error {
+	batch := im.batch
+	im.batch = nil
+	if len(batch) == 0 {
+		return nil
+	}
+
+	emails := make([]string, 0, len(batch))
+	for _, row := range batch {
+		emails = append(emails, row.email.String())
+	}
+	existing, err := im.service.userRepo.FindByEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+
+	failFast := im.opts.Mode == vo.ImportModeFailFast
+	stopped := false
+	var created, updated []*importRow
+	for _, row := range batch {
+		if stopped {
+			// fail-fast 停止后，同一批次中后续的行不再处理
+			row.result.Status = vo.ImportStatusSkipped
+			row.result.Code = vo.ImportCodeAborted
+			row.result.Message = "导入已停止"
+			im.report.Skipped++
+			continue
+		}
+
+		user, exists := existing[row.email.String()]
+		switch {
+		case exists && im.opts.Mode != vo.ImportModeUpsert:
+			im.reject(row.result, vo.ImportCodeEmailExists, "邮箱已被使用")
+			stopped = failFast
+		case exists:
+			row.before = userSnapshot(user)
+			if err := applyImportRow(user, row); err != nil {
+				im.reject(row.result, importErrorCode(err), err.Error())
+				stopped = failFast
+				continue
+			}
+			row.user = user
+			row.result.ID = user.ID
+			if !user.HasChanges() {
+				row.result.Status = vo.ImportStatusSkipped
+				row.result.Code = vo.ImportCodeUnchanged
+				im.report.Skipped++
+				continue
+			}
+			updated = append(updated, row)
+		default:
+			user, err := entity.NewUser(row.name.String(), row.email.String(), row.password)
+			if err != nil {
+				im.reject(row.result, importErrorCode(err), err.Error())
+				stopped = failFast
+				continue
+			}
+			row.user = user
+			created = append(created, row)
+		}
+	}
+
+	if !im.opts.DryRun && len(created)+len(updated) > 0 {
+		createdUsers, updatedUsers := importUsers(created), importUsers(updated)
+		err := im.service.write(ctx, func(ctx context.Context) error {
+			if err := im.service.userRepo.SaveBatch(ctx, createdUsers, updatedUsers); err != nil {
+				return err
+			}
+			return im.service.audit(ctx, importRecords(created, updated)...)
+		}, append(createdUsers, updatedUsers...)...)
+		if err != nil {
+			return err
+		}
+		if im.pending != nil {
+			im.pending = append(im.pending, append(createdUsers, updatedUsers...)...)
+		} else {
+			im.service.publishEvents(ctx, append(createdUsers, updatedUsers...)...)
+		}
+	}
+
+	for _, row := range created {
+		row.result.Status = vo.ImportStatusCreated
+		row.result.ID = row.user.ID
+		im.report.Created++
+	}
+	for _, row := range updated {
+		row.result.Status = vo.ImportStatusUpdated
+		im.report.Updated++
+	}
+	return nil
+}
+
+// applyImportRow 将导入行应用到已存在的用户，未提供密码时保留原密码
+func applyImportRow(user *entity.User, row *importRow) error {
+	if err := user.UpdateName(row.name.String()); err != nil {
+		return err
+	}
+	if row.password != "" {
+		return user.UpdatePassword(row.password)
+	}
+	return nil
+}
+
+// importRecords 为写入的行生成审计记录，与 SaveBatch 一样先新建后更新
+func importRecords(created, updated []*importRow) []auditService.Record {
+	records := make([]auditService.Record, 0, len(created)+len(updated))
+	for _, row := range created {
+		records = append(records, userRecord(auditEntity.ActionUserCreated, row.user.ID, nil, userSnapshot(row.user)))
+	}
+	for _, row := range updated {
+		records = append(records, userRecord(auditEntity.ActionUserUpdated, row.user.ID, row.before, userSnapshot(row.user)))
+	}
+	return records
+}
+
+func importUsers(rows []*importRow) []*entity.User {
+	users := make([]*entity.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.user)
+	}
+	return users
+}
+
+// importErrorCode 将领域校验错误映射为导入错误码
+func importErrorCode(err error) string {
+	switch {
+	case errors.Is(err, vo.ErrUserNameEmpty), errors.Is(err, vo.ErrUserNameLength):
+		return vo.ImportCodeInvalidName
+	case errors.Is(err, vo.ErrEmailEmpty), errors.Is(err, vo.ErrEmailInvalid):
+		return vo.ImportCodeInvalidEmail
+	case errors.Is(err, vo.ErrPasswordEmpty), errors.Is(err, vo.ErrPasswordTooShort):
+		return vo.ImportCodeInvalidPassword
+	}
+	return vo.ImportCodeInvalidRow
+}
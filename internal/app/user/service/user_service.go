@@ -1,58 +1,141 @@
 package service
 
 import (
+	auditService "base-gin/internal/app/audit/service"
+	auditEntity "base-gin/internal/domain/audit/entity"
+	"base-gin/internal/domain/event"
 	"base-gin/internal/domain/user/entity"
 	"base-gin/internal/domain/user/repository"
 	domainService "base-gin/internal/domain/user/service"
 	"base-gin/internal/domain/user/vo"
+	"base-gin/pkg/jsonpatch"
+	"base-gin/pkg/textsearch"
+	"base-gin/pkg/tracing"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
 )
 
+// purgeBatchSize 清理已删除用户时每批查询的条数
+const purgeBatchSize = 200
+
+// ErrUnsupportedPatchType 不支持的补丁格式
+var ErrUnsupportedPatchType = errors.New("不支持的补丁格式，请使用 application/merge-patch+json 或 application/json-patch+json")
+
+// Transactor 在同一数据库事务中执行 fn，fn 通过 ctx 调用的仓储操作都属于该事务
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserService struct {
 	userRepo          repository.UserRepository
 	userDomainService *domainService.UserDomainService
+	publisher         event.Publisher
+	transactor        Transactor
+	auditor           *auditService.AuditService
+	tracer            *tracing.Tracer
 }
 
-func NewUserService(userRepo repository.UserRepository, userDomainService *domainService.UserDomainService) *UserService {
+// NewUserService tracer 为 nil 时不记录 span，auditor 为 nil 时不记录审计，
+// transactor 为 nil 时仓储操作各自提交，fail-fast 导入也不能整体回滚
+func NewUserService(userRepo repository.UserRepository, userDomainService *domainService.UserDomainService, publisher event.Publisher, transactor Transactor, auditor *auditService.AuditService, tracer *tracing.Tracer) *UserService {
 	return &UserService{
 		userRepo:          userRepo,
 		userDomainService: userDomainService,
+		publisher:         publisher,
+		transactor:        transactor,
+		auditor:           auditor,
+		tracer:            tracer,
 	}
 }
 
-func (s *UserService) GetUser(id int) (*vo.UserResponse, error) {
-	user, err := s.userRepo.FindByID(id)
+// startSpan 为服务方法创建子 span
+func (s *UserService) startSpan(ctx context.Context, method string, attrs ...tracing.Attribute) (context.Context, *tracing.Span) {
+	return s.tracer.Start(ctx, "UserService."+method, tracing.WithAttributes(attrs...))
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int) (*vo.UserResponse, error) {
+	ctx, span := s.startSpan(ctx, "GetUser", tracing.Int("user.id", id))
+	defer span.End()
+
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	return &vo.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:        user.ID,
+		Name:      user.Name.String(),
+		Email:     user.Email.String(),
+		Version:   user.Version,
+		UpdatedAt: user.UpdatedAt,
 	}, nil
 }
 
-func (s *UserService) GetAllUsers() ([]*vo.UserResponse, error) {
-	users, err := s.userRepo.FindAll()
+// ListUsers 按条件分页查询用户
+func (s *UserService) ListUsers(ctx context.Context, criteria repository.UserSearchCriteria) (*vo.UserListResult, error) {
+	ctx, span := s.startSpan(ctx, "ListUsers")
+	defer span.End()
+
+	result, err := s.userRepo.Search(ctx, criteria)
 	if err != nil {
 		return nil, err
 	}
 
-	responses := make([]*vo.UserResponse, 0, len(users))
-	for _, user := range users {
+	responses := make([]*vo.UserResponse, 0, len(result.Users))
+	for _, user := range result.Users {
 		responses = append(responses, &vo.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
+			ID:        user.ID,
+			Name:      user.Name.String(),
+			Email:     user.Email.String(),
+			DeletedAt: user.DeletedAt,
+		})
+	}
+
+	return &vo.UserListResult{
+		Users:  responses,
+		Total:  result.Total,
+		Cursor: result.Cursor,
+	}, nil
+}
+
+// SearchUsers 按关键字全文检索用户，结果按相关度排序并高亮命中部分
+func (s *UserService) SearchUsers(ctx context.Context, keyword string, limit int) ([]*vo.UserSearchResponse, error) {
+	ctx, span := s.startSpan(ctx, "SearchUsers")
+	defer span.End()
+
+	tokens := textsearch.QueryTokens(keyword)
+	hits, err := s.userRepo.SearchText(ctx, tokens, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*vo.UserSearchResponse, 0, len(hits))
+	for _, hit := range hits {
+		name, email := hit.User.Name.String(), hit.User.Email.String()
+		responses = append(responses, &vo.UserSearchResponse{
+			ID:    hit.User.ID,
+			Name:  name,
+			Email: email,
+			Rank:  hit.Rank,
+			Highlight: vo.UserHighlight{
+				Name:  textsearch.Highlight(name, tokens, "<mark>", "</mark>"),
+				Email: textsearch.Highlight(email, tokens, "<mark>", "</mark>"),
+			},
 		})
 	}
 
 	return responses, nil
 }
 
-func (s *UserService) CreateUser(req *vo.UserCreateRequest) (*vo.UserResponse, error) {
+func (s *UserService) CreateUser(ctx context.Context, req *vo.UserCreateRequest) (*vo.UserResponse, error) {
+	ctx, span := s.startSpan(ctx, "CreateUser")
+	defer span.End()
+
 	// 使用领域服务验证
-	if err := s.userDomainService.ValidateUserForCreation(req.Name, req.Email, req.Password); err != nil {
+	if err := s.userDomainService.ValidateUserForCreation(ctx, req.Name, req.Email, req.Password); err != nil {
 		return nil, err
 	}
 
@@ -63,29 +146,45 @@ func (s *UserService) CreateUser(req *vo.UserCreateRequest) (*vo.UserResponse, e
 	}
 
 	// 保存用户
-	if err := s.userRepo.Save(user); err != nil {
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Save(ctx, user); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserCreated, user.ID, nil, userSnapshot(user)))
+	}, user); err != nil {
 		return nil, err
 	}
+	s.publishEvents(ctx, user)
 
 	return &vo.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:      user.ID,
+		Name:    user.Name.String(),
+		Email:   user.Email.String(),
+		Version: user.Version,
 	}, nil
 }
 
-func (s *UserService) UpdateUser(id int, req *vo.UserUpdateRequest) (*vo.UserResponse, error) {
+// UpdateUser 更新用户，ifMatch 不为空时要求当前版本号在其中
+func (s *UserService) UpdateUser(ctx context.Context, id int, req *vo.UserUpdateRequest, ifMatch []int) (*vo.UserResponse, error) {
+	ctx, span := s.startSpan(ctx, "UpdateUser", tracing.Int("user.id", id))
+	defer span.End()
+
 	// 使用领域服务验证
-	if err := s.userDomainService.ValidateUserForUpdate(id, req.Name, req.Email); err != nil {
+	if err := s.userDomainService.ValidateUserForUpdate(ctx, id, req.Name, req.Email); err != nil {
 		return nil, err
 	}
 
 	// 获取用户
-	user, err := s.userRepo.FindByID(id)
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkVersion(user, ifMatch); err != nil {
+		return nil, err
+	}
+	before := userSnapshot(user)
+
 	// 更新用户信息
 	if err := user.UpdateName(req.Name); err != nil {
 		return nil, err
@@ -96,17 +195,328 @@ func (s *UserService) UpdateUser(id int, req *vo.UserUpdateRequest) (*vo.UserRes
 	}
 
 	// 保存更新
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserUpdated, user.ID, before, userSnapshot(user)))
+	}, user); err != nil {
 		return nil, err
 	}
+	s.publishEvents(ctx, user)
 
 	return &vo.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		ID:      user.ID,
+		Name:    user.Name.String(),
+		Email:   user.Email.String(),
+		Version: user.Version,
 	}, nil
 }
 
-func (s *UserService) DeleteUser(id int) error {
-	return s.userRepo.Delete(id)
+// PatchUser 部分更新用户，支持 JSON Merge Patch 和 JSON Patch。
+// 补丁应用在用户文档 {"id", "name", "email"} 上，字段被删除（null 或 remove）视为错误，
+// 只有值发生变化的字段会被校验和保存。
+func (s *UserService) PatchUser(ctx context.Context, id int, req *vo.UserPatchRequest, ifMatch []int) (*vo.UserResponse, error) {
+	ctx, span := s.startSpan(ctx, "PatchUser", tracing.Int("user.id", id))
+	defer span.End()
+
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkVersion(user, ifMatch); err != nil {
+		return nil, err
+	}
+	before := userSnapshot(user)
+
+	doc := map[string]interface{}{
+		"id":              float64(user.ID),
+		entity.FieldName:  user.Name.String(),
+		entity.FieldEmail: user.Email.String(),
+	}
+
+	var patched interface{}
+	switch req.ContentType {
+	case jsonpatch.MediaTypeMergePatch:
+		patched, err = jsonpatch.MergePatch(doc, req.Body)
+	case jsonpatch.MediaTypeJSONPatch:
+		patched, err = jsonpatch.Apply(doc, req.Body)
+	default:
+		return nil, ErrUnsupportedPatchType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyUserPatch(user, patched); err != nil {
+		return nil, err
+	}
+
+	if user.IsChanged(entity.FieldEmail) {
+		if err := s.userDomainService.CheckEmailUnique(ctx, user.Email.String(), id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserUpdated, user.ID, before, userSnapshot(user)))
+	}, user); err != nil {
+		return nil, err
+	}
+	s.publishEvents(ctx, user)
+
+	return &vo.UserResponse{
+		ID:      user.ID,
+		Name:    user.Name.String(),
+		Email:   user.Email.String(),
+		Version: user.Version,
+	}, nil
+}
+
+// applyUserPatch 将补丁后的文档逐字段应用到实体，收集所有字段错误
+func applyUserPatch(user *entity.User, patched interface{}) error {
+	obj, ok := patched.(map[string]interface{})
+	if !ok {
+		return vo.ValidationErrors{{Field: "", Message: "补丁结果必须是 JSON 对象"}}
+	}
+
+	var errs vo.ValidationErrors
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case "id", entity.FieldName, entity.FieldEmail:
+		default:
+			errs = append(errs, vo.FieldError{Field: key, Message: "不支持修改该字段"})
+		}
+	}
+
+	if id, ok := obj["id"]; !ok || id != float64(user.ID) {
+		errs = append(errs, vo.FieldError{Field: "id", Message: "只读字段不能修改"})
+	}
+
+	updaters := []struct {
+		field  string
+		update func(string) error
+	}{
+		{entity.FieldName, user.UpdateName},
+		{entity.FieldEmail, user.UpdateEmail},
+	}
+	for _, u := range updaters {
+		value, present := obj[u.field]
+		if !present {
+			errs = append(errs, vo.FieldError{Field: u.field, Message: "必填字段不能删除"})
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			errs = append(errs, vo.FieldError{Field: u.field, Message: "必须是字符串"})
+			continue
+		}
+		if err := u.update(str); err != nil {
+			errs = append(errs, vo.FieldError{Field: u.field, Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// DeleteUser 删除用户，ifMatch 不为空时要求当前版本号在其中
+func (s *UserService) DeleteUser(ctx context.Context, id int, ifMatch []int) error {
+	ctx, span := s.startSpan(ctx, "DeleteUser", tracing.Int("user.id", id))
+	defer span.End()
+
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(user, ifMatch); err != nil {
+		return err
+	}
+
+	// 未携带 If-Match 时不做版本校验
+	version := 0
+	if len(ifMatch) > 0 {
+		version = user.Version
+	}
+
+	before := userSnapshot(user)
+	user.MarkDeleted()
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, user, version); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserDeleted, user.ID, before, userSnapshot(user)))
+	}, user); err != nil {
+		return err
+	}
+	s.publishEvents(ctx, user)
+	return nil
+}
+
+// RestoreUser 恢复软删除的用户
+func (s *UserService) RestoreUser(ctx context.Context, id int) (*vo.UserResponse, error) {
+	ctx, span := s.startSpan(ctx, "RestoreUser", tracing.Int("user.id", id))
+	defer span.End()
+
+	user, err := s.userRepo.FindDeletedByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MarkRestored()
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Restore(ctx, user); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserRestored, id, nil, nil))
+	}, user); err != nil {
+		return nil, err
+	}
+	s.publishEvents(ctx, user)
+	return s.GetUser(ctx, id)
+}
+
+// PurgeUser 永久删除已软删除的用户
+func (s *UserService) PurgeUser(ctx context.Context, id int) error {
+	ctx, span := s.startSpan(ctx, "PurgeUser", tracing.Int("user.id", id))
+	defer span.End()
+
+	user, err := s.userRepo.FindDeletedByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.purge(ctx, user)
+}
+
+// PurgeDeletedUsers 永久删除在 before 之前被软删除的用户，dryRun 为 true 时只返回将被删除的用户
+func (s *UserService) PurgeDeletedUsers(ctx context.Context, before time.Time, dryRun bool) (*vo.PurgeReport, error) {
+	ctx, span := s.startSpan(ctx, "PurgeDeletedUsers", tracing.Bool("dry_run", dryRun))
+	defer span.End()
+
+	report := &vo.PurgeReport{
+		DryRun:        dryRun,
+		DeletedBefore: before,
+		Users:         []*vo.UserResponse{},
+	}
+
+	afterID := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		users, err := s.userRepo.FindDeletedBefore(ctx, before, afterID, purgeBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+			if !dryRun {
+				if err := s.purge(ctx, user); err != nil {
+					// 查询之后被恢复的用户跳过
+					if errors.Is(err, repository.ErrUserNotDeleted) {
+						continue
+					}
+					return report, err
+				}
+			}
+			report.Users = append(report.Users, &vo.UserResponse{
+				ID:        user.ID,
+				Name:      user.Name.String(),
+				Email:     user.Email.String(),
+				DeletedAt: user.DeletedAt,
+			})
+			report.Count++
+		}
+
+		if len(users) < purgeBatchSize {
+			return report, nil
+		}
+	}
+}
+
+// purge 在一个事务中永久删除用户并写入审计记录，提交后发布 UserPurged 事件
+func (s *UserService) purge(ctx context.Context, user *entity.User) error {
+	user.MarkPurged()
+	if err := s.write(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.HardDelete(ctx, user); err != nil {
+			return err
+		}
+		return s.audit(ctx, userRecord(auditEntity.ActionUserPurged, user.ID, nil, nil))
+	}, user); err != nil {
+		return err
+	}
+	s.publishEvents(ctx, user)
+	return nil
+}
+
+// write 在一个事务中执行仓储写入 fn，并将 users 记录的领域事件交给事务内处理器（如创建 webhook 投递记录），
+// 任一步失败时整体回滚；提交后再由 publishEvents 发布给其他处理器
+func (s *UserService) write(ctx context.Context, fn func(ctx context.Context) error, users ...*entity.User) error {
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		var events []event.Event
+		for _, user := range users {
+			events = append(events, user.Events()...)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		return s.publisher.PublishInTransaction(ctx, events...)
+	})
+}
+
+// transaction 在 transactor 的事务中执行 fn，transactor 为 nil 时直接执行
+func (s *UserService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fn(ctx)
+	}
+	return s.transactor.Transaction(ctx, fn)
+}
+
+// publishEvents 发布实体在本次操作中记录的领域事件。修改已提交，
+// 处理器失败只记录日志，不影响本次操作的结果；请求取消后仍会发布
+func (s *UserService) publishEvents(ctx context.Context, users ...*entity.User) {
+	ctx = context.WithoutCancel(ctx)
+	var events []event.Event
+	for _, user := range users {
+		events = append(events, user.PullEvents()...)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if err := s.publisher.Publish(ctx, events...); err != nil {
+		log.Printf("领域事件处理失败: %v", err)
+	}
+}
+
+// checkVersion 校验用户当前版本号是否在 If-Match 给出的版本中，为空时不校验。
+// 校验通过后仓储仍会以加载时的版本号做条件更新，防止校验与写入之间被并发修改
+func checkVersion(user *entity.User, ifMatch []int) error {
+	if len(ifMatch) == 0 {
+		return nil
+	}
+	for _, version := range ifMatch {
+		if version == user.Version {
+			return nil
+		}
+	}
+	return repository.ErrVersionConflict
 }
@@ -0,0 +1,63 @@
+package job
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/app/webhook/service"
+	"base-gin/pkg/health"
+	"context"
+	"log"
+	"time"
+)
+
+// DeliveryJob 定时发送到期的 Webhook 投递，多个实例可同时运行，投递通过租约分配
+type DeliveryJob struct {
+	webhookService *service.WebhookService
+	interval       time.Duration
+	health         *health.Checker
+}
+
+// NewDeliveryJob checker 不为 nil 时运行期间注册心跳检查
+func NewDeliveryJob(config *configs.Config, webhookService *service.WebhookService, checker *health.Checker) *DeliveryJob {
+	return &DeliveryJob{
+		webhookService: webhookService,
+		health:         checker,
+		interval:       time.Duration(config.Webhook.PollIntervalMs) * time.Millisecond,
+	}
+}
+
+// Start 按间隔轮询投递，直到 ctx 取消
+func (j *DeliveryJob) Start(ctx context.Context) {
+	interval := j.interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	heartbeat := j.health.Heartbeat("job:webhook_delivery", interval)
+	defer heartbeat.Stop()
+
+	for {
+		// 满批时立即继续，积压清空后再等待下一次轮询
+		for {
+			heartbeat.Beat()
+			n, err := j.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Webhook 投递失败: %v", err)
+			}
+			if err != nil || n < j.webhookService.BatchSize() {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 发送一批到期的投递，返回领取的投递数
+func (j *DeliveryJob) RunOnce(ctx context.Context) (int, error) {
+	return j.webhookService.DeliverDue(ctx)
+}
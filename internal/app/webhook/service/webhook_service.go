@@ -0,0 +1,476 @@
+package service
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/domain/event"
+	userEntity "base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/webhook/entity"
+	"base-gin/internal/domain/webhook/repository"
+	"base-gin/internal/domain/webhook/vo"
+	"base-gin/pkg/netguard"
+	"base-gin/pkg/uuid"
+	"base-gin/pkg/webhooksig"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// snippetSize 投递记录中保存的请求体和响应体长度上限（字节）
+const snippetSize = 2 << 10
+
+// 投递请求头，签名相关的请求头见 webhooksig
+const (
+	HeaderDeliveryID = "X-Webhook-ID"
+	HeaderEventID    = "X-Webhook-Event-ID"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderAttempt    = "X-Webhook-Attempt"
+)
+
+// SupportedEvents 可订阅的事件类型，entity.EventWildcard 订阅全部
+var SupportedEvents = userEntity.EventNames
+
+var (
+	ErrUnsupportedEvent     = errors.New("不支持的事件类型")
+	ErrSubscriptionDisabled = errors.New("订阅已停用，请先重新启用")
+	ErrForbiddenURL         = errors.New("回调地址不能指向内网、回环或链路本地地址")
+)
+
+// envelope 投递的请求体，id 在同一事件投递给不同订阅时相同
+type envelope struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       event.Event `json:"data"`
+}
+
+type WebhookService struct {
+	repo         repository.WebhookRepository
+	guard        *netguard.Guard
+	client       *http.Client
+	policy       entity.RetryPolicy
+	disableAfter int
+	worker       string
+	batchSize    int
+	lease        time.Duration
+}
+
+// NewWebhookService 创建 Webhook 服务并订阅事件总线上的所有事件。投递只连接公网地址且不跟随重定向，
+// WEBHOOK_ALLOWED_NETWORKS 中的网段除外；网段无效时返回错误
+func NewWebhookService(config *configs.Config, repo repository.WebhookRepository, bus *event.Bus) (*WebhookService, error) {
+	cfg := config.Webhook
+	guard, err := netguard.NewGuard(strings.Split(cfg.AllowedNetworks, ","))
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	s := &WebhookService{
+		repo:   repo,
+		guard:  guard,
+		client: guard.Client(time.Duration(cfg.TimeoutSeconds) * time.Second),
+		policy: entity.RetryPolicy{
+			MaxAttempts: cfg.MaxAttempts,
+			Base:        time.Duration(cfg.RetryBackoffSeconds) * time.Second,
+			Max:         time.Duration(cfg.RetryMaxBackoffSeconds) * time.Second,
+		},
+		disableAfter: cfg.DisableAfterFailures,
+		worker:       fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewV4()[:8]),
+		batchSize:    cfg.BatchSize,
+		lease:        time.Duration(cfg.LeaseSeconds) * time.Second,
+	}
+	bus.SubscribeAll(s.HandleEvent, event.WithName("webhook"), event.InTransaction())
+	return s, nil
+}
+
+// BatchSize 每次领取的投递数
+func (s *WebhookService) BatchSize() int {
+	return s.batchSize
+}
+
+// CreateSubscription 创建订阅，响应中包含签名密钥
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *vo.SubscriptionCreateRequest) (*vo.SubscriptionResponse, error) {
+	if err := validateEvents(req.Events); err != nil {
+		return nil, err
+	}
+	subscription, err := entity.NewSubscription(req.URL, req.Events, req.Secret, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(subscription.URL); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	resp := toSubscriptionResponse(subscription)
+	resp.Secret = subscription.Secret
+	return resp, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int) (*vo.SubscriptionResponse, error) {
+	subscription, err := s.repo.FindSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSubscriptionResponse(subscription), nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*vo.SubscriptionResponse, error) {
+	subscriptions, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*vo.SubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		responses = append(responses, toSubscriptionResponse(subscription))
+	}
+	return responses, nil
+}
+
+// UpdateSubscription 修改订阅，重新启用时清零连续失败次数
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id int, req *vo.SubscriptionUpdateRequest) (*vo.SubscriptionResponse, error) {
+	subscription, err := s.repo.FindSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := subscription.UpdateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		if err := s.checkURL(subscription.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Events != nil {
+		if err := validateEvents(req.Events); err != nil {
+			return nil, err
+		}
+		if err := subscription.UpdateEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		subscription.Description = strings.TrimSpace(*req.Description)
+		subscription.UpdatedAt = time.Now()
+	}
+	if req.Active != nil && *req.Active != subscription.Active {
+		if *req.Active {
+			subscription.Enable()
+		} else {
+			subscription.Disable("手动停用")
+		}
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return toSubscriptionResponse(subscription), nil
+}
+
+// checkURL 拒绝主机为内网 IP 或 localhost 的回调地址；域名在投递连接时按解析结果校验
+func (s *WebhookService) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return entity.ErrInvalidURL
+	}
+	if err := s.guard.CheckHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenURL, err)
+	}
+	return nil
+}
+
+// DeleteSubscription 删除订阅及其投递记录
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int) error {
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// ListDeliveries 分页查询订阅的投递记录，最新的在前
+func (s *WebhookService) ListDeliveries(ctx context.Context, criteria repository.DeliveryCriteria) ([]*vo.DeliveryResponse, int64, error) {
+	if _, err := s.repo.FindSubscription(ctx, criteria.SubscriptionID); err != nil {
+		return nil, 0, err
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, criteria)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	responses := make([]*vo.DeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, toDeliveryResponse(delivery))
+	}
+	return responses, total, nil
+}
+
+// GetDelivery 查询投递详情及全部尝试记录
+func (s *WebhookService) GetDelivery(ctx context.Context, subscriptionID, id int) (*vo.DeliveryDetailResponse, error) {
+	delivery, err := s.repo.FindDelivery(ctx, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.repo.ListAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &vo.DeliveryDetailResponse{
+		DeliveryResponse: *toDeliveryResponse(delivery),
+		Payload:          json.RawMessage(delivery.Payload),
+		History:          make([]*vo.AttemptResponse, 0, len(attempts)),
+	}
+	for _, a := range attempts {
+		detail.History = append(detail.History, &vo.AttemptResponse{
+			Attempt:         a.Attempt,
+			RequestHeaders:  a.RequestHeaders,
+			RequestBody:     a.RequestBody,
+			ResponseStatus:  a.ResponseStatus,
+			ResponseHeaders: a.ResponseHeaders,
+			ResponseBody:    a.ResponseBody,
+			Error:           a.Error,
+			DurationMs:      a.Duration.Milliseconds(),
+			CreatedAt:       a.CreatedAt,
+		})
+	}
+	return detail, nil
+}
+
+// Redeliver 手动重新投递，由投递任务在下一次轮询时发送。订阅停用时返回 ErrSubscriptionDisabled
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID, id int) (*vo.DeliveryResponse, error) {
+	subscription, err := s.repo.FindSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.Active {
+		return nil, ErrSubscriptionDisabled
+	}
+
+	delivery, err := s.repo.FindDelivery(ctx, subscriptionID, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Redeliver()
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return toDeliveryResponse(delivery), nil
+}
+
+// HandleEvent 为订阅了该事件的启用订阅各创建一条待投递记录。作为事务内处理器在用户变更的事务中执行，
+// 投递记录与变更一起提交，失败时变更也回滚，不会出现已提交的变更没有投递记录
+func (s *WebhookService) HandleEvent(ctx context.Context, e event.Event) error {
+	subscriptions, err := s.repo.FindActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var matched []*entity.Subscription
+	for _, subscription := range subscriptions {
+		if subscription.Matches(e.EventName()) {
+			matched = append(matched, subscription)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	env := envelope{ID: uuid.NewV4(), Type: e.EventName(), OccurredAt: e.OccurredAt(), Data: e}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*entity.Delivery, 0, len(matched))
+	for _, subscription := range matched {
+		deliveries = append(deliveries, &entity.Delivery{
+			SubscriptionID: subscription.ID,
+			EventID:        env.ID,
+			EventType:      env.Type,
+			Payload:        string(payload),
+			Status:         entity.DeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// DeliverDue 领取一批到期的投递并逐条发送，返回领取的投递数
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, s.worker, s.batchSize, s.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[int]*entity.Subscription)
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			// 未发送的投递在租约过期后重新领取
+			return len(deliveries), err
+		}
+
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = s.repo.FindSubscription(ctx, delivery.SubscriptionID)
+			if errors.Is(err, repository.ErrSubscriptionNotFound) {
+				// 订阅已删除，投递记录随之删除
+				continue
+			}
+			if err != nil {
+				return len(deliveries), err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+		if !subscription.Active {
+			continue
+		}
+
+		attempt := s.send(ctx, subscription, delivery)
+		delivery.RecordAttempt(attempt, s.policy)
+		disabled, err := s.repo.RecordAttempt(ctx, delivery, attempt, s.disableAfter)
+		if err != nil {
+			return len(deliveries), err
+		}
+		if delivery.Status == entity.DeliveryFailed {
+			log.Printf("Webhook 投递 %d (%s) 尝试 %d 次失败，不再重试: %s", delivery.ID, delivery.EventType, delivery.Attempts, delivery.LastError)
+		}
+		if disabled {
+			log.Printf("Webhook 订阅 %d 连续 %d 次投递失败，已自动停用", subscription.ID, s.disableAfter)
+			subscription.Active = false
+		}
+	}
+	return len(deliveries), nil
+}
+
+// send 发送一次投递请求，网络错误和非 2xx 响应都记录为失败
+func (s *WebhookService) send(ctx context.Context, subscription *entity.Subscription, delivery *entity.Delivery) *entity.DeliveryAttempt {
+	attempt := &entity.DeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts + 1,
+		RequestBody: snippet([]byte(delivery.Payload)),
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.CreatedAt = time.Now()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "base-gin-webhook/1.0")
+	req.Header.Set(HeaderDeliveryID, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderAttempt, strconv.Itoa(attempt.Attempt))
+	webhooksig.SetHeaders(req.Header, subscription.Secret, time.Now(), body)
+	attempt.RequestHeaders = flattenHeader(req.Header)
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	attempt.Duration = time.Since(start)
+	attempt.CreatedAt = time.Now()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, snippetSize))
+	// 读完剩余响应体以便复用连接
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.ResponseStatus = resp.StatusCode
+	attempt.ResponseHeaders = flattenHeader(resp.Header)
+	attempt.ResponseBody = snippet(respBody)
+	if !attempt.Succeeded() {
+		attempt.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// validateEvents 校验事件类型是否可订阅
+func validateEvents(events []string) error {
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if e == "" || e == entity.EventWildcard {
+			continue
+		}
+		supported := false
+		for _, name := range SupportedEvents {
+			if e == name {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("%w: %s", ErrUnsupportedEvent, e)
+		}
+	}
+	return nil
+}
+
+// snippet 截取开头 snippetSize 字节，不截断多字节字符
+func snippet(b []byte) string {
+	if len(b) <= snippetSize {
+		return string(b)
+	}
+	n := snippetSize
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	return string(b[:n]) + "…"
+}
+
+// flattenHeader 将请求头转换为单值映射，多个值以逗号连接
+func flattenHeader(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+func toSubscriptionResponse(s *entity.Subscription) *vo.SubscriptionResponse {
+	return &vo.SubscriptionResponse{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		Events:              s.Events,
+		Description:         s.Description,
+		Active:              s.Active,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		DisabledReason:      s.DisabledReason,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func toDeliveryResponse(d *entity.Delivery) *vo.DeliveryResponse {
+	return &vo.DeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+}
@@ -0,0 +1,173 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 审计对象类型
+const TargetTypeUser = "user"
+
+// 审计操作
+const (
+	ActionUserCreated  = "user.created"
+	ActionUserUpdated  = "user.updated"
+	ActionUserDeleted  = "user.deleted"
+	ActionUserRestored = "user.restored"
+	ActionUserPurged   = "user.purged"
+)
+
+// ErrChainBroken 审计记录的哈希链不连续或内容被修改
+var ErrChainBroken = errors.New("审计记录哈希链校验失败")
+
+// sensitiveKeys 字段名包含这些词时只记录发生了变化，不记录值
+var sensitiveKeys = []string{"password", "secret", "token"}
+
+// Change 字段变更，敏感字段只标记 Redacted，不保存前后的值
+type Change struct {
+	Before   any  `json:"before"`
+	After    any  `json:"after"`
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// Entry 一条审计记录。ID 从 1 开始连续递增，每条记录的 Hash 覆盖自身内容和上一条的 Hash，
+// 修改、删除或插入中间的记录都会使之后的哈希链校验失败。
+// ActorSource 为操作者的来源（proxy、client、admin_token、system），client 表示客户端自称、未经认证
+type Entry struct {
+	ID          int64
+	Actor       string
+	ActorSource string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Changes     map[string]Change
+	// RawChanges Changes 序列化后的 JSON，哈希基于保存的原文计算
+	RawChanges string
+	RequestID  string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	PrevHash   string
+	Hash       string
+}
+
+// Diff 比较操作前后的字段快照，返回值不同的字段。before 为 nil 表示新建，after 为 nil 表示删除；
+// nil 指针视为 nil，非 nil 指针比较指向的值
+func Diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for key, value := range after {
+		if _, ok := before[key]; ok {
+			continue
+		}
+		if changed := diffValue(key, nil, value); changed != nil {
+			changes[key] = *changed
+		}
+	}
+	for key, value := range before {
+		if changed := diffValue(key, value, after[key]); changed != nil {
+			changes[key] = *changed
+		}
+	}
+	return changes
+}
+
+func diffValue(key string, before, after any) *Change {
+	before, after = deref(before), deref(after)
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	lower := strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lower, sensitive) {
+			return &Change{Redacted: true}
+		}
+	}
+	return &Change{Before: before, After: after}
+}
+
+func deref(value any) any {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer {
+		return value
+	}
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
+
+// Seal 设置序号和上一条记录的哈希，序列化 Changes 并计算本条哈希。
+// CreatedAt 截断到微秒，与数据库保存的精度一致
+func (e *Entry) Seal(id int64, prevHash string) error {
+	raw, err := json.Marshal(e.Changes)
+	if err != nil {
+		return fmt.Errorf("序列化审计变更失败: %w", err)
+	}
+	e.ID = id
+	e.PrevHash = prevHash
+	e.RawChanges = string(raw)
+	e.CreatedAt = e.CreatedAt.Truncate(time.Microsecond)
+	e.Hash = e.ComputeHash()
+	return nil
+}
+
+// ComputeHash 按固定字段顺序计算 SHA-256，时间统一为 UTC。actor_source 为空时不参与计算，
+// 与增加该字段之前写入的记录兼容
+func (e *Entry) ComputeHash() string {
+	changes := e.RawChanges
+	if changes == "" {
+		changes = "null"
+	}
+	payload, _ := json.Marshal(struct {
+		ID          int64           `json:"id"`
+		PrevHash    string          `json:"prev_hash"`
+		Actor       string          `json:"actor"`
+		ActorSource string          `json:"actor_source,omitempty"`
+		Action      string          `json:"action"`
+		TargetType  string          `json:"target_type"`
+		TargetID    string          `json:"target_id"`
+		Changes     json.RawMessage `json:"changes"`
+		RequestID   string          `json:"request_id"`
+		IP          string          `json:"ip"`
+		UserAgent   string          `json:"user_agent"`
+		CreatedAt   string          `json:"created_at"`
+	}{
+		ID:          e.ID,
+		PrevHash:    e.PrevHash,
+		Actor:       e.Actor,
+		ActorSource: e.ActorSource,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Changes:     json.RawMessage(changes),
+		RequestID:   e.RequestID,
+		IP:          e.IP,
+		UserAgent:   e.UserAgent,
+		CreatedAt:   e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Follows 校验 e 紧接在 prev 之后且内容未被修改，prev 为 nil 表示 e 应为第一条记录
+func (e *Entry) Follows(prev *Entry) error {
+	wantID, wantPrevHash := int64(1), ""
+	if prev != nil {
+		wantID, wantPrevHash = prev.ID+1, prev.Hash
+	}
+	switch {
+	case e.ID != wantID:
+		return fmt.Errorf("%w: 期望记录 %d，实际为 %d，中间的记录可能已被删除", ErrChainBroken, wantID, e.ID)
+	case e.PrevHash != wantPrevHash:
+		return fmt.Errorf("%w: 记录 %d 的 prev_hash 与上一条不一致", ErrChainBroken, e.ID)
+	case e.ComputeHash() != e.Hash:
+		return fmt.Errorf("%w: 记录 %d 的内容与哈希不一致", ErrChainBroken, e.ID)
+	}
+	return nil
+}
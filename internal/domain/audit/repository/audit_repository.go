@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"context"
+	"time"
+)
+
+// AuditCriteria 审计记录查询条件，空值不参与筛选
+type AuditCriteria struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	// From、To 按创建时间筛选，包含 From，不包含 To
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
+
+// AuditRepository 审计记录仓储接口，只能追加和查询，不提供修改和删除
+type AuditRepository interface {
+	// Append 在一个事务中按顺序追加记录，为每条记录分配连续的 ID 并计算哈希链
+	Append(ctx context.Context, entries ...*entity.Entry) error
+	// List 按 ID 倒序分页查询
+	List(ctx context.Context, criteria AuditCriteria) ([]*entity.Entry, int64, error)
+	// ListAfter 按 ID 正序返回 ID 大于 afterID 的最多 limit 条记录，用于校验哈希链
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]*entity.Entry, error)
+}
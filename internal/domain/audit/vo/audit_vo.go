@@ -0,0 +1,45 @@
+package vo
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"time"
+)
+
+// AuditListQuery 审计记录查询参数，from、to 为 RFC 3339 时间
+type AuditListQuery struct {
+	Actor      string     `form:"actor"`
+	Action     string     `form:"action"`
+	TargetType string     `form:"target_type"`
+	TargetID   string     `form:"target_id"`
+	RequestID  string     `form:"request_id"`
+	From       *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type AuditEntryResponse struct {
+	ID          int64                    `json:"id"`
+	Actor       string                   `json:"actor"`
+	ActorSource string                   `json:"actor_source,omitempty"`
+	Action      string                   `json:"action"`
+	TargetType  string                   `json:"target_type"`
+	TargetID    string                   `json:"target_id"`
+	Changes     map[string]entity.Change `json:"changes"`
+	RequestID   string                   `json:"request_id,omitempty"`
+	IP          string                   `json:"ip,omitempty"`
+	UserAgent   string                   `json:"user_agent,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+	PrevHash    string                   `json:"prev_hash"`
+	Hash        string                   `json:"hash"`
+}
+
+// AuditVerifyResponse 哈希链校验结果。HeadHash 为最后一条记录的哈希，
+// 定期保存到外部系统后可发现末尾记录被删除
+type AuditVerifyResponse struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	HeadID   int64  `json:"head_id"`
+	HeadHash string `json:"head_hash"`
+	// BrokenAt 第一条校验失败的记录 ID
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
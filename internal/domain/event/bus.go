@@ -0,0 +1,224 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Handler 事件处理器
+type Handler func(ctx context.Context, e Event) error
+
+// Option 订阅选项
+type Option func(*subscription)
+
+// Async 异步投递：Publish 不等待处理器执行，失败只记录日志
+func Async() Option {
+	return func(s *subscription) {
+		s.async = true
+	}
+}
+
+// InTransaction 事务内处理器：由 PublishInTransaction 在写入数据的事务中调用，返回错误时事务回滚，
+// 适用于必须与变更一起提交的副作用（如创建 webhook 投递记录）。Publish 不调用这类处理器
+func InTransaction() Option {
+	return func(s *subscription) {
+		s.inTransaction = true
+	}
+}
+
+// WithRetry 处理器返回错误或 panic 时最多执行 attempts 次，第 n 次重试前等待 backoff*2^(n-1)
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(s *subscription) {
+		if attempts > 1 {
+			s.attempts = attempts
+		}
+		s.backoff = backoff
+	}
+}
+
+// WithName 设置订阅者名称，用于日志和错误信息
+func WithName(name string) Option {
+	return func(s *subscription) {
+		s.name = name
+	}
+}
+
+type subscription struct {
+	id        uint64
+	name      string
+	eventType reflect.Type // 为 nil 时接收所有事件
+	handler   Handler
+	async     bool
+	attempts  int
+	backoff   time.Duration
+	// inTransaction 只由 PublishInTransaction 调用
+	inTransaction bool
+}
+
+// Bus 进程内事件总线。同步处理器按订阅顺序在 Publish 中依次执行，
+// 异步处理器各自在独立的 goroutine 中执行；每个处理器的 panic 被单独恢复，不影响其他处理器
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscription
+	nextID uint64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 订阅类型为 T 的事件
+func Subscribe[T Event](b *Bus, handler func(ctx context.Context, e T) error, opts ...Option) (unsubscribe func()) {
+	return b.subscribe(reflect.TypeFor[T](), func(ctx context.Context, e Event) error {
+		return handler(ctx, e.(T))
+	}, opts)
+}
+
+// SubscribeAll 订阅所有事件，适用于审计、转发等不关心具体类型的处理器
+func (b *Bus) SubscribeAll(handler Handler, opts ...Option) (unsubscribe func()) {
+	return b.subscribe(nil, handler, opts)
+}
+
+func (b *Bus) subscribe(eventType reflect.Type, handler Handler, opts []Option) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub := &subscription{id: b.nextID, eventType: eventType, handler: handler, attempts: 1}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	if sub.name == "" {
+		sub.name = fmt.Sprintf("subscriber#%d", sub.id)
+	}
+
+	// 写时复制，Publish 持有的快照不受影响
+	subs := make([]*subscription, len(b.subs), len(b.subs)+1)
+	copy(subs, b.subs)
+	b.subs = append(subs, sub)
+
+	return func() { b.unsubscribe(sub.id) }
+}
+
+func (b *Bus) unsubscribe(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := make([]*subscription, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if sub.id != id {
+			subs = append(subs, sub)
+		}
+	}
+	b.subs = subs
+}
+
+// Publish 按顺序发布事件，返回所有同步处理器最终失败的错误。
+// 异步处理器使用不随 ctx 取消的上下文，总线关闭后改为同步执行
+func (b *Bus) Publish(ctx context.Context, events ...Event) error {
+	var errs []error
+	for _, e := range events {
+		b.mu.RLock()
+		subs := b.subs
+		closed := b.closed
+		var async []*subscription
+		for _, sub := range subs {
+			if sub.async && !sub.inTransaction && !closed && sub.matches(e) {
+				async = append(async, sub)
+			}
+		}
+		b.wg.Add(len(async))
+		b.mu.RUnlock()
+
+		for _, sub := range async {
+			go func(sub *subscription, e Event) {
+				defer b.wg.Done()
+				if err := sub.deliver(context.WithoutCancel(ctx), e); err != nil {
+					log.Printf("异步事件处理失败: %v", err)
+				}
+			}(sub, e)
+		}
+
+		for _, sub := range subs {
+			if sub.inTransaction || (sub.async && !closed) || !sub.matches(e) {
+				continue
+			}
+			if err := sub.deliver(ctx, e); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishInTransaction 在调用方的事务中按顺序执行事务内处理器，遇到第一个错误即返回，调用方应回滚事务。
+// ctx 携带调用方的事务；处理器不重试，异步选项对其无效
+func (b *Bus) PublishInTransaction(ctx context.Context, events ...Event) error {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, e := range events {
+		for _, sub := range subs {
+			if !sub.inTransaction || !sub.matches(e) {
+				continue
+			}
+			if err := sub.call(ctx, e); err != nil {
+				return fmt.Errorf("%s 处理 %s 失败: %w", sub.name, e.EventName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close 等待正在执行的异步处理器结束，之后发布的事件全部同步处理
+func (b *Bus) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+func (s *subscription) matches(e Event) bool {
+	return s.eventType == nil || reflect.TypeOf(e) == s.eventType
+}
+
+// deliver 执行处理器并按订阅选项重试
+func (s *subscription) deliver(ctx context.Context, e Event) error {
+	var err error
+	for attempt := 1; attempt <= s.attempts; attempt++ {
+		if attempt > 1 {
+			timer := time.NewTimer(s.backoff << (attempt - 2))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("%s 处理 %s 失败: %w", s.name, e.EventName(), errors.Join(err, ctx.Err()))
+			case <-timer.C:
+			}
+		}
+
+		if err = s.call(ctx, e); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s 处理 %s 失败: %w", s.name, e.EventName(), err)
+}
+
+// call 执行处理器，将 panic 转换为错误
+func (s *subscription) call(ctx context.Context, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.handler(ctx, e)
+}
@@ -0,0 +1,19 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Event 领域事件，事件类型由 EventName 标识，发生时间由实体在状态变化时记录
+type Event interface {
+	EventName() string
+	OccurredAt() time.Time
+}
+
+// Publisher 领域事件发布者。应用服务在写入数据的事务中调用 PublishInTransaction，
+// 提交成功后调用 Publish 发布实体记录的事件
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+	PublishInTransaction(ctx context.Context, events ...Event) error
+}
@@ -0,0 +1,78 @@
+package entity
+
+import "time"
+
+// 用户领域事件名称
+const (
+	EventUserCreated      = "user.created"
+	EventUserRenamed      = "user.renamed"
+	EventUserEmailChanged = "user.email_changed"
+	EventUserDeleted      = "user.deleted"
+	EventUserRestored     = "user.restored"
+	EventUserPurged       = "user.purged"
+)
+
+// EventNames 所有用户领域事件名称
+var EventNames = []string{EventUserCreated, EventUserRenamed, EventUserEmailChanged, EventUserDeleted, EventUserRestored, EventUserPurged}
+
+// UserCreated 用户已创建
+type UserCreated struct {
+	UserID int       `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e UserCreated) EventName() string     { return EventUserCreated }
+func (e UserCreated) OccurredAt() time.Time { return e.At }
+
+// UserRenamed 用户名已修改
+type UserRenamed struct {
+	UserID  int       `json:"user_id"`
+	OldName string    `json:"old_name"`
+	NewName string    `json:"new_name"`
+	At      time.Time `json:"occurred_at"`
+}
+
+func (e UserRenamed) EventName() string     { return EventUserRenamed }
+func (e UserRenamed) OccurredAt() time.Time { return e.At }
+
+// UserEmailChanged 用户邮箱已修改
+type UserEmailChanged struct {
+	UserID   int       `json:"user_id"`
+	OldEmail string    `json:"old_email"`
+	NewEmail string    `json:"new_email"`
+	At       time.Time `json:"occurred_at"`
+}
+
+func (e UserEmailChanged) EventName() string     { return EventUserEmailChanged }
+func (e UserEmailChanged) OccurredAt() time.Time { return e.At }
+
+// UserDeleted 用户已（软）删除
+type UserDeleted struct {
+	UserID int       `json:"user_id"`
+	Email  string    `json:"email"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e UserDeleted) EventName() string     { return EventUserDeleted }
+func (e UserDeleted) OccurredAt() time.Time { return e.At }
+
+// UserRestored 软删除的用户已恢复
+type UserRestored struct {
+	UserID int       `json:"user_id"`
+	Email  string    `json:"email"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e UserRestored) EventName() string     { return EventUserRestored }
+func (e UserRestored) OccurredAt() time.Time { return e.At }
+
+// UserPurged 用户已永久删除，不再携带个人信息
+type UserPurged struct {
+	UserID int       `json:"user_id"`
+	At     time.Time `json:"occurred_at"`
+}
+
+func (e UserPurged) EventName() string     { return EventUserPurged }
+func (e UserPurged) OccurredAt() time.Time { return e.At }
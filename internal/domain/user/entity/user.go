@@ -1,89 +1,186 @@
 package entity
 
 import (
-	"errors"
-	"regexp"
+	"base-gin/internal/domain/event"
+	"base-gin/internal/domain/user/vo"
 	"time"
 )
 
+// 可变更的字段名，与持久化列名一致
+const (
+	FieldName     = "name"
+	FieldEmail    = "email"
+	FieldPassword = "password"
+)
+
 type User struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"`
+	ID       int         `json:"id"`
+	Name     vo.UserName `json:"name"`
+	Email    vo.Email    `json:"email"`
+	Password vo.Password `json:"-"`
+	// Version 乐观锁版本号，每次持久化更新后加一
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt 软删除时间，未删除时为 nil
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// changes 记录自加载以来被修改的字段，仓储据此只更新变更的列
+	changes map[string]bool
+	// events 记录自加载以来发生的领域事件，由应用服务在持久化成功后取出发布
+	events []event.Event
 }
 
 func NewUser(name, email, password string) (*User, error) {
-	user := &User{
-		Name:      name,
-		Email:     email,
-		Password:  password,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	userName, err := vo.NewUserName(name)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := user.Validate(); err != nil {
+	userEmail, err := vo.NewEmail(email)
+	if err != nil {
 		return nil, err
 	}
 
+	userPassword, err := vo.NewPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &User{
+		Name:      userName,
+		Email:     userEmail,
+		Password:  userPassword,
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	// 用户 ID 在保存后才确定，由 PullEvents 补全
+	user.record(UserCreated{Name: userName.String(), Email: userEmail.String(), At: now})
 	return user, nil
 }
 
+// Validate 检查实体是否完整，字段格式已由值对象在创建时校验
 func (u *User) Validate() error {
-	if u.Name == "" {
-		return errors.New("用户名不能为空")
+	if u.Name.IsZero() {
+		return vo.ErrUserNameEmpty
 	}
 
-	if len(u.Name) < 2 || len(u.Name) > 50 {
-		return errors.New("用户名长度必须在2-50个字符之间")
+	if u.Email.IsZero() {
+		return vo.ErrEmailEmpty
 	}
 
-	if u.Email == "" {
-		return errors.New("邮箱不能为空")
+	if u.Password.IsZero() {
+		return vo.ErrPasswordEmpty
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(u.Email) {
-		return errors.New("邮箱格式不正确")
+	return nil
+}
+
+func (u *User) UpdateName(name string) error {
+	userName, err := vo.NewUserName(name)
+	if err != nil {
+		return err
 	}
 
-	if u.Password == "" {
-		return errors.New("密码不能为空")
+	if !u.Name.Equals(userName) {
+		u.record(UserRenamed{UserID: u.ID, OldName: u.Name.String(), NewName: userName.String(), At: time.Now()})
+		u.Name = userName
+		u.markChanged(FieldName)
 	}
+	return nil
+}
 
-	if len(u.Password) < 6 {
-		return errors.New("密码长度不能少于6位")
+func (u *User) UpdateEmail(email string) error {
+	userEmail, err := vo.NewEmail(email)
+	if err != nil {
+		return err
 	}
 
+	if !u.Email.Equals(userEmail) {
+		u.record(UserEmailChanged{UserID: u.ID, OldEmail: u.Email.String(), NewEmail: userEmail.String(), At: time.Now()})
+		u.Email = userEmail
+		u.markChanged(FieldEmail)
+	}
 	return nil
 }
 
-func (u *User) UpdateName(name string) error {
-	if name == "" {
-		return errors.New("用户名不能为空")
-	}
-	if len(name) < 2 || len(name) > 50 {
-		return errors.New("用户名长度必须在2-50个字符之间")
+func (u *User) UpdatePassword(password string) error {
+	userPassword, err := vo.NewPassword(password)
+	if err != nil {
+		return err
 	}
 
-	u.Name = name
-	u.UpdatedAt = time.Now()
+	if u.Password.Value() != userPassword.Value() {
+		u.Password = userPassword
+		u.markChanged(FieldPassword)
+	}
 	return nil
 }
 
-func (u *User) UpdateEmail(email string) error {
-	if email == "" {
-		return errors.New("邮箱不能为空")
+// MarkDeleted 标记用户已删除并记录 UserDeleted 事件，实际删除由仓储完成
+func (u *User) MarkDeleted() {
+	now := time.Now()
+	u.DeletedAt = &now
+	u.record(UserDeleted{UserID: u.ID, Email: u.Email.String(), At: now})
+}
+
+// MarkRestored 清除删除时间并记录 UserRestored 事件，实际恢复由仓储完成
+func (u *User) MarkRestored() {
+	u.DeletedAt = nil
+	u.record(UserRestored{UserID: u.ID, Email: u.Email.String(), At: time.Now()})
+}
+
+// MarkPurged 记录 UserPurged 事件，实际永久删除由仓储完成
+func (u *User) MarkPurged() {
+	u.record(UserPurged{UserID: u.ID, At: time.Now()})
+}
+
+func (u *User) markChanged(field string) {
+	if u.changes == nil {
+		u.changes = make(map[string]bool)
 	}
+	u.changes[field] = true
+	u.UpdatedAt = time.Now()
+}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(email) {
-		return errors.New("邮箱格式不正确")
+// IsChanged 判断字段是否被修改
+func (u *User) IsChanged(field string) bool {
+	return u.changes[field]
+}
+
+// HasChanges 判断是否有字段被修改
+func (u *User) HasChanges() bool {
+	return len(u.changes) > 0
+}
+
+// ClearChanges 清除修改记录，在持久化成功后调用
+func (u *User) ClearChanges() {
+	u.changes = nil
+}
+
+func (u *User) record(e event.Event) {
+	u.events = append(u.events, e)
+}
+
+// Events 返回已记录但尚未取出的领域事件，创建时尚未分配的用户 ID 在此补全。
+// 仓储在保存的事务中调用它写入发件箱
+func (u *User) Events() []event.Event {
+	events := make([]event.Event, len(u.events))
+	for i, e := range u.events {
+		if created, ok := e.(UserCreated); ok && created.UserID == 0 {
+			created.UserID = u.ID
+			e = created
+		}
+		events[i] = e
 	}
+	return events
+}
 
-	u.Email = email
-	u.UpdatedAt = time.Now()
-	return nil
+// PullEvents 取出并清空已记录的领域事件，在持久化成功后调用
+func (u *User) PullEvents() []event.Event {
+	events := u.Events()
+	u.events = nil
+	return events
 }
@@ -1,12 +1,109 @@
 package repository
 
-import "base-gin/internal/domain/user/entity"
+import (
+	"base-gin/internal/domain/user/entity"
+	"base-gin/pkg/pagination"
+	"base-gin/pkg/query"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict 用户已被其他请求修改，版本号不一致
+var ErrVersionConflict = errors.New("用户已被修改，请重新获取后再试")
+
+// ErrUserNotDeleted 用户未被软删除，不能恢复或永久删除
+var ErrUserNotDeleted = errors.New("用户未被删除")
+
+// ErrEmailTaken 邮箱已被其他未删除的用户使用
+var ErrEmailTaken = errors.New("邮箱已被其他用户使用")
+
+// UserQuerySchema 用户列表允许筛选和排序的字段
+var UserQuerySchema = query.NewSchema(
+	query.Field{Name: "id", Type: query.TypeInt, Sortable: true},
+	query.Field{Name: "name", Type: query.TypeString, Sortable: true},
+	query.Field{Name: "email", Type: query.TypeString, Sortable: true},
+	query.Field{Name: "created_at", Type: query.TypeTime, Sortable: true},
+	query.Field{Name: "updated_at", Type: query.TypeTime, Sortable: true},
+)
+
+// 用户状态筛选
+const (
+	StatusActive  = "active"
+	StatusDeleted = "deleted"
+	StatusAll     = "all"
+)
+
+// UserSearchCriteria 用户查询条件
+type UserSearchCriteria struct {
+	Page     int
+	PageSize int
+
+	// Query 通用筛选与排序条件，已按 UserQuerySchema 校验
+	Query *query.Query
+
+	NameContains string
+	EmailDomain  string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Status       string
+
+	// Cursor 不为空时使用游标分页，忽略 Page/PageSize 且不统计总数
+	Cursor *pagination.CursorQuery
+}
+
+// UserSearchResult 用户查询结果
+type UserSearchResult struct {
+	Users  []*entity.User
+	Total  int64
+	Cursor pagination.CursorMeta
+}
+
+// UserSearchHit 全文检索命中的用户
+type UserSearchHit struct {
+	User *entity.User
+	// Rank 相关度，越大越相关
+	Rank float64
+}
+
+// SortKeys 返回排序键，以 id 作为最后一个键保证顺序唯一
+func (c UserSearchCriteria) SortKeys() []pagination.SortKey {
+	return c.Query.SortKeys("id")
+}
+
+// IsValidStatus 判断状态筛选值是否合法
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusDeleted, StatusAll:
+		return true
+	}
+	return false
+}
 
 type UserRepository interface {
-	FindByID(id int) (*entity.User, error)
-	FindByEmail(email string) (*entity.User, error)
-	FindAll() ([]*entity.User, error)
-	Save(user *entity.User) error
-	Update(user *entity.User) error
-	Delete(id int) error
+	FindByID(ctx context.Context, id int) (*entity.User, error)
+	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	FindAll(ctx context.Context) ([]*entity.User, error)
+	Search(ctx context.Context, criteria UserSearchCriteria) (*UserSearchResult, error)
+	// Stream 按条件和排序逐个读取用户，忽略分页，fn 返回错误时停止
+	Stream(ctx context.Context, criteria UserSearchCriteria, fn func(*entity.User) error) error
+	// SearchText 按分词后的关键字全文检索，词元之间为 AND 关系，最后一个词元按前缀匹配
+	SearchText(ctx context.Context, tokens []string, limit int) ([]*UserSearchHit, error)
+	// FindByEmails 批量查询未删除的用户，返回以规范化邮箱为键的映射
+	FindByEmails(ctx context.Context, emails []string) (map[string]*entity.User, error)
+	Save(ctx context.Context, user *entity.User) error
+	// SaveBatch 在同一事务中批量创建和更新用户，任一失败则全部回滚
+	SaveBatch(ctx context.Context, created []*entity.User, updated []*entity.User) error
+	// Update 只更新已变更的字段，并在版本号与 user.Version 一致时将其加一，否则返回 ErrVersionConflict
+	Update(ctx context.Context, user *entity.User) error
+	// Delete 软删除用户并写入其记录的领域事件，version 大于 0 时仅在版本号一致时删除，否则返回 ErrVersionConflict
+	Delete(ctx context.Context, user *entity.User, version int) error
+	// FindDeletedByID 查询已软删除的用户，用户未被删除时返回 ErrUserNotDeleted
+	FindDeletedByID(ctx context.Context, id int) (*entity.User, error)
+	// Restore 恢复软删除的用户并写入其记录的领域事件，邮箱已被重新注册时返回 ErrEmailTaken
+	Restore(ctx context.Context, user *entity.User) error
+	// HardDelete 永久删除已软删除的用户并写入其记录的领域事件，用户未被删除时返回 ErrUserNotDeleted
+	HardDelete(ctx context.Context, user *entity.User) error
+	// FindDeletedBefore 按 ID 升序查询在 before 之前被软删除的用户，afterID 用于分批遍历
+	FindDeletedBefore(ctx context.Context, before time.Time, afterID int, limit int) ([]*entity.User, error)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"base-gin/internal/domain/user/entity"
 	"base-gin/internal/domain/user/repository"
+	"context"
 	"errors"
 )
 
@@ -17,8 +18,8 @@ func NewUserDomainService(userRepo repository.UserRepository) *UserDomainService
 }
 
 // CheckEmailUnique 检查邮箱是否唯一
-func (s *UserDomainService) CheckEmailUnique(email string, excludeID int) error {
-	user, err := s.userRepo.FindByEmail(email)
+func (s *UserDomainService) CheckEmailUnique(ctx context.Context, email string, excludeID int) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return nil // 邮箱不存在，可以使用
 	}
@@ -31,9 +32,9 @@ func (s *UserDomainService) CheckEmailUnique(email string, excludeID int) error
 }
 
 // ValidateUserForCreation 验证用户创建
-func (s *UserDomainService) ValidateUserForCreation(name, email, password string) error {
+func (s *UserDomainService) ValidateUserForCreation(ctx context.Context, name, email, password string) error {
 	// 检查邮箱唯一性
-	if err := s.CheckEmailUnique(email, 0); err != nil {
+	if err := s.CheckEmailUnique(ctx, email, 0); err != nil {
 		return err
 	}
 
@@ -43,15 +44,15 @@ func (s *UserDomainService) ValidateUserForCreation(name, email, password string
 }
 
 // ValidateUserForUpdate 验证用户更新
-func (s *UserDomainService) ValidateUserForUpdate(id int, name, email string) error {
+func (s *UserDomainService) ValidateUserForUpdate(ctx context.Context, id int, name, email string) error {
 	// 检查用户是否存在
-	user, err := s.userRepo.FindByID(id)
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("用户不存在")
 	}
 
 	// 检查邮箱唯一性（排除自己）
-	if err := s.CheckEmailUnique(email, id); err != nil {
+	if err := s.CheckEmailUnique(ctx, email, id); err != nil {
 		return err
 	}
 
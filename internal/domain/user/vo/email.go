@@ -0,0 +1,70 @@
+package vo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrEmailEmpty   = errors.New("邮箱不能为空")
+	ErrEmailInvalid = errors.New("邮箱格式不正确")
+)
+
+// emailRegex 邮箱格式规则，全局只编译一次
+var emailRegex = regexp.MustCompile(`^[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}$`)
+
+// Email 邮箱值对象，创建后不可变，内部始终保存规范化后的值
+type Email struct {
+	value string
+}
+
+// NewEmail 创建邮箱值对象：去除首尾空格并统一转换为小写后再校验格式
+func NewEmail(raw string) (Email, error) {
+	value := NormalizeEmail(raw)
+	if value == "" {
+		return Email{}, ErrEmailEmpty
+	}
+
+	if !emailRegex.MatchString(value) {
+		return Email{}, ErrEmailInvalid
+	}
+
+	return Email{value: value}, nil
+}
+
+// RestoreEmail 从持久化数据重建邮箱值对象，不做校验
+func RestoreEmail(value string) Email {
+	return Email{value: value}
+}
+
+// NormalizeEmail 返回邮箱的规范化形式（去空格、转小写），用于查询和比较
+func NormalizeEmail(raw string) string {
+	return strings.ToLower(strings.TrimSpace(raw))
+}
+
+func (e Email) String() string {
+	return e.value
+}
+
+// Domain 返回邮箱的域名部分
+func (e Email) Domain() string {
+	if i := strings.LastIndexByte(e.value, '@'); i >= 0 {
+		return e.value[i+1:]
+	}
+	return ""
+}
+
+// IsZero 判断是否为零值
+func (e Email) IsZero() bool {
+	return e.value == ""
+}
+
+// Equals 判断两个邮箱是否相同
+func (e Email) Equals(other Email) bool {
+	return e.value == other.value
+}
+
+func (e Email) MarshalText() ([]byte, error) {
+	return []byte(e.value), nil
+}
@@ -0,0 +1,47 @@
+package vo
+
+import "errors"
+
+const PasswordMinLength = 6
+
+var (
+	ErrPasswordEmpty    = errors.New("密码不能为空")
+	ErrPasswordTooShort = errors.New("密码长度不能少于6位")
+)
+
+// Password 密码值对象，String 不输出明文，避免被误写入日志
+type Password struct {
+	value string
+}
+
+// NewPassword 创建密码值对象，密码不做任何规范化
+func NewPassword(raw string) (Password, error) {
+	if raw == "" {
+		return Password{}, ErrPasswordEmpty
+	}
+
+	if len(raw) < PasswordMinLength {
+		return Password{}, ErrPasswordTooShort
+	}
+
+	return Password{value: raw}, nil
+}
+
+// RestorePassword 从持久化数据重建密码值对象，不做校验
+func RestorePassword(value string) Password {
+	return Password{value: value}
+}
+
+// Value 返回密码原始值，仅供持久化使用
+func (p Password) Value() string {
+	return p.value
+}
+
+func (p Password) String() string {
+	return "******"
+}
+
+// IsZero 判断是否为零值
+func (p Password) IsZero() bool {
+	return p.value == ""
+}
@@ -0,0 +1,52 @@
+package vo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UserExportColumns 可导出的列，密码不在其中
+var UserExportColumns = []string{"id", "name", "email", "created_at", "updated_at", "deleted_at"}
+
+// DefaultUserExportColumns 未指定列时导出的列
+var DefaultUserExportColumns = []string{"id", "name", "email", "created_at"}
+
+// UserExportQuery 导出请求参数，筛选与排序参数与列表接口相同
+type UserExportQuery struct {
+	Format  string `form:"format"`
+	Columns string `form:"columns"`
+}
+
+// ParseExportColumns 解析逗号分隔的列名，为空时返回默认列
+func ParseExportColumns(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultUserExportColumns, nil
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, column := range strings.Split(value, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" || seen[column] {
+			continue
+		}
+		if !isExportColumn(column) {
+			return nil, fmt.Errorf("不支持导出的列: %s", column)
+		}
+		seen[column] = true
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return DefaultUserExportColumns, nil
+	}
+	return columns, nil
+}
+
+func isExportColumn(column string) bool {
+	for _, c := range UserExportColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package vo
+
+// 导入模式
+const (
+	// ImportModeFailFast 遇到第一个无效行即停止，之前的行照常写入
+	ImportModeFailFast = "fail-fast"
+	// ImportModeSkipInvalid 跳过无效行和已存在的邮箱，继续导入
+	ImportModeSkipInvalid = "skip-invalid"
+	// ImportModeUpsert 按邮箱更新已存在的用户，跳过无效行
+	ImportModeUpsert = "upsert"
+)
+
+// 单行导入结果
+const (
+	ImportStatusCreated = "created"
+	ImportStatusUpdated = "updated"
+	ImportStatusSkipped = "skipped"
+	ImportStatusFailed  = "failed"
+)
+
+// 导入错误码
+const (
+	ImportCodeInvalidRow      = "INVALID_ROW"
+	ImportCodeInvalidName     = "INVALID_NAME"
+	ImportCodeInvalidEmail    = "INVALID_EMAIL"
+	ImportCodeInvalidPassword = "INVALID_PASSWORD"
+	ImportCodeEmailExists     = "EMAIL_EXISTS"
+	ImportCodeDuplicateInFile = "DUPLICATE_IN_FILE"
+	ImportCodeUnchanged       = "UNCHANGED"
+	ImportCodeAborted         = "ABORTED"
+)
+
+// UserImportQuery 导入请求参数
+type UserImportQuery struct {
+	Format string `form:"format"`
+	Mode   string `form:"mode"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Mode   string
+	DryRun bool
+	// BatchSize 每个事务写入的行数
+	BatchSize int
+}
+
+// ImportRowResult 单行导入结果，Row 为输入中的行号
+type ImportRowResult struct {
+	Row     int    `json:"row"`
+	Email   string `json:"email,omitempty"`
+	Status  string `json:"status"`
+	ID      int    `json:"id,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ImportReport 导入结果汇总，Aborted 表示 fail-fast 模式下提前停止
+type ImportReport struct {
+	Mode    string             `json:"mode"`
+	DryRun  bool               `json:"dry_run"`
+	Total   int                `json:"total"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Aborted bool               `json:"aborted"`
+	Rows    []*ImportRowResult `json:"rows"`
+}
+
+// IsValidImportMode 判断导入模式是否合法
+func IsValidImportMode(mode string) bool {
+	switch mode {
+	case ImportModeFailFast, ImportModeSkipInvalid, ImportModeUpsert:
+		return true
+	}
+	return false
+}
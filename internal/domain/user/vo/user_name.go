@@ -0,0 +1,61 @@
+package vo
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	UserNameMinLength = 2
+	UserNameMaxLength = 50
+)
+
+var (
+	ErrUserNameEmpty  = errors.New("用户名不能为空")
+	ErrUserNameLength = errors.New("用户名长度必须在2-50个字符之间")
+)
+
+// UserName 用户名值对象，创建后不可变
+type UserName struct {
+	value string
+}
+
+// NewUserName 创建用户名值对象：去除首尾空格并做 NFKC 规范化，按字符数校验长度
+func NewUserName(raw string) (UserName, error) {
+	value := norm.NFKC.String(strings.TrimSpace(raw))
+	if value == "" {
+		return UserName{}, ErrUserNameEmpty
+	}
+
+	if n := utf8.RuneCountInString(value); n < UserNameMinLength || n > UserNameMaxLength {
+		return UserName{}, ErrUserNameLength
+	}
+
+	return UserName{value: value}, nil
+}
+
+// RestoreUserName 从持久化数据重建用户名值对象，不做校验
+func RestoreUserName(value string) UserName {
+	return UserName{value: value}
+}
+
+func (n UserName) String() string {
+	return n.value
+}
+
+// IsZero 判断是否为零值
+func (n UserName) IsZero() bool {
+	return n.value == ""
+}
+
+// Equals 判断两个用户名是否相同
+func (n UserName) Equals(other UserName) bool {
+	return n.value == other.value
+}
+
+func (n UserName) MarshalText() ([]byte, error) {
+	return []byte(n.value), nil
+}
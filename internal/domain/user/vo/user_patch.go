@@ -0,0 +1,26 @@
+package vo
+
+import "strings"
+
+// UserPatchRequest 部分更新请求，Body 按 ContentType 解释为 JSON Merge Patch 或 JSON Patch
+type UserPatchRequest struct {
+	ContentType string
+	Body        []byte
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors 字段校验错误列表
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "参数校验失败: " + strings.Join(msgs, "; ")
+}
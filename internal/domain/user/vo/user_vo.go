@@ -1,5 +1,10 @@
 package vo
 
+import (
+	"base-gin/pkg/pagination"
+	"time"
+)
+
 type UserCreateRequest struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
@@ -15,4 +20,62 @@ type UserResponse struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	// Version 乐观锁版本号，通过 ETag 响应头返回
+	Version int `json:"-"`
+	// UpdatedAt 最后修改时间，通过 Last-Modified 响应头返回
+	UpdatedAt time.Time `json:"-"`
+	// DeletedAt 软删除时间，仅在查询已删除用户时返回
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// PurgeQuery 清理过期已删除用户的参数，dry_run 默认为 true
+type PurgeQuery struct {
+	OlderThanDays int  `form:"older_than_days" binding:"required,min=1"`
+	DryRun        bool `form:"dry_run"`
+}
+
+// PurgeReport 清理结果，DryRun 为 true 时 Users 为将被永久删除的用户
+type PurgeReport struct {
+	DryRun        bool            `json:"dry_run"`
+	DeletedBefore time.Time       `json:"deleted_before"`
+	Count         int             `json:"count"`
+	Users         []*UserResponse `json:"users"`
+}
+
+// UserListQuery 用户列表查询参数
+type UserListQuery struct {
+	Mode        string `form:"mode"`
+	Name        string `form:"name"`
+	EmailDomain string `form:"email_domain"`
+	CreatedFrom string `form:"created_from"`
+	CreatedTo   string `form:"created_to"`
+	Status      string `form:"status"`
+}
+
+// UserListResult 用户列表查询结果
+type UserListResult struct {
+	Users  []*UserResponse
+	Total  int64
+	Cursor pagination.CursorMeta
+}
+
+// UserSearchQuery 用户全文检索参数
+type UserSearchQuery struct {
+	Q     string `form:"q" binding:"required"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// UserSearchResponse 用户全文检索结果
+type UserSearchResponse struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	Email     string        `json:"email"`
+	Rank      float64       `json:"rank"`
+	Highlight UserHighlight `json:"highlight"`
+}
+
+// UserHighlight 命中关键字高亮后的字段，已做 HTML 转义
+type UserHighlight struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// 投递状态
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery 一个事件对一个订阅的投递，失败时按重试策略多次尝试
+type Delivery struct {
+	ID             int
+	SubscriptionID int
+	// EventID 事件唯一标识，同一事件投递给不同订阅时相同，接收方据此去重
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DeliveryAttempt 一次投递尝试的记录，请求和响应体只保留开头部分
+type DeliveryAttempt struct {
+	ID              int
+	DeliveryID      int
+	Attempt         int
+	RequestHeaders  map[string]string
+	RequestBody     string
+	ResponseStatus  int
+	ResponseHeaders map[string]string
+	ResponseBody    string
+	Error           string
+	Duration        time.Duration
+	CreatedAt       time.Time
+}
+
+// Succeeded 是否收到 2xx 响应
+func (a *DeliveryAttempt) Succeeded() bool {
+	return a.Error == "" && a.ResponseStatus >= 200 && a.ResponseStatus <= 299
+}
+
+// RetryPolicy 投递重试策略：第 n 次失败后等待 Base*2^(n-1)，不超过 Max，
+// 实际间隔在 [d/2, d] 之间随机，避免大量投递同时重试
+type RetryPolicy struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+// Delay 第 attempt 次失败后的重试间隔
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Base
+	for i := 1; i < attempt && i < 30; i++ {
+		delay *= 2
+		if p.Max > 0 && delay >= p.Max {
+			delay = p.Max
+			break
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// RecordAttempt 根据尝试结果更新投递状态，达到最大次数后不再重试
+func (d *Delivery) RecordAttempt(attempt *DeliveryAttempt, policy RetryPolicy) {
+	now := attempt.CreatedAt
+	d.Attempts = attempt.Attempt
+	d.LastAttemptAt = &now
+	d.LastStatusCode = attempt.ResponseStatus
+	d.LastError = attempt.Error
+	d.UpdatedAt = now
+
+	switch {
+	case attempt.Succeeded():
+		d.Status = DeliverySucceeded
+		d.NextAttemptAt = nil
+	case policy.MaxAttempts > 0 && d.Attempts >= policy.MaxAttempts:
+		d.Status = DeliveryFailed
+		d.NextAttemptAt = nil
+	default:
+		next := now.Add(policy.Delay(d.Attempts))
+		d.Status = DeliveryPending
+		d.NextAttemptAt = &next
+	}
+}
+
+// Redeliver 手动重新投递：立即再尝试一次，尝试次数继续累加。
+// 仍在重试中的投递相当于提前下一次尝试；已失败的投递再次失败后不会自动重试
+func (d *Delivery) Redeliver() {
+	now := time.Now()
+	d.Status = DeliveryPending
+	d.NextAttemptAt = &now
+	d.UpdatedAt = now
+}
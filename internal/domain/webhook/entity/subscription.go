@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EventWildcard 订阅所有事件
+const EventWildcard = "*"
+
+// secretPrefix 自动生成的签名密钥前缀，便于识别
+const secretPrefix = "whsec_"
+
+var (
+	ErrInvalidURL     = errors.New("回调地址必须是 http 或 https 的绝对地址")
+	ErrEventsRequired = errors.New("至少需要订阅一个事件类型")
+	ErrSecretTooShort = errors.New("签名密钥长度不能少于16位")
+)
+
+// Subscription Webhook 订阅，事件发生时向 URL 投递签名的请求。
+// 连续失败次数达到阈值后自动停用，重新启用时清零
+type Subscription struct {
+	ID          int
+	URL         string
+	Events      []string
+	Secret      string
+	Description string
+	Active      bool
+	// ConsecutiveFailures 连续失败的投递尝试次数，任一次成功后清零
+	ConsecutiveFailures int
+	DisabledAt          *time.Time
+	DisabledReason      string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// NewSubscription 创建订阅，secret 为空时自动生成
+func NewSubscription(rawURL string, events []string, secret, description string) (*Subscription, error) {
+	s := &Subscription{Description: strings.TrimSpace(description), Active: true}
+	if err := s.UpdateURL(rawURL); err != nil {
+		return nil, err
+	}
+	if err := s.UpdateEvents(events); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		secret = GenerateSecret()
+	} else if len(secret) < 16 {
+		return nil, ErrSecretTooShort
+	}
+	s.Secret = secret
+
+	now := time.Now()
+	s.CreatedAt, s.UpdatedAt = now, now
+	return s, nil
+}
+
+// GenerateSecret 生成随机签名密钥
+func GenerateSecret() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return secretPrefix + hex.EncodeToString(b)
+}
+
+// UpdateURL 校验并修改回调地址
+func (s *Subscription) UpdateURL(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	s.URL = u.String()
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateEvents 修改订阅的事件类型，去重并去除空白，是否为已知事件由应用服务校验
+func (s *Subscription) UpdateEvents(events []string) error {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if e == "" || seen[e] {
+			continue
+		}
+		seen[e] = true
+		normalized = append(normalized, e)
+	}
+	if len(normalized) == 0 {
+		return ErrEventsRequired
+	}
+	s.Events = normalized
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Matches 判断订阅是否接收该类型的事件
+func (s *Subscription) Matches(eventType string) bool {
+	for _, e := range s.Events {
+		if e == EventWildcard || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Enable 重新启用订阅并清零失败计数
+func (s *Subscription) Enable() {
+	s.Active = true
+	s.ConsecutiveFailures = 0
+	s.DisabledAt = nil
+	s.DisabledReason = ""
+	s.UpdatedAt = time.Now()
+}
+
+// Disable 停用订阅，停用期间不投递
+func (s *Subscription) Disable(reason string) {
+	now := time.Now()
+	s.Active = false
+	s.DisabledAt = &now
+	s.DisabledReason = reason
+	s.UpdatedAt = now
+}
+
+// AutoDisableReason 连续失败自动停用时记录的原因
+func AutoDisableReason(failures int) string {
+	return fmt.Sprintf("连续 %d 次投递失败，已自动停用", failures)
+}
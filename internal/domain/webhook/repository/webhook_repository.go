@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"base-gin/internal/domain/webhook/entity"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSubscriptionNotFound 订阅不存在
+var ErrSubscriptionNotFound = errors.New("订阅不存在")
+
+// ErrDeliveryNotFound 投递记录不存在
+var ErrDeliveryNotFound = errors.New("投递记录不存在")
+
+// DeliveryCriteria 投递记录查询条件
+type DeliveryCriteria struct {
+	SubscriptionID int
+	// Status 为空时不按状态筛选
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// WebhookRepository Webhook 订阅与投递记录仓储接口
+type WebhookRepository interface {
+	SaveSubscription(ctx context.Context, subscription *entity.Subscription) error
+	// UpdateSubscription 保存订阅的地址、事件、描述和启用状态
+	UpdateSubscription(ctx context.Context, subscription *entity.Subscription) error
+	// DeleteSubscription 删除订阅及其投递记录
+	DeleteSubscription(ctx context.Context, id int) error
+	FindSubscription(ctx context.Context, id int) (*entity.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error)
+	// FindActiveSubscriptions 查询所有启用的订阅
+	FindActiveSubscriptions(ctx context.Context) ([]*entity.Subscription, error)
+
+	// CreateDeliveries 批量创建待投递记录
+	CreateDeliveries(ctx context.Context, deliveries []*entity.Delivery) error
+	FindDelivery(ctx context.Context, subscriptionID, id int) (*entity.Delivery, error)
+	ListDeliveries(ctx context.Context, criteria DeliveryCriteria) ([]*entity.Delivery, int64, error)
+	// ListAttempts 按尝试顺序返回投递的所有尝试记录
+	ListAttempts(ctx context.Context, deliveryID int) ([]*entity.DeliveryAttempt, error)
+	// ClaimDueDeliveries 领取最多 limit 条到期且订阅仍启用的待投递记录，并加上 lease 时长的租约
+	ClaimDueDeliveries(ctx context.Context, worker string, limit int, lease time.Duration) ([]*entity.Delivery, error)
+	// RecordAttempt 在同一事务中保存尝试记录、投递状态和订阅的连续失败次数，
+	// 失败次数达到 disableAfter（大于 0 时）时停用订阅，返回订阅是否因此被停用
+	RecordAttempt(ctx context.Context, delivery *entity.Delivery, attempt *entity.DeliveryAttempt, disableAfter int) (disabled bool, err error)
+	// UpdateDelivery 保存投递状态和下次尝试时间，用于手动重新投递
+	UpdateDelivery(ctx context.Context, delivery *entity.Delivery) error
+}
@@ -0,0 +1,77 @@
+package vo
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SubscriptionCreateRequest 创建订阅请求，secret 为空时自动生成
+type SubscriptionCreateRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+}
+
+// SubscriptionUpdateRequest 修改订阅请求，未传的字段保持不变。
+// active 设为 true 会清零连续失败次数，用于重新启用自动停用的订阅
+type SubscriptionUpdateRequest struct {
+	URL         *string  `json:"url"`
+	Events      []string `json:"events"`
+	Description *string  `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+type SubscriptionResponse struct {
+	ID          int      `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	// Secret 签名密钥，仅在创建时返回一次
+	Secret              string     `json:"secret,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// DeliveryListQuery 投递记录查询参数
+type DeliveryListQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending succeeded failed"`
+}
+
+type DeliveryResponse struct {
+	ID             int        `json:"id"`
+	SubscriptionID int        `json:"subscription_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DeliveryDetailResponse 投递详情，包含完整载荷和每次尝试的请求、响应片段
+type DeliveryDetailResponse struct {
+	DeliveryResponse
+	Payload json.RawMessage    `json:"payload"`
+	History []*AttemptResponse `json:"history"`
+}
+
+// AttemptResponse 一次投递尝试，请求体和响应体只保留开头部分
+type AttemptResponse struct {
+	Attempt         int               `json:"attempt"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	RequestBody     string            `json:"request_body"`
+	ResponseStatus  int               `json:"response_status,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	DurationMs      int64             `json:"duration_ms"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
@@ -2,8 +2,10 @@ package cache
 
 import (
 	"base-gin/configs"
+	"context"
 	"fmt"
 	"log"
+	"net"
 )
 
 type RedisClient struct {
@@ -26,6 +28,16 @@ func (r *RedisClient) GetConnectionString() string {
 	return fmt.Sprintf("%s:%d", r.config.Host, r.config.Port)
 }
 
+// Ping 检查 Redis 端口是否可连接
+func (r *RedisClient) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", r.GetConnectionString())
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 func (r *RedisClient) Close() error {
 	// 在真实项目中，这里会关闭 Redis 连接
 	log.Println("Redis连接已关闭")
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"base-gin/configs"
+	"base-gin/pkg/metrics"
+	"sync"
+	"time"
+)
+
+// Store 响应缓存存储，按标签批量失效
+type Store interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration, tags ...string)
+	// InvalidateTags 删除带有任一标签的缓存项
+	InvalidateTags(tags ...string)
+}
+
+// NewResponseStore 根据配置创建 HTTP 响应缓存，未启用时返回 nil；registry 不为 nil 时记录命中率和条数
+func NewResponseStore(config *configs.Config, registry *metrics.Registry) Store {
+	if !config.HTTPCache.StoreEnabled {
+		return nil
+	}
+	store := NewMemoryStore(config.HTTPCache.StoreMaxEntries)
+	if registry == nil {
+		return store
+	}
+	return NewInstrumentedStore(store, registry, "response")
+}
+
+// InstrumentedStore 统计缓存命中和未命中次数的 Store
+type InstrumentedStore struct {
+	Store
+	hits   *metrics.Counter
+	misses *metrics.Counter
+}
+
+// NewInstrumentedStore 包装 store，name 为指标的 cache 标签；store 实现 Len() int 时同时记录 cache_entries。
+// 指标按名称注册，同一注册表只能包装一个 Store
+func NewInstrumentedStore(store Store, registry *metrics.Registry, name string) *InstrumentedStore {
+	requests := registry.NewCounterVec("cache_requests_total",
+		"Total number of cache lookups by result.", "cache", "result")
+	if sized, ok := store.(interface{ Len() int }); ok {
+		registry.MustRegister(metrics.CollectorFunc(func() []metrics.Family {
+			return []metrics.Family{{
+				Name: "cache_entries", Help: "Number of entries in the cache.", Type: metrics.TypeGauge,
+				Samples: []metrics.Sample{{
+					Name:   "cache_entries",
+					Labels: []metrics.Label{{Name: "cache", Value: name}},
+					Value:  float64(sized.Len()),
+				}},
+			}}
+		}))
+	}
+	return &InstrumentedStore{
+		Store:  store,
+		hits:   requests.With(name, "hit"),
+		misses: requests.With(name, "miss"),
+	}
+}
+
+func (s *InstrumentedStore) Get(key string) ([]byte, bool) {
+	value, ok := s.Store.Get(key)
+	if ok {
+		s.hits.Inc()
+	} else {
+		s.misses.Inc()
+	}
+	return value, ok
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// MemoryStore 进程内缓存，多实例部署时各实例独立，依赖 TTL 兜底
+type MemoryStore struct {
+	mutex      sync.Mutex
+	entries    map[string]*memoryEntry
+	tags       map[string]map[string]struct{}
+	maxEntries int
+}
+
+// NewMemoryStore 创建进程内缓存，maxEntries 小于等于 0 时不限制条数
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		entries:    make(map[string]*memoryEntry),
+		tags:       make(map[string]map[string]struct{}),
+		maxEntries: maxEntries,
+	}
+}
+
+func (s *MemoryStore) Get(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		s.remove(key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *MemoryStore) Set(key string, value []byte, ttl time.Duration, tags ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.remove(key)
+	if s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.removeExpired()
+		if len(s.entries) >= s.maxEntries {
+			// 缓存已满时不再写入，等待过期或失效后腾出空间
+			return
+		}
+	}
+
+	s.entries[key] = &memoryEntry{value: value, expiresAt: time.Now().Add(ttl), tags: tags}
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+func (s *MemoryStore) InvalidateTags(tags ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(key)
+		}
+		delete(s.tags, tag)
+	}
+}
+
+// Len 返回缓存条数，包含尚未清理的过期项
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) remove(key string) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+	for _, tag := range entry.tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
+
+func (s *MemoryStore) removeExpired() {
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			s.remove(key)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+// SQLite 和 PostgreSQL 均通过触发器拒绝修改和删除审计记录，只能追加。
+// 触发器挡住的是应用和误操作，有数据库管理员权限者仍可删除触发器，篡改由哈希链校验发现
+var sqliteAuditStatements = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_logs_no_update BEFORE UPDATE ON audit_logs BEGIN
+		SELECT RAISE(ABORT, 'audit_logs is append-only');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_logs_no_delete BEFORE DELETE ON audit_logs BEGIN
+		SELECT RAISE(ABORT, 'audit_logs is append-only');
+	END`,
+}
+
+var postgresAuditStatements = []string{
+	`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_logs is append-only';
+	END;
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`,
+	`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+}
+
+// setupAuditLog 创建禁止修改和删除审计记录的触发器
+func (db *DB) setupAuditLog() error {
+	statements := sqliteAuditStatements
+	if db.getDatabaseType() == "postgres" {
+		statements = postgresAuditStatements
+	}
+	for _, stmt := range statements {
+		if err := db.gormDB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
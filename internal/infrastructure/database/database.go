@@ -3,6 +3,9 @@ package database
 import (
 	"base-gin/configs"
 	"base-gin/internal/infrastructure/database/models"
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/pkg/metrics"
+	"base-gin/pkg/tracing"
 	"fmt"
 	"log"
 	"os"
@@ -11,17 +14,24 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 )
 
+// sqlitePath SQLite 数据库文件路径
+const sqlitePath = "data/app.db"
+
 type DB struct {
-	config *configs.DatabaseConfig
-	gormDB *gorm.DB
+	config          *configs.DatabaseConfig
+	logger          gormlogger.Interface
+	gormDB          *gorm.DB
+	fullTextEnabled bool
 }
 
-func NewDB(config *configs.Config) *DB {
+// NewDB 连接数据库并执行迁移，SQL 日志跟随 logger 的级别；registry 不为 nil 时记录查询和连接池指标，tracer 不为 nil 时为 SQL 创建 span
+func NewDB(config *configs.Config, logger *logging.Logger, registry *metrics.Registry, tracer *tracing.Tracer) *DB {
 	db := &DB{
 		config: &config.Database,
+		logger: logging.NewGormLogger(logger),
 	}
 
 	// 初始化GORM连接
@@ -29,6 +39,17 @@ func NewDB(config *configs.Config) *DB {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	if registry != nil {
+		if err := db.registerMetrics(registry); err != nil {
+			log.Fatalf("Failed to register database metrics: %v", err)
+		}
+	}
+	if tracer != nil {
+		if err := db.registerTracing(tracer); err != nil {
+			log.Fatalf("Failed to register database tracing: %v", err)
+		}
+	}
+
 	// 自动迁移数据库表
 	if err := db.migrate(); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
@@ -46,7 +67,7 @@ func (db *DB) initGORM() error {
 	switch db.getDatabaseType() {
 	case "sqlite":
 		// 使用SQLite - 确保数据目录存在
-		dbPath := "data/app.db"
+		dbPath := sqlitePath
 		mkdirErr := os.MkdirAll(filepath.Dir(dbPath), 0755)
 		if mkdirErr != nil {
 			return fmt.Errorf("failed to create database directory: %v", mkdirErr)
@@ -59,7 +80,7 @@ func (db *DB) initGORM() error {
 		dialector = postgres.Open(dsn)
 	default:
 		// 默认使用SQLite - 确保数据目录存在
-		dbPath := "data/app.db"
+		dbPath := sqlitePath
 		if mkErr := os.MkdirAll(filepath.Dir(dbPath), 0755); mkErr != nil {
 			return fmt.Errorf("failed to create database directory: %v", mkErr)
 		}
@@ -68,7 +89,7 @@ func (db *DB) initGORM() error {
 
 	// 配置GORM
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: db.logger,
 	}
 
 	db.gormDB, err = gorm.Open(dialector, gormConfig)
@@ -77,7 +98,20 @@ func (db *DB) initGORM() error {
 
 func (db *DB) migrate() error {
 	// 自动迁移所有模型
-	if err := db.gormDB.AutoMigrate(&models.UserModel{}); err != nil {
+	if err := db.gormDB.AutoMigrate(
+		&models.UserModel{},
+		&models.SchemaMigrationModel{},
+		&models.OutboxMessageModel{},
+		&models.WebhookSubscriptionModel{},
+		&models.WebhookDeliveryModel{},
+		&models.WebhookDeliveryAttemptModel{},
+		&models.AuditLogModel{},
+	); err != nil {
+		return err
+	}
+
+	// 执行数据迁移
+	if err := db.runDataMigrations(); err != nil {
 		return err
 	}
 
@@ -88,11 +122,23 @@ func (db *DB) migrate() error {
 		// db.gormDB.Exec("DROP INDEX IF EXISTS idx_users_email_active")
 
 		// 创建支持软删除的部分唯一索引（只对未删除的记录）
-		return db.gormDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL").Error
+		if err := db.gormDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL").Error; err != nil {
+			return err
+		}
 	} else {
 		// PostgreSQL支持部分索引
-		return db.gormDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL").Error
+		if err := db.gormDB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL").Error; err != nil {
+			return err
+		}
 	}
+
+	// 审计记录只能追加
+	if err := db.setupAuditLog(); err != nil {
+		return err
+	}
+
+	// 创建全文索引
+	return db.setupFullTextSearch()
 }
 
 func (db *DB) getDatabaseType() string {
@@ -111,7 +157,7 @@ func (db *DB) GetGormDB() *gorm.DB {
 func (db *DB) GetConnectionString() string {
 	switch db.getDatabaseType() {
 	case "sqlite":
-		return "data/app.db"
+		return sqlitePath
 	default:
 		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 			db.config.Host, db.config.Port, db.config.Username, db.config.Password, db.config.Database)
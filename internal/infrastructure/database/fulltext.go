@@ -0,0 +1,89 @@
+package database
+
+import (
+	"log"
+	"strings"
+)
+
+// SQLite 使用 FTS5 外部内容表索引 users.search_text，并由触发器保持同步。
+// 注意 go-sqlite3 需要以 sqlite_fts5 构建标签编译才包含 FTS5 模块。
+var sqliteFullTextStatements = []string{
+	`CREATE TRIGGER IF NOT EXISTS users_fts_ai AFTER INSERT ON users BEGIN
+		INSERT INTO users_fts(rowid, search_text) VALUES (new.id, new.search_text);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS users_fts_ad AFTER DELETE ON users BEGIN
+		INSERT INTO users_fts(users_fts, rowid, search_text) VALUES ('delete', old.id, old.search_text);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS users_fts_au AFTER UPDATE OF search_text ON users BEGIN
+		INSERT INTO users_fts(users_fts, rowid, search_text) VALUES ('delete', old.id, old.search_text);
+		INSERT INTO users_fts(rowid, search_text) VALUES (new.id, new.search_text);
+	END`,
+}
+
+var sqliteFullTextTriggers = []string{"users_fts_ai", "users_fts_ad", "users_fts_au"}
+
+// PostgreSQL 使用基于 search_text 的生成列 tsvector 和 GIN 索引
+var postgresFullTextStatements = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', coalesce(search_text, ''))) STORED`,
+	`CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector)`,
+}
+
+// setupFullTextSearch 创建全文索引。SQLite 不支持 FTS5 时删除触发器并退化为 LIKE 查询。
+func (db *DB) setupFullTextSearch() error {
+	if db.getDatabaseType() == "postgres" {
+		for _, stmt := range postgresFullTextStatements {
+			if err := db.gormDB.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		db.fullTextEnabled = true
+		return nil
+	}
+
+	var existing int64
+	if err := db.gormDB.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'users_fts'").Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	err := db.gormDB.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts5(search_text, content='users', content_rowid='id')").Error
+	if err != nil {
+		if !strings.Contains(err.Error(), "no such module") {
+			return err
+		}
+		// 触发器引用了不可用的 FTS5 表，保留会导致写入 users 失败
+		for _, name := range sqliteFullTextTriggers {
+			if err := db.gormDB.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+				return err
+			}
+		}
+		log.Println("SQLite 未启用 FTS5（需要 -tags sqlite_fts5 构建），全文检索退化为 LIKE 查询")
+		return nil
+	}
+
+	for _, stmt := range sqliteFullTextStatements {
+		if err := db.gormDB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	// 新建索引时根据已有数据重建
+	if existing == 0 {
+		if err := db.gormDB.Exec("INSERT INTO users_fts(users_fts) VALUES ('rebuild')").Error; err != nil {
+			return err
+		}
+	}
+
+	db.fullTextEnabled = true
+	return nil
+}
+
+// FullTextEnabled 数据库是否支持全文索引
+func (db *DB) FullTextEnabled() bool {
+	return db.fullTextEnabled
+}
+
+// Dialect 返回数据库类型：sqlite 或 postgres
+func (db *DB) Dialect() string {
+	return db.getDatabaseType()
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"base-gin/internal/infrastructure/database/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Ping 检查数据库连接是否可用
+func (db *DB) Ping(ctx context.Context) error {
+	sqlDB, err := db.gormDB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// PendingMigrations 返回尚未成功执行的数据迁移版本，部分完成的迁移会在下次启动时重试
+func (db *DB) PendingMigrations(ctx context.Context) ([]string, error) {
+	var applied []string
+	if err := db.gormDB.WithContext(ctx).Model(&models.SchemaMigrationModel{}).Pluck("version", &applied).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	var pending []string
+	for _, m := range dataMigrations {
+		if !done[m.Version] {
+			pending = append(pending, m.Version)
+		}
+	}
+	return pending, nil
+}
+
+// CheckMigrations 有未完成的数据迁移时返回错误，用于健康检查
+func (db *DB) CheckMigrations(ctx context.Context) error {
+	pending, err := db.PendingMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("数据迁移未完成: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// FilePath 返回 SQLite 数据库文件路径，其他数据库返回空字符串
+func (db *DB) FilePath() string {
+	if db.getDatabaseType() != "sqlite" {
+		return ""
+	}
+	return sqlitePath
+}
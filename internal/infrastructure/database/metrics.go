@@ -0,0 +1,95 @@
+package database
+
+import (
+	"base-gin/pkg/metrics"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// metricsStartKey 查询开始时间在 Statement 中的键
+const metricsStartKey = "metrics:start"
+
+// registrar GORM 回调注册点
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// registerMetrics 通过 GORM 回调统计查询耗时和错误次数，并在抓取时读取连接池状态
+func (db *DB) registerMetrics(registry *metrics.Registry) error {
+	duration := registry.NewHistogramVec("db_query_duration_seconds",
+		"Database query latency in seconds.", metrics.DefBuckets, "operation", "table")
+	errorsTotal := registry.NewCounterVec("db_query_errors_total",
+		"Total number of failed database queries, excluding record not found.", "operation", "table")
+
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(metricsStartKey, time.Now())
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(metricsStartKey)
+			if !ok {
+				return
+			}
+			table := tx.Statement.Table
+			if table == "" {
+				table = "unknown"
+			}
+			duration.With(operation, table).Observe(time.Since(value.(time.Time)).Seconds())
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				errorsTotal.With(operation, table).Inc()
+			}
+		}
+	}
+
+	callback := db.gormDB.Callback()
+	for _, p := range []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", callback.Create().Before("gorm:create"), callback.Create().After("gorm:create")},
+		{"query", callback.Query().Before("gorm:query"), callback.Query().After("gorm:query")},
+		{"update", callback.Update().Before("gorm:update"), callback.Update().After("gorm:update")},
+		{"delete", callback.Delete().Before("gorm:delete"), callback.Delete().After("gorm:delete")},
+		{"row", callback.Row().Before("gorm:row"), callback.Row().After("gorm:row")},
+		{"raw", callback.Raw().Before("gorm:raw"), callback.Raw().After("gorm:raw")},
+	} {
+		if err := p.before.Register("metrics:before_"+p.operation, before); err != nil {
+			return err
+		}
+		if err := p.after.Register("metrics:after_"+p.operation, after(p.operation)); err != nil {
+			return err
+		}
+	}
+
+	return db.registerPoolMetrics(registry)
+}
+
+// registerPoolMetrics 连接池状态在抓取时从 sql.DBStats 读取
+func (db *DB) registerPoolMetrics(registry *metrics.Registry) error {
+	sqlDB, err := db.gormDB.DB()
+	if err != nil {
+		return err
+	}
+	return registry.Register(metrics.CollectorFunc(func() []metrics.Family {
+		stats := sqlDB.Stats()
+		gauge := func(name, help string, v float64) metrics.Family {
+			return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge, Samples: []metrics.Sample{{Name: name, Value: v}}}
+		}
+		counter := func(name, help string, v float64) metrics.Family {
+			return metrics.Family{Name: name, Help: help, Type: metrics.TypeCounter, Samples: []metrics.Sample{{Name: name, Value: v}}}
+		}
+		return []metrics.Family{
+			gauge("db_max_open_connections", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections)),
+			gauge("db_open_connections", "The number of established connections both in use and idle.", float64(stats.OpenConnections)),
+			gauge("db_in_use_connections", "The number of connections currently in use.", float64(stats.InUse)),
+			gauge("db_idle_connections", "The number of idle connections.", float64(stats.Idle)),
+			counter("db_wait_count_total", "The total number of connections waited for.", float64(stats.WaitCount)),
+			counter("db_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", stats.WaitDuration.Seconds()),
+			counter("db_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.", float64(stats.MaxIdleClosed)),
+			counter("db_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.", float64(stats.MaxIdleTimeClosed)),
+			counter("db_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", float64(stats.MaxLifetimeClosed)),
+		}
+	}))
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"base-gin/internal/infrastructure/database/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errMigrationIncomplete 迁移部分完成，下次启动时需要重新执行
+var errMigrationIncomplete = errors.New("迁移未完全完成")
+
+// dataMigration 数据迁移，在表结构迁移之后按顺序执行且只成功执行一次
+type dataMigration struct {
+	Version string
+	Run     func(tx *gorm.DB) error
+}
+
+// dataMigrations 所有数据迁移，新增迁移追加到末尾
+var dataMigrations = []dataMigration{
+	{Version: "20240601_normalize_user_emails", Run: normalizeUserEmailsMigration},
+	{Version: "20240610_backfill_user_search_text", Run: backfillUserSearchText},
+}
+
+func (db *DB) runDataMigrations() error {
+	for _, m := range dataMigrations {
+		var count int64
+		if err := db.gormDB.Model(&models.SchemaMigrationModel{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if err := m.Run(db.gormDB); err != nil {
+			if errors.Is(err, errMigrationIncomplete) {
+				log.Printf("数据迁移 %s 未完成，将在下次启动时重试", m.Version)
+				continue
+			}
+			return fmt.Errorf("数据迁移 %s 失败: %w", m.Version, err)
+		}
+
+		if err := db.gormDB.Create(&models.SchemaMigrationModel{Version: m.Version, AppliedAt: time.Now()}).Error; err != nil {
+			return err
+		}
+		log.Printf("数据迁移 %s 已完成", m.Version)
+	}
+	return nil
+}
+
+// EmailCollision 规范化后邮箱相同的一组用户
+type EmailCollision struct {
+	Email   string
+	UserIDs []uint
+}
+
+// EmailNormalizationReport 邮箱规范化结果
+type EmailNormalizationReport struct {
+	Updated    int
+	Collisions []EmailCollision
+}
+
+// NormalizeUserEmails 将未删除用户的邮箱统一转换为小写。
+// 转换后会与其他用户冲突的邮箱保持不变，并在报告中列出，需要人工处理。
+func NormalizeUserEmails(tx *gorm.DB) (*EmailNormalizationReport, error) {
+	var users []models.UserModel
+	if err := tx.Select("id", "email").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]models.UserModel)
+	var order []string
+	for _, u := range users {
+		key := strings.ToLower(strings.TrimSpace(u.Email))
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], u)
+	}
+
+	report := &EmailNormalizationReport{}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		for _, key := range order {
+			group := groups[key]
+			if len(group) > 1 {
+				collision := EmailCollision{Email: key}
+				for _, u := range group {
+					collision.UserIDs = append(collision.UserIDs, u.ID)
+				}
+				report.Collisions = append(report.Collisions, collision)
+				continue
+			}
+
+			u := group[0]
+			if u.Email == key {
+				continue
+			}
+			if err := tx.Model(&models.UserModel{}).Where("id = ?", u.ID).UpdateColumn("email", key).Error; err != nil {
+				return err
+			}
+			report.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func normalizeUserEmailsMigration(tx *gorm.DB) error {
+	report, err := NormalizeUserEmails(tx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("邮箱规范化: 更新 %d 条记录, 冲突 %d 组", report.Updated, len(report.Collisions))
+	for _, c := range report.Collisions {
+		log.Printf("邮箱冲突: %s 对应用户 %v", c.Email, c.UserIDs)
+	}
+
+	if len(report.Collisions) > 0 {
+		return errMigrationIncomplete
+	}
+	return nil
+}
+
+// backfillUserSearchText 为已有用户生成全文检索文档（包括已删除用户，以便恢复后可被检索）
+func backfillUserSearchText(tx *gorm.DB) error {
+	var users []models.UserModel
+	return tx.Unscoped().Select("id", "name", "email").FindInBatches(&users, 500, func(_ *gorm.DB, _ int) error {
+		for _, u := range users {
+			err := tx.Unscoped().Model(&models.UserModel{}).Where("id = ?", u.ID).
+				UpdateColumn("search_text", models.UserSearchText(u.Name, u.Email)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
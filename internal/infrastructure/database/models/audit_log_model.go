@@ -0,0 +1,70 @@
+package models
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"encoding/json"
+	"time"
+)
+
+// AuditLogModel 审计记录，只追加不修改。ID 由仓储按顺序分配，Changes 以 JSON 保存，
+// 数据库触发器拒绝 UPDATE 和 DELETE。ActorSource 在早于该字段的记录中为空
+type AuditLogModel struct {
+	ID          int64     `gorm:"primarykey;autoIncrement:false"`
+	Actor       string    `gorm:"type:varchar(128);not null;index"`
+	ActorSource string    `gorm:"type:varchar(20);not null;default:''"`
+	Action      string    `gorm:"type:varchar(50);not null;index"`
+	TargetType  string    `gorm:"type:varchar(50);not null;index:idx_audit_logs_target,priority:1"`
+	TargetID    string    `gorm:"type:varchar(64);not null;index:idx_audit_logs_target,priority:2"`
+	Changes     string    `gorm:"type:text;not null"`
+	RequestID   string    `gorm:"type:varchar(128);not null;default:'';index"`
+	IP          string    `gorm:"type:varchar(64);not null;default:''"`
+	UserAgent   string    `gorm:"type:varchar(512);not null;default:''"`
+	CreatedAt   time.Time `gorm:"not null;index"`
+	PrevHash    string    `gorm:"type:varchar(64);not null"`
+	Hash        string    `gorm:"type:varchar(64);not null"`
+}
+
+// TableName 指定表名
+func (AuditLogModel) TableName() string {
+	return "audit_logs"
+}
+
+// ToEntity 将GORM模型转换为领域实体，Changes 解析失败时保留原文用于哈希校验
+func (m *AuditLogModel) ToEntity() *entity.Entry {
+	e := &entity.Entry{
+		ID:          m.ID,
+		Actor:       m.Actor,
+		ActorSource: m.ActorSource,
+		Action:      m.Action,
+		TargetType:  m.TargetType,
+		TargetID:    m.TargetID,
+		RawChanges:  m.Changes,
+		RequestID:   m.RequestID,
+		IP:          m.IP,
+		UserAgent:   m.UserAgent,
+		CreatedAt:   m.CreatedAt,
+		PrevHash:    m.PrevHash,
+		Hash:        m.Hash,
+	}
+	json.Unmarshal([]byte(m.Changes), &e.Changes)
+	return e
+}
+
+// NewAuditLogModel 从已计算哈希的领域实体创建GORM模型
+func NewAuditLogModel(e *entity.Entry) *AuditLogModel {
+	return &AuditLogModel{
+		ID:          e.ID,
+		Actor:       e.Actor,
+		ActorSource: e.ActorSource,
+		Action:      e.Action,
+		TargetType:  e.TargetType,
+		TargetID:    e.TargetID,
+		Changes:     e.RawChanges,
+		RequestID:   e.RequestID,
+		IP:          e.IP,
+		UserAgent:   e.UserAgent,
+		CreatedAt:   e.CreatedAt,
+		PrevHash:    e.PrevHash,
+		Hash:        e.Hash,
+	}
+}
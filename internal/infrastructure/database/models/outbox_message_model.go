@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// 发件箱消息状态
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusDead      = "dead"
+)
+
+// OutboxMessageModel 事务性发件箱消息，与业务数据在同一事务中写入，由中继投递到外部系统。
+// LockedBy/LockedUntil 为中继的租约，租约过期的消息可被其他中继重新领取
+type OutboxMessageModel struct {
+	ID            uint      `gorm:"primarykey"`
+	EventID       string    `gorm:"type:varchar(36);not null;uniqueIndex"`
+	EventName     string    `gorm:"type:varchar(100);not null"`
+	AggregateType string    `gorm:"type:varchar(50);not null"`
+	AggregateID   string    `gorm:"type:varchar(64);not null"`
+	Payload       string    `gorm:"type:text;not null"`
+	OccurredAt    time.Time `gorm:"not null"`
+	Status        string    `gorm:"type:varchar(20);not null;default:pending;index:idx_outbox_status_next,priority:1"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_status_next,priority:2"`
+	LockedBy      string    `gorm:"type:varchar(64);not null;default:''"`
+	LockedUntil   *time.Time
+	LastError     string `gorm:"type:text;not null;default:''"`
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+}
+
+// TableName 指定表名
+func (OutboxMessageModel) TableName() string {
+	return "outbox_messages"
+}
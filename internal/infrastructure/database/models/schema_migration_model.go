@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SchemaMigrationModel 记录已执行的数据迁移
+type SchemaMigrationModel struct {
+	Version   string    `gorm:"primarykey;type:varchar(100)" json:"version"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// TableName 指定表名
+func (SchemaMigrationModel) TableName() string {
+	return "schema_migrations"
+}
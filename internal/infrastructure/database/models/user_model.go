@@ -2,6 +2,8 @@ package models
 
 import (
 	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/vo"
+	"base-gin/pkg/textsearch"
 	"time"
 
 	"gorm.io/gorm"
@@ -9,13 +11,15 @@ import (
 
 // UserModel GORM数据模型，用于数据库操作
 type UserModel struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	Name      string         `gorm:"type:varchar(50);not null" json:"name"`
-	Email     string         `gorm:"type:varchar(100);not null" json:"email"`
-	Password  string         `gorm:"type:varchar(255);not null" json:"-"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID         uint           `gorm:"primarykey" json:"id"`
+	Name       string         `gorm:"type:varchar(50);not null" json:"name"`
+	Email      string         `gorm:"type:varchar(100);not null" json:"email"`
+	Password   string         `gorm:"type:varchar(255);not null" json:"-"`
+	SearchText string         `gorm:"type:text;not null;default:''" json:"-"`
+	Version    int            `gorm:"not null;default:1" json:"version"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName 指定表名
@@ -25,13 +29,20 @@ func (UserModel) TableName() string {
 
 // ToEntity 将GORM模型转换为领域实体
 func (m *UserModel) ToEntity() *entity.User {
+	var deletedAt *time.Time
+	if m.DeletedAt.Valid {
+		deletedAt = &m.DeletedAt.Time
+	}
+
 	return &entity.User{
 		ID:        int(m.ID),
-		Name:      m.Name,
-		Email:     m.Email,
-		Password:  m.Password,
+		Name:      vo.RestoreUserName(m.Name),
+		Email:     vo.RestoreEmail(m.Email),
+		Password:  vo.RestorePassword(m.Password),
+		Version:   m.Version,
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
+		DeletedAt: deletedAt,
 	}
 }
 
@@ -40,13 +51,20 @@ func (m *UserModel) FromEntity(user *entity.User) {
 	if user.ID > 0 {
 		m.ID = uint(user.ID)
 	}
-	m.Name = user.Name
-	m.Email = user.Email
-	m.Password = user.Password
+	m.Name = user.Name.String()
+	m.Email = user.Email.String()
+	m.Password = user.Password.Value()
+	m.SearchText = UserSearchText(user.Name.String(), user.Email.String())
+	m.Version = user.Version
 	m.CreatedAt = user.CreatedAt
 	m.UpdatedAt = user.UpdatedAt
 }
 
+// UserSearchText 生成用户的全文检索文档，保存在 search_text 列并由数据库全文索引使用
+func UserSearchText(name, email string) string {
+	return textsearch.Document(name, email)
+}
+
 // NewUserModelFromEntity 从领域实体创建新的GORM模型
 func NewUserModelFromEntity(user *entity.User) *UserModel {
 	model := &UserModel{}
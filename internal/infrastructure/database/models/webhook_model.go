@@ -0,0 +1,180 @@
+package models
+
+import (
+	"base-gin/internal/domain/webhook/entity"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// WebhookSubscriptionModel Webhook 订阅，事件类型以逗号分隔保存
+type WebhookSubscriptionModel struct {
+	ID                  uint   `gorm:"primarykey"`
+	URL                 string `gorm:"type:varchar(2048);not null"`
+	Events              string `gorm:"type:varchar(1000);not null"`
+	Secret              string `gorm:"type:varchar(255);not null"`
+	Description         string `gorm:"type:varchar(255);not null;default:''"`
+	Active              bool   `gorm:"not null;default:true;index"`
+	ConsecutiveFailures int    `gorm:"not null;default:0"`
+	DisabledAt          *time.Time
+	DisabledReason      string `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// TableName 指定表名
+func (WebhookSubscriptionModel) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// ToEntity 将GORM模型转换为领域实体
+func (m *WebhookSubscriptionModel) ToEntity() *entity.Subscription {
+	return &entity.Subscription{
+		ID:                  int(m.ID),
+		URL:                 m.URL,
+		Events:              strings.Split(m.Events, ","),
+		Secret:              m.Secret,
+		Description:         m.Description,
+		Active:              m.Active,
+		ConsecutiveFailures: m.ConsecutiveFailures,
+		DisabledAt:          m.DisabledAt,
+		DisabledReason:      m.DisabledReason,
+		CreatedAt:           m.CreatedAt,
+		UpdatedAt:           m.UpdatedAt,
+	}
+}
+
+// NewWebhookSubscriptionModel 从领域实体创建GORM模型
+func NewWebhookSubscriptionModel(s *entity.Subscription) *WebhookSubscriptionModel {
+	return &WebhookSubscriptionModel{
+		ID:                  uint(s.ID),
+		URL:                 s.URL,
+		Events:              strings.Join(s.Events, ","),
+		Secret:              s.Secret,
+		Description:         s.Description,
+		Active:              s.Active,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		DisabledReason:      s.DisabledReason,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+// WebhookDeliveryModel Webhook 投递记录，LockedBy/LockedUntil 为投递任务的租约
+type WebhookDeliveryModel struct {
+	ID             uint       `gorm:"primarykey"`
+	SubscriptionID uint       `gorm:"not null;index"`
+	EventID        string     `gorm:"type:varchar(36);not null;index"`
+	EventType      string     `gorm:"type:varchar(100);not null"`
+	Payload        string     `gorm:"type:text;not null"`
+	Status         string     `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int        `gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time
+	LastStatusCode int    `gorm:"not null;default:0"`
+	LastError      string `gorm:"type:text;not null;default:''"`
+	LockedBy       string `gorm:"type:varchar(64);not null;default:''"`
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName 指定表名
+func (WebhookDeliveryModel) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ToEntity 将GORM模型转换为领域实体
+func (m *WebhookDeliveryModel) ToEntity() *entity.Delivery {
+	return &entity.Delivery{
+		ID:             int(m.ID),
+		SubscriptionID: int(m.SubscriptionID),
+		EventID:        m.EventID,
+		EventType:      m.EventType,
+		Payload:        m.Payload,
+		Status:         m.Status,
+		Attempts:       m.Attempts,
+		NextAttemptAt:  m.NextAttemptAt,
+		LastAttemptAt:  m.LastAttemptAt,
+		LastStatusCode: m.LastStatusCode,
+		LastError:      m.LastError,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
+	}
+}
+
+// NewWebhookDeliveryModel 从领域实体创建GORM模型
+func NewWebhookDeliveryModel(d *entity.Delivery) *WebhookDeliveryModel {
+	return &WebhookDeliveryModel{
+		ID:             uint(d.ID),
+		SubscriptionID: uint(d.SubscriptionID),
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastAttemptAt:  d.LastAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+// WebhookDeliveryAttemptModel 投递尝试记录，请求头和响应头以 JSON 保存
+type WebhookDeliveryAttemptModel struct {
+	ID              uint   `gorm:"primarykey"`
+	DeliveryID      uint   `gorm:"not null;index"`
+	Attempt         int    `gorm:"not null"`
+	RequestHeaders  string `gorm:"type:text;not null"`
+	RequestBody     string `gorm:"type:text;not null"`
+	ResponseStatus  int    `gorm:"not null;default:0"`
+	ResponseHeaders string `gorm:"type:text;not null"`
+	ResponseBody    string `gorm:"type:text;not null"`
+	Error           string `gorm:"type:text;not null;default:''"`
+	DurationMs      int64  `gorm:"not null;default:0"`
+	CreatedAt       time.Time
+}
+
+// TableName 指定表名
+func (WebhookDeliveryAttemptModel) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+// ToEntity 将GORM模型转换为领域实体
+func (m *WebhookDeliveryAttemptModel) ToEntity() *entity.DeliveryAttempt {
+	attempt := &entity.DeliveryAttempt{
+		ID:             int(m.ID),
+		DeliveryID:     int(m.DeliveryID),
+		Attempt:        m.Attempt,
+		RequestBody:    m.RequestBody,
+		ResponseStatus: m.ResponseStatus,
+		ResponseBody:   m.ResponseBody,
+		Error:          m.Error,
+		Duration:       time.Duration(m.DurationMs) * time.Millisecond,
+		CreatedAt:      m.CreatedAt,
+	}
+	json.Unmarshal([]byte(m.RequestHeaders), &attempt.RequestHeaders)
+	json.Unmarshal([]byte(m.ResponseHeaders), &attempt.ResponseHeaders)
+	return attempt
+}
+
+// NewWebhookDeliveryAttemptModel 从领域实体创建GORM模型
+func NewWebhookDeliveryAttemptModel(a *entity.DeliveryAttempt) *WebhookDeliveryAttemptModel {
+	requestHeaders, _ := json.Marshal(a.RequestHeaders)
+	responseHeaders, _ := json.Marshal(a.ResponseHeaders)
+	return &WebhookDeliveryAttemptModel{
+		DeliveryID:      uint(a.DeliveryID),
+		Attempt:         a.Attempt,
+		RequestHeaders:  string(requestHeaders),
+		RequestBody:     a.RequestBody,
+		ResponseStatus:  a.ResponseStatus,
+		ResponseHeaders: string(responseHeaders),
+		ResponseBody:    a.ResponseBody,
+		Error:           a.Error,
+		DurationMs:      a.Duration.Milliseconds(),
+		CreatedAt:       a.CreatedAt,
+	}
+}
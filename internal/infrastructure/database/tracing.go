@@ -0,0 +1,92 @@
+package database
+
+import (
+	"base-gin/pkg/tracing"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey SQL span 在 Statement 中的键
+const tracingSpanKey = "tracing:span"
+
+// registerTracing 通过 GORM 回调为每条 SQL 创建子 span，记录脱敏后的语句、表名和影响行数。
+// 只在 context 中已有 span 时记录，迁移和没有链路的后台查询不产生孤立的 span
+func (db *DB) registerTracing(tracer *tracing.Tracer) error {
+	system := db.getDatabaseType()
+	if system == "postgres" {
+		system = "postgresql"
+	}
+
+	before := func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil || !tracing.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		_, span := tracer.Start(ctx, "db", tracing.WithKind(tracing.KindClient),
+			tracing.WithAttributes(tracing.String("db.system", system)))
+		tx.InstanceSet(tracingSpanKey, span)
+	}
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(tracingSpanKey)
+			if !ok {
+				return
+			}
+			span := value.(*tracing.Span)
+			defer span.End()
+
+			statement := tx.Statement.SQL.String()
+			if op := sqlOperation(statement); op != "" {
+				operation = op
+			}
+			table := tx.Statement.Table
+			name := operation
+			if table != "" {
+				name += " " + table
+			}
+			span.SetName(name)
+			span.SetAttributes(
+				tracing.String("db.operation.name", operation),
+				tracing.String("db.collection.name", table),
+				tracing.String("db.query.text", tracing.SanitizeSQL(statement)),
+				tracing.Int64("db.response.rows_affected", tx.Statement.RowsAffected),
+			)
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				span.RecordError(tx.Error)
+			}
+		}
+	}
+
+	callback := db.gormDB.Callback()
+	for _, p := range []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"INSERT", callback.Create().Before("gorm:create"), callback.Create().After("gorm:create")},
+		{"SELECT", callback.Query().Before("gorm:query"), callback.Query().After("gorm:query")},
+		{"UPDATE", callback.Update().Before("gorm:update"), callback.Update().After("gorm:update")},
+		{"DELETE", callback.Delete().Before("gorm:delete"), callback.Delete().After("gorm:delete")},
+		{"SELECT", callback.Row().Before("gorm:row"), callback.Row().After("gorm:row")},
+		{"EXEC", callback.Raw().Before("gorm:raw"), callback.Raw().After("gorm:raw")},
+	} {
+		name := strings.ToLower(p.operation)
+		if err := p.before.Register("tracing:before_"+name, before); err != nil {
+			return err
+		}
+		if err := p.after.Register("tracing:after_"+name, after(p.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlOperation 返回语句的第一个关键字，如 SELECT、INSERT
+func sqlOperation(statement string) string {
+	statement = strings.TrimSpace(statement)
+	if i := strings.IndexAny(statement, " \t\n("); i > 0 {
+		statement = statement[:i]
+	}
+	return strings.ToUpper(statement)
+}
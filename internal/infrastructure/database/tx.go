@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey 上下文中保存当前事务的键
+type txKey struct{}
+
+// Transaction 在数据库事务中执行 fn，fn 通过 ctx 调用的仓储操作（见 Conn）都属于该事务，
+// fn 返回错误时整体回滚；ctx 中已有事务时直接加入
+func (db *DB) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+	return db.gormDB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// Conn 返回 ctx 中的事务，没有事务时返回 db。仓储中的 Transaction 在已有事务中执行时使用保存点
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction 判断 ctx 中是否已有事务
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
@@ -0,0 +1,200 @@
+package eventstream
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/domain/event"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 推送给客户端的事件，ID 由启动标识和序号组成，重启后旧 ID 不会被误认
+type Message struct {
+	ID   string
+	Type string
+	Data []byte
+	seq  uint64
+}
+
+// Broker 订阅事件总线并把事件分发给 SSE 连接，保留最近的事件用于断线重连后补发。
+// 连接的发送缓冲写满时断开该连接，客户端携带 Last-Event-ID 重连后从缓冲中补发
+type Broker struct {
+	mu           sync.Mutex
+	epoch        string
+	seq          uint64
+	buffer       []*Message
+	head         int
+	size         int
+	clientBuffer int
+	subscribers  map[*Subscription]struct{}
+	closed       bool
+	unsubscribe  func()
+}
+
+// Subscription 一个 SSE 连接的订阅，C 关闭表示连接被断开或 Broker 已关闭
+type Subscription struct {
+	C      <-chan *Message
+	ch     chan *Message
+	filter func(eventType string) bool
+	broker *Broker
+}
+
+// NewBroker 创建事件分发器并订阅事件总线，清理时关闭所有连接
+func NewBroker(config *configs.Config, bus *event.Bus) (*Broker, func()) {
+	b := NewMemoryBroker(config.EventStream.ReplayBufferSize, config.EventStream.ClientBufferSize)
+	b.unsubscribe = bus.SubscribeAll(b.handle, event.WithName("eventstream"))
+	return b, b.Close
+}
+
+// NewMemoryBroker 创建不订阅事件总线的分发器，通过 Publish 推送事件，用于测试
+func NewMemoryBroker(replaySize, clientBuffer int) *Broker {
+	if replaySize < 0 {
+		replaySize = 0
+	}
+	if clientBuffer <= 0 {
+		clientBuffer = 16
+	}
+	return &Broker{
+		epoch:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		buffer:       make([]*Message, replaySize),
+		clientBuffer: clientBuffer,
+		subscribers:  make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) handle(ctx context.Context, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b.Publish(e.EventName(), data)
+	return nil
+}
+
+// Publish 分配 ID 并分发事件，不会阻塞
+func (b *Broker) Publish(eventType string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	msg := &Message{ID: fmt.Sprintf("%s-%d", b.epoch, b.seq), Type: eventType, Data: data, seq: b.seq}
+	if len(b.buffer) > 0 {
+		b.buffer[(b.head+b.size)%len(b.buffer)] = msg
+		if b.size < len(b.buffer) {
+			b.size++
+		} else {
+			b.head = (b.head + 1) % len(b.buffer)
+		}
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter(eventType) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// 连接消费过慢，断开后由客户端重连补发
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe 订阅之后的事件，并返回 lastEventID 之后已缓冲的事件。
+// lastEventID 来自其他启动周期、已超出缓冲范围或无法识别时 resumed 为 false，
+// 客户端可能漏掉了事件，应重新加载完整数据。Broker 已关闭时返回 nil
+func (b *Broker) Subscribe(lastEventID string, filter func(eventType string) bool) (sub *Subscription, replay []*Message, resumed bool) {
+	if filter == nil {
+		filter = func(string) bool { return true }
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil, false
+	}
+
+	ch := make(chan *Message, b.clientBuffer)
+	sub = &Subscription{C: ch, ch: ch, filter: filter, broker: b}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	lastSeq, ok := b.parseID(lastEventID)
+	if !ok {
+		return sub, nil, false
+	}
+
+	oldest := b.seq + 1
+	if b.size > 0 {
+		oldest = b.buffer[b.head].seq
+	}
+	if lastSeq+1 < oldest {
+		return sub, nil, false
+	}
+	for i := 0; i < b.size; i++ {
+		msg := b.buffer[(b.head+i)%len(b.buffer)]
+		if msg.seq > lastSeq && filter(msg.Type) {
+			replay = append(replay, msg)
+		}
+	}
+	return sub, replay, true
+}
+
+// parseID 解析本次启动分配的事件 ID
+func (b *Broker) parseID(id string) (uint64, bool) {
+	epoch, seqValue, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqValue, 10, 64)
+	if err != nil || seq > b.seq {
+		return 0, false
+	}
+	return seq, true
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// remove 移除订阅并关闭其通道，调用方需持有锁
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// Subscribers 当前连接数
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close 取消事件总线订阅并断开所有连接
+func (b *Broker) Close() {
+	// 先取消总线订阅再加锁，避免与正在分发的事件互相等待
+	if b.unsubscribe != nil {
+		b.unsubscribe()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
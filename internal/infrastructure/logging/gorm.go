@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold 超过该耗时的 SQL 按 warn 级别输出
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger 按 Logger 的当前级别过滤 GORM 日志：debug、info 输出所有 SQL，warn 只输出慢查询和错误，error 只输出错误
+type gormLogger struct {
+	logger *Logger
+	base   gormlogger.Interface
+}
+
+// NewGormLogger 创建跟随 logger 级别的 GORM 日志
+func NewGormLogger(logger *Logger) gormlogger.Interface {
+	return &gormLogger{
+		logger: logger,
+		// 与 gormlogger.Default 相同的输出格式
+		base: gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
+			SlowThreshold: slowQueryThreshold,
+			LogLevel:      gormlogger.Info,
+			Colorful:      true,
+		}),
+	}
+}
+
+// LogMode 级别由 Logger 统一控制，忽略 GORM 的设置
+func (g *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return g
+}
+
+func (g *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.logger.Enabled(LevelInfo) {
+		g.base.Info(ctx, msg, data...)
+	}
+}
+
+func (g *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.logger.Enabled(LevelWarn) {
+		g.base.Warn(ctx, msg, data...)
+	}
+}
+
+func (g *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	g.base.Error(ctx, msg, data...)
+}
+
+func (g *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	level := LevelInfo
+	switch {
+	case err != nil:
+		level = LevelError
+	case time.Since(begin) > slowQueryThreshold:
+		level = LevelWarn
+	}
+	if g.logger.Enabled(level) {
+		g.base.Trace(ctx, begin, fc, err)
+	}
+}
@@ -2,12 +2,54 @@ package logging
 
 import (
 	"base-gin/configs"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync/atomic"
 )
 
+// Level 日志级别，运行时可通过管理端口调整
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// ErrInvalidLevel 日志级别不是 debug、info、warn、error 之一
+var ErrInvalidLevel = errors.New("日志级别必须是 debug、info、warn 或 error")
+
+// ParseLevel 解析日志级别，不区分大小写
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warning" {
+		s = "warn"
+	}
+	for i, name := range levelNames {
+		if name == s {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, ErrInvalidLevel
+}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
 type Logger struct {
 	config *configs.LogConfig
+	level  atomic.Int32
 }
 
 func NewLogger(config *configs.Config) *Logger {
@@ -15,23 +57,64 @@ func NewLogger(config *configs.Config) *Logger {
 		config: &config.Log,
 	}
 
+	level, err := ParseLevel(logger.config.Level)
+	if err != nil {
+		log.Printf("LOG_LEVEL=%s 无效，使用 info", logger.config.Level)
+	}
+	logger.SetLevel(level)
+
 	// 在真实项目中，这里会配置日志输出
 	log.Printf("日志配置: Level=%s, File=%s",
-		logger.config.Level, logger.config.File)
+		level, logger.config.File)
 
 	return logger
 }
 
+// Level 当前日志级别
+func (l *Logger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// SetLevel 修改日志级别，立即对应用日志、请求日志和 SQL 日志生效
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Enabled 是否输出 level 级别的日志
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
 func (l *Logger) Info(message string) {
-	log.Printf("[INFO] %s", message)
+	if l.Enabled(LevelInfo) {
+		log.Printf("[INFO] %s", message)
+	}
+}
+
+func (l *Logger) Warn(message string) {
+	if l.Enabled(LevelWarn) {
+		log.Printf("[WARN] %s", message)
+	}
 }
 
 func (l *Logger) Error(message string) {
 	log.Printf("[ERROR] %s", message)
 }
 
+// Fields 结构化日志的附加字段
+type Fields map[string]any
+
+// ErrorWith 输出一行带 JSON 字段的错误日志，便于日志系统按字段检索
+func (l *Logger) ErrorWith(message string, fields Fields) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%q", fmt.Sprint(fields)))
+	}
+	log.Printf("[ERROR] %s %s", message, data)
+}
+
 func (l *Logger) Debug(message string) {
-	if l.config.Level == "debug" {
+	if l.Enabled(LevelDebug) {
 		log.Printf("[DEBUG] %s", message)
 	}
 }
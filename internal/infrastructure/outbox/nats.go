@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSSink 使用 NATS 文本协议发布消息，主题为 <subject>.<事件名>。
+// 每次发布后发送 PING 并等待 PONG，确认服务端已处理 PUB；连接失败时在下次发布重连。
+// 只依赖标准库，Kafka 等其他消息系统可按同样方式实现 Sink 接口
+type NATSSink struct {
+	addr    string
+	user    *url.Userinfo
+	subject string
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSSink 解析 nats://[user:pass@]host:port 格式的地址，首次发布时才建立连接
+func NewNATSSink(rawURL, subject string, timeout time.Duration) (*NATSSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("无效的 NATS 地址: %s", rawURL)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &NATSSink{addr: addr, user: u.User, subject: subject, timeout: timeout}, nil
+}
+
+func (s *NATSSink) Name() string { return "nats" }
+
+func (s *NATSSink) Publish(ctx context.Context, msg *Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := s.publish(ctx, s.subject+"."+msg.Type, payload); err != nil {
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	return nil
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.reader = conn, bufio.NewReader(conn)
+	s.setDeadline(ctx)
+
+	// 服务端连接后首先发送 INFO
+	line, err := s.readLine()
+	if err == nil && !strings.HasPrefix(line, "INFO ") {
+		err = fmt.Errorf("NATS 握手失败: %s", line)
+	}
+	if err == nil {
+		options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "base-gin-outbox"}
+		if s.user != nil {
+			options["user"] = s.user.Username()
+			options["pass"], _ = s.user.Password()
+		}
+		connect, _ := json.Marshal(options)
+		_, err = fmt.Fprintf(s.conn, "CONNECT %s\r\n", connect)
+	}
+	if err != nil {
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+func (s *NATSSink) publish(ctx context.Context, subject string, payload []byte) error {
+	s.setDeadline(ctx)
+	if _, err := fmt.Fprintf(s.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload); err != nil {
+		return err
+	}
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("NATS " + line)
+		}
+		// +OK、INFO 等其他消息忽略
+	}
+}
+
+func (s *NATSSink) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *NATSSink) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+}
+
+func (s *NATSSink) closeLocked() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn, s.reader = nil, nil
+	}
+}
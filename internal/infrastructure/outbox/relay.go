@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"base-gin/configs"
+	"base-gin/pkg/health"
+	"base-gin/pkg/uuid"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrInvalidRelayConfig 轮询间隔、批次大小或租约时长不是正数，批次大小为 0 时中继会空转占满 CPU
+var ErrInvalidRelayConfig = errors.New("OUTBOX_POLL_INTERVAL_MS、OUTBOX_BATCH_SIZE 和 OUTBOX_LEASE_SECONDS 必须大于 0")
+
+// Relay 轮询发件箱并将消息投递到 Sink，失败按指数退避重试，超过最大次数后进入死信状态。
+// 多个实例可同时运行，消息通过租约分配，投递语义为至少一次
+type Relay struct {
+	store       *Store
+	sink        Sink
+	worker      string
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	health      *health.Checker
+}
+
+// NewRelay 创建发件箱中继，store 或 sink 为 nil 时中继不启用；checker 不为 nil 时运行期间注册心跳检查。
+// 启用时轮询间隔、批次大小或租约时长不是正数返回 ErrInvalidRelayConfig
+func NewRelay(config *configs.Config, store *Store, sink Sink, checker *health.Checker) (*Relay, error) {
+	cfg := config.Outbox
+	if store != nil && sink != nil && (cfg.PollIntervalMs <= 0 || cfg.BatchSize <= 0 || cfg.LeaseSeconds <= 0) {
+		return nil, ErrInvalidRelayConfig
+	}
+	hostname, _ := os.Hostname()
+	return &Relay{
+		store:       store,
+		sink:        sink,
+		worker:      fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewV4()[:8]),
+		interval:    time.Duration(cfg.PollIntervalMs) * time.Millisecond,
+		batchSize:   cfg.BatchSize,
+		lease:       time.Duration(cfg.LeaseSeconds) * time.Second,
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     time.Duration(cfg.RetryBackoffSeconds) * time.Second,
+		maxBackoff:  time.Duration(cfg.RetryMaxBackoffSeconds) * time.Second,
+		health:      checker,
+	}, nil
+}
+
+// Enabled 是否启用了发件箱
+func (r *Relay) Enabled() bool {
+	return r.store != nil && r.sink != nil
+}
+
+// Start 按间隔轮询投递，直到 ctx 取消
+func (r *Relay) Start(ctx context.Context) {
+	if !r.Enabled() {
+		log.Println("未启用 OUTBOX_ENABLED，发件箱中继不启动")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	heartbeat := r.health.Heartbeat("job:outbox_relay", r.interval)
+	defer heartbeat.Stop()
+
+	log.Printf("发件箱中继 %s 已启动，投递目标: %s", r.worker, r.sink.Name())
+	for {
+		// 满批时立即继续，积压清空后再等待下一次轮询
+		for {
+			heartbeat.Beat()
+			n, err := r.RunOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("发件箱投递失败: %v", err)
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 领取一批消息并逐条投递，返回领取的消息数
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	messages, err := r.store.Claim(ctx, r.worker, r.batchSize, r.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range messages {
+		if err := ctx.Err(); err != nil {
+			// 未投递的消息在租约过期后重新领取
+			return len(messages), err
+		}
+
+		msg := toMessage(m)
+		publishErr := r.sink.Publish(ctx, msg)
+		if publishErr == nil {
+			err = r.store.MarkDelivered(ctx, m.ID, r.worker)
+		} else {
+			dead := r.maxAttempts > 0 && msg.Attempt >= r.maxAttempts
+			if dead {
+				log.Printf("发件箱消息 %s (%s) 投递 %d 次失败，进入死信: %v", msg.ID, msg.Type, msg.Attempt, publishErr)
+			}
+			err = r.store.MarkFailed(ctx, m.ID, r.worker, publishErr, time.Now().Add(r.retryDelay(msg.Attempt)), dead)
+		}
+		if err != nil {
+			return len(messages), err
+		}
+	}
+	return len(messages), nil
+}
+
+// retryDelay 第 attempt 次失败后的重试间隔：backoff*2^(attempt-1)，不超过 maxBackoff
+func (r *Relay) retryDelay(attempt int) time.Duration {
+	delay := r.backoff
+	for i := 1; i < attempt && i < 30; i++ {
+		delay *= 2
+		if r.maxBackoff > 0 && delay >= r.maxBackoff {
+			return r.maxBackoff
+		}
+	}
+	return delay
+}
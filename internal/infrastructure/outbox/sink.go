@@ -0,0 +1,166 @@
+package outbox
+
+import (
+	"base-gin/configs"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Sink 发件箱消息的投递目标。Publish 返回 nil 表示对方已确认接收，
+// 返回错误时中继按退避策略重试，因此实现需容忍重复投递
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// NewSink 根据配置创建投递目标，清理函数关闭文件或连接。未启用发件箱时返回 nil
+func NewSink(config *configs.Config) (Sink, func(), error) {
+	sink, err := newSink(config.Outbox)
+	if err != nil || sink == nil {
+		return nil, func() {}, err
+	}
+	return sink, func() {
+		if closer, ok := sink.(io.Closer); ok {
+			closer.Close()
+		}
+	}, nil
+}
+
+func newSink(cfg configs.OutboxConfig) (Sink, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Sink {
+	case "memory":
+		return NewMemorySink(), nil
+	case "file":
+		return NewFileSink(cfg.FilePath)
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("OUTBOX_SINK=http 时必须配置 OUTBOX_HTTP_URL")
+		}
+		return NewHTTPSink(cfg.HTTPURL, time.Duration(cfg.HTTPTimeout)*time.Second), nil
+	case "nats":
+		return NewNATSSink(cfg.NATSURL, cfg.NATSSubject, time.Duration(cfg.NATSTimeout)*time.Second)
+	default:
+		return nil, fmt.Errorf("不支持的发件箱投递目标: %s", cfg.Sink)
+	}
+}
+
+// MemorySink 保存在内存中的投递目标，用于测试和本地开发
+type MemorySink struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewMemorySink 创建内存投递目标
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Name() string { return "memory" }
+
+func (s *MemorySink) Publish(ctx context.Context, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages 返回已接收消息的副本
+func (s *MemorySink) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.messages...)
+}
+
+// FileSink 将消息以 JSON Lines 格式追加到文件，每条消息写入后同步到磁盘
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink 打开（必要时创建）输出文件
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建发件箱输出目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开发件箱输出文件失败: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+func (s *FileSink) Publish(ctx context.Context, msg *Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close 关闭输出文件
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// HTTPSink 以 POST 请求投递消息，2xx 响应视为成功
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink 创建 HTTP 投递目标
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPSink) Name() string { return "http" }
+
+func (s *HTTPSink) Publish(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", msg.ID)
+	req.Header.Set("X-Event-Type", msg.Type)
+	req.Header.Set("X-Delivery-Attempt", strconv.Itoa(msg.Attempt))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
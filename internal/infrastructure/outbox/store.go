@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/domain/event"
+	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/database/models"
+	"base-gin/pkg/uuid"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxErrorLength 记录的投递错误最大长度
+const maxErrorLength = 1000
+
+// Message 投递给 Sink 的消息，ID 为事件唯一标识，消费方可据此去重
+type Message struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+	// Attempt 本次为第几次投递
+	Attempt int `json:"-"`
+}
+
+// Store 发件箱消息的存取，Append 由仓储在业务事务中调用，其余方法供中继使用
+type Store struct {
+	db      *gorm.DB
+	dialect string
+}
+
+// NewStore 创建发件箱存储，未启用发件箱时返回 nil，仓储不写入消息
+func NewStore(config *configs.Config, database *database.DB) *Store {
+	if !config.Outbox.Enabled {
+		return nil
+	}
+	return &Store{
+		db:      database.GetGormDB(),
+		dialect: database.Dialect(),
+	}
+}
+
+// Append 在 tx 所属事务中写入事件，s 为 nil 时不做任何事
+func (s *Store) Append(tx *gorm.DB, aggregateType, aggregateID string, events []event.Event) error {
+	if s == nil || len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]*models.OutboxMessageModel, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("序列化事件 %s 失败: %w", e.EventName(), err)
+		}
+		rows = append(rows, &models.OutboxMessageModel{
+			EventID:       uuid.NewV4(),
+			EventName:     e.EventName(),
+			AggregateType: aggregateType,
+			AggregateID:   aggregateID,
+			Payload:       string(payload),
+			OccurredAt:    e.OccurredAt(),
+			Status:        models.OutboxStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	return tx.Create(rows).Error
+}
+
+// Claim 领取最多 limit 条到期的待投递消息并加上租约。
+// PostgreSQL 使用 FOR UPDATE SKIP LOCKED 避免多个中继争抢同一批行；
+// SQLite 没有行锁，依靠带租约条件的更新抢占，未抢到的行不会返回
+func (s *Store) Claim(ctx context.Context, worker string, limit int, lease time.Duration) ([]*models.OutboxMessageModel, error) {
+	var claimed []*models.OutboxMessageModel
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)",
+			models.OutboxStatusPending, now, now).
+			Order("id").
+			Limit(limit)
+		if s.dialect == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+
+		var candidates []*models.OutboxMessageModel
+		if err := query.Find(&candidates).Error; err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(candidates))
+		for _, m := range candidates {
+			ids = append(ids, m.ID)
+		}
+		until := now.Add(lease)
+		err := tx.Model(&models.OutboxMessageModel{}).
+			Where("id IN ? AND (locked_until IS NULL OR locked_until < ?)", ids, now).
+			Updates(map[string]interface{}{"locked_by": worker, "locked_until": until}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ? AND locked_by = ?", ids, worker).Order("id").Find(&claimed).Error
+	})
+	return claimed, err
+}
+
+// MarkDelivered 标记消息已投递，租约已被其他中继接管时不做修改
+func (s *Store) MarkDelivered(ctx context.Context, id uint, worker string) error {
+	now := time.Now()
+	return s.db.WithContext(ctx).Model(&models.OutboxMessageModel{}).
+		Where("id = ? AND locked_by = ?", id, worker).
+		Updates(map[string]interface{}{
+			"status":       models.OutboxStatusDelivered,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"delivered_at": now,
+			"last_error":   "",
+			"locked_by":    "",
+			"locked_until": nil,
+		}).Error
+}
+
+// MarkFailed 记录投递失败，dead 为 true 时消息进入死信状态，否则在 next 之后重试
+func (s *Store) MarkFailed(ctx context.Context, id uint, worker string, cause error, next time.Time, dead bool) error {
+	status := models.OutboxStatusPending
+	if dead {
+		status = models.OutboxStatusDead
+	}
+	message := cause.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+
+	return s.db.WithContext(ctx).Model(&models.OutboxMessageModel{}).
+		Where("id = ? AND locked_by = ?", id, worker).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": next,
+			"last_error":      message,
+			"locked_by":       "",
+			"locked_until":    nil,
+		}).Error
+}
+
+func toMessage(m *models.OutboxMessageModel) *Message {
+	return &Message{
+		ID:            m.EventID,
+		Type:          m.EventName,
+		AggregateType: m.AggregateType,
+		AggregateID:   m.AggregateID,
+		OccurredAt:    m.OccurredAt,
+		Data:          json.RawMessage(m.Payload),
+		Attempt:       m.Attempts + 1,
+	}
+}
@@ -0,0 +1,126 @@
+package audit_impl
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"base-gin/internal/domain/audit/repository"
+	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/database/models"
+	querylang "base-gin/pkg/query"
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// auditLockKey PostgreSQL 追加审计记录时使用的事务级咨询锁
+const auditLockKey = 7_421_309_001
+
+// GormAuditRepository GORM实现的审计仓储
+type GormAuditRepository struct {
+	db      *gorm.DB
+	dialect string
+	// mutex 串行化本进程内的追加，PostgreSQL 多实例之间通过咨询锁串行化
+	mutex sync.Mutex
+}
+
+// NewGormAuditRepository 创建新的GORM审计仓储
+func NewGormAuditRepository(database *database.DB) *GormAuditRepository {
+	return &GormAuditRepository{
+		db:      database.GetGormDB(),
+		dialect: database.Dialect(),
+	}
+}
+
+// Append 读取最后一条记录的 ID 和哈希，依次为 entries 分配 ID 并计算哈希后在同一事务中写入。
+// 追加必须串行，否则两条记录会接在同一条之后，主键冲突使后写入的事务失败。
+// ctx 中已有事务时加入该事务，不再加进程内的锁：SQLite 由外层事务此前的写入持有的写锁串行化，
+// 持锁的外层事务等待进程内的锁会与其他事务互相等待
+func (r *GormAuditRepository) Append(ctx context.Context, entries ...*entity.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if !database.InTransaction(ctx) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+	}
+
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if r.dialect == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		var last models.AuditLogModel
+		if err := tx.Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+
+		rows := make([]*models.AuditLogModel, 0, len(entries))
+		id, prevHash := last.ID, last.Hash
+		for _, e := range entries {
+			id++
+			if err := e.Seal(id, prevHash); err != nil {
+				return err
+			}
+			prevHash = e.Hash
+			rows = append(rows, models.NewAuditLogModel(e))
+		}
+		return tx.Create(rows).Error
+	})
+}
+
+func (r *GormAuditRepository) List(ctx context.Context, criteria repository.AuditCriteria) ([]*entity.Entry, int64, error) {
+	query := database.Conn(ctx, r.db).Model(&models.AuditLogModel{})
+	for column, value := range map[string]string{
+		"actor":       criteria.Actor,
+		"action":      criteria.Action,
+		"target_type": criteria.TargetType,
+		"target_id":   criteria.TargetID,
+		"request_id":  criteria.RequestID,
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if criteria.From != nil {
+		query = query.Where(querylang.CompareTime(query, "created_at", ">=", *criteria.From))
+	}
+	if criteria.To != nil {
+		query = query.Where(querylang.CompareTime(query, "created_at", "<", *criteria.To))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logModels []models.AuditLogModel
+	err := query.Order("id DESC").
+		Offset((criteria.Page - 1) * criteria.PageSize).
+		Limit(criteria.PageSize).
+		Find(&logModels).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]*entity.Entry, 0, len(logModels))
+	for _, model := range logModels {
+		entries = append(entries, model.ToEntity())
+	}
+	return entries, total, nil
+}
+
+func (r *GormAuditRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]*entity.Entry, error) {
+	var logModels []models.AuditLogModel
+	if err := database.Conn(ctx, r.db).Where("id > ?", afterID).Order("id").Limit(limit).Find(&logModels).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entity.Entry, 0, len(logModels))
+	for _, model := range logModels {
+		entries = append(entries, model.ToEntity())
+	}
+	return entries, nil
+}
@@ -2,29 +2,49 @@ package user_impl
 
 import (
 	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/repository"
+	"base-gin/internal/domain/user/vo"
 	"base-gin/internal/infrastructure/database"
 	"base-gin/internal/infrastructure/database/models"
+	"base-gin/internal/infrastructure/outbox"
+	"base-gin/pkg/pagination"
+	querylang "base-gin/pkg/query"
+	"base-gin/pkg/textsearch"
+	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// aggregateTypeUser 发件箱中用户事件的聚合类型
+const aggregateTypeUser = "user"
+
 // GormUserRepository GORM实现的用户仓储
 type GormUserRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	dialect  string
+	fullText bool
+	// outbox 为 nil 时不写入发件箱
+	outbox *outbox.Store
 }
 
-// NewGormUserRepository 创建新的GORM用户仓储
-func NewGormUserRepository(database *database.DB) *GormUserRepository {
+// NewGormUserRepository 创建新的GORM用户仓储，outbox 不为 nil 时实体记录的领域事件与变更在同一事务中写入发件箱
+func NewGormUserRepository(database *database.DB, outbox *outbox.Store) *GormUserRepository {
 	return &GormUserRepository{
-		db: database.GetGormDB(),
+		db:       database.GetGormDB(),
+		dialect:  database.Dialect(),
+		fullText: database.FullTextEnabled(),
+		outbox:   outbox,
 	}
 }
 
-func (r *GormUserRepository) FindByID(id int) (*entity.User, error) {
+func (r *GormUserRepository) FindByID(ctx context.Context, id int) (*entity.User, error) {
 	var userModel models.UserModel
 
-	if err := r.db.First(&userModel, id).Error; err != nil {
+	if err := database.Conn(ctx, r.db).First(&userModel, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
 		}
@@ -34,10 +54,10 @@ func (r *GormUserRepository) FindByID(id int) (*entity.User, error) {
 	return userModel.ToEntity(), nil
 }
 
-func (r *GormUserRepository) FindByEmail(email string) (*entity.User, error) {
+func (r *GormUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var userModel models.UserModel
 
-	if err := r.db.Where("email = ?", email).First(&userModel).Error; err != nil {
+	if err := database.Conn(ctx, r.db).Where("email = ?", vo.NormalizeEmail(email)).First(&userModel).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("用户不存在")
 		}
@@ -47,10 +67,31 @@ func (r *GormUserRepository) FindByEmail(email string) (*entity.User, error) {
 	return userModel.ToEntity(), nil
 }
 
-func (r *GormUserRepository) FindAll() ([]*entity.User, error) {
+func (r *GormUserRepository) FindByEmails(ctx context.Context, emails []string) (map[string]*entity.User, error) {
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		normalized = append(normalized, vo.NormalizeEmail(email))
+	}
+
+	users := make(map[string]*entity.User, len(emails))
+	if len(normalized) == 0 {
+		return users, nil
+	}
+
 	var userModels []models.UserModel
+	if err := database.Conn(ctx, r.db).Where("email IN ?", normalized).Find(&userModels).Error; err != nil {
+		return nil, err
+	}
+	for _, userModel := range userModels {
+		users[userModel.Email] = userModel.ToEntity()
+	}
+	return users, nil
+}
 
-	if err := r.db.Find(&userModels).Error; err != nil {
+func (r *GormUserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
+	var userModels []models.UserModel
+
+	if err := database.Conn(ctx, r.db).Find(&userModels).Error; err != nil {
 		return nil, err
 	}
 
@@ -62,10 +103,194 @@ func (r *GormUserRepository) FindAll() ([]*entity.User, error) {
 	return users, nil
 }
 
-func (r *GormUserRepository) Save(user *entity.User) error {
+// Search 按条件分页查询用户
+func (r *GormUserRepository) Search(ctx context.Context, criteria repository.UserSearchCriteria) (*repository.UserSearchResult, error) {
+	query := r.filteredQuery(ctx, criteria)
+
+	if criteria.Cursor != nil {
+		return r.searchByCursor(query, criteria)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var userModels []models.UserModel
+	err := query.Scopes(querylang.OrderScope(criteria.SortKeys())).
+		Offset((criteria.Page - 1) * criteria.PageSize).
+		Limit(criteria.PageSize).
+		Find(&userModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*entity.User, 0, len(userModels))
+	for _, userModel := range userModels {
+		users = append(users, userModel.ToEntity())
+	}
+
+	return &repository.UserSearchResult{Users: users, Total: total}, nil
+}
+
+// Stream 使用数据库游标逐行读取，内存中只保留当前行
+func (r *GormUserRepository) Stream(ctx context.Context, criteria repository.UserSearchCriteria, fn func(*entity.User) error) error {
+	query := r.filteredQuery(ctx, criteria).Scopes(querylang.OrderScope(criteria.SortKeys()))
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userModel models.UserModel
+		if err := query.ScanRows(rows, &userModel); err != nil {
+			return err
+		}
+		if err := fn(userModel.ToEntity()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filteredQuery 按状态和筛选条件构造查询，不包含排序和分页
+func (r *GormUserRepository) filteredQuery(ctx context.Context, criteria repository.UserSearchCriteria) *gorm.DB {
+	query := database.Conn(ctx, r.db).Model(&models.UserModel{})
+
+	switch criteria.Status {
+	case repository.StatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	case repository.StatusAll:
+		query = query.Unscoped()
+	}
+
+	if criteria.NameContains != "" {
+		query = query.Where("LOWER(name) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(criteria.NameContains))+"%")
+	}
+	if criteria.EmailDomain != "" {
+		query = query.Where("email LIKE ? ESCAPE '\\'", "%@"+escapeLike(vo.NormalizeEmail(criteria.EmailDomain)))
+	}
+	if criteria.CreatedFrom != nil {
+		query = query.Where(querylang.CompareTime(query, "created_at", ">=", *criteria.CreatedFrom))
+	}
+	if criteria.CreatedTo != nil {
+		query = query.Where(querylang.CompareTime(query, "created_at", "<", *criteria.CreatedTo))
+	}
+	return query.Scopes(criteria.Query.Scope())
+}
+
+// searchByCursor 使用游标（keyset）分页查询
+func (r *GormUserRepository) searchByCursor(query *gorm.DB, criteria repository.UserSearchCriteria) (*repository.UserSearchResult, error) {
+	keys := criteria.SortKeys()
+
+	var userModels []models.UserModel
+	if err := query.Scopes(criteria.Cursor.Scope()).Find(&userModels).Error; err != nil {
+		return nil, err
+	}
+
+	userModels, meta, err := pagination.Paginate(criteria.Cursor, userModels, func(m models.UserModel) []interface{} {
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, userSortValue(&m, key.Column))
+		}
+		return values
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*entity.User, 0, len(userModels))
+	for _, userModel := range userModels {
+		users = append(users, userModel.ToEntity())
+	}
+
+	return &repository.UserSearchResult{Users: users, Cursor: meta}, nil
+}
+
+// userSortValue 返回排序字段对应的值
+func userSortValue(m *models.UserModel, column string) interface{} {
+	switch column {
+	case "name":
+		return m.Name
+	case "email":
+		return m.Email
+	case "created_at":
+		return m.CreatedAt
+	case "updated_at":
+		return m.UpdatedAt
+	default:
+		return m.ID
+	}
+}
+
+// userSearchRow 全文检索结果行
+type userSearchRow struct {
+	models.UserModel
+	Rank float64
+}
+
+// SearchText 全文检索：SQLite 使用 FTS5 + bm25，PostgreSQL 使用 tsvector + ts_rank，
+// 数据库不支持全文索引时退化为 LIKE 查询
+func (r *GormUserRepository) SearchText(ctx context.Context, tokens []string, limit int) ([]*repository.UserSearchHit, error) {
+	if len(tokens) == 0 {
+		return []*repository.UserSearchHit{}, nil
+	}
+
+	db := database.Conn(ctx, r.db)
+	var rows []userSearchRow
+
+	switch {
+	case r.dialect == "postgres":
+		tsquery := textsearch.TSQuery(tokens)
+		err := db.Raw(`SELECT users.*, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank
+			FROM users
+			WHERE deleted_at IS NULL AND search_vector @@ to_tsquery('simple', ?)
+			ORDER BY rank DESC, id
+			LIMIT ?`, tsquery, tsquery, limit).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	case r.fullText:
+		err := db.Raw(`SELECT users.*, -bm25(users_fts) AS rank
+			FROM users_fts JOIN users ON users.id = users_fts.rowid
+			WHERE users_fts MATCH ? AND users.deleted_at IS NULL
+			ORDER BY bm25(users_fts), users.id
+			LIMIT ?`, textsearch.FTS5Query(tokens), limit).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	default:
+		query := db.Model(&models.UserModel{})
+		for _, token := range tokens {
+			query = query.Where("search_text LIKE ? ESCAPE '\\'", "%"+escapeLike(token)+"%")
+		}
+		var userModels []models.UserModel
+		if err := query.Order("id").Limit(limit).Find(&userModels).Error; err != nil {
+			return nil, err
+		}
+		for _, userModel := range userModels {
+			rows = append(rows, userSearchRow{UserModel: userModel})
+		}
+	}
+
+	hits := make([]*repository.UserSearchHit, 0, len(rows))
+	for i := range rows {
+		hits = append(hits, &repository.UserSearchHit{User: rows[i].ToEntity(), Rank: rows[i].Rank})
+	}
+	return hits, nil
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r *GormUserRepository) Save(ctx context.Context, user *entity.User) error {
 	// 检查邮箱是否已存在（只检查未删除的记录）
 	var existingUser models.UserModel
-	if err := r.db.Where("email = ?", user.Email).First(&existingUser).Error; err == nil {
+	if err := database.Conn(ctx, r.db).Where("email = ?", user.Email.String()).First(&existingUser).Error; err == nil {
 		return errors.New("邮箱已存在")
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
@@ -73,66 +298,263 @@ func (r *GormUserRepository) Save(user *entity.User) error {
 
 	userModel := models.NewUserModelFromEntity(user)
 
-	if err := r.db.Create(userModel).Error; err != nil {
-		return err
-	}
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(userModel).Error; err != nil {
+			return err
+		}
 
-	// 更新实体的ID
-	user.ID = int(userModel.ID)
-	user.CreatedAt = userModel.CreatedAt
-	user.UpdatedAt = userModel.UpdatedAt
+		// 更新实体的ID
+		user.ID = int(userModel.ID)
+		user.CreatedAt = userModel.CreatedAt
+		user.UpdatedAt = userModel.UpdatedAt
+
+		return r.appendEvents(tx, user)
+	})
+}
 
+// Update 只更新实体中被修改的字段，没有修改时不访问数据库
+func (r *GormUserRepository) Update(ctx context.Context, user *entity.User) error {
+	if !user.HasChanges() {
+		return nil
+	}
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return r.update(tx, user)
+	})
+	if err != nil {
+		return err
+	}
+	markUpdated(user)
 	return nil
 }
 
-func (r *GormUserRepository) Update(user *entity.User) error {
-	userModel := models.NewUserModelFromEntity(user)
+// update 在 tx 中写入用户的修改，不修改实体；事务提交后由调用方调用 markUpdated，
+// 批次中后面的写入失败回滚时实体的版本号仍与数据库一致
+func (r *GormUserRepository) update(tx *gorm.DB, user *entity.User) error {
+	if !user.HasChanges() {
+		return nil
+	}
 
-	result := r.db.Model(&userModel).Where("id = ?", user.ID).Updates(map[string]interface{}{
-		"name":       user.Name,
-		"email":      user.Email,
-		"password":   user.Password,
+	updates := map[string]interface{}{
 		"updated_at": user.UpdatedAt,
-	})
+		"version":    gorm.Expr("version + 1"),
+	}
+	if user.IsChanged(entity.FieldName) {
+		updates["name"] = user.Name.String()
+	}
+	if user.IsChanged(entity.FieldEmail) {
+		updates["email"] = user.Email.String()
+	}
+	if user.IsChanged(entity.FieldName) || user.IsChanged(entity.FieldEmail) {
+		updates["search_text"] = models.UserSearchText(user.Name.String(), user.Email.String())
+	}
+	if user.IsChanged(entity.FieldPassword) {
+		updates["password"] = user.Password.Value()
+	}
+
+	result := tx.Model(&models.UserModel{}).
+		Where("id = ? AND version = ?", user.ID, user.Version).
+		Updates(updates)
 
 	if result.Error != nil {
 		return result.Error
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("用户不存在")
+		return missingOrConflict(tx, user.ID)
 	}
 
-	return nil
+	return r.appendEvents(tx, user)
 }
 
-func (r *GormUserRepository) Delete(id int) error {
-	// 使用软删除（默认行为）
-	result := r.db.Delete(&models.UserModel{}, id)
+// markUpdated 写入提交后更新实体的版本号并清除修改记录
+func markUpdated(users ...*entity.User) {
+	for _, user := range users {
+		if user.HasChanges() {
+			user.Version++
+			user.ClearChanges()
+		}
+	}
+}
 
-	if result.Error != nil {
-		return result.Error
+// appendEvents 在 tx 所属事务中将实体记录的领域事件写入发件箱
+func (r *GormUserRepository) appendEvents(tx *gorm.DB, user *entity.User) error {
+	return r.outbox.Append(tx, aggregateTypeUser, strconv.Itoa(user.ID), user.Events())
+}
+
+// SaveBatch 批量插入新用户并逐个更新已有用户，唯一索引冲突时返回 repository.ErrEmailTaken
+func (r *GormUserRepository) SaveBatch(ctx context.Context, created []*entity.User, updated []*entity.User) error {
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if len(created) > 0 {
+			userModels := make([]*models.UserModel, 0, len(created))
+			for _, user := range created {
+				userModels = append(userModels, models.NewUserModelFromEntity(user))
+			}
+			if err := tx.CreateInBatches(userModels, 100).Error; err != nil {
+				return err
+			}
+			for i, userModel := range userModels {
+				created[i].ID = int(userModel.ID)
+				created[i].CreatedAt = userModel.CreatedAt
+				created[i].UpdatedAt = userModel.UpdatedAt
+				if err := r.appendEvents(tx, created[i]); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, user := range updated {
+			if err := r.update(tx, user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if isUniqueViolation(err) {
+		return repository.ErrEmailTaken
+	}
+	if err != nil {
+		return err
 	}
+	markUpdated(updated...)
+	return nil
+}
 
-	if result.RowsAffected == 0 {
+func (r *GormUserRepository) Delete(ctx context.Context, user *entity.User, version int) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// 使用软删除（默认行为）
+		query := tx
+		if version > 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Delete(&models.UserModel{}, user.ID)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return missingOrConflict(tx, user.ID)
+		}
+
+		return r.appendEvents(tx, user)
+	})
+}
+
+// missingOrConflict 条件更新未命中时区分用户不存在和版本冲突
+func missingOrConflict(tx *gorm.DB, id int) error {
+	var count int64
+	if err := tx.Model(&models.UserModel{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
 		return errors.New("用户不存在")
 	}
+	return repository.ErrVersionConflict
+}
 
-	return nil
+// FindDeletedByID 查询已软删除的用户，区分用户不存在和未被删除
+func (r *GormUserRepository) FindDeletedByID(ctx context.Context, id int) (*entity.User, error) {
+	var userModel models.UserModel
+	if err := database.Conn(ctx, r.db).Unscoped().First(&userModel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+
+	if !userModel.DeletedAt.Valid {
+		return nil, repository.ErrUserNotDeleted
+	}
+	return userModel.ToEntity(), nil
+}
+
+// Restore 恢复软删除的用户。部分唯一索引只约束未删除的用户，
+// 因此恢复前需检查邮箱是否已被重新注册，并发注册时由唯一索引兜底
+func (r *GormUserRepository) Restore(ctx context.Context, user *entity.User) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserModel{}).Where("email = ?", user.Email.String()).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return repository.ErrEmailTaken
+		}
+
+		result := tx.Unscoped().Model(&models.UserModel{}).
+			Where("id = ? AND deleted_at IS NOT NULL", user.ID).
+			Updates(map[string]interface{}{
+				"deleted_at": nil,
+				"updated_at": time.Now(),
+				"version":    gorm.Expr("version + 1"),
+			})
+		if isUniqueViolation(result.Error) {
+			return repository.ErrEmailTaken
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		// 查询之后被并发恢复或永久删除
+		if result.RowsAffected == 0 {
+			return missingOrNotDeleted(tx, user.ID)
+		}
+
+		return r.appendEvents(tx, user)
+	})
 }
 
-// HardDelete 提供硬删除选项（如果需要的话）
-func (r *GormUserRepository) HardDelete(id int) error {
-	// 使用 Unscoped() 进行硬删除（真实删除）
-	result := r.db.Unscoped().Delete(&models.UserModel{}, id)
+// HardDelete 永久删除已软删除的用户，未删除的用户需先软删除
+func (r *GormUserRepository) HardDelete(ctx context.Context, user *entity.User) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// 使用 Unscoped() 进行硬删除（真实删除）
+		result := tx.Unscoped().Where("deleted_at IS NOT NULL").Delete(&models.UserModel{}, user.ID)
 
-	if result.Error != nil {
-		return result.Error
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return missingOrNotDeleted(tx, user.ID)
+		}
+
+		return r.appendEvents(tx, user)
+	})
+}
+
+// missingOrNotDeleted 针对已删除用户的条件写入未命中时区分用户不存在和未被删除
+func missingOrNotDeleted(tx *gorm.DB, id int) error {
+	var count int64
+	if err := tx.Model(&models.UserModel{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return repository.ErrUserNotDeleted
 	}
+	return errors.New("用户不存在")
+}
 
-	if result.RowsAffected == 0 {
-		return errors.New("用户不存在")
+func (r *GormUserRepository) FindDeletedBefore(ctx context.Context, before time.Time, afterID int, limit int) ([]*entity.User, error) {
+	var userModels []models.UserModel
+	err := database.Conn(ctx, r.db).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND id > ?", before, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&userModels).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	users := make([]*entity.User, 0, len(userModels))
+	for _, userModel := range userModels {
+		users = append(users, userModel.ToEntity())
+	}
+	return users, nil
+}
+
+// isUniqueViolation 判断是否违反唯一约束（SQLite 和 PostgreSQL）
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || strings.Contains(msg, "SQLSTATE 23505")
 }
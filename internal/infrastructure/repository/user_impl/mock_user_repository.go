@@ -2,8 +2,15 @@ package user_impl
 
 import (
 	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/repository"
+	"base-gin/internal/domain/user/vo"
 	"base-gin/internal/infrastructure/database"
+	"base-gin/pkg/textsearch"
+	"context"
 	"errors"
+	"math"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -32,25 +39,28 @@ func (r *MockUserRepository) initMockData() {
 	users := []*entity.User{
 		{
 			ID:        1,
-			Name:      "张三",
-			Email:     "zhangsan@example.com",
-			Password:  "password123",
+			Name:      vo.RestoreUserName("张三"),
+			Email:     vo.RestoreEmail("zhangsan@example.com"),
+			Version:   1,
+			Password:  vo.RestorePassword("password123"),
 			CreatedAt: time.Now().Add(-24 * time.Hour),
 			UpdatedAt: time.Now().Add(-24 * time.Hour),
 		},
 		{
 			ID:        2,
-			Name:      "李四",
-			Email:     "lisi@example.com",
-			Password:  "password456",
+			Name:      vo.RestoreUserName("李四"),
+			Email:     vo.RestoreEmail("lisi@example.com"),
+			Version:   1,
+			Password:  vo.RestorePassword("password456"),
 			CreatedAt: time.Now().Add(-12 * time.Hour),
 			UpdatedAt: time.Now().Add(-12 * time.Hour),
 		},
 		{
 			ID:        3,
-			Name:      "王五",
-			Email:     "wangwu@example.com",
-			Password:  "password789",
+			Name:      vo.RestoreUserName("王五"),
+			Email:     vo.RestoreEmail("wangwu@example.com"),
+			Version:   1,
+			Password:  vo.RestorePassword("password789"),
 			CreatedAt: time.Now().Add(-6 * time.Hour),
 			UpdatedAt: time.Now().Add(-6 * time.Hour),
 		},
@@ -64,7 +74,7 @@ func (r *MockUserRepository) initMockData() {
 	}
 }
 
-func (r *MockUserRepository) FindByID(id int) (*entity.User, error) {
+func (r *MockUserRepository) FindByID(ctx context.Context, id int) (*entity.User, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -78,12 +88,13 @@ func (r *MockUserRepository) FindByID(id int) (*entity.User, error) {
 	return &userCopy, nil
 }
 
-func (r *MockUserRepository) FindByEmail(email string) (*entity.User, error) {
+func (r *MockUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
+	normalized := vo.NormalizeEmail(email)
 	for _, user := range r.users {
-		if user.Email == email {
+		if user.Email.String() == normalized {
 			// 返回副本以避免外部修改
 			userCopy := *user
 			return &userCopy, nil
@@ -93,7 +104,7 @@ func (r *MockUserRepository) FindByEmail(email string) (*entity.User, error) {
 	return nil, errors.New("用户不存在")
 }
 
-func (r *MockUserRepository) FindAll() ([]*entity.User, error) {
+func (r *MockUserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
@@ -107,7 +118,172 @@ func (r *MockUserRepository) FindAll() ([]*entity.User, error) {
 	return users, nil
 }
 
-func (r *MockUserRepository) Save(user *entity.User) error {
+func (r *MockUserRepository) Search(ctx context.Context, criteria repository.UserSearchCriteria) (*repository.UserSearchResult, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if criteria.Cursor != nil {
+		return nil, errors.New("Mock 仓储不支持游标分页")
+	}
+	if criteria.Query != nil && len(criteria.Query.Filters) > 0 {
+		return nil, errors.New("Mock 仓储不支持通用筛选")
+	}
+
+	// Mock 仓储不保留已删除用户
+	if criteria.Status == repository.StatusDeleted {
+		return &repository.UserSearchResult{Users: []*entity.User{}}, nil
+	}
+
+	matched := make([]*entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		if criteria.NameContains != "" && !strings.Contains(strings.ToLower(user.Name.String()), strings.ToLower(criteria.NameContains)) {
+			continue
+		}
+		if criteria.EmailDomain != "" && user.Email.Domain() != vo.NormalizeEmail(criteria.EmailDomain) {
+			continue
+		}
+		if criteria.CreatedFrom != nil && user.CreatedAt.Before(*criteria.CreatedFrom) {
+			continue
+		}
+		if criteria.CreatedTo != nil && !user.CreatedAt.Before(*criteria.CreatedTo) {
+			continue
+		}
+		userCopy := *user
+		matched = append(matched, &userCopy)
+	}
+
+	// Mock 仓储只按第一个排序键排序
+	sortKey := criteria.SortKeys()[0]
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		var less, equal bool
+		switch sortKey.Column {
+		case "name":
+			less, equal = a.Name.String() < b.Name.String(), a.Name.Equals(b.Name)
+		case "email":
+			less, equal = a.Email.String() < b.Email.String(), a.Email.Equals(b.Email)
+		case "created_at":
+			less, equal = a.CreatedAt.Before(b.CreatedAt), a.CreatedAt.Equal(b.CreatedAt)
+		case "updated_at":
+			less, equal = a.UpdatedAt.Before(b.UpdatedAt), a.UpdatedAt.Equal(b.UpdatedAt)
+		default:
+			less, equal = a.ID < b.ID, a.ID == b.ID
+		}
+		if equal {
+			return a.ID < b.ID
+		}
+		if sortKey.Desc {
+			return !less
+		}
+		return less
+	})
+
+	total := int64(len(matched))
+	start := (criteria.Page - 1) * criteria.PageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + criteria.PageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return &repository.UserSearchResult{Users: matched[start:end], Total: total}, nil
+}
+
+func (r *MockUserRepository) Stream(ctx context.Context, criteria repository.UserSearchCriteria, fn func(*entity.User) error) error {
+	criteria.Page, criteria.PageSize = 1, math.MaxInt32
+	result, err := r.Search(ctx, criteria)
+	if err != nil {
+		return err
+	}
+	for _, user := range result.Users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MockUserRepository) SearchText(ctx context.Context, tokens []string, limit int) ([]*repository.UserSearchHit, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	hits := make([]*repository.UserSearchHit, 0)
+	for _, user := range r.users {
+		document := textsearch.Document(user.Name.String(), user.Email.String())
+		matched := len(tokens) > 0
+		for _, token := range tokens {
+			if !strings.Contains(document, token) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			userCopy := *user
+			hits = append(hits, &repository.UserSearchHit{User: &userCopy})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].User.ID < hits[j].User.ID })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (r *MockUserRepository) FindByEmails(ctx context.Context, emails []string) (map[string]*entity.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	wanted := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		wanted[vo.NormalizeEmail(email)] = true
+	}
+
+	users := make(map[string]*entity.User)
+	for _, user := range r.users {
+		if wanted[user.Email.String()] {
+			userCopy := *user
+			users[user.Email.String()] = &userCopy
+		}
+	}
+	return users, nil
+}
+
+// SaveBatch Mock 仓储先检查全部版本号再写入，模拟事务的整体生效
+func (r *MockUserRepository) SaveBatch(ctx context.Context, created []*entity.User, updated []*entity.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, user := range updated {
+		stored, exists := r.users[user.ID]
+		if !exists {
+			return errors.New("用户不存在")
+		}
+		if stored.Version != user.Version {
+			return repository.ErrVersionConflict
+		}
+	}
+
+	now := time.Now()
+	for _, user := range created {
+		user.ID = r.nextID
+		r.nextID++
+		user.CreatedAt = now
+		user.UpdatedAt = now
+		r.users[user.ID] = user
+	}
+	for _, user := range updated {
+		user.Version++
+		user.UpdatedAt = now
+		user.ClearChanges()
+		r.users[user.ID] = user
+	}
+	return nil
+}
+
+func (r *MockUserRepository) Save(ctx context.Context, user *entity.User) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -120,27 +296,65 @@ func (r *MockUserRepository) Save(user *entity.User) error {
 	return nil
 }
 
-func (r *MockUserRepository) Update(user *entity.User) error {
+func (r *MockUserRepository) Update(ctx context.Context, user *entity.User) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.users[user.ID]; !exists {
+	stored, exists := r.users[user.ID]
+	if !exists {
 		return errors.New("用户不存在")
 	}
+	if stored.Version != user.Version {
+		return repository.ErrVersionConflict
+	}
 
+	user.Version++
 	user.UpdatedAt = time.Now()
+	user.ClearChanges()
 	r.users[user.ID] = user
 	return nil
 }
 
-func (r *MockUserRepository) Delete(id int) error {
+func (r *MockUserRepository) Delete(ctx context.Context, user *entity.User, version int) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.users[id]; !exists {
+	id := user.ID
+	stored, exists := r.users[id]
+	if !exists {
 		return errors.New("用户不存在")
 	}
+	if version > 0 && stored.Version != version {
+		return repository.ErrVersionConflict
+	}
 
 	delete(r.users, id)
 	return nil
 }
+
+// FindDeletedByID Mock 仓储不保留已删除用户，找不到已删除的用户
+func (r *MockUserRepository) FindDeletedByID(ctx context.Context, id int) (*entity.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if _, exists := r.users[id]; exists {
+		return nil, repository.ErrUserNotDeleted
+	}
+	return nil, errors.New("用户不存在")
+}
+
+// Restore Mock 仓储不保留已删除用户，无法恢复
+func (r *MockUserRepository) Restore(ctx context.Context, user *entity.User) error {
+	_, err := r.FindDeletedByID(ctx, user.ID)
+	return err
+}
+
+// HardDelete Mock 仓储的删除本身就是永久删除，没有可永久删除的用户
+func (r *MockUserRepository) HardDelete(ctx context.Context, user *entity.User) error {
+	_, err := r.FindDeletedByID(ctx, user.ID)
+	return err
+}
+
+func (r *MockUserRepository) FindDeletedBefore(ctx context.Context, before time.Time, afterID int, limit int) ([]*entity.User, error) {
+	return []*entity.User{}, nil
+}
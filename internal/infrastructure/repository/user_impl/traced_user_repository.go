@@ -0,0 +1,131 @@
+package user_impl
+
+import (
+	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/repository"
+	"base-gin/pkg/tracing"
+	"context"
+	"time"
+)
+
+// TracedUserRepository 为每次仓储调用创建子 span 并记录错误
+type TracedUserRepository struct {
+	repo   repository.UserRepository
+	tracer *tracing.Tracer
+}
+
+// NewTracedUserRepository 包装 GORM 仓储，tracer 为 nil 时直接返回原仓储
+func NewTracedUserRepository(repo *GormUserRepository, tracer *tracing.Tracer) repository.UserRepository {
+	if tracer == nil {
+		return repo
+	}
+	return &TracedUserRepository{repo: repo, tracer: tracer}
+}
+
+func (r *TracedUserRepository) start(ctx context.Context, method string, attrs ...tracing.Attribute) (context.Context, *tracing.Span) {
+	return r.tracer.Start(ctx, "UserRepository."+method, tracing.WithAttributes(attrs...))
+}
+
+// endSpan 记录错误并结束 span，返回原错误
+func endSpan(span *tracing.Span, err error) error {
+	span.RecordError(err)
+	span.End()
+	return err
+}
+
+func (r *TracedUserRepository) FindByID(ctx context.Context, id int) (*entity.User, error) {
+	ctx, span := r.start(ctx, "FindByID", tracing.Int("user.id", id))
+	user, err := r.repo.FindByID(ctx, id)
+	return user, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ctx, span := r.start(ctx, "FindByEmail")
+	user, err := r.repo.FindByEmail(ctx, email)
+	return user, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) FindAll(ctx context.Context) ([]*entity.User, error) {
+	ctx, span := r.start(ctx, "FindAll")
+	users, err := r.repo.FindAll(ctx)
+	span.SetAttributes(tracing.Int("result.count", len(users)))
+	return users, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) Search(ctx context.Context, criteria repository.UserSearchCriteria) (*repository.UserSearchResult, error) {
+	ctx, span := r.start(ctx, "Search", tracing.Bool("cursor", criteria.Cursor != nil))
+	result, err := r.repo.Search(ctx, criteria)
+	if result != nil {
+		span.SetAttributes(tracing.Int("result.count", len(result.Users)))
+	}
+	return result, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) Stream(ctx context.Context, criteria repository.UserSearchCriteria, fn func(*entity.User) error) error {
+	ctx, span := r.start(ctx, "Stream")
+	count := 0
+	err := r.repo.Stream(ctx, criteria, func(user *entity.User) error {
+		count++
+		return fn(user)
+	})
+	span.SetAttributes(tracing.Int("result.count", count))
+	return endSpan(span, err)
+}
+
+func (r *TracedUserRepository) SearchText(ctx context.Context, tokens []string, limit int) ([]*repository.UserSearchHit, error) {
+	ctx, span := r.start(ctx, "SearchText", tracing.Int("search.tokens", len(tokens)), tracing.Int("search.limit", limit))
+	hits, err := r.repo.SearchText(ctx, tokens, limit)
+	span.SetAttributes(tracing.Int("result.count", len(hits)))
+	return hits, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) FindByEmails(ctx context.Context, emails []string) (map[string]*entity.User, error) {
+	ctx, span := r.start(ctx, "FindByEmails", tracing.Int("emails.count", len(emails)))
+	users, err := r.repo.FindByEmails(ctx, emails)
+	return users, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) Save(ctx context.Context, user *entity.User) error {
+	ctx, span := r.start(ctx, "Save")
+	err := r.repo.Save(ctx, user)
+	span.SetAttributes(tracing.Int("user.id", user.ID))
+	return endSpan(span, err)
+}
+
+func (r *TracedUserRepository) SaveBatch(ctx context.Context, created []*entity.User, updated []*entity.User) error {
+	ctx, span := r.start(ctx, "SaveBatch", tracing.Int("created.count", len(created)), tracing.Int("updated.count", len(updated)))
+	return endSpan(span, r.repo.SaveBatch(ctx, created, updated))
+}
+
+func (r *TracedUserRepository) Update(ctx context.Context, user *entity.User) error {
+	ctx, span := r.start(ctx, "Update", tracing.Int("user.id", user.ID))
+	return endSpan(span, r.repo.Update(ctx, user))
+}
+
+func (r *TracedUserRepository) Delete(ctx context.Context, user *entity.User, version int) error {
+	ctx, span := r.start(ctx, "Delete", tracing.Int("user.id", user.ID))
+	return endSpan(span, r.repo.Delete(ctx, user, version))
+}
+
+func (r *TracedUserRepository) FindDeletedByID(ctx context.Context, id int) (*entity.User, error) {
+	ctx, span := r.start(ctx, "FindDeletedByID", tracing.Int("user.id", id))
+	user, err := r.repo.FindDeletedByID(ctx, id)
+	return user, endSpan(span, err)
+}
+
+func (r *TracedUserRepository) Restore(ctx context.Context, user *entity.User) error {
+	ctx, span := r.start(ctx, "Restore", tracing.Int("user.id", user.ID))
+	return endSpan(span, r.repo.Restore(ctx, user))
+}
+
+func (r *TracedUserRepository) HardDelete(ctx context.Context, user *entity.User) error {
+	ctx, span := r.start(ctx, "HardDelete", tracing.Int("user.id", user.ID))
+	return endSpan(span, r.repo.HardDelete(ctx, user))
+}
+
+func (r *TracedUserRepository) FindDeletedBefore(ctx context.Context, before time.Time, afterID int, limit int) ([]*entity.User, error) {
+	ctx, span := r.start(ctx, "FindDeletedBefore", tracing.Int("after_id", afterID), tracing.Int("limit", limit))
+	users, err := r.repo.FindDeletedBefore(ctx, before, afterID, limit)
+	span.SetAttributes(tracing.Int("result.count", len(users)))
+	return users, endSpan(span, err)
+}
@@ -0,0 +1,294 @@
+package webhook_impl
+
+import (
+	"base-gin/internal/domain/webhook/entity"
+	"base-gin/internal/domain/webhook/repository"
+	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/database/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormWebhookRepository GORM实现的 Webhook 仓储
+type GormWebhookRepository struct {
+	db      *gorm.DB
+	dialect string
+}
+
+// NewGormWebhookRepository 创建新的GORM Webhook 仓储
+func NewGormWebhookRepository(database *database.DB) *GormWebhookRepository {
+	return &GormWebhookRepository{
+		db:      database.GetGormDB(),
+		dialect: database.Dialect(),
+	}
+}
+
+func (r *GormWebhookRepository) SaveSubscription(ctx context.Context, subscription *entity.Subscription) error {
+	model := models.NewWebhookSubscriptionModel(subscription)
+	if err := database.Conn(ctx, r.db).Create(model).Error; err != nil {
+		return err
+	}
+	subscription.ID = int(model.ID)
+	return nil
+}
+
+func (r *GormWebhookRepository) UpdateSubscription(ctx context.Context, subscription *entity.Subscription) error {
+	model := models.NewWebhookSubscriptionModel(subscription)
+	result := database.Conn(ctx, r.db).Model(&models.WebhookSubscriptionModel{}).
+		Where("id = ?", subscription.ID).
+		Updates(map[string]interface{}{
+			"url":                  model.URL,
+			"events":               model.Events,
+			"description":          model.Description,
+			"active":               model.Active,
+			"consecutive_failures": model.ConsecutiveFailures,
+			"disabled_at":          model.DisabledAt,
+			"disabled_reason":      model.DisabledReason,
+			"updated_at":           model.UpdatedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) DeleteSubscription(ctx context.Context, id int) error {
+	return database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		deliveries := tx.Model(&models.WebhookDeliveryModel{}).Select("id").Where("subscription_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&models.WebhookDeliveryAttemptModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDeliveryModel{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.WebhookSubscriptionModel{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrSubscriptionNotFound
+		}
+		return nil
+	})
+}
+
+func (r *GormWebhookRepository) FindSubscription(ctx context.Context, id int) (*entity.Subscription, error) {
+	var model models.WebhookSubscriptionModel
+	if err := database.Conn(ctx, r.db).First(&model, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return model.ToEntity(), nil
+}
+
+func (r *GormWebhookRepository) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
+	return r.findSubscriptions(database.Conn(ctx, r.db))
+}
+
+func (r *GormWebhookRepository) FindActiveSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
+	return r.findSubscriptions(database.Conn(ctx, r.db).Where("active = ?", true))
+}
+
+func (r *GormWebhookRepository) findSubscriptions(query *gorm.DB) ([]*entity.Subscription, error) {
+	var subscriptionModels []models.WebhookSubscriptionModel
+	if err := query.Order("id").Find(&subscriptionModels).Error; err != nil {
+		return nil, err
+	}
+
+	subscriptions := make([]*entity.Subscription, 0, len(subscriptionModels))
+	for _, model := range subscriptionModels {
+		subscriptions = append(subscriptions, model.ToEntity())
+	}
+	return subscriptions, nil
+}
+
+func (r *GormWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*entity.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	deliveryModels := make([]*models.WebhookDeliveryModel, 0, len(deliveries))
+	for _, d := range deliveries {
+		deliveryModels = append(deliveryModels, models.NewWebhookDeliveryModel(d))
+	}
+	if err := database.Conn(ctx, r.db).Create(deliveryModels).Error; err != nil {
+		return err
+	}
+	for i, model := range deliveryModels {
+		deliveries[i].ID = int(model.ID)
+	}
+	return nil
+}
+
+func (r *GormWebhookRepository) FindDelivery(ctx context.Context, subscriptionID, id int) (*entity.Delivery, error) {
+	var model models.WebhookDeliveryModel
+	err := database.Conn(ctx, r.db).Where("id = ? AND subscription_id = ?", id, subscriptionID).First(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return model.ToEntity(), nil
+}
+
+// ListDeliveries 按创建时间倒序分页查询投递记录
+func (r *GormWebhookRepository) ListDeliveries(ctx context.Context, criteria repository.DeliveryCriteria) ([]*entity.Delivery, int64, error) {
+	query := database.Conn(ctx, r.db).Model(&models.WebhookDeliveryModel{}).Where("subscription_id = ?", criteria.SubscriptionID)
+	if criteria.Status != "" {
+		query = query.Where("status = ?", criteria.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveryModels []models.WebhookDeliveryModel
+	err := query.Order("id DESC").
+		Offset((criteria.Page - 1) * criteria.PageSize).
+		Limit(criteria.PageSize).
+		Find(&deliveryModels).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := make([]*entity.Delivery, 0, len(deliveryModels))
+	for _, model := range deliveryModels {
+		deliveries = append(deliveries, model.ToEntity())
+	}
+	return deliveries, total, nil
+}
+
+func (r *GormWebhookRepository) ListAttempts(ctx context.Context, deliveryID int) ([]*entity.DeliveryAttempt, error) {
+	var attemptModels []models.WebhookDeliveryAttemptModel
+	if err := database.Conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("id").Find(&attemptModels).Error; err != nil {
+		return nil, err
+	}
+
+	attempts := make([]*entity.DeliveryAttempt, 0, len(attemptModels))
+	for _, model := range attemptModels {
+		attempts = append(attempts, model.ToEntity())
+	}
+	return attempts, nil
+}
+
+// ClaimDueDeliveries 与发件箱相同：PostgreSQL 使用 FOR UPDATE SKIP LOCKED，SQLite 依靠租约列的条件更新
+func (r *GormWebhookRepository) ClaimDueDeliveries(ctx context.Context, worker string, limit int, lease time.Duration) ([]*entity.Delivery, error) {
+	var claimed []models.WebhookDeliveryModel
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		query := tx.Model(&models.WebhookDeliveryModel{}).
+			Select("webhook_deliveries.id").
+			Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id").
+			Where("webhook_subscriptions.active = ?", true).
+			Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", entity.DeliveryPending, now).
+			Where("(webhook_deliveries.locked_until IS NULL OR webhook_deliveries.locked_until < ?)", now).
+			Order("webhook_deliveries.id").
+			Limit(limit)
+		if r.dialect == "postgres" {
+			query = query.Clauses(clause.Locking{
+				Strength: "UPDATE",
+				Table:    clause.Table{Name: "webhook_deliveries"},
+				Options:  "SKIP LOCKED",
+			})
+		}
+
+		var ids []uint
+		if err := query.Pluck("webhook_deliveries.id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		err := tx.Model(&models.WebhookDeliveryModel{}).
+			Where("id IN ? AND (locked_until IS NULL OR locked_until < ?)", ids, now).
+			Updates(map[string]interface{}{"locked_by": worker, "locked_until": now.Add(lease)}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ? AND locked_by = ?", ids, worker).Order("id").Find(&claimed).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*entity.Delivery, 0, len(claimed))
+	for _, model := range claimed {
+		deliveries = append(deliveries, model.ToEntity())
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt 连续失败次数在数据库中累加，多个投递任务并发时也能准确停用订阅
+func (r *GormWebhookRepository) RecordAttempt(ctx context.Context, delivery *entity.Delivery, attempt *entity.DeliveryAttempt, disableAfter int) (bool, error) {
+	disabled := false
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(models.NewWebhookDeliveryAttemptModel(attempt)).Error; err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.WebhookDeliveryModel{}).
+			Where("id = ?", delivery.ID).
+			Updates(map[string]interface{}{
+				"status":           delivery.Status,
+				"attempts":         delivery.Attempts,
+				"next_attempt_at":  delivery.NextAttemptAt,
+				"last_attempt_at":  delivery.LastAttemptAt,
+				"last_status_code": delivery.LastStatusCode,
+				"last_error":       delivery.LastError,
+				"locked_by":        "",
+				"locked_until":     nil,
+				"updated_at":       delivery.UpdatedAt,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		subscriptions := tx.Model(&models.WebhookSubscriptionModel{}).Where("id = ?", delivery.SubscriptionID)
+		if attempt.Succeeded() {
+			return subscriptions.Update("consecutive_failures", 0).Error
+		}
+		if err := subscriptions.Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		if disableAfter <= 0 {
+			return nil
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.WebhookSubscriptionModel{}).
+			Where("id = ? AND active = ? AND consecutive_failures >= ?", delivery.SubscriptionID, true, disableAfter).
+			Updates(map[string]interface{}{
+				"active":          false,
+				"disabled_at":     now,
+				"disabled_reason": entity.AutoDisableReason(disableAfter),
+				"updated_at":      now,
+			})
+		disabled = result.RowsAffected > 0
+		return result.Error
+	})
+	return disabled, err
+}
+
+func (r *GormWebhookRepository) UpdateDelivery(ctx context.Context, delivery *entity.Delivery) error {
+	return database.Conn(ctx, r.db).Model(&models.WebhookDeliveryModel{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"updated_at":      delivery.UpdatedAt,
+		}).Error
+}
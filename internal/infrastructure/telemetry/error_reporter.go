@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"base-gin/configs"
+	"base-gin/pkg/errreport"
+	"context"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// NewErrorReporter 根据配置创建错误上报，清理函数发送队列中剩余的事件。未配置 ERROR_REPORT_DSN 时返回 nil
+func NewErrorReporter(config *configs.Config) (errreport.ErrorReporter, func(), error) {
+	cfg := config.ErrorReport
+	if cfg.DSN == "" {
+		return nil, func() {}, nil
+	}
+
+	release := cfg.Release
+	if release == "" {
+		release = vcsRevision()
+	}
+	hostname, _ := os.Hostname()
+	reporter, err := errreport.NewSentryReporter(errreport.SentryOptions{
+		DSN:           cfg.DSN,
+		Environment:   cfg.Environment,
+		Release:       release,
+		ServerName:    hostname,
+		RateLimit:     cfg.RateLimit,
+		BatchSize:     cfg.BatchSize,
+		FlushInterval: time.Duration(cfg.FlushIntervalMs) * time.Millisecond,
+		QueueSize:     cfg.QueueSize,
+		Timeout:       time.Duration(cfg.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return nil, func() {}, err
+	}
+	log.Printf("错误上报已启用: 环境 %s，每分钟最多 %d 个事件", cfg.Environment, cfg.RateLimit)
+
+	return reporter, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := reporter.Shutdown(ctx); err != nil {
+			log.Printf("关闭错误上报失败: %v", err)
+		}
+		if dropped := reporter.Dropped(); dropped > 0 {
+			log.Printf("超过速率限制或队列已满，共丢弃 %d 个错误事件", dropped)
+		}
+	}, nil
+}
+
+// vcsRevision 构建时嵌入的 VCS 修订号，没有时为空
+func vcsRevision() string {
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"base-gin/configs"
+	"base-gin/pkg/tracing"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// NewTracer 根据配置创建追踪器，清理函数导出剩余的 span 并关闭导出目标。未启用追踪时返回 nil
+func NewTracer(config *configs.Config) (*tracing.Tracer, func(), error) {
+	cfg := config.Tracing
+	if !cfg.Enabled {
+		return nil, func() {}, nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	tracer := tracing.NewTracer(tracing.Options{
+		ServiceName:  cfg.ServiceName,
+		Sampler:      tracing.ParentBased(tracing.TraceIDRatio(cfg.SampleRatio)),
+		Exporter:     exporter,
+		BatchSize:    cfg.BatchSize,
+		BatchTimeout: time.Duration(cfg.BatchTimeoutMs) * time.Millisecond,
+		QueueSize:    cfg.QueueSize,
+	})
+	log.Printf("链路追踪已启用: 导出方式 %s，采样比例 %g", cfg.Exporter, cfg.SampleRatio)
+
+	return tracer, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := tracer.Shutdown(ctx); err != nil {
+			log.Printf("关闭链路追踪失败: %v", err)
+		}
+		if dropped := tracer.Dropped(); dropped > 0 {
+			log.Printf("导出队列已满，共丢弃 %d 个 span", dropped)
+		}
+	}, nil
+}
+
+func newExporter(cfg configs.TracingConfig) (tracing.Exporter, error) {
+	switch cfg.Exporter {
+	case "stdout":
+		return tracing.NewWriterExporter(os.Stdout), nil
+	case "file":
+		return tracing.NewFileExporter(cfg.FilePath)
+	case "otlp":
+		if cfg.OTLPEndpoint == "" {
+			return nil, fmt.Errorf("TRACING_EXPORTER=otlp 时必须配置 TRACING_OTLP_ENDPOINT")
+		}
+		headers, err := parseHeaders(cfg.OTLPHeaders)
+		if err != nil {
+			return nil, err
+		}
+		return tracing.NewOTLPExporter(cfg.OTLPEndpoint, headers, time.Duration(cfg.OTLPTimeoutSeconds)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("不支持的链路追踪导出方式: %s", cfg.Exporter)
+	}
+}
+
+// parseHeaders 解析 键=值,键=值 格式的请求头
+func parseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("无效的 TRACING_OTLP_HEADERS: %q", pair)
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
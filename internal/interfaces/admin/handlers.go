@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/interfaces/middleware"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildInfo 版本信息，VCS 字段来自 go build 时嵌入的版本控制信息
+type BuildInfo struct {
+	Module       string    `json:"module"`
+	Version      string    `json:"version"`
+	GoVersion    string    `json:"go_version"`
+	Platform     string    `json:"platform"`
+	VCS          string    `json:"vcs,omitempty"`
+	Revision     string    `json:"revision,omitempty"`
+	RevisionTime string    `json:"revision_time,omitempty"`
+	Modified     bool      `json:"modified"`
+	StartedAt    time.Time `json:"started_at"`
+	Uptime       string    `json:"uptime"`
+}
+
+// RouteInfo 业务端口注册的路由
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// version 返回模块版本、Go 版本和 VCS 修订号
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	info := BuildInfo{
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		StartedAt: s.startedAt,
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Module = build.Main.Path
+		info.Version = build.Main.Version
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs":
+				info.VCS = setting.Value
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.RevisionTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, gin.H{"data": info})
+}
+
+// routes 返回业务端口的路由表，按路径和方法排序
+func (s *Server) routes(w http.ResponseWriter, r *http.Request) {
+	routes := make([]RouteInfo, 0)
+	for _, route := range s.router.Routes() {
+		routes = append(routes, RouteInfo{Method: route.Method, Path: route.Path, Handler: route.Handler})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	writeJSON(w, http.StatusOK, gin.H{"data": routes})
+}
+
+// effectiveConfig 返回脱敏后的当前配置
+func (s *Server) effectiveConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, gin.H{"data": s.config.Redacted()})
+}
+
+func (s *Server) getLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, gin.H{"data": gin.H{"level": s.logger.Level().String()}})
+}
+
+// setLogLevel 修改日志级别，请求体为 {"level": "debug"}，重启后恢复为 LOG_LEVEL
+func (s *Server) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+	level, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous := s.logger.Level()
+	s.logger.SetLevel(level)
+	log.Printf("管理端口将日志级别从 %s 修改为 %s", previous, level)
+	writeJSON(w, http.StatusOK, gin.H{"data": gin.H{"level": level.String()}, "message": "日志级别已修改"})
+}
+
+func (s *Server) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, gin.H{"data": s.maintenance.Status()})
+}
+
+// setMaintenance 切换维护模式，请求体为 {"enabled": true, "message": "...", "retry_after": 60}
+func (s *Server) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Enabled    *bool  `json:"enabled"`
+		Message    string `json:"message"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+	if req.RetryAfter < 0 {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": "retry_after 不能为负数"})
+		return
+	}
+
+	message := "维护模式已关闭"
+	if *req.Enabled {
+		s.maintenance.Enable(req.Message, req.RetryAfter)
+		message = "维护模式已开启"
+	} else {
+		s.maintenance.Disable()
+	}
+	log.Printf("管理端口: %s", message)
+	writeJSON(w, http.StatusOK, gin.H{"data": s.maintenance.Status(), "message": message})
+}
+
+func (s *Server) getIPRules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, gin.H{"data": s.ipRules.Config()})
+}
+
+// setIPRules 替换可信代理、转发请求头和各分组的允许、拒绝列表，请求体与 GET /ip-rules 的 data 相同，
+// 未提供的分组不再限制；重启后恢复为环境变量的配置
+func (s *Server) setIPRules(w http.ResponseWriter, r *http.Request) {
+	var req middleware.IPRulesConfig
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+	if err := s.ipRules.Update(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("管理端口修改了客户端 IP 规则: 可信代理 %v，分组 %d 个", req.TrustedProxies, len(req.Groups))
+	writeJSON(w, http.StatusOK, gin.H{"data": s.ipRules.Config(), "message": "客户端 IP 规则已更新"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("写入管理端口响应失败: %v", err)
+	}
+}
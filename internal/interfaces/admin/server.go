@@ -0,0 +1,130 @@
+// Package admin 实现独立于业务端口的管理 HTTP 服务：pprof、expvar、版本信息、路由表、
+// 脱敏后的配置，以及调整日志级别、切换维护模式和修改客户端 IP 规则的接口
+package admin
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/interfaces/middleware"
+	"crypto/subtle"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrUnprotected 未配置令牌且监听地址不是回环地址
+var ErrUnprotected = errors.New("管理端口未配置 ADMIN_TOKEN 时只能监听回环地址")
+
+// Server 管理 HTTP 服务
+type Server struct {
+	config      *configs.Config
+	router      *gin.Engine
+	logger      *logging.Logger
+	maintenance *middleware.MaintenanceMode
+	ipRules     *middleware.IPRules
+	token       string
+	startedAt   time.Time
+	server      *http.Server
+}
+
+// NewServer 创建管理服务，未启用时返回 nil。没有令牌时监听地址必须是回环地址，否则返回 ErrUnprotected
+func NewServer(config *configs.Config, router *gin.Engine, logger *logging.Logger, maintenance *middleware.MaintenanceMode, ipRules *middleware.IPRules) (*Server, error) {
+	cfg := config.Admin
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Token == "" && !isLoopbackAddr(cfg.Addr) {
+		return nil, fmt.Errorf("%w: %s", ErrUnprotected, cfg.Addr)
+	}
+
+	s := &Server{
+		config:      config,
+		router:      router,
+		logger:      logger,
+		maintenance: maintenance,
+		ipRules:     ipRules,
+		token:       cfg.Token,
+		startedAt:   time.Now(),
+	}
+	s.server = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s, nil
+}
+
+// Handler 返回带访问控制的路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	mux.HandleFunc("GET /version", s.version)
+	mux.HandleFunc("GET /routes", s.routes)
+	mux.HandleFunc("GET /config", s.effectiveConfig)
+	mux.HandleFunc("GET /log-level", s.getLogLevel)
+	mux.HandleFunc("POST /log-level", s.setLogLevel)
+	mux.HandleFunc("GET /maintenance", s.getMaintenance)
+	mux.HandleFunc("POST /maintenance", s.setMaintenance)
+	mux.HandleFunc("GET /ip-rules", s.getIPRules)
+	mux.HandleFunc("PUT /ip-rules", s.setIPRules)
+
+	return s.protect(mux)
+}
+
+// protect 配置了令牌时校验 Authorization: Bearer，否则只接受回环地址的请求
+func (s *Server) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeJSON(w, http.StatusUnauthorized, gin.H{"error": "无效的管理令牌"})
+				return
+			}
+		} else if !isLoopbackAddr(r.RemoteAddr) {
+			writeJSON(w, http.StatusForbidden, gin.H{"error": "只允许本机访问"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe 启动管理服务，关闭后返回 http.ErrServerClosed
+func (s *Server) ListenAndServe() error {
+	log.Printf("管理端口启动在 %s", s.server.Addr)
+	return s.server.ListenAndServe()
+}
+
+// Close 关闭管理服务
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// isLoopbackAddr addr 为 host:port 或 host，host 为回环 IP 或 localhost 时返回 true
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
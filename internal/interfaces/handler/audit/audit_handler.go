@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"base-gin/internal/app/audit/service"
+	"base-gin/internal/domain/audit/repository"
+	"base-gin/internal/domain/audit/vo"
+	"base-gin/pkg/pagination"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListEntries 按操作者、操作、对象、请求 ID 和时间范围分页查询审计记录
+func (h *AuditHandler) ListEntries(c *gin.Context) {
+	page := pagination.PageRequest{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分页参数错误"})
+		return
+	}
+
+	var query vo.AuditListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from、to 必须是 RFC 3339 格式的时间"})
+		return
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from 必须早于 to"})
+		return
+	}
+
+	entries, total, err := h.auditService.ListEntries(c.Request.Context(), repository.AuditCriteria{
+		Actor:      query.Actor,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+		RequestID:  query.RequestID,
+		From:       query.From,
+		To:         query.To,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := pagination.NewPageResponse(total, page.Page, page.PageSize, entries)
+	if link := resp.LinkHeader(c.Request.URL); link != "" {
+		c.Header("Link", link)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Verify 校验整条哈希链，记录被篡改时 valid 为 false 并返回第一条异常记录的 ID
+func (h *AuditHandler) Verify(c *gin.Context) {
+	result, err := h.auditService.Verify(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
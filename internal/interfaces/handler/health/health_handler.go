@@ -0,0 +1,80 @@
+package health
+
+import (
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/pkg/health"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+	logger  *logging.Logger
+}
+
+func NewHealthHandler(checker *health.Checker, logger *logging.Logger) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// Livez 存活检查，只包含后台任务心跳等进程自身的检查，依赖故障不会使其失败
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.reply(c, h.checker.Live(c.Request.Context()))
+}
+
+// Readyz 就绪检查，包含所有依赖检查，服务关闭期间始终返回 503
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	h.reply(c, h.checker.Ready(c.Request.Context()))
+}
+
+// Healthz 所有检查的汇总，不受关闭状态影响
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	h.reply(c, h.checker.Health(c.Request.Context()))
+}
+
+// reply 可用时返回 200，否则返回 503；带 verbose 参数时返回每项检查的状态和耗时。
+// 健康检查挂在业务端口上，错误详情可能包含数据库地址等内部信息，只写日志不返回
+func (h *HealthHandler) reply(c *gin.Context, report *health.Report) {
+	h.logFailures(report)
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+
+	body := gin.H{"status": report.Status}
+	if verbose(c) {
+		checks := make([]health.Result, 0, len(report.Checks))
+		for _, result := range report.Checks {
+			public := *result
+			public.Error = ""
+			checks = append(checks, public)
+		}
+		body["checks"] = checks
+	}
+	c.JSON(status, body)
+}
+
+// logFailures 记录失败检查的错误，缓存结果在首次执行时已记录过
+func (h *HealthHandler) logFailures(report *health.Report) {
+	for _, result := range report.Checks {
+		if result.Status == health.StatusOK || result.Cached || result.Error == "" {
+			continue
+		}
+		h.logger.Warn(fmt.Sprintf("健康检查 %s 失败: %s", result.Name, result.Error))
+	}
+}
+
+// verbose ?verbose、?verbose=1、?verbose=true 均视为开启
+func verbose(c *gin.Context) bool {
+	value, ok := c.GetQuery("verbose")
+	if !ok {
+		return false
+	}
+	return value != "0" && value != "false"
+}
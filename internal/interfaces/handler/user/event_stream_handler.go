@@ -0,0 +1,183 @@
+package user
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/infrastructure/eventstream"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// eventReset 无法从 Last-Event-ID 续传时发送的事件，客户端应重新加载完整列表
+const eventReset = "reset"
+
+// EventStreamHandler 通过 SSE 推送用户变更事件
+type EventStreamHandler struct {
+	broker    *eventstream.Broker
+	heartbeat time.Duration
+	retry     int
+	// tokens 访问令牌及其可读取的事件类型，未配置令牌时为空，拒绝所有连接
+	tokens []streamToken
+}
+
+// streamToken 一个访问令牌，allowed 为 nil 表示可读取全部事件
+type streamToken struct {
+	token   []byte
+	allowed []string
+}
+
+func NewEventStreamHandler(config *configs.Config, broker *eventstream.Broker) *EventStreamHandler {
+	cfg := config.EventStream
+	return &EventStreamHandler{
+		broker:    broker,
+		heartbeat: time.Duration(cfg.HeartbeatSeconds) * time.Second,
+		retry:     cfg.RetryMs,
+		tokens:    parseStreamTokens(cfg.Tokens),
+	}
+}
+
+// parseStreamTokens 解析 令牌:类型|类型 格式的令牌配置，不带类型或类型为 * 时可读取全部事件
+func parseStreamTokens(value string) []streamToken {
+	var tokens []streamToken
+	for _, entry := range strings.Split(value, ",") {
+		token, types, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		if token == "" {
+			continue
+		}
+
+		var allowed []string
+		for _, t := range strings.Split(types, "|") {
+			if t = strings.TrimSpace(t); t == "*" {
+				allowed = nil
+				break
+			} else if t != "" {
+				allowed = append(allowed, t)
+			}
+		}
+		tokens = append(tokens, streamToken{token: []byte(token), allowed: allowed})
+	}
+	return tokens
+}
+
+// StreamEvents 以 SSE 推送用户变更事件。
+// types 按事件类型筛选；携带 Last-Event-ID 请求头（或 last_event_id 参数）时先补发之后的事件
+func (h *EventStreamHandler) StreamEvents(c *gin.Context) {
+	if len(h.tokens) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "未配置 EVENT_STREAM_TOKENS，事件流不可用"})
+		return
+	}
+	allowed, ok := h.authorize(c)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="events"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少或无效的访问令牌"})
+		return
+	}
+
+	var requested []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if !slices.Contains(entity.EventNames, t) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的事件类型: %s", t)})
+			return
+		}
+		if allowed != nil && !slices.Contains(allowed, t) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("无权读取事件: %s", t)})
+			return
+		}
+		requested = append(requested, t)
+	}
+	if len(requested) == 0 {
+		requested = allowed
+	}
+	filter := func(eventType string) bool {
+		return requested == nil || slices.Contains(requested, eventType)
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	sub, replay, resumed := h.broker.Subscribe(lastEventID, filter)
+	if sub == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "服务正在关闭"})
+		return
+	}
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 禁止 nginx 等反向代理缓冲响应
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if h.retry > 0 {
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", h.retry)
+	}
+	if !resumed {
+		sse.Encode(c.Writer, sse.Event{Event: eventReset, Data: gin.H{"last_event_id": lastEventID}})
+	}
+	for _, msg := range replay {
+		writeStreamMessage(c, msg)
+	}
+	c.Writer.Flush()
+
+	heartbeat := h.heartbeat
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// 连接消费过慢或服务关闭，客户端重连后按 Last-Event-ID 补发
+				return
+			}
+			writeStreamMessage(c, msg)
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// authorize 校验访问令牌，返回可读取的事件类型，nil 表示全部。令牌按常量时间比较，
+// 并与所有令牌逐一比较，不因匹配位置泄露信息。
+// 浏览器的 EventSource 无法设置请求头，因此也接受 access_token 参数，日志和错误上报中会隐藏其值
+func (h *EventStreamHandler) authorize(c *gin.Context) ([]string, bool) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if token == "" {
+		token = c.Query("access_token")
+	}
+	if token == "" {
+		return nil, false
+	}
+
+	var allowed []string
+	matched := false
+	for _, t := range h.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), t.token) == 1 && !matched {
+			allowed, matched = t.allowed, true
+		}
+	}
+	return allowed, matched
+}
+
+func writeStreamMessage(c *gin.Context, msg *eventstream.Message) {
+	sse.Encode(c.Writer, sse.Event{Id: msg.ID, Event: msg.Type, Data: msg.Data})
+}
@@ -2,10 +2,24 @@ package user
 
 import (
 	"base-gin/internal/app/user/service"
+	"base-gin/internal/domain/user/repository"
 	"base-gin/internal/domain/user/vo"
+	"base-gin/internal/interfaces/middleware"
 	"base-gin/internal/interfaces/validation"
+	"base-gin/pkg/dataformat"
+	"base-gin/pkg/jsonpatch"
+	"base-gin/pkg/pagination"
+	querylang "base-gin/pkg/query"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,12 +27,14 @@ import (
 type UserHandler struct {
 	userService *service.UserService
 	validator   *validation.Validator
+	cursorCodec *pagination.CursorCodec
 }
 
-func NewUserHandler(userService *service.UserService, validator *validation.Validator) *UserHandler {
+func NewUserHandler(userService *service.UserService, validator *validation.Validator, cursorCodec *pagination.CursorCodec) *UserHandler {
 	return &UserHandler{
 		userService: userService,
 		validator:   validator,
+		cursorCodec: cursorCodec,
 	}
 }
 
@@ -31,18 +47,219 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUser(id)
+	user, err := h.userService.GetUser(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", userETag(user.Version))
+	if !user.UpdatedAt.IsZero() {
+		c.Header("Last-Modified", user.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
 	c.JSON(http.StatusOK, gin.H{"data": user})
 }
 
-// GetAllUsers 获取所有用户
-func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	users, err := h.userService.GetAllUsers()
+// ListUsers 分页查询用户列表，传入 after/before 或 mode=cursor 时使用游标分页
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	h.listUsers(c, repository.StatusActive)
+}
+
+// listUsers 按状态分页查询用户。status 由路由决定，公开接口只能查询正常用户
+func (h *UserHandler) listUsers(c *gin.Context, status string) {
+	page := pagination.PageRequest{Page: 1, PageSize: 10}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分页参数错误"})
+		return
+	}
+
+	var query vo.UserListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+	if status != repository.StatusActive {
+		query.Status = ""
+	}
+
+	criteria, err := buildSearchCriteria(&page, &query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criteria.Status = status
+
+	values := c.Request.URL.Query()
+	if err := applyLegacyOrder(values); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	criteria.Query, err = repository.UserQuerySchema.Parse(values)
+	if err != nil {
+		var errs querylang.Errors
+		errors.As(err, &errs)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "查询参数错误", "details": errs})
+		return
+	}
+
+	var cursorReq pagination.CursorRequest
+	if err := c.ShouldBindQuery(&cursorReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分页参数错误"})
+		return
+	}
+
+	cursorMode := query.Mode == "cursor" || cursorReq.After != "" || cursorReq.Before != ""
+	if cursorMode {
+		criteria.Cursor, err = h.cursorCodec.NewCursorQuery(&cursorReq, criteria.SortKeys())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.userService.ListUsers(c.Request.Context(), criteria)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if cursorMode {
+		c.JSON(http.StatusOK, pagination.NewCursorResponse(result.Cursor, result.Users))
+		return
+	}
+
+	resp := pagination.NewPageResponse(result.Total, page.Page, page.PageSize, result.Users)
+	if link := resp.LinkHeader(c.Request.URL); link != "" {
+		c.Header("Link", link)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// buildSearchCriteria 将查询参数转换为仓储查询条件
+func buildSearchCriteria(page *pagination.PageRequest, query *vo.UserListQuery) (repository.UserSearchCriteria, error) {
+	criteria := repository.UserSearchCriteria{
+		Page:         page.Page,
+		PageSize:     page.PageSize,
+		NameContains: strings.TrimSpace(query.Name),
+		EmailDomain:  strings.TrimSpace(query.EmailDomain),
+		Status:       repository.StatusActive,
+	}
+
+	// 已删除的用户只能通过管理接口查询，调用方在解析后自行设置 Status
+	if query.Status != "" && query.Status != repository.StatusActive {
+		return criteria, fmt.Errorf("不支持的状态: %s，已删除的用户请通过管理接口查询", query.Status)
+	}
+
+	if query.CreatedFrom != "" {
+		from, _, err := parseTimeParam(query.CreatedFrom)
+		if err != nil {
+			return criteria, fmt.Errorf("created_from 格式错误: %s", query.CreatedFrom)
+		}
+		criteria.CreatedFrom = &from
+	}
+
+	if query.CreatedTo != "" {
+		to, dateOnly, err := parseTimeParam(query.CreatedTo)
+		if err != nil {
+			return criteria, fmt.Errorf("created_to 格式错误: %s", query.CreatedTo)
+		}
+		// 只传日期时包含当天
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		criteria.CreatedTo = &to
+	}
+
+	return criteria, nil
+}
+
+// ParseSearchCriteria 从查询参数解析筛选与排序条件（不含分页），供导出接口和命令行共用。
+// status 只接受 active，需要导出已删除用户的调用方在解析后设置 Status
+func ParseSearchCriteria(values url.Values) (repository.UserSearchCriteria, error) {
+	query := vo.UserListQuery{
+		Name:        values.Get("name"),
+		EmailDomain: values.Get("email_domain"),
+		CreatedFrom: values.Get("created_from"),
+		CreatedTo:   values.Get("created_to"),
+		Status:      values.Get("status"),
+	}
+
+	criteria, err := buildSearchCriteria(&pagination.PageRequest{}, &query)
+	if err != nil {
+		return criteria, err
+	}
+
+	values = maps.Clone(values)
+	if err := applyLegacyOrder(values); err != nil {
+		return criteria, err
+	}
+	criteria.Query, err = repository.UserQuerySchema.Parse(values)
+	return criteria, err
+}
+
+// legacyOrderParam 旧版排序方向参数，已由 sort 的 - 前缀取代
+const legacyOrderParam = "order"
+
+// applyLegacyOrder 兼容旧版的 order 参数：sort 为单个字段（默认 id）时将 order=desc 转换为 - 前缀并删除 order；
+// 方向无效、sort 包含多个字段或已带 - 前缀时无法确定含义，返回错误提示改用 sort
+func applyLegacyOrder(values url.Values) error {
+	orders, ok := values[legacyOrderParam]
+	if !ok {
+		return nil
+	}
+	if len(orders) != 1 {
+		return errors.New("order 参数已废弃，请使用 sort 参数，- 前缀表示降序")
+	}
+
+	var desc bool
+	switch strings.ToLower(strings.TrimSpace(orders[0])) {
+	case "asc":
+	case "desc":
+		desc = true
+	default:
+		return fmt.Errorf("不支持的排序方向: %s，order 参数已废弃，请使用 sort 参数，- 前缀表示降序", orders[0])
+	}
+
+	sort := strings.TrimSpace(values.Get(querylang.ParamSort))
+	if len(values[querylang.ParamSort]) > 1 || strings.ContainsAny(sort, ",-") {
+		return errors.New("order 参数已废弃，不能与多个排序字段或带 - 前缀的 sort 同时使用，请只使用 sort 参数")
+	}
+	if sort == "" {
+		sort = "id"
+	}
+	if desc {
+		sort = "-" + sort
+	}
+	values.Set(querylang.ParamSort, sort)
+	values.Del(legacyOrderParam)
+	return nil
+}
+
+// parseTimeParam 解析 RFC3339 或 YYYY-MM-DD 格式的时间参数
+func parseTimeParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	return t, true, err
+}
+
+// SearchUsers 按关键字全文检索用户，支持前缀联想
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	query := vo.UserSearchQuery{Limit: 10}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+
+	if strings.TrimSpace(query.Q) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "搜索关键字不能为空"})
+		return
+	}
+
+	users, err := h.userService.SearchUsers(c.Request.Context(), query.Q, query.Limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -51,11 +268,60 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": users})
 }
 
+// ExportUsers 按列表接口的筛选与排序条件流式导出用户，支持 CSV、NDJSON 和 JSON
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	query := vo.UserExportQuery{Format: dataformat.FormatCSV}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+
+	columns, err := vo.ParseExportColumns(query.Columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	criteria, err := ParseSearchCriteria(c.Request.URL.Query())
+	if err != nil {
+		var errs querylang.Errors
+		if errors.As(err, &errs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "查询参数错误", "details": errs})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writer, err := dataformat.NewWriter(query.Format, c.Writer, columns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的导出格式: %s", query.Format)})
+		return
+	}
+
+	filename := fmt.Sprintf("users-%s.%s", time.Now().Format("20060102-150405"), query.Format)
+	c.Header("Content-Type", dataformat.ContentType(query.Format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	count, err := h.userService.ExportUsers(c.Request.Context(), criteria, columns, writer)
+	if err != nil {
+		// 客户端已断开时无需处理；否则响应头已发出，只能中断连接让客户端知道导出不完整
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		log.Printf("导出用户失败，已写出 %d 行: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
 // CreateUser 创建用户
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req vo.UserCreateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+	if err := validation.BindJSON(c, &req); err != nil {
+		status, message := validation.BindError(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
@@ -75,7 +341,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(&req)
+	user, err := h.userService.CreateUser(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -84,6 +350,52 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": user, "message": "用户创建成功"})
 }
 
+// ImportUsers 从 CSV 或 NDJSON 批量导入用户，返回每行的处理结果
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	query := vo.UserImportQuery{Mode: vo.ImportModeFailFast}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+		return
+	}
+
+	if !vo.IsValidImportMode(query.Mode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的导入模式: %s", query.Mode)})
+		return
+	}
+
+	format := query.Format
+	if format == "" {
+		format = dataformat.FormatFromContentType(c.ContentType())
+	}
+	reader, err := dataformat.NewReader(format, c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "请使用 text/csv 或 application/x-ndjson 格式导入"})
+		return
+	}
+
+	report, err := h.userService.ImportUsers(c.Request.Context(), reader, vo.ImportOptions{Mode: query.Mode, DryRun: query.DryRun})
+	// 每个批次单独提交，后续批次失败时前面的批次已经写入
+	if !report.DryRun && report.Created+report.Updated > 0 {
+		middleware.MarkModified(c)
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "导入文件过大", "data": report})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "data": report})
+		return
+	}
+
+	if report.Aborted {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "导入已停止，存在无效行", "data": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
 // UpdateUser 更新用户
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	idStr := c.Param("id")
@@ -93,9 +405,16 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	ifMatch, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": repository.ErrVersionConflict.Error()})
+		return
+	}
+
 	var req vo.UserUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数格式错误"})
+	if err := validation.BindJSON(c, &req); err != nil {
+		status, message := validation.BindError(err)
+		c.JSON(status, gin.H{"error": message})
 		return
 	}
 
@@ -110,12 +429,69 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateUser(id, &req)
+	user, err := h.userService.UpdateUser(c.Request.Context(), id, &req, ifMatch)
 	if err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.Header("ETag", userETag(user.Version))
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "用户更新成功"})
+}
+
+// PatchUser 部分更新用户
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	ifMatch, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": repository.ErrVersionConflict.Error()})
+		return
+	}
+
+	contentType := c.ContentType()
+	if contentType != jsonpatch.MediaTypeMergePatch && contentType != jsonpatch.MediaTypeJSONPatch {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": service.ErrUnsupportedPatchType.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "请求体过大"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+		return
+	}
+
+	user, err := h.userService.PatchUser(c.Request.Context(), id, &vo.UserPatchRequest{ContentType: contentType, Body: body}, ifMatch)
+	if err != nil {
+		var validationErrs vo.ValidationErrors
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		case errors.As(err, &validationErrs):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "参数校验失败", "details": validationErrs})
+		case errors.Is(err, jsonpatch.ErrTestFailed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", userETag(user.Version))
 	c.JSON(http.StatusOK, gin.H{"data": user, "message": "用户更新成功"})
 }
 
@@ -128,10 +504,114 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.DeleteUser(id); err != nil {
+	ifMatch, ok := parseIfMatch(c.GetHeader("If-Match"))
+	if !ok {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": repository.ErrVersionConflict.Error()})
+		return
+	}
+
+	if err := h.userService.DeleteUser(c.Request.Context(), id, ifMatch); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "用户删除成功"})
 }
+
+// userETag 由版本号生成强 ETag
+func userETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch 解析 If-Match 请求头，返回可接受的版本号。
+// 请求头为空或为 * 时返回 nil 表示不校验；If-Match 使用强比较，弱 ETag 和无法识别的值不匹配任何版本，
+// 全部不匹配时 ok 为 false
+func parseIfMatch(header string) (versions []int, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil || version <= 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, len(versions) > 0
+}
+
+// ListDeletedUsers 分页查询已软删除的用户，参数与 ListUsers 相同，忽略 status
+func (h *UserHandler) ListDeletedUsers(c *gin.Context) {
+	h.listUsers(c, repository.StatusDeleted)
+}
+
+// RestoreUser 恢复软删除的用户
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	user, err := h.userService.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) || errors.Is(err, repository.ErrUserNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": user, "message": "用户恢复成功"})
+}
+
+// PurgeUser 永久删除已软删除的用户
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := h.userService.PurgeUser(c.Request.Context(), id); err != nil {
+		if errors.Is(err, repository.ErrUserNotDeleted) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户已永久删除"})
+}
+
+// PurgeDeletedUsers 永久删除超过指定天数的已删除用户，默认只预览（dry_run=true）
+func (h *UserHandler) PurgeDeletedUsers(c *gin.Context) {
+	query := vo.PurgeQuery{DryRun: true}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "older_than_days 必须是正整数"})
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -query.OlderThanDays)
+	report, err := h.userService.PurgeDeletedUsers(c.Request.Context(), before, query.DryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "data": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
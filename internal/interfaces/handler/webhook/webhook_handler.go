@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"base-gin/internal/app/webhook/service"
+	"base-gin/internal/domain/webhook/entity"
+	"base-gin/internal/domain/webhook/repository"
+	"base-gin/internal/domain/webhook/vo"
+	"base-gin/internal/interfaces/validation"
+	"base-gin/pkg/pagination"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ListSubscriptions 查询所有订阅
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+// CreateSubscription 创建订阅，签名密钥只在此响应中返回
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req vo.SubscriptionCreateRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		status, message := validation.BindError(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": subscription, "message": "订阅创建成功"})
+}
+
+// GetSubscription 获取单个订阅
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.webhookService.GetSubscription(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscription})
+}
+
+// UpdateSubscription 修改订阅，active=true 重新启用已停用的订阅
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req vo.SubscriptionUpdateRequest
+	if err := validation.BindJSON(c, &req); err != nil {
+		status, message := validation.BindError(err)
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateSubscription(c.Request.Context(), id, &req)
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscription, "message": "订阅更新成功"})
+}
+
+// DeleteSubscription 删除订阅及其投递记录
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), id); err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "订阅删除成功"})
+}
+
+// ListDeliveries 分页查询订阅的投递记录，可按状态筛选
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	page := pagination.PageRequest{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分页参数错误"})
+		return
+	}
+
+	var query vo.DeliveryListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status 必须是 pending、succeeded 或 failed"})
+		return
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), repository.DeliveryCriteria{
+		SubscriptionID: id,
+		Status:         query.Status,
+		Page:           page.Page,
+		PageSize:       page.PageSize,
+	})
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := pagination.NewPageResponse(total, page.Page, page.PageSize, deliveries)
+	if link := resp.LinkHeader(c.Request.URL); link != "" {
+		c.Header("Link", link)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDelivery 获取投递详情，包含每次尝试的请求和响应片段
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := paramID(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": delivery})
+}
+
+// Redeliver 手动重新投递，投递任务会在下一次轮询时发送
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := paramID(c, "deliveryId")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		c.JSON(statusOf(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": delivery, "message": "已加入重新投递队列"})
+}
+
+// paramID 解析路径中的 ID 参数，无效时直接返回 400
+func paramID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// statusOf 将服务层错误转换为 HTTP 状态码
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrSubscriptionNotFound), errors.Is(err, repository.ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSubscriptionDisabled):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrInvalidURL), errors.Is(err, entity.ErrEventsRequired),
+		errors.Is(err, entity.ErrSecretTooShort), errors.Is(err, service.ErrUnsupportedEvent), errors.Is(err, service.ErrForbiddenURL):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"base-gin/pkg/requestmeta"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminActor 通过管理令牌认证的请求在审计记录中的操作者
+const adminActor = "admin"
+
+// AdminAuth 中间件保护业务端口上的管理接口。配置了令牌时校验 Authorization: Bearer，不匹配返回 401；
+// 未配置令牌时只有分组设置了允许列表才放行（来源由 IPFilter 限制），否则返回 403，避免默认对外开放。
+// 令牌校验通过且操作者不是由可信代理提供时，审计操作者记为 admin，来源为 admin_token
+func AdminAuth(token string, rules *IPRules, group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "无效的管理令牌"})
+				return
+			}
+			ctx := c.Request.Context()
+			if requestmeta.FromContext(ctx).ActorSource != requestmeta.ActorSourceProxy {
+				c.Request = c.Request.WithContext(requestmeta.WithActor(ctx, adminActor, requestmeta.ActorSourceAdminToken))
+			}
+		} else if !rules.Restricted(group) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理接口未配置 ADMIN_TOKEN 或 IP 允许列表，拒绝访问"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"base-gin/pkg/clientip"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPKey 上下文中保存解析后的客户端 IP 的键，日志格式化时通过 LogFormatterParams.Keys 读取
+const clientIPKey = "client_ip"
+
+// IPFilterRules 一个路由分组的允许、拒绝列表，元素为 CIDR 或单个 IP
+type IPFilterRules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// IPRulesConfig 客户端 IP 规则：可信代理、转发请求头和按路由分组的过滤列表
+type IPRulesConfig struct {
+	TrustedProxies []string                 `json:"trusted_proxies"`
+	Headers        []string                 `json:"headers"`
+	Groups         map[string]IPFilterRules `json:"groups"`
+}
+
+// IPRules 可在运行时整体替换的客户端 IP 规则，由管理端口修改，多个中间件共享同一实例
+type IPRules struct {
+	mutex    sync.RWMutex
+	config   IPRulesConfig
+	resolver *clientip.Resolver
+	filters  map[string]*clientip.Filter
+}
+
+// NewIPRules 创建客户端 IP 规则，CIDR 或请求头无效时返回错误
+func NewIPRules(config IPRulesConfig) (*IPRules, error) {
+	rules := &IPRules{}
+	if err := rules.Update(config); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Update 校验并替换全部规则，校验失败时保留原规则
+func (r *IPRules) Update(config IPRulesConfig) error {
+	resolver, err := clientip.NewResolver(config.TrustedProxies, config.Headers)
+	if err != nil {
+		return err
+	}
+	filters := make(map[string]*clientip.Filter, len(config.Groups))
+	for group, lists := range config.Groups {
+		filter, err := clientip.NewFilter(lists.Allow, lists.Deny)
+		if err != nil {
+			return fmt.Errorf("%s: %w", group, err)
+		}
+		filters[group] = filter
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.config = config
+	r.resolver = resolver
+	r.filters = filters
+	return nil
+}
+
+// Config 当前规则的副本
+func (r *IPRules) Config() IPRulesConfig {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	config := IPRulesConfig{
+		TrustedProxies: append([]string{}, r.config.TrustedProxies...),
+		Headers:        append([]string{}, r.config.Headers...),
+		Groups:         make(map[string]IPFilterRules, len(r.config.Groups)),
+	}
+	for group, lists := range r.config.Groups {
+		config.Groups[group] = IPFilterRules{
+			Allow: append([]string{}, lists.Allow...),
+			Deny:  append([]string{}, lists.Deny...),
+		}
+	}
+	return config
+}
+
+// Resolve 按当前的可信代理解析请求的客户端 IP
+func (r *IPRules) Resolve(req *http.Request) netip.Addr {
+	r.mutex.RLock()
+	resolver := r.resolver
+	r.mutex.RUnlock()
+	return resolver.ClientIP(req)
+}
+
+// FromTrustedProxy 按当前的可信代理判断请求的对端是否为可信代理，r 为 nil 时不信任任何对端
+func (r *IPRules) FromTrustedProxy(req *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.RLock()
+	resolver := r.resolver
+	r.mutex.RUnlock()
+	return resolver.FromTrustedProxy(req)
+}
+
+// Allowed 判断地址是否允许访问分组，未配置的分组不限制
+func (r *IPRules) Allowed(group string, addr netip.Addr) bool {
+	r.mutex.RLock()
+	filter, ok := r.filters[group]
+	r.mutex.RUnlock()
+	return !ok || filter.Allowed(addr)
+}
+
+// Restricted 分组是否配置了允许列表
+func (r *IPRules) Restricted(group string) bool {
+	r.mutex.RLock()
+	filter, ok := r.filters[group]
+	r.mutex.RUnlock()
+	return ok && filter.Restricted()
+}
+
+// RealIP 中间件按可信代理解析客户端 IP，需注册在其他中间件之前，之后通过 ClientIP 读取。
+// 引擎上的 ForwardedByClientIP 应关闭，避免 gin 信任任意来源的转发请求头
+func RealIP(rules *IPRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if addr := rules.Resolve(c.Request); addr.IsValid() {
+			c.Set(clientIPKey, addr.String())
+		}
+		c.Next()
+	}
+}
+
+// ClientIP 返回 RealIP 解析的客户端 IP，未经过 RealIP 时返回 gin 的 ClientIP
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(clientIPKey); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
+
+// IPFilter 中间件按分组的允许、拒绝列表限制客户端 IP，不允许时返回 403；规则在每个请求时读取，修改后立即生效
+func IPFilter(rules *IPRules, group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addr, _ := netip.ParseAddr(ClientIP(c))
+		if !rules.Allowed(group, addr) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "不允许从该地址访问"})
+			return
+		}
+		c.Next()
+	}
+}
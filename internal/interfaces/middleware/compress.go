@@ -0,0 +1,423 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrUnsupportedEncoding 没有内置实现的压缩编码
+var ErrUnsupportedEncoding = errors.New("不支持的压缩编码，内置 gzip 和 deflate")
+
+// Encoder 压缩编码器，通过 Reset 复用。compress/gzip、compress/zlib 的 Writer 满足该接口
+type Encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Codec 一种内容编码
+type Codec struct {
+	// Name Accept-Encoding 和 Content-Encoding 中的名称，如 gzip
+	Name string
+	// New 创建写入 w 的编码器
+	New func(w io.Writer) (Encoder, error)
+}
+
+// GzipCodec gzip 编码，level 为 compress/gzip 的压缩级别
+func GzipCodec(level int) Codec {
+	return Codec{Name: "gzip", New: func(w io.Writer) (Encoder, error) {
+		return gzip.NewWriterLevel(w, level)
+	}}
+}
+
+// DeflateCodec deflate 编码，按 RFC 9110 使用 zlib 格式，level 为 compress/zlib 的压缩级别
+func DeflateCodec(level int) Codec {
+	return Codec{Name: "deflate", New: func(w io.Writer) (Encoder, error) {
+		return zlib.NewWriterLevel(w, level)
+	}}
+}
+
+// BuiltinCodec 按名称返回内置的编码，其他编码需自行构造 Codec
+func BuiltinCodec(name string, level int) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "gzip":
+		return GzipCodec(level), nil
+	case "deflate":
+		return DeflateCodec(level), nil
+	default:
+		return Codec{}, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, name)
+	}
+}
+
+// CompressionOptions 响应压缩配置
+type CompressionOptions struct {
+	// Codecs 可用的编码，按服务端偏好排列，Accept-Encoding 权重相同时靠前的优先
+	Codecs []Codec
+	// MinSize 响应体达到该字节数才压缩，提前 Flush 的流式响应不受限制
+	MinSize int
+	// ContentTypes 压缩的响应类型，如 application/json，text/* 匹配所有 text 类型
+	ContentTypes []string
+}
+
+// Compressor 编译后的压缩配置，每种编码维护一个编码器池
+type Compressor struct {
+	codecs       []*pooledCodec
+	minSize      int
+	contentTypes map[string]bool
+	typePrefixes []string
+}
+
+type pooledCodec struct {
+	Codec
+	pool sync.Pool
+}
+
+// NewCompressor 校验编码并预先创建一个编码器，压缩级别无效时返回错误
+func NewCompressor(opts CompressionOptions) (*Compressor, error) {
+	compressor := &Compressor{minSize: opts.MinSize, contentTypes: make(map[string]bool)}
+	for _, codec := range opts.Codecs {
+		encoder, err := codec.New(io.Discard)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", codec.Name, err)
+		}
+		pooled := &pooledCodec{Codec: Codec{Name: strings.ToLower(codec.Name), New: codec.New}}
+		pooled.pool.Put(encoder)
+		compressor.codecs = append(compressor.codecs, pooled)
+	}
+	for _, contentType := range opts.ContentTypes {
+		contentType = strings.ToLower(strings.TrimSpace(contentType))
+		if prefix, ok := strings.CutSuffix(contentType, "*"); ok {
+			compressor.typePrefixes = append(compressor.typePrefixes, prefix)
+		} else if contentType != "" {
+			compressor.contentTypes[contentType] = true
+		}
+	}
+	return compressor, nil
+}
+
+// negotiate 按 Accept-Encoding 的权重选择编码，没有可接受的编码时返回 nil
+func (p *Compressor) negotiate(acceptEncoding string) *pooledCodec {
+	weights := make(map[string]float64)
+	for _, item := range splitHeaderList(acceptEncoding) {
+		name, params, _ := strings.Cut(item, ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+
+	var best *pooledCodec
+	bestWeight := 0.0
+	for _, codec := range p.codecs {
+		weight, ok := weights[codec.Name]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = codec, weight
+		}
+	}
+	return best
+}
+
+func (p *Compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if p.contentTypes[mediaType] {
+		return true
+	}
+	for _, prefix := range p.typePrefixes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress 中间件按 Accept-Encoding 压缩响应。响应体先缓存到 MinSize 再决定是否压缩，
+// 处理函数调用 Flush 或 WriteHeaderNow 时立即决定，之后每次 Flush 都刷新编码器，
+// 因此导出和事件流可以边压缩边发送。需注册在 Recovery 之前，panic 时的错误响应同样经过压缩。
+// 压缩后的响应是不同的表示，强 ETag 加上 -<编码> 后缀；请求中的 If-Match、If-None-Match
+// 先去掉该后缀，处理函数按原始 ETag 比较
+func Compress(compressor *Compressor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		suffixed := compressor.stripETagSuffixes(c.Request.Header)
+		if c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		// 是否压缩取决于 Accept-Encoding，缓存必须按它区分，未压缩的响应也一样
+		addVary(c.Writer.Header(), "Accept-Encoding")
+
+		codec := compressor.negotiate(c.GetHeader("Accept-Encoding"))
+		if codec == nil {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, compressor: compressor, codec: codec, suffixedETag: suffixed[codec.Name]}
+		c.Writer = w
+		completed := false
+		defer func() {
+			// Recovery 为中断的连接重新抛出 http.ErrAbortHandler 时不再写出压缩流的结尾
+			if completed {
+				w.finish()
+			} else {
+				w.abort()
+			}
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+		completed = true
+	}
+}
+
+// stripETagSuffixes 去掉条件请求头中由 Compress 加上的编码后缀，返回出现过的编码
+func (p *Compressor) stripETagSuffixes(header http.Header) map[string]bool {
+	var suffixed map[string]bool
+	for _, name := range []string{"If-Match", "If-None-Match"} {
+		value := header.Get(name)
+		if value == "" || value == "*" {
+			continue
+		}
+		tags := splitHeaderList(value)
+		changed := false
+		for i, tag := range tags {
+			for _, codec := range p.codecs {
+				if trimmed, ok := strings.CutSuffix(tag, "-"+codec.Name+`"`); ok {
+					tags[i] = trimmed + `"`
+					changed = true
+					if suffixed == nil {
+						suffixed = make(map[string]bool)
+					}
+					suffixed[codec.Name] = true
+					break
+				}
+			}
+		}
+		if changed {
+			header.Set(name, strings.Join(tags, ", "))
+		}
+	}
+	return suffixed
+}
+
+// compressWriter 缓存不足 MinSize 的响应体，决定压缩后写入编码器
+type compressWriter struct {
+	gin.ResponseWriter
+	compressor *Compressor
+	codec      *pooledCodec
+	buffer     []byte
+	decided    bool
+	encoder    Encoder
+	// suffixedETag 条件请求携带的是压缩响应的 ETag，304 响应返回同样带后缀的 ETag
+	suffixedETag bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buffer = append(w.buffer, data...)
+		if len(w.buffer) < w.compressor.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 处理函数要求立即发送响应头，按流式响应处理，不再等待 MinSize
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 先刷新编码器再刷新连接，客户端可以立即解压已发送的部分
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buffer) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	if !w.decided && len(w.buffer) > 0 {
+		return len(w.buffer)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// decide 根据状态码和响应头决定是否压缩，写出已缓存的响应体
+func (w *compressWriter) decide() error {
+	w.decided = true
+	buffered := w.buffer
+	w.buffer = nil
+
+	if w.shouldCompress() {
+		header := w.Header()
+		encoder, err := w.codec.get(w.ResponseWriter)
+		if err == nil {
+			header.Set("Content-Encoding", w.codec.Name)
+			header.Del("Content-Length")
+			w.suffixETag()
+			w.encoder = encoder
+		}
+	} else if w.Status() == http.StatusNotModified && w.suffixedETag {
+		w.suffixETag()
+	}
+	if len(buffered) == 0 {
+		return nil
+	}
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buffered)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buffered)
+	return err
+}
+
+func (w *compressWriter) shouldCompress() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	for _, directive := range splitHeaderList(header.Get("Cache-Control")) {
+		if strings.EqualFold(directive, "no-transform") {
+			return false
+		}
+	}
+	return w.compressor.compressible(header.Get("Content-Type"))
+}
+
+// suffixETag 为强 ETag 加上编码后缀，弱 ETag 本身不区分字节级差异，保持不变
+func (w *compressWriter) suffixETag() {
+	header := w.Header()
+	etag := header.Get("ETag")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return
+	}
+	header.Set("ETag", etag[:len(etag)-1]+"-"+w.codec.Name+`"`)
+}
+
+// finish 写出不足 MinSize 的响应体或结束压缩流，编码器放回池中
+func (w *compressWriter) finish() {
+	if !w.decided {
+		if len(w.buffer) == 0 {
+			return
+		}
+		// 响应体过小，不压缩，由 net/http 设置 Content-Length
+		w.decided = true
+		w.ResponseWriter.Write(w.buffer)
+		w.buffer = nil
+		return
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.codec.put(w.encoder)
+		w.encoder = nil
+	}
+}
+
+// abort 连接已中断，丢弃缓存的响应体，编码器不写出结尾，重置后放回池中
+func (w *compressWriter) abort() {
+	w.buffer = nil
+	if w.encoder != nil {
+		w.codec.put(w.encoder)
+		w.encoder = nil
+	}
+}
+
+func (c *pooledCodec) get(w io.Writer) (Encoder, error) {
+	if encoder, ok := c.pool.Get().(Encoder); ok {
+		encoder.Reset(w)
+		return encoder, nil
+	}
+	return c.New(w)
+}
+
+func (c *pooledCodec) put(encoder Encoder) {
+	// 不再引用响应，避免池中的编码器持有已结束的连接
+	encoder.Reset(io.Discard)
+	c.pool.Put(encoder)
+}
+
+// DecompressRequest 中间件解压 Content-Encoding: gzip 的请求体，解压后超过 maxSize 字节时读取返回
+// *http.MaxBytesError，由处理函数返回 413，防止压缩炸弹；其他编码返回 415。
+// 需注册在 MaxBodySize 之前，路由的请求体大小限制按解压后的大小计算
+func DecompressRequest(maxSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		switch encoding {
+		case "", "identity":
+			c.Next()
+			return
+		case "gzip", "x-gzip":
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "不支持的请求体编码，只支持 gzip"})
+			return
+		}
+
+		reader, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "无效的 gzip 请求体"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, &gzipBody{Reader: reader, body: c.Request.Body}, maxSize)
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
+
+// gzipBody 关闭时同时关闭原始请求体
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
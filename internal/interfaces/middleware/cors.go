@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsRegexPrefix 以此开头的来源按正则表达式匹配完整的 Origin
+const corsRegexPrefix = "regex:"
+
+// ErrCORSWildcardCredentials 允许携带凭据时浏览器不接受 Access-Control-Allow-Origin: *
+var ErrCORSWildcardCredentials = errors.New("允许携带凭据时来源不能配置为 *，请列出具体的来源")
+
+// CORSPolicy 跨域策略。AllowedOrigins 每项可以是：
+//   - *：允许任意来源，不能与 AllowCredentials 同时使用
+//   - 完整来源，如 https://app.example.com
+//   - 子域名通配，如 https://*.example.com，匹配任意层级的子域名，不匹配 example.com 本身
+//   - regex: 开头的正则表达式，匹配完整的 Origin，如 regex:^https://pr-[0-9]+\.preview\.example\.com$
+type CORSPolicy struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders 预检请求允许的请求头，包含 * 时允许任意请求头
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials 为 true 时允许携带 Cookie 和 Authorization
+	AllowCredentials bool
+	// MaxAge 浏览器缓存预检结果的时间，0 表示不缓存
+	MaxAge time.Duration
+}
+
+// CORSOverride 路径以 PathPrefix 开头的请求使用单独的策略，多个前缀匹配时使用最长的
+type CORSOverride struct {
+	PathPrefix string
+	Policy     CORSPolicy
+}
+
+// CORSRules 编译后的跨域策略
+type CORSRules struct {
+	defaults  *corsPolicy
+	overrides []corsOverride
+}
+
+type corsOverride struct {
+	prefix string
+	policy *corsPolicy
+}
+
+type corsPolicy struct {
+	allowAll      bool
+	exact         map[string]bool
+	wildcards     []corsWildcard
+	patterns      []*regexp.Regexp
+	methods       map[string]bool
+	anyHeader     bool
+	headers       map[string]bool
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	credentials   bool
+	maxAge        string
+}
+
+// corsWildcard https://*.example.com 拆分为 https:// 和 .example.com
+type corsWildcard struct {
+	prefix string
+	suffix string
+}
+
+// NewCORS 编译跨域策略，来源或正则表达式无效时返回错误
+func NewCORS(policy CORSPolicy, overrides ...CORSOverride) (*CORSRules, error) {
+	defaults, err := compileCORSPolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	rules := &CORSRules{defaults: defaults}
+	for _, o := range overrides {
+		compiled, err := compileCORSPolicy(o.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o.PathPrefix, err)
+		}
+		rules.overrides = append(rules.overrides, corsOverride{prefix: strings.TrimSuffix(o.PathPrefix, "/"), policy: compiled})
+	}
+	sort.SliceStable(rules.overrides, func(i, j int) bool {
+		return len(rules.overrides[i].prefix) > len(rules.overrides[j].prefix)
+	})
+	return rules, nil
+}
+
+func compileCORSPolicy(policy CORSPolicy) (*corsPolicy, error) {
+	p := &corsPolicy{
+		exact:         make(map[string]bool),
+		methods:       make(map[string]bool),
+		headers:       make(map[string]bool),
+		exposeHeaders: strings.Join(policy.ExposedHeaders, ", "),
+		credentials:   policy.AllowCredentials,
+	}
+	for _, origin := range policy.AllowedOrigins {
+		switch {
+		case origin == "*":
+			p.allowAll = true
+		case strings.HasPrefix(origin, corsRegexPrefix):
+			pattern, err := regexp.Compile(`^(?:` + strings.TrimPrefix(origin, corsRegexPrefix) + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("无效的来源正则表达式 %q: %w", origin, err)
+			}
+			p.patterns = append(p.patterns, pattern)
+		case strings.Contains(origin, "*"):
+			prefix, suffix, ok := strings.Cut(strings.ToLower(origin), "*.")
+			if !ok || !strings.HasSuffix(prefix, "://") || strings.Contains(suffix, "*") || suffix == "" {
+				return nil, fmt.Errorf("无效的通配来源 %q，格式应为 https://*.example.com", origin)
+			}
+			p.wildcards = append(p.wildcards, corsWildcard{prefix: prefix, suffix: "." + suffix})
+		default:
+			p.exact[normalizeOrigin(origin)] = true
+		}
+	}
+	if p.allowAll && p.credentials {
+		return nil, ErrCORSWildcardCredentials
+	}
+
+	methods := make([]string, 0, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		method = strings.ToUpper(method)
+		p.methods[method] = true
+		methods = append(methods, method)
+	}
+	p.allowMethods = strings.Join(methods, ", ")
+
+	for _, header := range policy.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	p.allowHeaders = strings.Join(policy.AllowedHeaders, ", ")
+
+	if seconds := int(policy.MaxAge / time.Second); seconds > 0 {
+		p.maxAge = strconv.Itoa(seconds)
+	}
+	return p, nil
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// policyFor 返回路径适用的策略
+func (r *CORSRules) policyFor(path string) *corsPolicy {
+	for _, o := range r.overrides {
+		if path == o.prefix || strings.HasPrefix(path, o.prefix+"/") {
+			return o.policy
+		}
+	}
+	return r.defaults
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+	normalized := normalizeOrigin(origin)
+	if p.exact[normalized] {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if strings.HasPrefix(normalized, w.prefix) && strings.HasSuffix(normalized, w.suffix) {
+			sub := normalized[len(w.prefix) : len(normalized)-len(w.suffix)]
+			if sub != "" && !strings.ContainsAny(sub, "/:@?#") {
+				return true
+			}
+		}
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowRequestHeaders 检查预检请求的 Access-Control-Request-Headers 是否都被允许
+func (p *corsPolicy) allowRequestHeaders(requested string) bool {
+	if p.anyHeader {
+		return true
+	}
+	for _, header := range splitHeaderList(requested) {
+		if !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS 中间件按来源白名单处理跨域请求。不允许的来源不返回 Access-Control-Allow-Origin，
+// 由浏览器拦截响应；不允许的预检请求返回 403。预检请求不会进入路由分组的中间件，
+// 因此分组的策略通过 CORSOverride 按路径前缀配置
+func CORS(rules *CORSRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := rules.policyFor(c.Request.URL.Path)
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		header := c.Writer.Header()
+
+		// 除允许任意来源且不携带凭据外，响应随 Origin 变化，缓存需按 Origin 区分
+		if !policy.allowAll {
+			addVary(header, "Origin")
+		}
+		if preflight {
+			addVary(header, "Access-Control-Request-Method", "Access-Control-Request-Headers")
+		}
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		allowed := policy.allowOrigin(origin)
+		if preflight {
+			if !allowed ||
+				!policy.methods[strings.ToUpper(c.GetHeader("Access-Control-Request-Method"))] ||
+				!policy.allowRequestHeaders(c.GetHeader("Access-Control-Request-Headers")) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "跨域请求不被允许"})
+				return
+			}
+			policy.setOriginHeaders(header, origin)
+			header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+			if policy.anyHeader {
+				// 携带凭据时 * 不被浏览器视为通配，直接返回请求的头
+				header.Set("Access-Control-Allow-Headers", c.GetHeader("Access-Control-Request-Headers"))
+			} else if policy.allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+			}
+			if policy.maxAge != "" {
+				header.Set("Access-Control-Max-Age", policy.maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			policy.setOriginHeaders(header, origin)
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+		}
+		c.Next()
+	}
+}
+
+func (p *corsPolicy) setOriginHeaders(header http.Header, origin string) {
+	if p.allowAll {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// addVary 向 Vary 头追加尚未包含的字段，不覆盖其他中间件设置的值
+func addVary(header http.Header, names ...string) {
+	existing := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range splitHeaderList(value) {
+			existing[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	for _, name := range names {
+		if !existing[http.CanonicalHeaderKey(name)] {
+			header.Add("Vary", name)
+			existing[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
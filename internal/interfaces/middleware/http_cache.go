@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"base-gin/internal/infrastructure/cache"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachedHeaders 随缓存响应一起保存的响应头，其他头（如 CORS）由中间件每次重新设置
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Link"}
+
+// CachePolicy 路由的 HTTP 缓存策略
+type CachePolicy struct {
+	// MaxAge Cache-Control 的 max-age（秒），0 表示客户端每次都需验证
+	MaxAge int
+	// Vary 响应的 Vary 头，同时作为服务端缓存键的一部分
+	Vary string
+	// Store 不为空时在服务端缓存 200 响应
+	Store cache.Store
+	TTL   time.Duration
+	// Tags 返回响应的缓存标签，修改数据时按标签失效
+	Tags func(c *gin.Context) []string
+}
+
+type cachedResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header"`
+	Body   []byte            `json:"body"`
+}
+
+// HTTPCache 中间件处理 GET 请求的条件请求与缓存：
+// 优先使用处理器设置的 ETag（如资源版本号），否则按响应体计算弱 ETag；
+// 请求的 If-None-Match 或 If-Modified-Since 命中时返回 304
+func HTTPCache(policy CachePolicy) gin.HandlerFunc {
+	varyHeaders := splitHeaderList(policy.Vary)
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := cacheKey(c.Request, varyHeaders)
+		if policy.Store != nil && !requestsNoCache(c.Request) {
+			if data, ok := policy.Store.Get(key); ok {
+				var entry cachedResponse
+				if err := json.Unmarshal(data, &entry); err == nil {
+					for name, value := range entry.Header {
+						c.Header(name, value)
+					}
+					c.Header("X-Cache", "HIT")
+					policy.setHeaders(c.Writer.Header())
+					writeConditional(c.Writer, c.Request, entry.Status, entry.Body)
+					c.Abort()
+					return
+				}
+			}
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.status != http.StatusOK {
+			writeConditional(w.ResponseWriter, nil, w.status, w.body.Bytes())
+			return
+		}
+
+		header := w.Header()
+		if header.Get("ETag") == "" {
+			header.Set("ETag", weakETag(w.body.Bytes()))
+		}
+		policy.setHeaders(header)
+
+		if policy.Store != nil {
+			entry := cachedResponse{Status: w.status, Header: make(map[string]string), Body: w.body.Bytes()}
+			for _, name := range cachedHeaders {
+				if value := header.Get(name); value != "" {
+					entry.Header[name] = value
+				}
+			}
+			if data, err := json.Marshal(entry); err == nil {
+				var tags []string
+				if policy.Tags != nil {
+					tags = policy.Tags(c)
+				}
+				policy.Store.Set(key, data, policy.TTL, tags...)
+			}
+			header.Set("X-Cache", "MISS")
+		}
+
+		writeConditional(w.ResponseWriter, c.Request, w.status, w.body.Bytes())
+	}
+}
+
+// modifiedKey 处理函数标记已提交修改的上下文键
+const modifiedKey = "cache.modified"
+
+// MarkModified 标记本次请求已提交修改。请求以错误状态结束但部分修改已提交时（如导入中途失败）
+// 由处理函数调用，InvalidateCache 仍会清除缓存
+func MarkModified(c *gin.Context) {
+	c.Set(modifiedKey, true)
+}
+
+// InvalidateCache 中间件在修改类请求成功后按标签清除服务端缓存
+func InvalidateCache(store cache.Store, tags func(c *gin.Context) []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if store == nil {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if c.Writer.Status() < http.StatusBadRequest || c.GetBool(modifiedKey) {
+			store.InvalidateTags(tags(c)...)
+		}
+	}
+}
+
+func (p CachePolicy) setHeaders(header http.Header) {
+	if header.Get("Cache-Control") == "" {
+		if p.MaxAge > 0 {
+			header.Set("Cache-Control", "private, max-age="+strconv.Itoa(p.MaxAge))
+		} else {
+			header.Set("Cache-Control", "private, no-cache")
+		}
+	}
+	addVary(header, splitHeaderList(p.Vary)...)
+}
+
+// writeConditional 写出响应，req 不为空且条件请求命中时改为 304
+func writeConditional(w gin.ResponseWriter, req *http.Request, status int, body []byte) {
+	if req != nil && status == http.StatusOK && notModified(req, w.Header()) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		w.WriteHeaderNow()
+		return
+	}
+
+	w.WriteHeader(status)
+	if len(body) == 0 {
+		w.WriteHeaderNow()
+		return
+	}
+	w.Write(body)
+}
+
+// notModified 判断条件请求是否命中。If-None-Match 使用弱比较，且存在时忽略 If-Modified-Since
+func notModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := header.Get("ETag")
+		for _, tag := range splitHeaderList(inm) {
+			if tag == "*" || (etag != "" && trimWeak(tag) == trimWeak(etag)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := req.Header.Get("If-Modified-Since")
+	lastModified := header.Get("Last-Modified")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+func requestsNoCache(req *http.Request) bool {
+	for _, directive := range splitHeaderList(req.Header.Get("Cache-Control")) {
+		if strings.EqualFold(directive, "no-cache") || strings.EqualFold(directive, "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(req *http.Request, varyHeaders []string) string {
+	var b strings.Builder
+	b.WriteString(req.URL.RequestURI())
+	for _, name := range varyHeaders {
+		b.WriteString("\n")
+		b.WriteString(req.Header.Get(name))
+	}
+	return b.String()
+}
+
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func trimWeak(tag string) string {
+	return strings.TrimPrefix(tag, "W/")
+}
+
+func splitHeaderList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// bufferedWriter 缓存处理器写出的响应体，以便计算 ETag 后再决定返回 200 还是 304
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	written bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
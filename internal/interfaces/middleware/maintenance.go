@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaintenanceMessage 未指定提示信息时的响应
+const defaultMaintenanceMessage = "服务维护中，请稍后再试"
+
+// MaintenanceStatus 维护模式状态
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	// RetryAfter 建议客户端重试的间隔（秒），0 表示不返回 Retry-After
+	RetryAfter int        `json:"retry_after,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// MaintenanceMode 维护模式开关，由管理端口切换，多个中间件共享同一实例
+type MaintenanceMode struct {
+	mutex  sync.RWMutex
+	status MaintenanceStatus
+}
+
+func NewMaintenanceMode() *MaintenanceMode {
+	return &MaintenanceMode{}
+}
+
+// Enable 开启维护模式，message 为空时使用默认提示
+func (m *MaintenanceMode) Enable(message string, retryAfter int) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	now := time.Now()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.status = MaintenanceStatus{Enabled: true, Message: message, RetryAfter: retryAfter, Since: &now}
+}
+
+// Disable 关闭维护模式
+func (m *MaintenanceMode) Disable() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.status = MaintenanceStatus{}
+}
+
+// Status 当前状态
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.status
+}
+
+// Maintenance 中间件在维护模式下返回 503，只应用于业务接口，健康检查和指标不受影响
+func Maintenance(mode *MaintenanceMode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := mode.Status()
+		if !status.Enabled {
+			c.Next()
+			return
+		}
+
+		if status.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(status.RetryAfter))
+		}
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": status.Message})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"base-gin/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 未匹配任何路由的请求使用的 route 标签，避免原始路径导致标签数量失控
+const unmatchedRoute = "unmatched"
+
+// otherMethod 非标准方法使用的 method 标签，客户端可以发送任意方法名
+const otherMethod = "OTHER"
+
+// standardMethods 按原样作为 method 标签的请求方法
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Metrics 中间件按路由模板、方法和状态码统计请求数和耗时。在 defer 中记录，
+// Recovery 为中断连接重新抛出 http.ErrAbortHandler 时请求同样被统计
+func Metrics(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.NewCounterVec("http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "status")
+	duration := registry.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds.", metrics.DefBuckets, "method", "route", "status")
+	inFlight := registry.NewGauge("http_requests_in_flight",
+		"Number of HTTP requests currently being served.")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		inFlight.Inc()
+		defer func() {
+			inFlight.Dec()
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			method := c.Request.Method
+			if !standardMethods[method] {
+				method = otherMethod
+			}
+			status := strconv.Itoa(c.Writer.Status())
+			requests.With(method, route, status).Inc()
+			duration.With(method, route, status).Observe(time.Since(start).Seconds())
+		}()
+
+		c.Next()
+	}
+}
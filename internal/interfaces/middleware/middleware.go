@@ -1,50 +1,86 @@
 package middleware
 
 import (
+	"base-gin/internal/infrastructure/logging"
 	"fmt"
-	"log"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Logger 中间件记录请求日志
-func Logger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
+// Logger 中间件记录请求日志，按 info 级别输出，5xx 响应按 error 级别输出
+func Logger(logger *logging.Logger) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
+				logClientIP(param),
+				param.TimeStamp.Format(time.RFC1123),
+				param.Method,
+				logPath(param),
+				param.Request.Proto,
+				param.StatusCode,
+				param.Latency,
+				param.Request.UserAgent(),
+				param.ErrorMessage,
+			)
+		},
+		Skip: func(c *gin.Context) bool {
+			if c.Writer.Status() >= http.StatusInternalServerError {
+				return !logger.Enabled(logging.LevelError)
+			}
+			return !logger.Enabled(logging.LevelInfo)
+		},
 	})
 }
 
-// Recovery 中间件处理panic
-func Recovery() gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		if err, ok := recovered.(string); ok {
-			log.Printf("panic recovered: %s", err)
+// sensitiveQueryParams 请求日志和错误上报中隐藏值的查询参数
+var sensitiveQueryParams = []string{"access_token"}
+
+// redactURL 返回隐藏敏感查询参数值的 URL 副本，没有敏感参数时原样返回
+func redactURL(u *url.URL) *url.URL {
+	query := u.Query()
+	redacted := false
+	for _, name := range sensitiveQueryParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
 		}
-		c.JSON(500, gin.H{"error": "Internal server error"})
-	})
+	}
+	if !redacted {
+		return u
+	}
+	copied := *u
+	copied.RawQuery = query.Encode()
+	return &copied
 }
 
-// CORS 中间件处理跨域
-func CORS() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+// logPath 请求路径和查询参数，敏感参数的值已隐藏
+func logPath(param gin.LogFormatterParams) string {
+	if param.Request == nil || param.Request.URL.RawQuery == "" {
+		return param.Path
+	}
+	return param.Request.URL.Path + "?" + redactURL(param.Request.URL).RawQuery
+}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+// logClientIP 优先使用 RealIP 解析的客户端 IP
+func logClientIP(param gin.LogFormatterParams) string {
+	if ip, ok := param.Keys[clientIPKey].(string); ok && ip != "" {
+		return ip
+	}
+	return param.ClientIP
+}
+
+// RequireIfMatch 中间件要求修改类请求携带 If-Match 请求头，缺少时返回 428
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if c.GetHeader("If-Match") == "" {
+				c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"error": "缺少 If-Match 请求头"})
+				return
+			}
 		}
 
 		c.Next()
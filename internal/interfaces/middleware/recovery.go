@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/pkg/errreport"
+	"base-gin/pkg/requestmeta"
+	"base-gin/pkg/tracing"
+	"base-gin/pkg/uuid"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentTypeProblem RFC 9457 问题详情的响应类型
+const ContentTypeProblem = "application/problem+json; charset=utf-8"
+
+// Recovery 中间件处理 panic：记录完整调用栈和请求信息，交给 reporter 上报（为 nil 时不上报），
+// 并返回带错误 ID 的 500 问题详情，用户反馈时提供错误 ID 即可在日志和上报中找到对应记录
+func Recovery(logger *logging.Logger, reporter errreport.ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler 用于在响应已开始后中断连接，交给 net/http 处理
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			// 客户端已断开，无法响应，也不是服务端的错误
+			if isBrokenConnection(recovered) {
+				logger.Warn(fmt.Sprintf("客户端连接已断开: %s %s: %v", c.Request.Method, c.Request.URL.Path, recovered))
+				c.Abort()
+				return
+			}
+
+			event := newPanicEvent(c, recovered)
+			logger.ErrorWith("panic recovered", logging.Fields{
+				"error_id":   event.ID,
+				"error":      event.Message,
+				"type":       event.Type,
+				"method":     event.Request.Method,
+				"path":       c.Request.URL.Path,
+				"route":      event.Request.Route,
+				"request_id": event.Request.RequestID,
+				"user":       event.User,
+				"client_ip":  event.Request.IP,
+				"trace_id":   event.TraceID,
+				"stack":      string(debug.Stack()),
+			})
+			if reporter != nil {
+				reporter.Report(event)
+			}
+
+			span := tracing.SpanFromContext(c.Request.Context())
+			span.SetAttributes(tracing.String("error.id", event.ID))
+			span.RecordError(fmt.Errorf("panic: %s", event.Message))
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			c.Header("Content-Type", ContentTypeProblem)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"type":       "about:blank",
+				"title":      http.StatusText(http.StatusInternalServerError),
+				"status":     http.StatusInternalServerError,
+				"error":      "服务器内部错误，请提供错误 ID 联系管理员",
+				"error_id":   event.ID,
+				"request_id": event.Request.RequestID,
+			})
+		}()
+		c.Next()
+	}
+}
+
+// newPanicEvent 根据 panic 值和请求生成上报事件，需在 recover 所在的 defer 函数中调用
+func newPanicEvent(c *gin.Context, recovered any) *errreport.Event {
+	meta := requestmeta.FromContext(c.Request.Context())
+	event := &errreport.Event{
+		ID:        uuid.NewV4(),
+		Timestamp: time.Now(),
+		Level:     errreport.LevelError,
+		Type:      fmt.Sprintf("%T", recovered),
+		Message:   fmt.Sprint(recovered),
+		Stack:     errreport.PanicStack(),
+		Request: &errreport.Request{
+			Method:    c.Request.Method,
+			URL:       redactURL(c.Request.URL).String(),
+			Route:     c.FullPath(),
+			RequestID: meta.RequestID,
+			IP:        ClientIP(c),
+			UserAgent: c.Request.UserAgent(),
+		},
+		User: meta.Actor,
+	}
+	if sc := tracing.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		event.TraceID = sc.TraceID.String()
+	}
+	return event
+}
+
+// isBrokenConnection 判断 panic 是否由写入已断开的连接引起
+func isBrokenConnection(recovered any) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	var syscallErr *os.SyscallError
+	if !errors.As(err, &opErr) || !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"base-gin/pkg/requestmeta"
+	"base-gin/pkg/uuid"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID 请求 ID 请求头，客户端未提供时由服务端生成并在响应中返回
+const HeaderRequestID = "X-Request-ID"
+
+// maxHeaderValueLength 请求 ID 和操作者的最大长度，超出时截断
+const maxHeaderValueLength = 128
+
+// RequestMeta 中间件将请求 ID、操作者、客户端 IP 和 User-Agent 写入请求的 context。
+// actorHeader 为携带操作者的请求头，应由网关在认证后设置：对端是 rules 中的可信代理时来源为 proxy，
+// 否则为客户端自称的 client；未提供时操作者为 anonymous
+func RequestMeta(actorHeader string, rules *IPRules) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := sanitizeHeaderValue(c.GetHeader(HeaderRequestID))
+		if requestID == "" {
+			requestID = uuid.NewV4()
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		actor, source := sanitizeHeaderValue(c.GetHeader(actorHeader)), ""
+		switch {
+		case actor == "":
+			actor = "anonymous"
+		case rules.FromTrustedProxy(c.Request):
+			source = requestmeta.ActorSourceProxy
+		default:
+			source = requestmeta.ActorSourceClient
+		}
+
+		ctx := requestmeta.NewContext(c.Request.Context(), requestmeta.Metadata{
+			RequestID:   requestID,
+			Actor:       actor,
+			ActorSource: source,
+			IP:          ClientIP(c),
+			UserAgent:   c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// sanitizeHeaderValue 去掉控制字符并限制长度，避免日志和审计记录被注入
+func sanitizeHeaderValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+	if len(value) > maxHeaderValueLength {
+		value = strings.ToValidUTF8(value[:maxHeaderValueLength], "")
+	}
+	return value
+}
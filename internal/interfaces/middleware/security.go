@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"base-gin/internal/interfaces/validation"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSPNoncePlaceholder 内容安全策略中的占位符，每个请求替换为新生成的随机值
+const CSPNoncePlaceholder = "{nonce}"
+
+// cspNonceKey 上下文中保存本次请求 CSP nonce 的键
+const cspNonceKey = "csp_nonce"
+
+// SecurityHeaders 安全响应头，字段为空或为 0 时不设置对应的响应头
+type SecurityHeaders struct {
+	// HSTSMaxAge 浏览器只通过 HTTPS 访问本站的时间，0 表示不发送 Strict-Transport-Security
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	// ContentSecurityPolicy 内容安全策略，其中的 {nonce} 替换为每个请求生成的随机值，
+	// 返回 HTML 的处理函数通过 CSPNonce 取得该值写入 <script nonce="...">
+	ContentSecurityPolicy string
+	// CSPReportOnly 为 true 时使用 Content-Security-Policy-Report-Only，只报告不拦截，便于上线前观察
+	CSPReportOnly     bool
+	ReferrerPolicy    string
+	PermissionsPolicy string
+	// FrameOptions X-Frame-Options 的值，DENY 或 SAMEORIGIN
+	FrameOptions string
+	// NoSniff 为 true 时发送 X-Content-Type-Options: nosniff
+	NoSniff bool
+}
+
+// Secure 中间件为所有响应设置安全响应头
+func Secure(headers SecurityHeaders) gin.HandlerFunc {
+	hsts := ""
+	if seconds := int64(headers.HSTSMaxAge / time.Second); seconds > 0 {
+		hsts = "max-age=" + strconv.FormatInt(seconds, 10)
+		if headers.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if headers.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	cspHeader := "Content-Security-Policy"
+	if headers.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	withNonce := strings.Contains(headers.ContentSecurityPolicy, CSPNoncePlaceholder)
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if headers.ContentSecurityPolicy != "" {
+			policy := headers.ContentSecurityPolicy
+			if withNonce {
+				nonce := newCSPNonce()
+				c.Set(cspNonceKey, nonce)
+				policy = strings.ReplaceAll(policy, CSPNoncePlaceholder, nonce)
+			}
+			header.Set(cspHeader, policy)
+		}
+		if headers.NoSniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if headers.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", headers.ReferrerPolicy)
+		}
+		if headers.PermissionsPolicy != "" {
+			header.Set("Permissions-Policy", headers.PermissionsPolicy)
+		}
+		if headers.FrameOptions != "" {
+			header.Set("X-Frame-Options", headers.FrameOptions)
+		}
+		c.Next()
+	}
+}
+
+// CSPNonce 返回本次请求的 CSP nonce，内容安全策略不含 {nonce} 时为空
+func CSPNonce(c *gin.Context) string {
+	return c.GetString(cspNonceKey)
+}
+
+// newCSPNonce 生成 128 位随机值，每个请求不同，攻击者无法预测
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("生成 CSP nonce 失败: %v", err))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// RequestLimits 请求行和请求头的长度上限，0 表示不限制
+type RequestLimits struct {
+	// MaxURLLength 请求目标（路径和查询参数）的最大字节数，超过返回 414
+	MaxURLLength int
+	// MaxHeaderBytes 所有请求头名称和值的总字节数，超过返回 431
+	MaxHeaderBytes int
+}
+
+// LimitRequest 中间件拒绝请求目标或请求头过长的请求，
+// 避免超长的查询参数和请求头占用解析、日志和下游服务的资源
+func LimitRequest(limits RequestLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limits.MaxURLLength > 0 && len(requestTarget(c.Request)) > limits.MaxURLLength {
+			c.AbortWithStatusJSON(http.StatusRequestURITooLong, gin.H{"error": fmt.Sprintf("请求地址过长，上限 %d 字节", limits.MaxURLLength)})
+			return
+		}
+		if limits.MaxHeaderBytes > 0 && headerSize(c.Request) > limits.MaxHeaderBytes {
+			c.AbortWithStatusJSON(http.StatusRequestHeaderFieldsTooLarge, gin.H{"error": fmt.Sprintf("请求头过大，上限 %d 字节", limits.MaxHeaderBytes)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestTarget 返回请求行中的请求目标，不经过 HTTP 服务器直接构造的请求没有 RequestURI
+func requestTarget(r *http.Request) string {
+	if r.RequestURI != "" {
+		return r.RequestURI
+	}
+	return r.URL.RequestURI()
+}
+
+// headerSize 按 名称: 值\r\n 的格式计算请求头的字节数，Host 不在 Header 中需单独计入
+func headerSize(r *http.Request) int {
+	size := len("Host: \r\n") + len(r.Host)
+	for name, values := range r.Header {
+		for _, value := range values {
+			size += len(name) + len(": \r\n") + len(value)
+		}
+	}
+	return size
+}
+
+// originalBodyKey 上下文中保存未限制大小的原始请求体的键
+const originalBodyKey = "original_body"
+
+// MaxBodySize 中间件限制请求体大小，读取超过 limit 时返回 *http.MaxBytesError，由处理函数返回 413。
+// 可以在全局、路由分组和单个路由上多次使用，后执行的覆盖先执行的，因此单个路由可以放宽全局限制；
+// 也因此不能在中间件中按 Content-Length 提前拒绝，后续路由的限制可能更宽
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		body, ok := c.Get(originalBodyKey)
+		if !ok {
+			body = c.Request.Body
+			c.Set(originalBodyKey, body)
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, body.(io.ReadCloser), limit)
+		c.Next()
+	}
+}
+
+// RequireJSON 中间件用于接收 JSON 请求体的路由：Content-Type 不是 application/json 时返回 415，
+// rejectUnknownFields 为 true 时 validation.BindJSON 拒绝请求体中的未知字段
+func RequireJSON(rejectUnknownFields bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != gin.MIMEJSON {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "请使用 application/json 格式的请求体"})
+			return
+		}
+		if rejectUnknownFields {
+			c.Set(validation.StrictJSONKey, true)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"base-gin/pkg/tracing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing 中间件为每个请求创建 server span，请求携带有效的 traceparent 时加入上游链路。
+// span 名称为 方法 + 路由模板，后续处理通过 c.Request.Context() 创建子 span
+func Tracing(tracer *tracing.Tracer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, err := tracing.ParseTraceparent(c.GetHeader("traceparent")); err == nil {
+			parent.TraceState = c.GetHeader("tracestate")
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+		}
+
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			tracing.WithKind(tracing.KindServer),
+			tracing.WithAttributes(
+				tracing.String("http.request.method", c.Request.Method),
+				tracing.String("url.path", c.Request.URL.Path),
+				tracing.String("http.route", route),
+				tracing.String("client.address", ClientIP(c)),
+				tracing.String("user_agent.original", c.Request.UserAgent()),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Int("http.response.status_code", status))
+		// 4xx 是客户端错误，server span 只将 5xx 标记为失败
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.AddEvent("exception", tracing.String("exception.message", c.Errors.String()))
+		}
+	}
+}
@@ -1,38 +1,206 @@
 package router
 
 import (
+	"base-gin/configs"
+	"base-gin/internal/domain/event"
+	userEntity "base-gin/internal/domain/user/entity"
+	"base-gin/internal/infrastructure/cache"
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/interfaces/handler/audit"
+	"base-gin/internal/interfaces/handler/health"
 	"base-gin/internal/interfaces/handler/user"
+	"base-gin/internal/interfaces/handler/webhook"
 	"base-gin/internal/interfaces/middleware"
+	"base-gin/pkg/errreport"
+	"base-gin/pkg/metrics"
+	"base-gin/pkg/tracing"
+	"context"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(userHandler *user.UserHandler) *gin.Engine {
+func NewRouter(config *configs.Config, userHandler *user.UserHandler, eventStreamHandler *user.EventStreamHandler, webhookHandler *webhook.WebhookHandler, auditHandler *audit.AuditHandler, healthHandler *health.HealthHandler, responseStore cache.Store, registry *metrics.Registry, tracer *tracing.Tracer, reporter errreport.ErrorReporter, logger *logging.Logger, maintenance *middleware.MaintenanceMode, cors *middleware.CORSRules, ipRules *middleware.IPRules, compressor *middleware.Compressor, bus *event.Bus) *gin.Engine {
 	r := gin.New()
+	// 客户端 IP 由 RealIP 按可信代理解析，不使用 gin 对转发请求头的处理（默认信任任意来源）
+	r.ForwardedByClientIP = false
+	r.SetTrustedProxies(nil)
 
 	// 注册中间件
-	r.Use(middleware.Logger())
-	r.Use(middleware.Recovery())
-	r.Use(middleware.CORS())
+	r.Use(middleware.RealIP(ipRules))
+	r.Use(middleware.RequestMeta(config.Audit.ActorHeader, ipRules))
+	r.Use(middleware.Logger(logger))
+	if tracer != nil {
+		r.Use(middleware.Tracing(tracer))
+	}
+	if config.Metrics.Enabled {
+		r.Use(middleware.Metrics(registry))
+	}
+	if compressor != nil {
+		r.Use(middleware.Compress(compressor))
+	}
+	r.Use(middleware.Recovery(logger, reporter))
+	r.Use(middleware.CORS(cors))
+
+	// 安全响应头和请求大小限制，默认值由 SECURITY_PROFILE 决定
+	security := config.Security
+	r.Use(middleware.Secure(middleware.SecurityHeaders{
+		HSTSMaxAge:            time.Duration(security.HSTSMaxAgeSeconds) * time.Second,
+		HSTSIncludeSubdomains: security.HSTSIncludeSubdomains,
+		HSTSPreload:           security.HSTSPreload,
+		ContentSecurityPolicy: security.ContentSecurityPolicy,
+		CSPReportOnly:         security.CSPReportOnly,
+		ReferrerPolicy:        security.ReferrerPolicy,
+		PermissionsPolicy:     security.PermissionsPolicy,
+		FrameOptions:          security.FrameOptions,
+		NoSniff:               true,
+	}))
+	r.Use(middleware.LimitRequest(middleware.RequestLimits{
+		MaxURLLength:   security.MaxURLLength,
+		MaxHeaderBytes: security.MaxHeaderBytes,
+	}))
+	// gzip 请求体先解压，MaxBodySize 和路由上的限制按解压后的大小计算
+	r.Use(middleware.DecompressRequest(int64(config.Compression.MaxDecompressedBytes)))
+	r.Use(middleware.MaxBodySize(int64(security.MaxBodyBytes)))
+	requireJSON := middleware.RequireJSON(security.RejectUnknownFields)
+	// 管理接口需要 ADMIN_TOKEN，未配置令牌时必须配置 IP_FILTER_ADMIN_ALLOW，否则拒绝访问
+	adminAuth := middleware.AdminAuth(config.Admin.Token, ipRules, IPGroupAdmin)
 
-	// 健康检查
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// 健康检查：/livez 存活、/readyz 就绪、/healthz 汇总，/health 为 /healthz 的旧路径
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+	r.GET("/healthz", healthHandler.Healthz)
+	r.GET("/health", healthHandler.Healthz)
+
+	// Prometheus 指标
+	if config.Metrics.Enabled {
+		r.GET(config.Metrics.Path, middleware.IPFilter(ipRules, IPGroupMetrics), gin.WrapH(registry.Handler()))
+	}
 
 	// API 路由组
 	api := r.Group("/api/v1")
+	api.Use(middleware.IPFilter(ipRules, IPGroupAPI))
+	api.Use(middleware.Maintenance(maintenance))
 	{
 		// 用户路由
 		userGroup := api.Group("/users")
+		if config.Server.RequireIfMatch {
+			userGroup.Use(middleware.RequireIfMatch())
+		}
+		userGroup.Use(middleware.InvalidateCache(responseStore, userWriteCacheTags))
+		subscribeCacheInvalidation(bus, responseStore)
+
+		httpCache := config.HTTPCache
+		listCache := middleware.HTTPCache(middleware.CachePolicy{
+			MaxAge: httpCache.ListMaxAge,
+			Vary:   httpCache.Vary,
+			Store:  responseStore,
+			TTL:    time.Duration(httpCache.StoreTTL) * time.Second,
+			Tags:   func(c *gin.Context) []string { return []string{userAllCacheTag, userListCacheTag} },
+		})
+		userCache := middleware.HTTPCache(middleware.CachePolicy{
+			MaxAge: httpCache.UserMaxAge,
+			Vary:   httpCache.Vary,
+			Store:  responseStore,
+			TTL:    time.Duration(httpCache.StoreTTL) * time.Second,
+			Tags:   func(c *gin.Context) []string { return []string{userAllCacheTag, userCacheTag(c.Param("id"))} },
+		})
 		{
-			userGroup.GET("", userHandler.GetAllUsers)
-			userGroup.GET("/:id", userHandler.GetUser)
-			userGroup.POST("", userHandler.CreateUser)
-			userGroup.PUT("/:id", userHandler.UpdateUser)
+			userGroup.GET("", listCache, userHandler.ListUsers)
+			userGroup.GET("/search", listCache, userHandler.SearchUsers)
+			userGroup.GET("/export", userHandler.ExportUsers)
+			userGroup.GET("/events", eventStreamHandler.StreamEvents)
+			userGroup.GET("/:id", userCache, userHandler.GetUser)
+			userGroup.POST("", requireJSON, userHandler.CreateUser)
+			userGroup.POST("/import", middleware.MaxBodySize(int64(security.ImportMaxBodyBytes)), userHandler.ImportUsers)
+			userGroup.PUT("/:id", requireJSON, userHandler.UpdateUser)
+			userGroup.PATCH("/:id", userHandler.PatchUser)
 			userGroup.DELETE("/:id", userHandler.DeleteUser)
 		}
+
+		// 管理路由：已删除用户的查询、恢复和永久删除
+		adminUserGroup := api.Group("/admin/users")
+		adminUserGroup.Use(middleware.IPFilter(ipRules, IPGroupAdmin), adminAuth)
+		adminUserGroup.Use(middleware.InvalidateCache(responseStore, userWriteCacheTags))
+		{
+			adminUserGroup.GET("/deleted", userHandler.ListDeletedUsers)
+			adminUserGroup.POST("/:id/restore", userHandler.RestoreUser)
+			adminUserGroup.DELETE("/:id", userHandler.PurgeUser)
+			adminUserGroup.POST("/purge", userHandler.PurgeDeletedUsers)
+		}
+
+		// Webhook 订阅与投递记录
+		webhookGroup := api.Group("/webhooks")
+		webhookGroup.Use(middleware.IPFilter(ipRules, IPGroupAdmin), adminAuth)
+		{
+			webhookGroup.GET("", webhookHandler.ListSubscriptions)
+			webhookGroup.POST("", requireJSON, webhookHandler.CreateSubscription)
+			webhookGroup.GET("/:id", webhookHandler.GetSubscription)
+			webhookGroup.PATCH("/:id", requireJSON, webhookHandler.UpdateSubscription)
+			webhookGroup.DELETE("/:id", webhookHandler.DeleteSubscription)
+			webhookGroup.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhookGroup.GET("/:id/deliveries/:deliveryId", webhookHandler.GetDelivery)
+			webhookGroup.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+		}
+
+		// 审计记录查询与哈希链校验
+		auditGroup := api.Group("/audit")
+		auditGroup.Use(middleware.IPFilter(ipRules, IPGroupAdmin), adminAuth)
+		{
+			auditGroup.GET("", auditHandler.ListEntries)
+			auditGroup.GET("/verify", auditHandler.Verify)
+		}
 	}
 
 	return r
 }
+
+// IP 过滤的分组：api 为所有业务接口，admin 为管理、审计和 Webhook 接口（同时受 api 限制），metrics 为指标接口
+const (
+	IPGroupAPI     = "api"
+	IPGroupAdmin   = "admin"
+	IPGroupMetrics = "metrics"
+)
+
+// 用户相关响应的缓存标签：userListCacheTag 用于列表和搜索结果，任何用户变化都会使其失效；
+// userAllCacheTag 用于所有用户响应，批量修改时使用
+const (
+	userListCacheTag = "users:list"
+	userAllCacheTag  = "users"
+)
+
+func userCacheTag(id string) string {
+	return "user:" + id
+}
+
+// subscribeCacheInvalidation 用户领域事件发布后清除列表和该用户的缓存。InvalidateCache 只覆盖经过用户路由的修改，
+// 导入中途失败前已提交的批次、后台任务和命令行等入口的修改依靠事件失效
+func subscribeCacheInvalidation(bus *event.Bus, store cache.Store) {
+	if bus == nil || store == nil {
+		return
+	}
+	invalidate := func(id int) error {
+		store.InvalidateTags(userListCacheTag, userCacheTag(strconv.Itoa(id)))
+		return nil
+	}
+	opt := event.WithName("response_cache")
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserCreated) error { return invalidate(e.UserID) }, opt)
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserRenamed) error { return invalidate(e.UserID) }, opt)
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserEmailChanged) error { return invalidate(e.UserID) }, opt)
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserDeleted) error { return invalidate(e.UserID) }, opt)
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserRestored) error { return invalidate(e.UserID) }, opt)
+	event.Subscribe(bus, func(ctx context.Context, e userEntity.UserPurged) error { return invalidate(e.UserID) }, opt)
+}
+
+// userWriteCacheTags 修改用户后需要失效的缓存标签
+func userWriteCacheTags(c *gin.Context) []string {
+	if id := c.Param("id"); id != "" {
+		return []string{userListCacheTag, userCacheTag(id)}
+	}
+	if strings.HasSuffix(c.FullPath(), "/import") {
+		return []string{userAllCacheTag}
+	}
+	return []string{userListCacheTag}
+}
@@ -0,0 +1,70 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// StrictJSONKey 上下文中该键为 true 时 BindJSON 拒绝请求体中的未知字段，由 middleware.RequireJSON 设置
+const StrictJSONKey = "strict_json"
+
+var (
+	// ErrUnknownField 请求体包含请求结构中没有的字段
+	ErrUnknownField = errors.New("请求体包含未知字段")
+	// ErrBodyTooLarge 请求体超过路由允许的大小
+	ErrBodyTooLarge = errors.New("请求体过大")
+)
+
+// BindJSON 与 ShouldBindJSON 相同，将请求体解析到 vo 请求并执行 binding 校验，请求体超过大小限制时返回 ErrBodyTooLarge。
+// 启用严格模式时拒绝未知字段和第一个 JSON 值之后的多余内容，避免字段名拼错或客户端传入不应修改的字段被静默忽略
+func BindJSON(c *gin.Context, obj any) error {
+	if c.Request == nil || c.Request.Body == nil {
+		return errors.New("请求体为空")
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	if binding.EnableDecoderUseNumber {
+		decoder.UseNumber()
+	}
+	strict := c.GetBool(StrictJSONKey) || binding.EnableDecoderDisallowUnknownFields
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("%w: 上限 %d 字节", ErrBodyTooLarge, maxBytesErr.Limit)
+		}
+		// encoding/json 对未知字段只返回 json: unknown field "name" 形式的错误
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+		return err
+	}
+	// 严格模式下请求体只能包含一个 JSON 值
+	if strict {
+		if _, err := decoder.Token(); err != io.EOF {
+			return errors.New("请求体包含多余的内容")
+		}
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// BindError 返回 BindJSON 失败时的状态码和错误信息：请求体过大返回 413，未知字段返回具体字段名，
+// 其他错误沿用统一的提示
+func BindError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge, err.Error()
+	case errors.Is(err, ErrUnknownField):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusBadRequest, "请求参数格式错误"
+	}
+}
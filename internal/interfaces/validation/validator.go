@@ -1,11 +1,10 @@
 package validation
 
 import (
-	"errors"
-	"net/mail"
-	"strings"
+	"base-gin/internal/domain/user/vo"
 )
 
+// Validator 请求参数校验，规则统一委托给领域值对象，避免多处维护
 type Validator struct{}
 
 func NewValidator() *Validator {
@@ -13,40 +12,16 @@ func NewValidator() *Validator {
 }
 
 func (v *Validator) ValidateEmail(email string) error {
-	email = strings.TrimSpace(email)
-	if email == "" {
-		return errors.New("邮箱不能为空")
-	}
-
-	_, err := mail.ParseAddress(email)
-	if err != nil {
-		return errors.New("邮箱格式不正确")
-	}
-
-	return nil
+	_, err := vo.NewEmail(email)
+	return err
 }
 
 func (v *Validator) ValidateName(name string) error {
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return errors.New("姓名不能为空")
-	}
-
-	if len(name) < 2 || len(name) > 50 {
-		return errors.New("姓名长度必须在2-50个字符之间")
-	}
-
-	return nil
+	_, err := vo.NewUserName(name)
+	return err
 }
 
 func (v *Validator) ValidatePassword(password string) error {
-	if password == "" {
-		return errors.New("密码不能为空")
-	}
-
-	if len(password) < 6 {
-		return errors.New("密码长度不能少于6位")
-	}
-
-	return nil
+	_, err := vo.NewPassword(password)
+	return err
 }
@@ -0,0 +1,213 @@
+// Package clientip 在可信代理之后解析真实的客户端 IP，并按 CIDR 允许、拒绝列表过滤地址。
+// 只有直接连接的对端属于可信代理时才读取转发请求头，否则客户端可以伪造自己的地址
+package clientip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// 支持的转发请求头
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-Ip"
+	HeaderForwarded     = "Forwarded"
+)
+
+// ErrUnsupportedHeader 不支持的转发请求头
+var ErrUnsupportedHeader = errors.New("不支持的转发请求头，可选 X-Forwarded-For、X-Real-IP、Forwarded")
+
+// ParsePrefixes 解析 CIDR 列表，单个 IP 视为只包含该地址的网段
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("无效的网段 %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 IP %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolver 根据可信代理和转发请求头解析客户端 IP，创建后只读，可并发使用
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// NewResolver trustedProxies 为可信代理的 CIDR，headers 为按顺序尝试的转发请求头，
+// 第一个能解析出地址的请求头生效
+func NewResolver(trustedProxies []string, headers []string) (*Resolver, error) {
+	trusted, err := ParsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	r := &Resolver{trusted: trusted}
+	for _, header := range headers {
+		header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+		switch header {
+		case HeaderXForwardedFor, HeaderXRealIP, HeaderForwarded:
+			r.headers = append(r.headers, header)
+		case "":
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedHeader, header)
+		}
+	}
+	return r, nil
+}
+
+// ClientIP 返回请求的客户端 IP。对端不是可信代理时直接返回对端地址；
+// 否则从右向左跳过可信代理，返回第一个不可信的地址，全部可信时返回最左侧的地址。
+// 转发请求头缺失或包含无效地址时尝试下一个请求头，都不可用时返回对端地址
+func (r *Resolver) ClientIP(req *http.Request) netip.Addr {
+	peer := parseAddr(req.RemoteAddr)
+	if !peer.IsValid() || !containsAddr(r.trusted, peer) {
+		return peer
+	}
+	for _, header := range r.headers {
+		var hops []string
+		switch header {
+		case HeaderXForwardedFor:
+			hops = splitValues(req.Header.Values(header))
+		case HeaderXRealIP:
+			hops = []string{req.Header.Get(header)}
+		case HeaderForwarded:
+			hops = forwardedFor(req.Header.Values(header))
+		}
+		if addr, ok := r.walk(hops); ok {
+			return addr
+		}
+	}
+	return peer
+}
+
+// FromTrustedProxy 判断请求的对端是否为可信代理，此时代理设置的请求头可信
+func (r *Resolver) FromTrustedProxy(req *http.Request) bool {
+	peer := parseAddr(req.RemoteAddr)
+	return peer.IsValid() && containsAddr(r.trusted, peer)
+}
+
+// walk hops 按经过的顺序排列，最后一项为最近的代理
+func (r *Resolver) walk(hops []string) (netip.Addr, bool) {
+	if len(hops) == 0 {
+		return netip.Addr{}, false
+	}
+	var addr netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr = parseAddr(hops[i])
+		if !addr.IsValid() {
+			return netip.Addr{}, false
+		}
+		if !containsAddr(r.trusted, addr) {
+			return addr, true
+		}
+	}
+	return addr, true
+}
+
+// parseAddr 解析 IP、IP:端口或 [IPv6]:端口，IPv4 映射的 IPv6 地址转换为 IPv4
+func parseAddr(value string) netip.Addr {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(strings.Trim(value, "[]")); err == nil {
+		return addr.Unmap()
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		if addr, err := netip.ParseAddr(host); err == nil {
+			return addr.Unmap()
+		}
+	}
+	return netip.Addr{}
+}
+
+// splitValues 合并多个请求头并按逗号拆分
+func splitValues(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	return items
+}
+
+// forwardedFor 返回 RFC 7239 Forwarded 请求头中每一跳的 for 参数，缺少 for 的一跳为空字符串
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, element := range splitValues(values) {
+		hop := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				hop = strings.Trim(value, `"`)
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// Filter CIDR 允许、拒绝列表，拒绝列表优先；允许列表为空时允许所有未被拒绝的地址
+type Filter struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// NewFilter 解析允许和拒绝的 CIDR 列表
+func NewFilter(allow, deny []string) (*Filter, error) {
+	allowPrefixes, err := ParsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyPrefixes, err := ParsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{allow: allowPrefixes, deny: denyPrefixes}, nil
+}
+
+// Restricted 是否配置了允许列表，即只允许列表中的地址访问
+func (f *Filter) Restricted() bool {
+	return len(f.allow) > 0
+}
+
+// Allowed 判断地址是否允许访问，无效的地址只在两个列表都为空时允许
+func (f *Filter) Allowed(addr netip.Addr) bool {
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return true
+	}
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	if containsAddr(f.deny, addr) {
+		return false
+	}
+	return len(f.allow) == 0 || containsAddr(f.allow, addr)
+}
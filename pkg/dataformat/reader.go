@@ -0,0 +1,159 @@
+package dataformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 支持的数据格式
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// maxLineSize NDJSON 单行最大长度
+const maxLineSize = 1 << 20
+
+// ErrUnsupportedFormat 不支持的数据格式
+var ErrUnsupportedFormat = errors.New("不支持的数据格式")
+
+// Record 读取到的一行数据，字段名统一为小写
+type Record struct {
+	// Line 在输入中的行号，从 1 开始
+	Line   int
+	Fields map[string]string
+	// Err 本行格式错误，不影响读取后续行
+	Err error
+}
+
+// Reader 逐行读取记录，读完时返回 io.EOF，其他错误表示无法继续读取
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// FormatFromContentType 根据 Content-Type 推断格式，无法识别时返回空字符串
+func FormatFromContentType(contentType string) string {
+	switch contentType {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return FormatNDJSON
+	case "application/json":
+		return FormatJSON
+	}
+	return ""
+}
+
+// NewReader 创建指定格式的读取器，导入只支持 CSV 和 NDJSON
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(r), nil
+	case FormatNDJSON:
+		return NewNDJSONReader(r), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+// NewCSVReader 创建 CSV 读取器，第一行为表头
+func NewCSVReader(r io.Reader) Reader {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	return &csvReader{r: cr}
+}
+
+func (r *csvReader) Read() (*Record, error) {
+	if r.header == nil {
+		header, err := r.r.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+		}
+		for i, name := range header {
+			// 去掉 Excel 导出时可能带有的 BOM
+			if i == 0 {
+				name = strings.TrimPrefix(name, "\ufeff")
+			}
+			header[i] = strings.ToLower(strings.TrimSpace(name))
+		}
+		r.header = header
+	}
+
+	values, err := r.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	line, _ := r.r.FieldPos(0)
+	var parseErr *csv.ParseError
+	if err != nil {
+		if errors.As(err, &parseErr) {
+			line = parseErr.StartLine
+			return &Record{Line: line, Err: fmt.Errorf("CSV 格式错误: %w", parseErr.Err)}, nil
+		}
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(r.header))
+	for i, name := range r.header {
+		fields[name] = strings.TrimSpace(values[i])
+	}
+	return &Record{Line: line, Fields: fields}, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONReader 创建 NDJSON 读取器，每行一个 JSON 对象，空行忽略
+func NewNDJSONReader(r io.Reader) Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &ndjsonReader{scanner: scanner}
+}
+
+func (r *ndjsonReader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			return &Record{Line: r.line, Err: errors.New("JSON 格式错误，每行必须是一个对象")}, nil
+		}
+
+		fields := make(map[string]string, len(obj))
+		for name, value := range obj {
+			switch v := value.(type) {
+			case string:
+				fields[strings.ToLower(name)] = strings.TrimSpace(v)
+			case nil:
+			default:
+				return &Record{Line: r.line, Err: fmt.Errorf("字段 %s 必须是字符串", name)}, nil
+			}
+		}
+		return &Record{Line: r.line, Fields: fields}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 NDJSON 失败: %w", err)
+	}
+	return nil, io.EOF
+}
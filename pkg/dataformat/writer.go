@@ -0,0 +1,147 @@
+package dataformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer 逐行写出记录，values 与创建时的列一一对应。Close 写出结尾并刷新缓冲，不关闭底层 io.Writer
+type Writer interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+// NewWriter 创建指定格式的写入器
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatNDJSON:
+		return &jsonWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case FormatJSON:
+		return &jsonWriter{w: bufio.NewWriter(w), columns: columns, array: true}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// ContentType 返回格式对应的 Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "application/json; charset=utf-8"
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	started bool
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	return w.w.Write(w.columns)
+}
+
+func (w *csvWriter) Write(values []interface{}) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	for i, value := range values {
+		w.record[i] = formatValue(value)
+	}
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// formatValue 将值格式化为 CSV 单元格，时间使用 RFC3339，nil 为空
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonWriter 写出 NDJSON 或 JSON 数组，对象的字段顺序与列顺序一致
+type jsonWriter struct {
+	w       *bufio.Writer
+	columns []string
+	array   bool
+	count   int
+}
+
+func (w *jsonWriter) Write(values []interface{}) error {
+	switch {
+	case w.array && w.count == 0:
+		w.w.WriteString("[\n")
+	case w.array:
+		w.w.WriteString(",\n")
+	}
+	w.count++
+
+	w.w.WriteByte('{')
+	for i, column := range w.columns {
+		if i > 0 {
+			w.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		w.w.Write(key)
+		w.w.WriteByte(':')
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		w.w.Write(value)
+	}
+	// bufio.Writer 的写入错误会保留，前面的错误在这里一并返回
+	if err := w.w.WriteByte('}'); err != nil {
+		return err
+	}
+	if !w.array {
+		return w.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (w *jsonWriter) Close() error {
+	if w.array {
+		if w.count == 0 {
+			w.w.WriteString("[]\n")
+		} else {
+			w.w.WriteString("\n]\n")
+		}
+	}
+	return w.w.Flush()
+}
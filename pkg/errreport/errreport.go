@@ -0,0 +1,154 @@
+// Package errreport 定义错误上报接口和上报内容，并提供 Sentry 协议的实现。
+// 上报在后台异步进行，Report 不阻塞调用方
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// 事件级别
+const (
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// ErrorReporter 错误上报接口。Report 不阻塞，无法及时发送时丢弃；Shutdown 发送队列中剩余的事件
+type ErrorReporter interface {
+	Report(event *Event)
+	Shutdown(ctx context.Context) error
+}
+
+// Frame 调用栈中的一帧
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	File     string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Line     int    `json:"lineno"`
+	// InApp 是否为本项目的代码，非第三方库和标准库
+	InApp bool `json:"in_app"`
+}
+
+// Request 发生错误的请求
+type Request struct {
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Route     string `json:"route,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// Event 一次错误。ID 为返回给用户的错误 ID，Stack 按调用顺序排列，最后一帧为出错的位置
+type Event struct {
+	ID        string
+	Timestamp time.Time
+	Level     string
+	// Type 错误类型，如 panic 值的 Go 类型
+	Type    string
+	Message string
+	Stack   []Frame
+	Request *Request
+	User    string
+	TraceID string
+	Tags    map[string]string
+}
+
+// Fingerprint 相同类型、消息和出错位置的事件视为同一个错误
+func (e *Event) Fingerprint() string {
+	location := ""
+	if n := len(e.Stack); n > 0 {
+		location = fmt.Sprintf("%s:%d", e.Stack[n-1].Function, e.Stack[n-1].Line)
+	}
+	return e.Type + "|" + e.Message + "|" + location
+}
+
+// PanicStack 在 recover 所在的 defer 函数中调用，返回引发 panic 的调用栈。
+// 去掉 runtime.gopanic 及之后的帧（recover 处理函数自身），以及越界、空指针等
+// 由 runtime 抛出的 panic 在出错位置之上的 runtime.panicIndex、runtime.sigpanic 等帧
+func PanicStack() []Frame {
+	frames := Stack(1)
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].Function == "runtime.gopanic" {
+			frames = frames[:i]
+			break
+		}
+	}
+	for len(frames) > 1 && frames[len(frames)-1].Module == "runtime" {
+		frames = frames[:len(frames)-1]
+	}
+	return frames
+}
+
+// maxFrames 记录的最大栈深度
+const maxFrames = 64
+
+// Stack 返回当前调用栈，skip 为跳过的调用方层数。按调用顺序排列，最后一帧为调用 Stack 的位置
+func Stack(skip int) []Frame {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(skip+2, pcs)
+	callers := runtime.CallersFrames(pcs[:n])
+
+	var frames []Frame
+	for {
+		f, more := callers.Next()
+		module, function := splitFunction(f.Function)
+		frames = append(frames, Frame{
+			Function: function,
+			Module:   module,
+			File:     shortFile(f.File),
+			AbsPath:  f.File,
+			Line:     f.Line,
+			InApp:    isInApp(module),
+		})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// splitFunction 将 base-gin/internal/pkg.(*T).Method 拆分为包路径和函数名。
+// runtime 的函数保留完整名称，便于识别 runtime.gopanic
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	module = name[:slash+1+dot]
+	if module == "runtime" {
+		return module, name
+	}
+	return module, name[slash+1+dot+1:]
+}
+
+// shortFile 保留文件路径的最后两级
+func shortFile(path string) string {
+	if i := strings.LastIndex(path, "/"); i > 0 {
+		if j := strings.LastIndex(path[:i], "/"); j >= 0 {
+			return path[j+1:]
+		}
+	}
+	return path
+}
+
+// mainModule 主模块路径，用于判断是否为本项目的代码
+var mainModule = func() string {
+	if build, ok := debug.ReadBuildInfo(); ok {
+		return build.Main.Path
+	}
+	return ""
+}()
+
+func isInApp(module string) bool {
+	return mainModule != "" && (module == mainModule || strings.HasPrefix(module, mainModule+"/"))
+}
@@ -0,0 +1,361 @@
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sentryClient 上报时的客户端标识
+const sentryClient = "base-gin-errreport/1.0"
+
+// ErrInvalidDSN DSN 格式不是 scheme://公钥@主机/项目ID
+var ErrInvalidDSN = errors.New("无效的 Sentry DSN，格式应为 https://<公钥>@<主机>/<项目ID>")
+
+// SentryOptions Sentry 上报配置
+type SentryOptions struct {
+	DSN         string
+	Environment string
+	Release     string
+	ServerName  string
+	// RateLimit 每分钟最多上报的事件数，也是允许的突发数，超过后丢弃
+	RateLimit int
+	// BatchSize 每批最多发送的事件数，FlushInterval 未满一批时的发送间隔。
+	// 同一批次中相同的错误只发送一次，并记录出现次数
+	BatchSize     int
+	FlushInterval time.Duration
+	// QueueSize 等待发送的事件数上限，超过后丢弃
+	QueueSize int
+	Timeout   time.Duration
+}
+
+// SentryReporter 以 Sentry envelope 协议发送事件，兼容 Sentry、GlitchTip 等服务端。
+// 服务端返回 429 时按 Retry-After 暂停发送，暂停期间的事件被丢弃
+type SentryReporter struct {
+	dsn      string
+	endpoint string
+	auth     string
+	opts     SentryOptions
+	client   *http.Client
+	limiter  *rateLimiter
+
+	queue    chan *Event
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+	// pausedUntil 服务端要求暂停发送的截止时间（Unix 纳秒）
+	pausedUntil atomic.Int64
+}
+
+// NewSentryReporter 解析 DSN 并启动后台发送，使用完毕后调用 Shutdown
+func NewSentryReporter(opts SentryOptions) (*SentryReporter, error) {
+	endpoint, key, err := parseDSN(opts.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = 60
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 20
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.QueueSize < opts.BatchSize {
+		opts.QueueSize = opts.BatchSize * 5
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	r := &SentryReporter{
+		dsn:      opts.DSN,
+		endpoint: endpoint,
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, key),
+		opts:     opts,
+		client:   &http.Client{Timeout: opts.Timeout},
+		limiter:  newRateLimiter(opts.RateLimit, time.Minute),
+		queue:    make(chan *Event, opts.QueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r, nil
+}
+
+// parseDSN 返回 envelope 接口地址和公钥
+func parseDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", ErrInvalidDSN
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return "", "", ErrInvalidDSN
+	}
+	endpoint = fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:slash], projectID)
+	return endpoint, u.User.Username(), nil
+}
+
+// Report 将事件加入发送队列，超过速率限制或队列已满时丢弃
+func (r *SentryReporter) Report(event *Event) {
+	select {
+	case <-r.stop:
+		return
+	default:
+	}
+	if !r.limiter.Allow() {
+		r.dropped.Add(1)
+		return
+	}
+	select {
+	case r.queue <- event:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Dropped 因速率限制、队列已满或服务端限流而丢弃的事件数
+func (r *SentryReporter) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+func (r *SentryReporter) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Event, 0, r.opts.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			r.sendBatch(batch)
+			batch = make([]*Event, 0, r.opts.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case event := <-r.queue:
+			batch = append(batch, event)
+			if len(batch) >= r.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-r.stop:
+			for {
+				select {
+				case event := <-r.queue:
+					batch = append(batch, event)
+					if len(batch) >= r.opts.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// sendBatch 合并批次中相同的错误后逐个发送，envelope 协议每个请求只能包含一个事件
+func (r *SentryReporter) sendBatch(batch []*Event) {
+	counts := make(map[string]int, len(batch))
+	unique := make([]*Event, 0, len(batch))
+	for _, event := range batch {
+		fingerprint := event.Fingerprint()
+		if counts[fingerprint] == 0 {
+			unique = append(unique, event)
+		}
+		counts[fingerprint]++
+	}
+
+	for _, event := range unique {
+		if time.Now().UnixNano() < r.pausedUntil.Load() {
+			r.dropped.Add(uint64(counts[event.Fingerprint()]))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.opts.Timeout)
+		if err := r.send(ctx, event, counts[event.Fingerprint()]); err != nil {
+			log.Printf("上报错误 %s 失败: %v", event.ID, err)
+		}
+		cancel()
+	}
+}
+
+func (r *SentryReporter) send(ctx context.Context, event *Event, occurrences int) error {
+	body, err := r.envelope(event, occurrences)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		r.pause(resp.Header.Get("Retry-After"))
+		return fmt.Errorf("服务端限流，暂停上报至 %s", time.Unix(0, r.pausedUntil.Load()).Format(time.RFC3339))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("服务端返回 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pause 按 Retry-After（秒）暂停发送，未提供或无法解析时暂停 60 秒
+func (r *SentryReporter) pause(retryAfter string) {
+	seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter))
+	if err != nil || seconds <= 0 {
+		seconds = 60
+	}
+	r.pausedUntil.Store(time.Now().Add(time.Duration(seconds) * time.Second).UnixNano())
+}
+
+// Shutdown 发送队列中剩余的事件，之后的 Report 被忽略
+func (r *SentryReporter) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+// envelope 生成只包含一个事件的 envelope：头部、条目头部和事件各占一行
+func (r *SentryReporter) envelope(event *Event, occurrences int) ([]byte, error) {
+	eventID := strings.ReplaceAll(event.ID, "-", "")
+	payload, err := json.Marshal(r.sentryEvent(eventID, event, occurrences))
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": eventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      r.dsn,
+	})
+	itemHeader, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(itemHeader)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// sentryEvent Sentry 事件格式，见 https://develop.sentry.dev/sdk/event-payloads/
+func (r *SentryReporter) sentryEvent(eventID string, event *Event, occurrences int) map[string]any {
+	tags := map[string]string{"error_id": event.ID}
+	for key, value := range event.Tags {
+		tags[key] = value
+	}
+	if event.TraceID != "" {
+		tags["trace_id"] = event.TraceID
+	}
+
+	payload := map[string]any{
+		"event_id":    eventID,
+		"timestamp":   event.Timestamp.UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       event.Level,
+		"logger":      "errreport",
+		"server_name": r.opts.ServerName,
+		"release":     r.opts.Release,
+		"environment": r.opts.Environment,
+		"exception": map[string]any{
+			"values": []map[string]any{{
+				"type":       event.Type,
+				"value":      event.Message,
+				"stacktrace": map[string]any{"frames": event.Stack},
+			}},
+		},
+		"tags":  tags,
+		"extra": map[string]any{"occurrences": occurrences},
+	}
+	if req := event.Request; req != nil {
+		if req.RequestID != "" {
+			tags["request_id"] = req.RequestID
+		}
+		if req.Route != "" {
+			tags["route"] = req.Route
+		}
+		payload["request"] = map[string]any{
+			"method":  req.Method,
+			"url":     req.URL,
+			"headers": map[string]string{"User-Agent": req.UserAgent},
+			"env":     map[string]string{"REMOTE_ADDR": req.IP},
+		}
+	}
+	if event.User != "" || event.Request != nil {
+		user := map[string]string{"id": event.User}
+		if event.Request != nil {
+			user["ip_address"] = event.Request.IP
+		}
+		payload["user"] = user
+	}
+	return payload
+}
+
+// rateLimiter 令牌桶，容量为 limit，每 per 时间补满
+type rateLimiter struct {
+	mu       sync.Mutex
+	tokens   float64
+	limit    float64
+	rate     float64
+	lastFill time.Time
+}
+
+func newRateLimiter(limit int, per time.Duration) *rateLimiter {
+	return &rateLimiter{
+		tokens:   float64(limit),
+		limit:    float64(limit),
+		rate:     float64(limit) / per.Seconds(),
+		lastFill: time.Now(),
+	}
+}
+
+// Allow 有剩余令牌时消耗一个并返回 true
+func (l *rateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.limit, l.tokens+now.Sub(l.lastFill).Seconds()*l.rate)
+	l.lastFill = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,20 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskSpace 检查 path 所在磁盘的可用空间不少于 minFree 字节
+func DiskSpace(path string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("磁盘可用空间 %d MB，低于 %d MB", free>>20, minFree>>20)
+		}
+		return nil
+	}
+}
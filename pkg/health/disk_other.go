@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package health
+
+import "errors"
+
+// freeBytes 当前平台不支持读取磁盘空间
+func freeBytes(path string) (uint64, error) {
+	return 0, errors.New("当前平台不支持检查磁盘空间")
+}
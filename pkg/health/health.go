@@ -0,0 +1,239 @@
+// Package health 实现存活、就绪和依赖健康检查：组件注册检查函数，
+// 检查结果按 TTL 缓存，并发请求共享同一次执行，避免探针频繁访问依赖
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查状态
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// StatusDegraded 只有可选检查失败，服务仍可用
+	StatusDegraded = "degraded"
+)
+
+// ErrShuttingDown 服务正在关闭，就绪检查失败以便负载均衡摘除实例
+var ErrShuttingDown = errors.New("服务正在关闭")
+
+// CheckFunc 检查函数，返回 nil 表示正常
+type CheckFunc func(ctx context.Context) error
+
+// Option 注册检查的选项
+type Option func(*check)
+
+// WithTimeout 单次检查的超时时间，默认使用 Checker 的配置
+func WithTimeout(d time.Duration) Option {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCacheTTL 检查结果的缓存时间，默认使用 Checker 的配置，0 表示不缓存
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *check) { c.cacheTTL = d }
+}
+
+// Liveness 同时作为存活检查，失败时进程应被重启（如后台任务卡死），依赖故障不应使用
+func Liveness() Option {
+	return func(c *check) { c.liveness = true }
+}
+
+// Optional 失败时整体状态为 degraded，不影响就绪
+func Optional() Option {
+	return func(c *check) { c.optional = true }
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+	liveness bool
+	optional bool
+
+	mu     sync.Mutex
+	result *Result
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs float64       `json:"latency_ms"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Cached    bool          `json:"cached"`
+	Optional  bool          `json:"optional,omitempty"`
+}
+
+// Report 一组检查的汇总
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks,omitempty"`
+}
+
+// OK 整体是否可用，degraded 视为可用
+func (r *Report) OK() bool {
+	return r.Status != StatusFail
+}
+
+// Checker 健康检查注册表
+type Checker struct {
+	timeout          time.Duration
+	cacheTTL         time.Duration
+	heartbeatTimeout time.Duration
+
+	mu           sync.RWMutex
+	checks       map[string]*check
+	shuttingDown atomic.Bool
+}
+
+// NewChecker timeout、cacheTTL 为检查的默认超时和缓存时间，heartbeatTimeout 为后台任务心跳的最短超时
+func NewChecker(timeout, cacheTTL, heartbeatTimeout time.Duration) *Checker {
+	return &Checker{
+		timeout:          timeout,
+		cacheTTL:         cacheTTL,
+		heartbeatTimeout: heartbeatTimeout,
+		checks:           make(map[string]*check),
+	}
+}
+
+// Register 注册检查，同名检查会被替换
+func (c *Checker) Register(name string, fn CheckFunc, opts ...Option) {
+	ch := &check{name: name, fn: fn, timeout: c.timeout, cacheTTL: c.cacheTTL}
+	for _, opt := range opts {
+		opt(ch)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = ch
+}
+
+// Unregister 移除检查
+func (c *Checker) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.checks, name)
+}
+
+// SetShuttingDown 标记服务正在关闭，之后就绪检查始终失败
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Live 执行存活检查
+func (c *Checker) Live(ctx context.Context) *Report {
+	return c.run(ctx, func(ch *check) bool { return ch.liveness })
+}
+
+// Ready 执行所有检查，服务关闭期间始终失败
+func (c *Checker) Ready(ctx context.Context) *Report {
+	report := c.run(ctx, func(*check) bool { return true })
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Checks = append([]*Result{{Name: "shutdown", Status: StatusFail, Error: ErrShuttingDown.Error(), CheckedAt: time.Now()}}, report.Checks...)
+	}
+	return report
+}
+
+// Health 执行所有检查，与 Ready 不同，不受关闭状态影响
+func (c *Checker) Health(ctx context.Context) *Report {
+	return c.run(ctx, func(*check) bool { return true })
+}
+
+func (c *Checker) run(ctx context.Context, include func(*check) bool) *Report {
+	c.mu.RLock()
+	var checks []*check
+	for _, ch := range c.checks {
+		if include(ch) {
+			checks = append(checks, ch)
+		}
+	}
+	c.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	results := make([]*Result, len(checks))
+	var wg sync.WaitGroup
+	for i, ch := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ch.execute(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if !result.Optional {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// execute 缓存有效时返回缓存结果，否则执行检查。同一检查同时只执行一次，并发请求等待并共享结果
+func (ch *check) execute(ctx context.Context) *Result {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.result != nil && ch.cacheTTL > 0 && time.Since(ch.result.CheckedAt) < ch.cacheTTL {
+		cached := *ch.result
+		cached.Cached = true
+		return &cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, ch.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("检查 panic: %v", r)
+			}
+		}()
+		done <- ch.fn(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("检查超时（%s）", ch.timeout)
+	}
+
+	latency := time.Since(start)
+	result := &Result{
+		Name:      ch.name,
+		Status:    StatusOK,
+		Latency:   latency,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		CheckedAt: time.Now(),
+		Optional:  ch.optional,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	// 调用方取消导致的失败不缓存，避免影响其他请求
+	if ctx.Err() == nil {
+		ch.result = result
+	}
+	copied := *result
+	return &copied
+}
@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat 后台任务的心跳，超过 maxAge 未更新时存活检查失败。方法对 nil 安全
+type Heartbeat struct {
+	checker *Checker
+	name    string
+	maxAge  time.Duration
+	last    atomic.Int64
+}
+
+// Heartbeat 为按 interval 循环执行的后台任务注册存活检查并记录一次心跳。
+// 超时时间为 3 倍 interval 与 heartbeatTimeout 中的较大值，任务单次执行较慢时不会误报
+func (c *Checker) Heartbeat(name string, interval time.Duration) *Heartbeat {
+	if c == nil {
+		return nil
+	}
+	maxAge := max(3*interval, c.heartbeatTimeout)
+	hb := &Heartbeat{checker: c, name: name, maxAge: maxAge}
+	hb.Beat()
+	c.Register(name, hb.check, Liveness(), WithCacheTTL(0))
+	return hb
+}
+
+// Beat 记录一次心跳
+func (h *Heartbeat) Beat() {
+	if h == nil {
+		return
+	}
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Stop 任务正常退出时移除检查
+func (h *Heartbeat) Stop() {
+	if h == nil {
+		return
+	}
+	h.checker.Unregister(h.name)
+}
+
+func (h *Heartbeat) check(ctx context.Context) error {
+	age := time.Since(time.Unix(0, h.last.Load()))
+	if age > h.maxAge {
+		return fmt.Errorf("已 %s 没有心跳，超过 %s", age.Round(time.Second), h.maxAge)
+	}
+	return nil
+}
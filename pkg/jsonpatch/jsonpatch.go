@@ -0,0 +1,365 @@
+// Package jsonpatch 实现 JSON Merge Patch（RFC 7396）和 JSON Patch（RFC 6902）。
+// 文档使用 encoding/json 解码得到的通用结构表示：map[string]interface{}、[]interface{}、
+// string、float64、bool 和 nil。
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch 补丁格式错误或无法应用到文档
+	ErrInvalidPatch = errors.New("无效的补丁")
+	// ErrTestFailed JSON Patch 的 test 操作未通过
+	ErrTestFailed = errors.New("补丁 test 操作未通过")
+)
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPatch, fmt.Sprintf(format, args...))
+}
+
+// MergePatch 按 RFC 7396 将补丁合并到文档，返回新文档，原文档不会被修改
+func MergePatch(doc interface{}, patch []byte) (interface{}, error) {
+	var p interface{}
+	if err := unmarshal(patch, &p); err != nil {
+		return nil, invalid("%v", err)
+	}
+	return mergeValue(deepCopy(doc), p), nil
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// Operation JSON Patch 操作。"value": null 是合法的值（replace 为 null、test 是否为 null），
+// 与缺少 value 成员通过 HasValue 区分
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+	// hasValue 补丁中是否出现 value 成员
+	hasValue bool
+}
+
+// UnmarshalJSON 解码操作并记录是否出现 value 成员
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	type plain Operation
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*plain)(op)); err != nil {
+		return err
+	}
+	_, op.hasValue = members["value"]
+	return nil
+}
+
+// HasValue 补丁中是否出现 value 成员，值为 null 时也返回 true
+func (op *Operation) HasValue() bool {
+	return op.hasValue
+}
+
+// Apply 按 RFC 6902 依次执行补丁中的操作，任一操作失败时整体失败，原文档不会被修改
+func Apply(doc interface{}, patch []byte) (interface{}, error) {
+	var ops []Operation
+	if err := unmarshal(patch, &ops); err != nil {
+		return nil, invalid("%v", err)
+	}
+
+	doc = deepCopy(doc)
+	for i, op := range ops {
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个操作 %s %s: %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := func() (interface{}, error) {
+		if !op.HasValue() {
+			return nil, invalid("缺少 value")
+		}
+		var v interface{}
+		if err := unmarshal(op.Value, &v); err != nil {
+			return nil, invalid("%v", err)
+		}
+		return v, nil
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, invalid("不能移动到自身的子路径")
+		}
+		doc, v, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(v))
+	case "test":
+		expected, err := value()
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return nil, invalid("不支持的操作 %q", op.Op)
+}
+
+// parsePointer 解析 JSON Pointer（RFC 6901）
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, invalid("路径必须以 / 开头: %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[token]
+			if !ok {
+				return nil, invalid("路径不存在: %s", token)
+			}
+			current = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, invalid("路径不存在: %s", token)
+		}
+	}
+	return current, nil
+}
+
+// add 在 path 处添加值，返回新的根文档
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(node)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+		return replaceAt(doc, path[:len(path)-1], node)
+	}
+	return nil, invalid("无法在非对象或数组上添加: %s", last)
+}
+
+// remove 删除 path 处的值，返回新的根文档和被删除的值
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, invalid("路径不存在: %s", last)
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = replaceAt(doc, path[:len(path)-1], node)
+		return doc, v, err
+	}
+	return nil, nil, invalid("路径不存在: %s", last)
+}
+
+// replaceAt 用新的切片替换 path 处的数组（切片扩容后需要写回父节点）
+func replaceAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		i, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	}
+	return doc, nil
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, invalid("无效的数组下标: %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, invalid("数组下标越界: %q", token)
+	}
+	return i, nil
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("补丁后存在多余内容")
+	}
+	return nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(node))
+		for k, val := range node {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(node))
+		for i, val := range node {
+			s[i] = deepCopy(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,288 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认的直方图桶（秒），适用于请求和查询耗时
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// atomicFloat 以原子操作读写的浮点数
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) { f.bits.Store(math.Float64bits(v)) }
+func (f *atomicFloat) load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+// Counter 只增不减的计数器
+type Counter struct {
+	value atomicFloat
+}
+
+func (c *Counter) Inc() { c.value.add(1) }
+
+// Add 增加计数，v 为负数时 panic
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("计数器不能减少")
+	}
+	c.value.add(v)
+}
+
+func (c *Counter) Value() float64 { return c.value.load() }
+
+// Gauge 可增可减的仪表盘
+type Gauge struct {
+	value atomicFloat
+}
+
+func (g *Gauge) Set(v float64)  { g.value.set(v) }
+func (g *Gauge) Add(v float64)  { g.value.add(v) }
+func (g *Gauge) Inc()           { g.value.add(1) }
+func (g *Gauge) Dec()           { g.value.add(-1) }
+func (g *Gauge) Value() float64 { return g.value.load() }
+
+// Histogram 按桶统计观测值的分布
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{upperBounds: buckets, counts: make([]atomic.Uint64, len(buckets))}
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	if i := sort.SearchFloat64s(h.upperBounds, v); i < len(h.upperBounds) {
+		h.counts[i].Add(1)
+	}
+	h.sum.add(v)
+	h.count.Add(1)
+}
+
+// Count 观测次数
+func (h *Histogram) Count() uint64 { return h.count.Load() }
+
+// Sum 观测值之和
+func (h *Histogram) Sum() float64 { return h.sum.load() }
+
+func (h *Histogram) samples(name string, labels []Label) []Sample {
+	samples := make([]Sample, 0, len(h.upperBounds)+3)
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += h.counts[i].Load()
+		samples = append(samples, Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", formatFloat(bound)), Value: float64(cumulative)})
+	}
+	count := h.count.Load()
+	samples = append(samples,
+		Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", "+Inf"), Value: float64(count)},
+		Sample{Name: name + "_sum", Labels: labels, Value: h.sum.load()},
+		Sample{Name: name + "_count", Labels: labels, Value: float64(count)},
+	)
+	return samples
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	return append(append(make([]Label, 0, len(labels)+1), labels...), Label{Name: name, Value: value})
+}
+
+// vec 按标签值保存子指标
+type vec[T any] struct {
+	labelNames []string
+	newMetric  func() T
+	mu         sync.RWMutex
+	children   map[string]*vecChild[T]
+}
+
+type vecChild[T any] struct {
+	labels []Label
+	metric T
+}
+
+func newVec[T any](labelNames []string, newMetric func() T) *vec[T] {
+	return &vec[T]{labelNames: labelNames, newMetric: newMetric, children: make(map[string]*vecChild[T])}
+}
+
+// with 返回标签值对应的子指标，不存在时创建。标签值个数与标签名不一致时 panic
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labelNames) {
+		panic(fmt.Sprintf("需要 %d 个标签值，得到 %d 个", len(v.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	child, ok := v.children[key]
+	v.mu.RUnlock()
+	if ok {
+		return child.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if child, ok := v.children[key]; ok {
+		return child.metric
+	}
+	labels := make([]Label, len(values))
+	for i, value := range values {
+		labels[i] = Label{Name: v.labelNames[i], Value: value}
+	}
+	child = &vecChild[T]{labels: labels, metric: v.newMetric()}
+	v.children[key] = child
+	return child.metric
+}
+
+// each 按标签值顺序遍历子指标
+func (v *vec[T]) each(fn func(labels []Label, metric T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	children := v.children
+	sort.Strings(keys)
+	selected := make([]*vecChild[T], len(keys))
+	for i, key := range keys {
+		selected[i] = children[key]
+	}
+	v.mu.RUnlock()
+
+	for _, child := range selected {
+		fn(child.labels, child.metric)
+	}
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	*vec[*Counter]
+}
+
+// With 返回标签值对应的计数器，标签值按注册时的标签名顺序传入
+func (v *CounterVec) With(values ...string) *Counter { return v.with(values) }
+
+// GaugeVec 带标签的仪表盘
+type GaugeVec struct {
+	*vec[*Gauge]
+}
+
+func (v *GaugeVec) With(values ...string) *Gauge { return v.with(values) }
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	*vec[*Histogram]
+}
+
+func (v *HistogramVec) With(values ...string) *Histogram { return v.with(values) }
+
+// NewCounter 注册计数器，名称重复时 panic
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	r.MustRegister(CollectorFunc(func() []Family {
+		return []Family{{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Name: name, Value: c.Value()}}}}
+	}))
+	return c
+}
+
+// NewCounterVec 注册带标签的计数器
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	v := &CounterVec{newVec(checkLabelNames(labelNames), func() *Counter { return &Counter{} })}
+	r.MustRegister(CollectorFunc(func() []Family {
+		f := Family{Name: name, Help: help, Type: TypeCounter}
+		v.each(func(labels []Label, c *Counter) {
+			f.Samples = append(f.Samples, Sample{Name: name, Labels: labels, Value: c.Value()})
+		})
+		return []Family{f}
+	}))
+	return v
+}
+
+// NewGauge 注册仪表盘
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	r.NewGaugeFunc(name, help, g.Value)
+	return g
+}
+
+// NewGaugeVec 注册带标签的仪表盘
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	v := &GaugeVec{newVec(checkLabelNames(labelNames), func() *Gauge { return &Gauge{} })}
+	r.MustRegister(CollectorFunc(func() []Family {
+		f := Family{Name: name, Help: help, Type: TypeGauge}
+		v.each(func(labels []Label, g *Gauge) {
+			f.Samples = append(f.Samples, Sample{Name: name, Labels: labels, Value: g.Value()})
+		})
+		return []Family{f}
+	}))
+	return v
+}
+
+// NewGaugeFunc 注册在抓取时调用 fn 取值的仪表盘
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.MustRegister(CollectorFunc(func() []Family {
+		return []Family{{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Name: name, Value: fn()}}}}
+	}))
+}
+
+// NewHistogram buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := newHistogram(checkBuckets(buckets))
+	r.MustRegister(CollectorFunc(func() []Family {
+		return []Family{{Name: name, Help: help, Type: TypeHistogram, Samples: h.samples(name, nil)}}
+	}))
+	return h
+}
+
+// NewHistogramVec 注册带标签的直方图，标签不能使用 le
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = checkBuckets(buckets)
+	for _, l := range labelNames {
+		if l == "le" {
+			panic("直方图不能使用 le 标签")
+		}
+	}
+	v := &HistogramVec{newVec(checkLabelNames(labelNames), func() *Histogram { return newHistogram(buckets) })}
+	r.MustRegister(CollectorFunc(func() []Family {
+		f := Family{Name: name, Help: help, Type: TypeHistogram}
+		v.each(func(labels []Label, h *Histogram) {
+			f.Samples = append(f.Samples, h.samples(name, labels)...)
+		})
+		return []Family{f}
+	}))
+	return v
+}
+
+func checkLabelNames(names []string) []string {
+	for _, name := range names {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			panic(fmt.Sprintf("无效的标签名称: %q", name))
+		}
+	}
+	return names
+}
+
+func checkBuckets(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		return DefBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic("直方图的桶必须升序排列")
+	}
+	return buckets
+}
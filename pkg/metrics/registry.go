@@ -0,0 +1,208 @@
+// Package metrics 实现 Prometheus 文本格式（0.0.4）的指标注册与输出，
+// 提供计数器、仪表盘和直方图，以及在抓取时计算指标值的 Collector 扩展点
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// ContentType 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Label 标签
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample 一行指标值，Name 为完整名称（直方图包含 _bucket、_sum、_count 后缀）
+type Sample struct {
+	Name   string
+	Labels []Label
+	Value  float64
+}
+
+// Family 同名指标的集合
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector 指标来源，每次抓取时调用 Collect
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc 将函数转换为 Collector
+type CollectorFunc func() []Family
+
+func (f CollectorFunc) Collect() []Family { return f() }
+
+// Registry 指标注册表，同一名称只能注册一次
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Register 注册 Collector，注册时调用一次 Collect 检查指标名称是否有效或重复
+func (r *Registry) Register(c Collector) error {
+	var names []string
+	for _, f := range c.Collect() {
+		names = append(names, f.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if !metricNamePattern.MatchString(name) {
+			return fmt.Errorf("无效的指标名称: %q", name)
+		}
+		if r.names[name] {
+			return fmt.Errorf("指标已注册: %s", name)
+		}
+	}
+	for _, name := range names {
+		r.names[name] = true
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// MustRegister 注册 Collector，失败时 panic
+func (r *Registry) MustRegister(c Collector) {
+	if err := r.Register(c); err != nil {
+		panic(err)
+	}
+}
+
+// Gather 收集所有指标，按名称排序
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	var families []Family
+	for _, c := range collectors {
+		families = append(families, c.Collect()...)
+	}
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// WriteTo 以文本格式输出所有指标
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range r.Gather() {
+		if len(f.Samples) == 0 {
+			continue
+		}
+		if f.Help != "" {
+			fmt.Fprintf(cw, "# HELP %s %s\n", f.Name, helpEscaper.Replace(f.Help))
+		}
+		fmt.Fprintf(cw, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			cw.WriteString(s.Name)
+			writeLabels(cw, s.Labels)
+			cw.WriteString(" ")
+			cw.WriteString(formatFloat(s.Value))
+			cw.WriteString("\n")
+		}
+	}
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// Handler 输出指标的 HTTP 处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeLabels(w *countingWriter, labels []Label) {
+	if len(labels) == 0 {
+		return
+	}
+	w.WriteString("{")
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(l.Name)
+		w.WriteString(`="`)
+		w.WriteString(labelEscaper.Replace(l.Value))
+		w.WriteString(`"`)
+	}
+	w.WriteString("}")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+func (c *countingWriter) WriteString(s string) {
+	c.Write([]byte(s))
+}
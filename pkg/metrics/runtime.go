@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+// NewGoCollector Go 运行时指标：goroutine 数、内存和 GC 统计、版本信息，以及进程启动时间
+func NewGoCollector() Collector {
+	start := float64(time.Now().Unix())
+	return CollectorFunc(func() []Family {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		gauge := func(name, help string, v float64) Family {
+			return Family{Name: name, Help: help, Type: TypeGauge, Samples: []Sample{{Name: name, Value: v}}}
+		}
+		counter := func(name, help string, v float64) Family {
+			return Family{Name: name, Help: help, Type: TypeCounter, Samples: []Sample{{Name: name, Value: v}}}
+		}
+		return []Family{
+			gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+			{Name: "go_info", Help: "Information about the Go environment.", Type: TypeGauge,
+				Samples: []Sample{{Name: "go_info", Labels: []Label{{Name: "version", Value: runtime.Version()}}, Value: 1}}},
+			gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(ms.Alloc)),
+			counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(ms.TotalAlloc)),
+			gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(ms.Sys)),
+			gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(ms.HeapInuse)),
+			gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(ms.HeapObjects)),
+			gauge("go_memstats_stack_inuse_bytes", "Number of bytes in use by the stack allocator.", float64(ms.StackInuse)),
+			counter("go_gc_cycles_total", "Number of completed GC cycles.", float64(ms.NumGC)),
+			counter("go_gc_pause_seconds_total", "Total GC stop-the-world pause time.", float64(ms.PauseTotalNs)/1e9),
+			gauge("go_gomaxprocs", "Value of GOMAXPROCS.", float64(runtime.GOMAXPROCS(0))),
+			gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", start),
+		}
+	})
+}
@@ -0,0 +1,96 @@
+// Package netguard 限制出站连接的目标地址，防止回调地址指向内网服务（SSRF）。
+// 校验在 net.Dialer 的 Control 中进行，此时域名已解析为实际连接的 IP，DNS 重绑定无法绕过
+package netguard
+
+import (
+	"base-gin/pkg/clientip"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 目标地址属于内网、回环、链路本地等不允许连接的网段
+var ErrForbiddenAddress = errors.New("不允许连接内网、回环或链路本地地址")
+
+// blockedPrefixes 除 netip.Addr 自带判断以外需要拒绝的网段
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"), // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留，包括广播地址
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64，可映射到任意 IPv4 地址
+}
+
+// Guard 出站地址校验，创建后只读，可并发使用
+type Guard struct {
+	allowed []netip.Prefix
+}
+
+// NewGuard allowed 为例外放行的 CIDR，如开发环境本机的接收服务
+func NewGuard(allowed []string) (*Guard, error) {
+	prefixes, err := clientip.ParsePrefixes(allowed)
+	if err != nil {
+		return nil, err
+	}
+	return &Guard{allowed: prefixes}, nil
+}
+
+// Check 校验地址是否允许连接
+func (g *Guard) Check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+		}
+	}
+	return nil
+}
+
+// CheckHost 校验 URL 中的主机，IP 直接校验，localhost 视为回环地址，其他域名在连接时校验
+func (g *Guard) CheckHost(host string) error {
+	if host == "localhost" {
+		return g.Check(netip.AddrFrom4([4]byte{127, 0, 0, 1}))
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.Check(addr)
+	}
+	return nil
+}
+
+// control 在建立连接前校验解析后的地址
+func (g *Guard) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return g.Check(addrPort.Addr())
+}
+
+// Client 返回只连接允许地址的 HTTP 客户端：不使用环境变量中的代理（否则校验的是代理地址），不跟随重定向
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: g.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
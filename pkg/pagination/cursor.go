@@ -0,0 +1,383 @@
+package pagination
+
+import (
+	"base-gin/pkg/sqltime"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultCursorLimit = 20
+	MaxCursorLimit     = 100
+)
+
+var (
+	ErrInvalidCursor   = errors.New("无效的分页游标")
+	ErrCursorConflict  = errors.New("after 和 before 不能同时使用")
+	ErrUnsupportedType = errors.New("游标不支持该字段类型")
+)
+
+// CursorRequest 游标分页请求
+type CursorRequest struct {
+	After  string `json:"after" form:"after"`
+	Before string `json:"before" form:"before"`
+	Limit  int    `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// CursorResponse 游标分页响应
+type CursorResponse struct {
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+	Data       interface{} `json:"data"`
+}
+
+// CursorMeta 当前页的游标信息
+type CursorMeta struct {
+	NextCursor string
+	PrevCursor string
+	HasMore    bool
+}
+
+// NewCursorResponse 创建游标分页响应
+func NewCursorResponse(meta CursorMeta, data interface{}) *CursorResponse {
+	return &CursorResponse{
+		NextCursor: meta.NextCursor,
+		PrevCursor: meta.PrevCursor,
+		HasMore:    meta.HasMore,
+		Data:       data,
+	}
+}
+
+// SortKey 排序键，最后一个键必须唯一（通常为主键）以保证顺序稳定
+type SortKey struct {
+	Column string
+	Desc   bool
+	// Time 为时间列，按实际时刻比较和排序，见 sqltime
+	Time bool
+}
+
+// CursorCodec 游标编解码器，游标内容使用 HMAC-SHA256 签名防止被篡改
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec 创建游标编解码器
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{secret: secret}
+}
+
+// cursorPayload 游标内容，记录排序列以拒绝在不同排序下复用的游标
+type cursorPayload struct {
+	Columns []string      `json:"k"`
+	Values  []cursorValue `json:"v"`
+}
+
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// Encode 将排序键的值编码为游标
+func (c *CursorCodec) Encode(keys []SortKey, values []interface{}) (string, error) {
+	if len(keys) != len(values) {
+		return "", ErrInvalidCursor
+	}
+
+	payload := cursorPayload{Columns: columnsOf(keys)}
+	for _, v := range values {
+		cv, err := encodeValue(v)
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, cv)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(data)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body)), nil
+}
+
+// Decode 校验签名并解析游标，排序列与 keys 不一致时返回错误
+func (c *CursorCodec) Decode(keys []SortKey, cursor string) ([]interface{}, error) {
+	body, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, c.sign(body)) {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if strings.Join(payload.Columns, ",") != strings.Join(columnsOf(keys), ",") || len(payload.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, 0, len(payload.Values))
+	for _, cv := range payload.Values {
+		v, err := decodeValue(cv)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func (c *CursorCodec) sign(body string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// CursorQuery 一次游标分页查询
+type CursorQuery struct {
+	codec    *CursorCodec
+	keys     []SortKey
+	values   []interface{}
+	limit    int
+	backward bool
+	resumed  bool
+}
+
+// NewCursorQuery 解析游标请求，keys 为本次查询的排序键
+func (c *CursorCodec) NewCursorQuery(req *CursorRequest, keys []SortKey) (*CursorQuery, error) {
+	if req.After != "" && req.Before != "" {
+		return nil, ErrCursorConflict
+	}
+
+	q := &CursorQuery{codec: c, keys: keys, limit: req.Limit}
+	if q.limit <= 0 {
+		q.limit = DefaultCursorLimit
+	}
+	if q.limit > MaxCursorLimit {
+		q.limit = MaxCursorLimit
+	}
+
+	cursor := req.After
+	if req.Before != "" {
+		cursor = req.Before
+		q.backward = true
+	}
+
+	if cursor != "" {
+		values, err := c.Decode(keys, cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.values = values
+		q.resumed = true
+	}
+
+	return q, nil
+}
+
+// Limit 返回每页条数
+func (q *CursorQuery) Limit() int {
+	return q.limit
+}
+
+// Scope 返回 GORM 作用域：添加游标条件、排序并多查一条用于判断是否还有数据。
+// 向前翻页（before）时排序方向取反，查询结果需交给 Paginate 恢复顺序。
+func (q *CursorQuery) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q.resumed {
+			db = db.Where(q.condition(db))
+		}
+
+		for _, key := range q.keys {
+			desc := key.Desc != q.backward
+			if key.Time {
+				db = db.Order(sqltime.OrderBy(db, db.Statement.Quote(clause.Column{Name: key.Column}), desc))
+				continue
+			}
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: key.Column}, Desc: desc})
+		}
+
+		return db.Limit(q.limit + 1)
+	}
+}
+
+// condition 构造排序键的元组比较条件。
+// 所有键方向一致时使用行值比较 (a, b) > (?, ?)，SQLite 和 PostgreSQL 均支持且便于命中索引；
+// 方向混合时展开为 a > ? OR (a = ? AND b < ?) 的形式。时间键与 Scope 的排序一样按实际时刻比较。
+func (q *CursorQuery) condition(db *gorm.DB) clause.Expression {
+	columns := make([]string, len(q.keys))
+	placeholders := make([]string, len(q.keys))
+	values := make([]interface{}, len(q.keys))
+	for i, key := range q.keys {
+		columns[i] = db.Statement.Quote(clause.Column{Name: key.Column})
+		placeholders[i], values[i] = "?", q.values[i]
+		if t, ok := q.values[i].(time.Time); ok && key.Time {
+			columns[i] = sqltime.Column(db, columns[i])
+			placeholders[i], values[i] = sqltime.Value(db, t)
+		}
+	}
+	greater := func(key SortKey) bool {
+		return key.Desc == q.backward
+	}
+
+	uniform := true
+	for _, key := range q.keys[1:] {
+		if key.Desc != q.keys[0].Desc {
+			uniform = false
+			break
+		}
+	}
+
+	if uniform {
+		op := "<"
+		if greater(q.keys[0]) {
+			op = ">"
+		}
+		sql := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", "))
+		return clause.Expr{SQL: sql, Vars: values}
+	}
+
+	var (
+		ors  []string
+		vars []interface{}
+	)
+	for i, key := range q.keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, columns[j]+" = "+placeholders[j])
+			vars = append(vars, values[j])
+		}
+		op := "<"
+		if greater(key) {
+			op = ">"
+		}
+		ands = append(ands, columns[i]+" "+op+" "+placeholders[i])
+		vars = append(vars, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	return clause.Expr{SQL: "(" + strings.Join(ors, " OR ") + ")", Vars: vars}
+}
+
+// Paginate 截掉多查的一条记录，恢复向前翻页的顺序并生成前后游标。
+// keyOf 按排序键顺序返回记录对应的值。
+func Paginate[T any](q *CursorQuery, rows []T, keyOf func(T) []interface{}) ([]T, CursorMeta, error) {
+	var meta CursorMeta
+
+	more := len(rows) > q.limit
+	if more {
+		rows = rows[:q.limit]
+	}
+
+	if q.backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, meta, nil
+	}
+
+	first, err := q.codec.Encode(q.keys, keyOf(rows[0]))
+	if err != nil {
+		return nil, meta, err
+	}
+	last, err := q.codec.Encode(q.keys, keyOf(rows[len(rows)-1]))
+	if err != nil {
+		return nil, meta, err
+	}
+
+	meta.HasMore = more
+	if q.backward {
+		// 从 before 游标往回翻，后面一定还有数据
+		meta.NextCursor = last
+		if more {
+			meta.PrevCursor = first
+		}
+	} else {
+		if more {
+			meta.NextCursor = last
+		}
+		if q.resumed {
+			meta.PrevCursor = first
+		}
+	}
+
+	return rows, meta, nil
+}
+
+func columnsOf(keys []SortKey) []string {
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column := key.Column
+		if key.Desc {
+			column = "-" + column
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func encodeValue(v interface{}) (cursorValue, error) {
+	switch val := v.(type) {
+	case string:
+		return cursorValue{Type: "s", Value: val}, nil
+	case int:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(int64(val), 10)}, nil
+	case int64:
+		return cursorValue{Type: "i", Value: strconv.FormatInt(val, 10)}, nil
+	case uint:
+		return cursorValue{Type: "u", Value: strconv.FormatUint(uint64(val), 10)}, nil
+	case uint64:
+		return cursorValue{Type: "u", Value: strconv.FormatUint(val, 10)}, nil
+	case float64:
+		return cursorValue{Type: "f", Value: strconv.FormatFloat(val, 'g', -1, 64)}, nil
+	case bool:
+		return cursorValue{Type: "b", Value: strconv.FormatBool(val)}, nil
+	case time.Time:
+		return cursorValue{Type: "t", Value: val.Format(time.RFC3339Nano)}, nil
+	}
+	return cursorValue{}, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+}
+
+func decodeValue(cv cursorValue) (interface{}, error) {
+	switch cv.Type {
+	case "s":
+		return cv.Value, nil
+	case "i":
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case "u":
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case "f":
+		return strconv.ParseFloat(cv.Value, 64)
+	case "b":
+		return strconv.ParseBool(cv.Value)
+	case "t":
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	}
+	return nil, ErrUnsupportedType
+}
@@ -1,6 +1,12 @@
 package pagination
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
 
 // PageRequest 分页请求
 type PageRequest struct {
@@ -53,3 +59,29 @@ func NewPageResponse(total int64, page, pageSize int, data interface{}) *PageRes
 		Data:       data,
 	}
 }
+
+// LinkHeader 生成 RFC 8288 Link 响应头，包含 first/prev/next/last，保留请求中的其他查询参数
+func (p *PageResponse) LinkHeader(u *url.URL) string {
+	if p.TotalPages == 0 {
+		return ""
+	}
+
+	link := func(page int, rel string) string {
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if p.HasPrev {
+		links = append(links, link(p.Page-1, "prev"))
+	}
+	if p.HasNext {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	links = append(links, link(p.TotalPages, "last"))
+
+	return strings.Join(links, ", ")
+}
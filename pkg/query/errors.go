@@ -0,0 +1,42 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 错误码
+const (
+	CodeInvalidSyntax       = "INVALID_SYNTAX"
+	CodeUnknownField        = "UNKNOWN_FIELD"
+	CodeUnsupportedOperator = "UNSUPPORTED_OPERATOR"
+	CodeInvalidValue        = "INVALID_VALUE"
+	CodeNotSortable         = "NOT_SORTABLE"
+	CodeTooMany             = "TOO_MANY"
+)
+
+// FieldError 单个查询参数的错误
+type FieldError struct {
+	Param   string `json:"param"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("%s(%s): %s", e.Param, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Param, e.Message)
+}
+
+// Errors 查询参数错误列表，解析时会收集所有错误一次性返回
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
+	}
+	return "查询参数错误: " + strings.Join(msgs, "; ")
+}
@@ -0,0 +1,304 @@
+package query
+
+import (
+	"base-gin/pkg/pagination"
+	"base-gin/pkg/sqltime"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ParamFilter 筛选参数名，格式 field:op:value，可重复
+	ParamFilter = "filter"
+	// ParamSort 排序参数名，格式 -created_at,name，- 表示降序
+	ParamSort = "sort"
+
+	maxInValues = 100
+)
+
+// Filter 已校验的筛选条件
+type Filter struct {
+	Field    string
+	Column   string
+	Operator Operator
+	Value    interface{}
+}
+
+// Sort 已校验的排序字段
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+	Time   bool
+}
+
+// Query 解析并校验后的查询
+type Query struct {
+	Filters []Filter
+	Sorts   []Sort
+}
+
+// Parse 从查询参数中解析 filter 和 sort，字段与操作符按 Schema 校验。
+// 所有错误会被收集后以 Errors 返回。
+func (s *Schema) Parse(values url.Values) (*Query, error) {
+	q := &Query{}
+	var errs Errors
+
+	filters := values[ParamFilter]
+	if len(filters) > s.MaxFilters {
+		errs = append(errs, &FieldError{Param: ParamFilter, Code: CodeTooMany, Message: fmt.Sprintf("筛选条件不能超过 %d 个", s.MaxFilters)})
+		filters = filters[:s.MaxFilters]
+	}
+	for _, raw := range filters {
+		f, err := s.parseFilter(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		q.Filters = append(q.Filters, *f)
+	}
+
+	if raw := values.Get(ParamSort); raw != "" {
+		sorts, sortErrs := s.parseSort(raw)
+		q.Sorts = sorts
+		errs = append(errs, sortErrs...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return q, nil
+}
+
+func (s *Schema) parseFilter(raw string) (*Filter, *FieldError) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 {
+		return nil, &FieldError{Param: ParamFilter, Code: CodeInvalidSyntax, Message: fmt.Sprintf("格式应为 field:op:value，得到 %q", raw)}
+	}
+
+	name, op, rawValue := strings.TrimSpace(parts[0]), Operator(strings.ToLower(strings.TrimSpace(parts[1]))), parts[2]
+	field, ok := s.Field(name)
+	if !ok {
+		return nil, &FieldError{Param: ParamFilter, Field: name, Code: CodeUnknownField, Message: "不支持筛选该字段"}
+	}
+
+	if !field.allows(op) {
+		return nil, &FieldError{Param: ParamFilter, Field: name, Code: CodeUnsupportedOperator, Message: fmt.Sprintf("%s 类型字段不支持操作符 %s", field.Type, op)}
+	}
+
+	invalid := func() *FieldError {
+		return &FieldError{Param: ParamFilter, Field: name, Code: CodeInvalidValue, Message: fmt.Sprintf("无效的 %s 值: %q", field.Type, rawValue)}
+	}
+
+	var value interface{}
+	switch op {
+	case OpIsNull:
+		v, err := parseBool(rawValue)
+		if err != nil {
+			return nil, invalid()
+		}
+		value = v
+	case OpIn:
+		items := strings.Split(rawValue, "|")
+		if len(items) > maxInValues {
+			return nil, &FieldError{Param: ParamFilter, Field: name, Code: CodeTooMany, Message: fmt.Sprintf("in 最多支持 %d 个值", maxInValues)}
+		}
+		list := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			v, ok := field.parseValue(item)
+			if !ok {
+				return nil, invalid()
+			}
+			list = append(list, v)
+		}
+		value = list
+	default:
+		v, ok := field.parseValue(rawValue)
+		if !ok {
+			return nil, invalid()
+		}
+		value = v
+	}
+
+	return &Filter{Field: field.Name, Column: field.column(), Operator: op, Value: value}, nil
+}
+
+func (s *Schema) parseSort(raw string) ([]Sort, Errors) {
+	var (
+		sorts []Sort
+		errs  Errors
+	)
+
+	items := strings.Split(raw, ",")
+	if len(items) > s.MaxSorts {
+		return nil, Errors{{Param: ParamSort, Code: CodeTooMany, Message: fmt.Sprintf("排序字段不能超过 %d 个", s.MaxSorts)}}
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		desc := strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(item, "-"), "+")
+
+		field, ok := s.Field(name)
+		if !ok {
+			errs = append(errs, &FieldError{Param: ParamSort, Field: name, Code: CodeUnknownField, Message: "不支持按该字段排序"})
+			continue
+		}
+		if !field.Sortable {
+			errs = append(errs, &FieldError{Param: ParamSort, Field: name, Code: CodeNotSortable, Message: "不支持按该字段排序"})
+			continue
+		}
+		if seen[field.Name] {
+			continue
+		}
+		seen[field.Name] = true
+		sorts = append(sorts, Sort{Field: field.Name, Column: field.column(), Desc: desc, Time: field.Type == TypeTime})
+	}
+
+	return sorts, errs
+}
+
+// Scope 将筛选条件编译为参数化的 GORM 作用域，列名来自白名单并经过转义
+func (q *Query) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if q == nil {
+			return db
+		}
+		for _, f := range q.Filters {
+			db = db.Where(f.expression(db, db.Statement.Quote(clause.Column{Name: f.Column})))
+		}
+		return db
+	}
+}
+
+// SortKeys 将排序字段转换为排序键，tieBreaker 不为空且未出现在排序中时追加到末尾，保证顺序稳定
+func (q *Query) SortKeys(tieBreaker string) []pagination.SortKey {
+	var keys []pagination.SortKey
+	hasTieBreaker := false
+	desc := false
+	if q != nil {
+		for _, s := range q.Sorts {
+			keys = append(keys, pagination.SortKey{Column: s.Column, Desc: s.Desc, Time: s.Time})
+			hasTieBreaker = hasTieBreaker || s.Column == tieBreaker
+		}
+		if len(q.Sorts) > 0 {
+			desc = q.Sorts[0].Desc
+		}
+	}
+	if tieBreaker != "" && !hasTieBreaker {
+		keys = append(keys, pagination.SortKey{Column: tieBreaker, Desc: desc})
+	}
+	return keys
+}
+
+// OrderScope 按排序键添加 ORDER BY，时间键按实际时刻排序
+func OrderScope(keys []pagination.SortKey) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, key := range keys {
+			if key.Time {
+				db = db.Order(sqltime.OrderBy(db, db.Statement.Quote(clause.Column{Name: key.Column}), key.Desc))
+				continue
+			}
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: key.Column}, Desc: key.Desc})
+		}
+		return db
+	}
+}
+
+// comparisonSQL 比较类操作符对应的 SQL 运算符
+var comparisonSQL = map[Operator]string{
+	OpEq:  "=",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// CompareTime 按实际时刻比较时间列与 value，op 为 =、<、<=、>、>=，见 sqltime.Compare
+func CompareTime(db *gorm.DB, column, op string, value time.Time) clause.Expression {
+	return sqltime.Compare(db, column, op, value)
+}
+
+// timeExpression 时间字段的比较，只有日期的值按当天的范围比较
+func (f *Filter) timeExpression(db *gorm.DB, column string) clause.Expression {
+	if d, ok := f.Value.(date); ok {
+		switch f.Operator {
+		case OpGt:
+			return CompareTime(db, column, ">=", d.end())
+		case OpGte:
+			return CompareTime(db, column, ">=", d.start)
+		case OpLt:
+			return CompareTime(db, column, "<", d.start)
+		case OpLte:
+			return CompareTime(db, column, "<", d.end())
+		default:
+			return clause.And(CompareTime(db, column, ">=", d.start), CompareTime(db, column, "<", d.end()))
+		}
+	}
+	return CompareTime(db, column, comparisonSQL[f.Operator], f.Value.(time.Time))
+}
+
+func (f *Filter) expression(db *gorm.DB, column string) clause.Expression {
+	switch f.Value.(type) {
+	case time.Time, date:
+		if _, ok := comparisonSQL[f.Operator]; ok {
+			return f.timeExpression(db, column)
+		}
+	}
+
+	switch f.Operator {
+	case OpNe:
+		return clause.Expr{SQL: column + " <> ?", Vars: []interface{}{f.Value}}
+	case OpGt:
+		return clause.Expr{SQL: column + " > ?", Vars: []interface{}{f.Value}}
+	case OpGte:
+		return clause.Expr{SQL: column + " >= ?", Vars: []interface{}{f.Value}}
+	case OpLt:
+		return clause.Expr{SQL: column + " < ?", Vars: []interface{}{f.Value}}
+	case OpLte:
+		return clause.Expr{SQL: column + " <= ?", Vars: []interface{}{f.Value}}
+	case OpLike:
+		return clause.Expr{SQL: "LOWER(" + column + ") LIKE ? ESCAPE '\\'", Vars: []interface{}{"%" + escapeLike(strings.ToLower(f.Value.(string))) + "%"}}
+	case OpPrefix:
+		return clause.Expr{SQL: column + " LIKE ? ESCAPE '\\'", Vars: []interface{}{escapeLike(f.Value.(string)) + "%"}}
+	case OpSuffix:
+		return clause.Expr{SQL: column + " LIKE ? ESCAPE '\\'", Vars: []interface{}{"%" + escapeLike(f.Value.(string))}}
+	case OpIn:
+		return clause.Expr{SQL: column + " IN ?", Vars: []interface{}{f.Value}}
+	case OpIsNull:
+		if f.Value.(bool) {
+			return clause.Expr{SQL: column + " IS NULL"}
+		}
+		return clause.Expr{SQL: column + " IS NOT NULL"}
+	default:
+		return clause.Expr{SQL: column + " = ?", Vars: []interface{}{f.Value}}
+	}
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, errors.New("invalid bool")
+}
+
+// IsQueryError 判断是否为查询参数错误
+func IsQueryError(err error) bool {
+	var errs Errors
+	return errors.As(err, &errs)
+}
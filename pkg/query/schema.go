@@ -0,0 +1,145 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType 字段类型，决定值的解析方式和可用操作符
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeInt
+	TypeTime
+	TypeBool
+)
+
+func (t FieldType) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeTime:
+		return "time"
+	case TypeBool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// Operator 筛选操作符
+type Operator string
+
+const (
+	OpEq     Operator = "eq"
+	OpNe     Operator = "ne"
+	OpGt     Operator = "gt"
+	OpGte    Operator = "gte"
+	OpLt     Operator = "lt"
+	OpLte    Operator = "lte"
+	OpLike   Operator = "like"   // 包含，不区分大小写
+	OpPrefix Operator = "prefix" // 前缀匹配
+	OpSuffix Operator = "suffix" // 后缀匹配
+	OpIn     Operator = "in"     // 多个值用 | 分隔
+	OpIsNull Operator = "isnull" // 值为 true 或 false
+)
+
+// defaultOperators 各类型默认允许的操作符
+var defaultOperators = map[FieldType][]Operator{
+	TypeString: {OpEq, OpNe, OpLike, OpPrefix, OpSuffix, OpIn},
+	TypeInt:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn},
+	TypeTime:   {OpEq, OpGt, OpGte, OpLt, OpLte},
+	TypeBool:   {OpEq, OpNe},
+}
+
+// Field 可查询字段定义
+type Field struct {
+	// Name 对外暴露的字段名
+	Name string
+	// Column 数据库列名，为空时与 Name 相同
+	Column string
+	Type   FieldType
+	// Operators 允许的操作符，为空时使用该类型的默认操作符
+	Operators []Operator
+	Sortable  bool
+}
+
+func (f *Field) column() string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return f.Name
+}
+
+func (f *Field) allows(op Operator) bool {
+	ops := f.Operators
+	if len(ops) == 0 {
+		ops = defaultOperators[f.Type]
+	}
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parseValue 按字段类型解析筛选值
+func (f *Field) parseValue(raw string) (interface{}, bool) {
+	switch f.Type {
+	case TypeInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		return v, err == nil
+	case TypeTime:
+		if v, err := time.Parse(time.RFC3339, raw); err == nil {
+			return v, true
+		}
+		v, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+		return date{start: v}, err == nil
+	case TypeBool:
+		v, err := strconv.ParseBool(raw)
+		return v, err == nil
+	default:
+		return raw, true
+	}
+}
+
+// date 只有日期的时间值，表示服务器时区中的一整天：eq 匹配当天任意时刻，gt 从次日开始，lte 包含当天
+type date struct {
+	start time.Time
+}
+
+func (d date) end() time.Time {
+	return d.start.AddDate(0, 0, 1)
+}
+
+// Schema 某个资源允许查询的字段白名单
+type Schema struct {
+	fields map[string]*Field
+	// MaxFilters 单次请求最多允许的筛选条件数
+	MaxFilters int
+	// MaxSorts 单次请求最多允许的排序字段数
+	MaxSorts int
+}
+
+// NewSchema 创建字段白名单
+func NewSchema(fields ...Field) *Schema {
+	s := &Schema{
+		fields:     make(map[string]*Field, len(fields)),
+		MaxFilters: 20,
+		MaxSorts:   3,
+	}
+	for i := range fields {
+		f := fields[i]
+		s.fields[f.Name] = &f
+	}
+	return s
+}
+
+// Field 按名称查找字段
+func (s *Schema) Field(name string) (*Field, bool) {
+	f, ok := s.fields[strings.TrimSpace(name)]
+	return f, ok
+}
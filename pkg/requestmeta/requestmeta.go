@@ -0,0 +1,48 @@
+// Package requestmeta 在 context 中传递请求的来源信息（请求 ID、操作者、IP、User-Agent），
+// 供审计等需要记录"谁在何时发起了操作"的模块读取
+package requestmeta
+
+import "context"
+
+// 操作者来源，审计记录据此区分经过认证的身份和客户端自称的身份
+const (
+	// ActorSourceProxy 操作者请求头由可信代理（认证网关）设置
+	ActorSourceProxy = "proxy"
+	// ActorSourceClient 操作者请求头由客户端直接提供，未经认证，只能作为参考
+	ActorSourceClient = "client"
+	// ActorSourceAdminToken 通过 ADMIN_TOKEN 认证的管理请求
+	ActorSourceAdminToken = "admin_token"
+	// ActorSourceSystem 后台任务等非 HTTP 调用
+	ActorSourceSystem = "system"
+)
+
+// Metadata 请求的来源信息，后台任务只设置 Actor 和 ActorSource
+type Metadata struct {
+	RequestID string
+	Actor     string
+	// ActorSource Actor 的来源，取值见 ActorSource 常量，未提供操作者时为空
+	ActorSource string
+	IP          string
+	UserAgent   string
+}
+
+type contextKey struct{}
+
+// NewContext 返回携带 meta 的 context
+func NewContext(ctx context.Context, meta Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// FromContext 返回 context 中的来源信息，没有时返回零值
+func FromContext(ctx context.Context) Metadata {
+	meta, _ := ctx.Value(contextKey{}).(Metadata)
+	return meta
+}
+
+// WithActor 设置操作者及其来源，保留其他字段
+func WithActor(ctx context.Context, actor, source string) context.Context {
+	meta := FromContext(ctx)
+	meta.Actor = actor
+	meta.ActorSource = source
+	return NewContext(ctx, meta)
+}
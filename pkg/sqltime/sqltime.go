@@ -0,0 +1,41 @@
+// Package sqltime 按实际时刻比较和排序数据库中的时间列。SQLite 以文本保存时间并带写入时的时区偏移，
+// 按字符串比较或排序在偏移不同时结果错误，因此用 julianday() 换算到同一时间轴；其他数据库直接使用列
+package sqltime
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func isSQLite(db *gorm.DB) bool {
+	return db.Dialector != nil && db.Dialector.Name() == "sqlite"
+}
+
+// Column 返回按时刻比较和排序的列表达式，column 需已转义
+func Column(db *gorm.DB, column string) string {
+	if isSQLite(db) {
+		return "julianday(" + column + ")"
+	}
+	return column
+}
+
+// Value 返回与 Column 对应的占位符和参数
+func Value(db *gorm.DB, value time.Time) (string, interface{}) {
+	if isSQLite(db) {
+		return "julianday(?)", value.UTC()
+	}
+	return "?", value
+}
+
+// Compare 比较时间列与 value，op 为 =、<、<=、>、>=
+func Compare(db *gorm.DB, column, op string, value time.Time) clause.Expression {
+	placeholder, v := Value(db, value)
+	return clause.Expr{SQL: Column(db, column) + " " + op + " " + placeholder, Vars: []interface{}{v}}
+}
+
+// OrderBy 返回时间列的排序子句，column 需已转义
+func OrderBy(db *gorm.DB, column string, desc bool) clause.OrderByColumn {
+	return clause.OrderByColumn{Column: clause.Column{Name: Column(db, column), Raw: true}, Desc: desc}
+}
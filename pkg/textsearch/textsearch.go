@@ -0,0 +1,167 @@
+// Package textsearch 提供全文检索使用的分词与高亮工具。
+// 中日韩文字没有空格分隔，按二元组（bigram）切分，其他文字按字母数字连续串切分并转为小写。
+package textsearch
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// isCJK 判断是否为需要按二元组切分的文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// splitRuns 按文字类型切分出连续片段，非字母数字字符作为分隔符
+func splitRuns(text string, fn func(run []rune, cjk bool)) {
+	var (
+		run []rune
+		cjk bool
+	)
+	flush := func() {
+		if len(run) > 0 {
+			fn(run, cjk)
+			run = nil
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+			}
+			cjk = true
+			run = append(run, r)
+		case isWord(r):
+			if cjk {
+				flush()
+			}
+			cjk = false
+			run = append(run, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+}
+
+// IndexTokens 返回建立索引使用的词元。中日韩文字同时输出单字和二元组，以支持单字查询。
+func IndexTokens(text string) []string {
+	var tokens []string
+	splitRuns(text, func(run []rune, cjk bool) {
+		if !cjk {
+			tokens = append(tokens, string(run))
+			return
+		}
+		for i := range run {
+			tokens = append(tokens, string(run[i]))
+			if i+1 < len(run) {
+				tokens = append(tokens, string(run[i:i+2]))
+			}
+		}
+	})
+	return tokens
+}
+
+// QueryTokens 返回查询使用的词元。中日韩文字只取二元组（单字时取单字），词元之间为 AND 关系。
+func QueryTokens(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	splitRuns(text, func(run []rune, cjk bool) {
+		if !cjk || len(run) == 1 {
+			add(string(run))
+			return
+		}
+		for i := 0; i+1 < len(run); i++ {
+			add(string(run[i : i+2]))
+		}
+	})
+	return tokens
+}
+
+// Document 将多个字段合并为一个索引文档，词元之间以空格分隔，
+// 数据库的全文索引（FTS5 unicode61 / PostgreSQL simple）会按空格切分。
+func Document(fields ...string) string {
+	var tokens []string
+	for _, field := range fields {
+		tokens = append(tokens, IndexTokens(field)...)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// FTS5Query 将查询词元转换为 FTS5 MATCH 表达式，最后一个词元使用前缀匹配以支持输入联想
+func FTS5Query(tokens []string) string {
+	parts := make([]string, 0, len(tokens))
+	for i, token := range tokens {
+		part := `"` + token + `"`
+		if i == len(tokens)-1 {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// TSQuery 将查询词元转换为 PostgreSQL to_tsquery 表达式，最后一个词元使用前缀匹配
+func TSQuery(tokens []string) string {
+	parts := make([]string, 0, len(tokens))
+	for i, token := range tokens {
+		part := token
+		if i == len(tokens)-1 {
+			part += ":*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Highlight 在原文中用 pre/post 标记出现的词元（不区分大小写），重叠的匹配会合并。
+// 原文会做 HTML 转义，结果可以直接作为 HTML 片段展示。
+func Highlight(text string, tokens []string, pre, post string) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// 大小写转换改变了字符数，退化为不区分大小写失败时的精确匹配
+		lower = runes
+	}
+
+	marked := make([]bool, len(runes))
+	for _, token := range tokens {
+		t := []rune(token)
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) == token {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(pre)
+		}
+		b.WriteString(html.EscapeString(string(r)))
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString(post)
+		}
+	}
+	return b.String()
+}
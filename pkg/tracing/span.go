@@ -0,0 +1,302 @@
+// Package tracing 实现与 OpenTelemetry 兼容的链路追踪：W3C traceparent 传播、采样、
+// 批量导出，以及 OTLP/JSON 编码的 span，可由 OpenTelemetry Collector 直接接收
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// TraceID 16 字节的链路 ID
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// SpanID 8 字节的 span ID
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool  { return s != SpanID{} }
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		putUint64(id[:8], rand.Uint64())
+		putUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		putUint64(id[:], rand.Uint64())
+	}
+	return id
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range 8 {
+		b[i] = byte(v >> (56 - 8*i))
+	}
+}
+
+// SpanContext 跨进程传播的 span 标识
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	// TraceState tracestate 请求头的原值，原样向下游传递
+	TraceState string
+	// Remote 为 true 表示来自上游请求
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// ErrInvalidTraceparent traceparent 格式错误
+var ErrInvalidTraceparent = errors.New("无效的 traceparent")
+
+// ParseTraceparent 解析 W3C traceparent 请求头，如 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01。
+// 未知版本按 00 版本的前缀解析，ff 版本和全零 ID 视为无效
+func ParseTraceparent(header string) (SpanContext, error) {
+	if len(header) < 55 || header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	version, ok := decodeHex(header[0:2])
+	if !ok || version[0] == 0xff || (version[0] == 0 && len(header) != 55) || (len(header) > 55 && header[55] != '-') {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	traceID, ok1 := decodeHex(header[3:35])
+	spanID, ok2 := decodeHex(header[36:52])
+	flags, ok3 := decodeHex(header[53:55])
+	if !ok1 || !ok2 || !ok3 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Sampled = flags[0]&1 == 1
+	sc.Remote = true
+	return sc, nil
+}
+
+// decodeHex 只接受小写十六进制
+func decodeHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// Traceparent 格式化为 traceparent 请求头
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// SpanKind span 类型，取值与 OTLP 一致
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode span 状态，取值与 OTLP 一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute span 属性，Value 为 string、bool、int、int64 或 float64
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String、Int、Int64、Bool、Float64 创建对应类型的属性
+func String(key, value string) Attribute          { return Attribute{key, value} }
+func Int(key string, value int) Attribute         { return Attribute{key, int64(value)} }
+func Int64(key string, value int64) Attribute     { return Attribute{key, value} }
+func Bool(key string, value bool) Attribute       { return Attribute{key, value} }
+func Float64(key string, value float64) Attribute { return Attribute{key, value} }
+
+// Event span 内的时间点事件
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// SpanData 已结束的 span，交给 Exporter 导出
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	ParentSpanID  SpanID
+	Kind          SpanKind
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    []Attribute
+	Events        []Event
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span 进行中的 span。未采样的 span 只用于传播上下文，不记录也不导出；
+// 所有方法对 nil 安全，未启用追踪时调用方无需判断
+type Span struct {
+	tracer    *Tracer
+	mu        sync.Mutex
+	data      SpanData
+	recording bool
+	ended     bool
+}
+
+// SpanContext 返回 span 的标识，nil 时返回零值
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// IsRecording 是否记录并导出
+func (s *Span) IsRecording() bool {
+	return s != nil && s.recording
+}
+
+// SetName 修改 span 名称
+func (s *Span) SetName(name string) {
+	s.update(func() { s.data.Name = name })
+}
+
+// SetAttributes 设置属性，同名属性覆盖
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	s.update(func() {
+		for _, attr := range attrs {
+			replaced := false
+			for i := range s.data.Attributes {
+				if s.data.Attributes[i].Key == attr.Key {
+					s.data.Attributes[i].Value = attr.Value
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				s.data.Attributes = append(s.data.Attributes, attr)
+			}
+		}
+	})
+}
+
+// AddEvent 添加事件
+func (s *Span) AddEvent(name string, attrs ...Attribute) {
+	s.update(func() {
+		s.data.Events = append(s.data.Events, Event{Name: name, Time: time.Now(), Attributes: attrs})
+	})
+}
+
+// RecordError 以 exception 事件记录错误并将状态设为 StatusError，err 为 nil 时忽略
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.AddEvent("exception",
+		String("exception.type", fmt.Sprintf("%T", err)),
+		String("exception.message", err.Error()))
+	s.SetStatus(StatusError, err.Error())
+}
+
+// SetStatus 设置状态，StatusOK 不会被后续设置覆盖
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.update(func() {
+		if s.data.StatusCode == StatusOK {
+			return
+		}
+		s.data.StatusCode = code
+		if code == StatusError {
+			s.data.StatusMessage = message
+		} else {
+			s.data.StatusMessage = ""
+		}
+	})
+}
+
+// End 结束 span 并提交导出，重复调用只生效一次
+func (s *Span) End() {
+	if s == nil || !s.recording {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+func (s *Span) update(fn func()) {
+	if s == nil || !s.recording {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		fn()
+	}
+}
+
+type spanKey struct{}
+type remoteSpanContextKey struct{}
+
+// ContextWithSpan 返回携带 span 的 context，之后创建的 span 以它为父 span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// ContextWithRemoteSpanContext 返回携带上游 span 标识的 context，通常来自 traceparent 请求头
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteSpanContextKey{}, sc)
+}
+
+// SpanFromContext 返回 context 中的 span，没有时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext 返回 context 中 span 的标识，没有 span 时返回上游的标识
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	return sc
+}
@@ -0,0 +1,63 @@
+package tracing
+
+import "strings"
+
+// SanitizeSQL 将 SQL 中的字符串和数字字面量替换为 ?，避免参数值（如邮箱、密码哈希）写入 span。
+// 标识符、双引号和反引号括起的名称以及 $1 形式的占位符保持不变
+func SanitizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			// 字符串字面量，'' 为转义的单引号
+			i++
+			for i < len(sql) {
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			b.WriteByte('?')
+		case c == '"' || c == '`':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				b.WriteString(sql[i:])
+				return b.String()
+			}
+			b.WriteString(sql[i : i+end+2])
+			i += end + 2
+		case isIdentByte(c) && !isDigit(c) || c == '$':
+			// 标识符或占位符，其中的数字不是字面量
+			start := i
+			i++
+			for i < len(sql) && isIdentByte(sql[i]) {
+				i++
+			}
+			b.WriteString(sql[start:i])
+		case isDigit(c) || (c == '.' && i+1 < len(sql) && isDigit(sql[i+1])):
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.' || sql[i] == 'e' || sql[i] == 'E' ||
+				(sql[i] == '-' || sql[i] == '+') && (sql[i-1] == 'e' || sql[i-1] == 'E')) {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isIdentByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || isDigit(c) || c == '_' || c >= 0x80
+}
@@ -0,0 +1,243 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler 决定新建的 span 是否采样
+type Sampler interface {
+	ShouldSample(parent SpanContext, traceID TraceID) bool
+}
+
+// SamplerFunc 将函数转换为 Sampler
+type SamplerFunc func(parent SpanContext, traceID TraceID) bool
+
+func (f SamplerFunc) ShouldSample(parent SpanContext, traceID TraceID) bool {
+	return f(parent, traceID)
+}
+
+// AlwaysSample 全部采样
+func AlwaysSample() Sampler {
+	return SamplerFunc(func(SpanContext, TraceID) bool { return true })
+}
+
+// NeverSample 全部不采样
+func NeverSample() Sampler {
+	return SamplerFunc(func(SpanContext, TraceID) bool { return false })
+}
+
+// TraceIDRatio 按链路 ID 采样 ratio 比例的链路，同一链路在各服务的决定一致
+func TraceIDRatio(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+	if ratio <= 0 {
+		return NeverSample()
+	}
+	bound := uint64(ratio * (1 << 63))
+	return SamplerFunc(func(_ SpanContext, traceID TraceID) bool {
+		return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+	})
+}
+
+// ParentBased 有父 span 时沿用父 span 的采样决定，否则由 root 决定
+func ParentBased(root Sampler) Sampler {
+	return SamplerFunc(func(parent SpanContext, traceID TraceID) bool {
+		if parent.IsValid() {
+			return parent.Sampled
+		}
+		return root.ShouldSample(parent, traceID)
+	})
+}
+
+// Options 追踪器配置
+type Options struct {
+	// ServiceName 写入导出数据的 service.name 资源属性
+	ServiceName string
+	// Sampler 为 nil 时全部采样
+	Sampler  Sampler
+	Exporter Exporter
+	// BatchSize 每批导出的最大 span 数，BatchTimeout 未满一批时的导出间隔
+	BatchSize    int
+	BatchTimeout time.Duration
+	// QueueSize 等待导出的 span 数上限，超过后丢弃
+	QueueSize int
+}
+
+// Tracer 创建 span，并在后台批量导出已结束的 span。所有方法对 nil 安全
+type Tracer struct {
+	resource  []Attribute
+	sampler   Sampler
+	exporter  Exporter
+	batchSize int
+	timeout   time.Duration
+
+	queue    chan SpanData
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+}
+
+// NewTracer 创建追踪器并启动后台导出，使用完毕后调用 Shutdown
+func NewTracer(opts Options) *Tracer {
+	if opts.Sampler == nil {
+		opts.Sampler = AlwaysSample()
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.BatchTimeout <= 0 {
+		opts.BatchTimeout = 5 * time.Second
+	}
+	if opts.QueueSize < opts.BatchSize {
+		opts.QueueSize = opts.BatchSize * 4
+	}
+	t := &Tracer{
+		resource:  []Attribute{String("service.name", opts.ServiceName), String("telemetry.sdk.language", "go")},
+		sampler:   opts.Sampler,
+		exporter:  opts.Exporter,
+		batchSize: opts.BatchSize,
+		timeout:   opts.BatchTimeout,
+		queue:     make(chan SpanData, opts.QueueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// SpanOption 创建 span 的选项
+type SpanOption func(*SpanData)
+
+// WithKind 设置 span 类型，默认为 KindInternal
+func WithKind(kind SpanKind) SpanOption {
+	return func(d *SpanData) { d.Kind = kind }
+}
+
+// WithAttributes 设置初始属性，采样器执行前即可确定的属性应在此传入
+func WithAttributes(attrs ...Attribute) SpanOption {
+	return func(d *SpanData) { d.Attributes = append(d.Attributes, attrs...) }
+}
+
+// Start 以 ctx 中的 span（或上游 span）为父 span 创建新的 span，返回携带新 span 的 context。
+// t 为 nil 时原样返回 ctx 和 nil span
+func (t *Tracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+	}
+	sc.Sampled = t.sampler.ShouldSample(parent, sc.TraceID)
+
+	span := &Span{tracer: t, recording: sc.Sampled}
+	span.data = SpanData{Name: name, SpanContext: sc, Kind: KindInternal, StartTime: time.Now()}
+	if parent.IsValid() {
+		span.data.ParentSpanID = parent.SpanID
+	}
+	for _, opt := range opts {
+		opt(&span.data)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Dropped 因队列已满而丢弃的 span 数
+func (t *Tracer) Dropped() uint64 {
+	if t == nil {
+		return 0
+	}
+	return t.dropped.Load()
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case <-t.stop:
+		return
+	default:
+	}
+	select {
+	case t.queue <- data:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(t.timeout)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if t.exporter != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := t.exporter.Export(ctx, t.resource, batch); err != nil {
+				log.Printf("导出 %d 个 span 失败: %v", len(batch), err)
+			}
+			cancel()
+		}
+		batch = make([]SpanData, 0, t.batchSize)
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case data := <-t.queue:
+					batch = append(batch, data)
+					if len(batch) >= t.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown 导出队列中剩余的 span 并关闭 Exporter，之后结束的 span 被丢弃
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	first := false
+	t.stopOnce.Do(func() {
+		close(t.stop)
+		first = true
+	})
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if first && t.exporter != nil {
+		return t.exporter.Shutdown(ctx)
+	}
+	return nil
+}
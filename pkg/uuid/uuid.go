@@ -0,0 +1,18 @@
+// Package uuid 生成随机 UUID，用作事件和消息的唯一标识
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewV4 生成 RFC 4122 版本 4（随机）UUID 字符串
+func NewV4() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
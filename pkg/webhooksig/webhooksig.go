@@ -0,0 +1,71 @@
+// Package webhooksig 实现 Webhook 请求的 HMAC-SHA256 签名与校验。
+// 签名内容为 "<时间戳>.<请求体>"，时间戳为 Unix 秒，接收方校验时间戳以防重放。
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名相关的请求头
+const (
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signaturePrefix 签名值前缀，标明算法
+const signaturePrefix = "sha256="
+
+var (
+	ErrMissingSignature = errors.New("缺少签名或时间戳")
+	ErrInvalidTimestamp = errors.New("时间戳无效或已过期")
+	ErrInvalidSignature = errors.New("签名不匹配")
+)
+
+// Sign 计算签名，返回 sha256=<十六进制摘要>
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeaders 为请求设置时间戳和签名头
+func SetHeaders(header http.Header, secret string, now time.Time, body []byte) {
+	timestamp := now.Unix()
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	header.Set(HeaderSignature, Sign(secret, timestamp, body))
+}
+
+// Verify 校验请求头中的签名，时间戳与 now 相差超过 tolerance 时视为重放。
+// 签名头可包含以逗号分隔的多个签名（轮换密钥期间），任一匹配即通过
+func Verify(header http.Header, secret string, body []byte, now time.Time, tolerance time.Duration) error {
+	timestampValue := header.Get(HeaderTimestamp)
+	signatures := header.Get(HeaderSignature)
+	if timestampValue == "" || signatures == "" {
+		return ErrMissingSignature
+	}
+
+	timestamp, err := strconv.ParseInt(timestampValue, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if diff := now.Sub(time.Unix(timestamp, 0)); diff > tolerance || diff < -tolerance {
+		return ErrInvalidTimestamp
+	}
+
+	expected := Sign(secret, timestamp, body)
+	for _, signature := range strings.Split(signatures, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -9,7 +9,7 @@ echo "🧪 开始运行测试..."
 
 # 运行单元测试
 echo "📋 运行单元测试..."
-if go test -v ./test/unit/...; then
+if go test -tags sqlite_fts5 -v ./test/unit/...; then
     echo "✅ 单元测试通过"
 else
     echo "❌ 单元测试失败"
@@ -18,7 +18,7 @@ fi
 
 # 运行集成测试
 echo "🔗 运行集成测试..."
-if go test -v ./test/integration/...; then
+if go test -tags sqlite_fts5 -v ./test/integration/...; then
     echo "✅ 集成测试通过"
 else
     echo "⚠️  集成测试失败，但继续执行"
@@ -1,16 +1,42 @@
 package integration_test
 
 import (
+	"base-gin/internal/domain/event"
+	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/infrastructure/database/models"
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/infrastructure/outbox"
+	"base-gin/internal/interfaces/admin"
+	"base-gin/pkg/webhooksig"
 	"base-gin/wire"
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
+// testAdminToken 业务端口上管理接口的令牌
+const testAdminToken = "admin-token"
+
 func TestUserAPI(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
 	// 初始化应用
 	app, cleanup, err := wire.InitializeApp()
 	if err != nil {
@@ -74,6 +100,556 @@ func TestUserAPI(t *testing.T) {
 		}
 	})
 
+	// 测试分页查询
+	t.Run("ListUsersPaginated", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?page=1&page_size=1&sort=-created_at&email_domain=example.com", nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if total, _ := response["total"].(float64); total < 1 {
+			t.Errorf("期望 total >= 1，得到 %v", response["total"])
+		}
+		if data, _ := response["data"].([]interface{}); len(data) != 1 {
+			t.Errorf("期望返回 1 条数据，得到 %d", len(data))
+		}
+		if link := w.Header().Get("Link"); !strings.Contains(link, `rel="first"`) {
+			t.Errorf("期望 Link 头包含 first，得到 %q", link)
+		}
+	})
+
+	// 测试游标分页
+	t.Run("ListUsersCursor", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?mode=cursor&limit=1&sort=-created_at", nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if _, ok := response["has_more"]; !ok {
+			t.Errorf("期望响应包含 has_more，得到 %s", w.Body.String())
+		}
+
+		req, _ = http.NewRequest("GET", "/api/v1/users?after=invalid", nil)
+		w = httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	// 测试旧版 order 参数
+	t.Run("ListUsersLegacyOrder", func(t *testing.T) {
+		list := func(query string) (int, []float64) {
+			req, _ := http.NewRequest("GET", "/api/v1/users?"+query, nil)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			var response struct {
+				Data []struct {
+					ID float64 `json:"id"`
+				} `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			ids := make([]float64, 0, len(response.Data))
+			for _, user := range response.Data {
+				ids = append(ids, user.ID)
+			}
+			return w.Code, ids
+		}
+
+		_, want := list("sort=-created_at&page_size=100")
+		tests := []struct {
+			name     string
+			query    string
+			wantCode int
+			wantIDs  []float64
+		}{
+			{"order=desc 转换为 - 前缀", "sort=created_at&order=desc&page_size=100", http.StatusOK, want},
+			{"无效的排序方向", "order=sideways", http.StatusBadRequest, nil},
+			{"与多个排序字段同时使用", "sort=name,email&order=desc", http.StatusBadRequest, nil},
+			{"与带 - 前缀的 sort 同时使用", "sort=-name&order=asc", http.StatusBadRequest, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				code, ids := list(tt.query)
+				if code != tt.wantCode {
+					t.Fatalf("期望状态码 %d，得到 %d", tt.wantCode, code)
+				}
+				if tt.wantIDs != nil && !slices.Equal(ids, tt.wantIDs) {
+					t.Errorf("期望顺序 %v，得到 %v", tt.wantIDs, ids)
+				}
+			})
+		}
+	})
+
+	// 测试非法排序字段
+	t.Run("ListUsersInvalidSort", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?sort=password", nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	// 测试通用筛选语法
+	t.Run("ListUsersFilter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users?filter=name:like:测试&filter=created_at:gte:2000-01-01&sort=-created_at,name", nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		req, _ = http.NewRequest("GET", "/api/v1/users?filter=password:eq:x&filter=created_at:gte:yesterday", nil)
+		w = httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("期望状态码 %d，得到 %d", http.StatusBadRequest, w.Code)
+		}
+
+		var response struct {
+			Details []map[string]string `json:"details"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Details) != 2 || response.Details[0]["code"] != "UNKNOWN_FIELD" || response.Details[1]["code"] != "INVALID_VALUE" {
+			t.Errorf("错误详情不符合预期: %s", w.Body.String())
+		}
+	})
+
+	// 测试全文检索
+	t.Run("SearchUsers", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v1/users/search?q="+url.QueryEscape("测试"), nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []struct {
+				Highlight struct {
+					Name string `json:"name"`
+				} `json:"highlight"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Data) == 0 || !strings.Contains(response.Data[0].Highlight.Name, "<mark>测试</mark>") {
+			t.Errorf("期望结果中包含高亮的关键字，得到 %s", w.Body.String())
+		}
+
+		req, _ = http.NewRequest("GET", "/api/v1/users/search?q=", nil)
+		w = httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	// 测试部分更新
+	t.Run("PatchUser", func(t *testing.T) {
+		if createdUserID == "" {
+			t.Skip("跳过测试：用户创建失败")
+		}
+
+		patch := func(contentType, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("PATCH", "/api/v1/users/"+createdUserID, strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := patch("application/merge-patch+json", `{"name":"新名字"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response map[string]map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["data"]["name"] != "新名字" || response["data"]["email"] != "test@example.com" {
+			t.Errorf("期望只修改用户名，得到 %s", w.Body.String())
+		}
+
+		if w := patch("application/merge-patch+json", `{"email":null,"name":"x"}`); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("期望状态码 %d，得到 %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		}
+
+		if w := patch("application/json-patch+json", `[{"op":"test","path":"/name","value":"旧名字"},{"op":"replace","path":"/name","value":"再改"}]`); w.Code != http.StatusConflict {
+			t.Errorf("期望状态码 %d，得到 %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+
+		if w := patch("application/json-patch+json", `[{"op":"replace","path":"/email","value":"Patched@Example.com"}]`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "patched@example.com") {
+			t.Errorf("期望邮箱更新并规范化，得到 %d: %s", w.Code, w.Body.String())
+		}
+
+		if w := patch("application/json", `{"name":"x"}`); w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusUnsupportedMediaType, w.Code)
+		}
+	})
+
+	// 测试乐观锁
+	t.Run("OptimisticConcurrency", func(t *testing.T) {
+		if createdUserID == "" {
+			t.Skip("跳过测试：用户创建失败")
+		}
+
+		send := func(method, ifMatch, contentType, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, "/api/v1/users/"+createdUserID, strings.NewReader(body))
+			if ifMatch != "" {
+				req.Header.Set("If-Match", ifMatch)
+			}
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			return w
+		}
+
+		etag := send("GET", "", "", "").Header().Get("ETag")
+		if !strings.HasPrefix(etag, `"`) {
+			t.Fatalf("期望强 ETag，得到 %q", etag)
+		}
+
+		w := send("PATCH", etag, "application/merge-patch+json", `{"name":"乐观锁"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		newETag := w.Header().Get("ETag")
+		if newETag == "" || newETag == etag {
+			t.Fatalf("期望更新后 ETag 变化，旧 %q 新 %q", etag, newETag)
+		}
+
+		tests := []struct {
+			method, ifMatch, contentType, body string
+		}{
+			{"PUT", etag, "application/json", `{"name":"过期版本","email":"test2@example.com"}`},
+			{"PATCH", etag, "application/merge-patch+json", `{"name":"过期版本"}`},
+			{"PATCH", "W/" + newETag, "application/merge-patch+json", `{"name":"弱比较"}`},
+			{"DELETE", etag, "", ""},
+		}
+		for _, tt := range tests {
+			if w := send(tt.method, tt.ifMatch, tt.contentType, tt.body); w.Code != http.StatusPreconditionFailed {
+				t.Errorf("%s If-Match %s 期望状态码 %d，得到 %d", tt.method, tt.ifMatch, http.StatusPreconditionFailed, w.Code)
+			}
+		}
+
+		if w := send("PATCH", `"1", `+newETag, "application/merge-patch+json", `{"name":"列表匹配"}`); w.Code != http.StatusOK {
+			t.Errorf("期望 If-Match 列表中任一版本匹配即可，得到 %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	// 测试条件请求
+	t.Run("ConditionalGet", func(t *testing.T) {
+		if createdUserID == "" {
+			t.Skip("跳过测试：用户创建失败")
+		}
+
+		for _, path := range []string{"/api/v1/users/" + createdUserID, "/api/v1/users?page_size=2"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			etag := w.Header().Get("ETag")
+			if w.Code != http.StatusOK || etag == "" {
+				t.Fatalf("%s 期望返回 ETag，得到 %d %q", path, w.Code, etag)
+			}
+
+			req, _ = http.NewRequest("GET", path, nil)
+			req.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			if w.Code != http.StatusNotModified {
+				t.Errorf("%s 期望状态码 %d，得到 %d", path, http.StatusNotModified, w.Code)
+			}
+		}
+	})
+
+	// 测试已删除用户的恢复和永久删除
+	t.Run("SoftDeleteManagement", func(t *testing.T) {
+		send := func(method, path, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, path, strings.NewReader(body))
+			if body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			req.Header.Set("Authorization", "Bearer "+testAdminToken)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			return w
+		}
+		create := func() string {
+			w := send("POST", "/api/v1/users", `{"name":"回收站","email":"trash@example.com","password":"password123"}`)
+			var response map[string]map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+			}
+			return fmt.Sprintf("%.0f", response["data"]["id"])
+		}
+
+		id := create()
+		for _, authorization := range []string{"", "Bearer wrong-token"} {
+			req, _ := http.NewRequest("POST", "/api/v1/admin/users/purge?older_than_days=0&dry_run=false", nil)
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("缺少或错误的管理令牌应返回 401，得到 %d", w.Code)
+			}
+		}
+		if w := send("DELETE", "/api/v1/admin/users/"+id, ""); w.Code != http.StatusConflict {
+			t.Errorf("未删除的用户不能永久删除，期望 %d，得到 %d", http.StatusConflict, w.Code)
+		}
+		send("DELETE", "/api/v1/users/"+id, "")
+
+		w := send("GET", "/api/v1/admin/users/deleted?page_size=100", "")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"id":`+id+`,`) || !strings.Contains(w.Body.String(), "deleted_at") {
+			t.Errorf("已删除列表应包含用户 %s 和删除时间: %s", id, w.Body.String())
+		}
+
+		// 公开接口不能查询已删除的用户
+		for _, path := range []string{"/api/v1/users?status=deleted", "/api/v1/users?status=all", "/api/v1/users/export?status=all"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), "trash@example.com") {
+				t.Errorf("%s 期望状态码 %d，得到 %d: %s", path, http.StatusBadRequest, w.Code, w.Body.String())
+			}
+		}
+		if w := send("GET", "/api/v1/admin/users/deleted?page_size=100&status=active", ""); !strings.Contains(w.Body.String(), `"id":`+id+`,`) {
+			t.Errorf("管理接口应忽略 status 参数: %s", w.Body.String())
+		}
+
+		// 邮箱已被重新注册时不能恢复
+		otherID := create()
+		if w := send("POST", "/api/v1/admin/users/"+id+"/restore", ""); w.Code != http.StatusConflict {
+			t.Errorf("期望状态码 %d，得到 %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+		send("DELETE", "/api/v1/users/"+otherID, "")
+		if w := send("POST", "/api/v1/admin/users/"+id+"/restore", ""); w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := send("GET", "/api/v1/users/"+id, ""); w.Code != http.StatusOK {
+			t.Errorf("恢复后应能获取用户，得到 %d", w.Code)
+		}
+
+		// 将删除时间提前，模拟超过保留期
+		send("DELETE", "/api/v1/users/"+id, "")
+		app.DB.GetGormDB().Exec("UPDATE users SET deleted_at = ? WHERE id = ?", time.Now().AddDate(0, 0, -40), id)
+
+		var report struct {
+			Data struct {
+				DryRun bool `json:"dry_run"`
+				Count  int  `json:"count"`
+			} `json:"data"`
+		}
+		w = send("POST", "/api/v1/admin/users/purge?older_than_days=30", "")
+		json.Unmarshal(w.Body.Bytes(), &report)
+		if w.Code != http.StatusOK || !report.Data.DryRun || report.Data.Count < 1 {
+			t.Errorf("期望预览至少 1 个用户，得到 %d %s", w.Code, w.Body.String())
+		}
+		if w := send("GET", "/api/v1/admin/users/deleted?page_size=100", ""); !strings.Contains(w.Body.String(), `"id":`+id+`,`) {
+			t.Error("dry-run 不应删除用户")
+		}
+
+		w = send("POST", "/api/v1/admin/users/purge?older_than_days=30&dry_run=false", "")
+		json.Unmarshal(w.Body.Bytes(), &report)
+		if w.Code != http.StatusOK || report.Data.DryRun || report.Data.Count < 1 {
+			t.Errorf("期望永久删除至少 1 个用户，得到 %d %s", w.Code, w.Body.String())
+		}
+		if w := send("POST", "/api/v1/admin/users/"+id+"/restore", ""); w.Code != http.StatusNotFound {
+			t.Errorf("永久删除后不能恢复，期望 %d，得到 %d", http.StatusNotFound, w.Code)
+		}
+
+		if w := send("DELETE", "/api/v1/admin/users/"+otherID, ""); w.Code != http.StatusOK {
+			t.Errorf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w := send("POST", "/api/v1/admin/users/purge", ""); w.Code != http.StatusBadRequest {
+			t.Errorf("缺少 older_than_days 时期望 %d，得到 %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	// 测试批量导入
+	t.Run("ImportUsers", func(t *testing.T) {
+		type importReport struct {
+			Total, Created, Updated, Skipped, Failed int
+			Aborted                                  bool
+			Rows                                     []struct {
+				Row    int
+				Status string
+				Code   string
+				ID     int
+			}
+		}
+		importUsers := func(query, contentType, body string) (int, importReport) {
+			req, _ := http.NewRequest("POST", "/api/v1/users/import"+query, strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			var response struct{ Data importReport }
+			json.Unmarshal(w.Body.Bytes(), &response)
+			return w.Code, response.Data
+		}
+
+		csv := "name,email,password\n" +
+			"导入一,import1@example.com,password123\n" +
+			"导入二,import2@example.com,123\n" +
+			"导入三,IMPORT1@example.com,password123\n" +
+			"导入四,import0@example.com,password123\n" +
+			"导入五,import5@example.com,password123\n"
+
+		// 已存在的用户
+		importUsers("", "application/x-ndjson", `{"name":"导入零","email":"import0@example.com","password":"password123"}`)
+
+		code, report := importUsers("?mode=skip-invalid&dry_run=true", "text/csv", csv)
+		if code != http.StatusOK || report.Created != 2 || report.Skipped != 3 || report.Rows[0].ID != 0 {
+			t.Fatalf("dry-run 期望预计创建 2 行跳过 3 行，得到 %d %+v", code, report)
+		}
+
+		code, report = importUsers("?mode=skip-invalid", "text/csv; charset=utf-8", csv)
+		if code != http.StatusOK || report.Created != 2 || report.Skipped != 3 {
+			t.Fatalf("期望创建 2 行跳过 3 行，得到 %d %+v", code, report)
+		}
+		wantCodes := []string{"", "INVALID_PASSWORD", "DUPLICATE_IN_FILE", "EMAIL_EXISTS", ""}
+		for i, row := range report.Rows {
+			if row.Row != i+2 || row.Code != wantCodes[i] {
+				t.Errorf("第 %d 行期望错误码 %q，得到 %+v", i+2, wantCodes[i], row)
+			}
+		}
+		if report.Rows[0].Status != "created" || report.Rows[0].ID == 0 {
+			t.Errorf("期望返回创建的用户 ID，得到 %+v", report.Rows[0])
+		}
+
+		ndjson := `{"name":"导入一改","email":"import1@example.com"}` + "\n" +
+			`{"name":"导入五","email":"import5@example.com"}` + "\n" +
+			`{"name":"导入六","email":"import6@example.com"}` + "\n" +
+			`{"name":"导入七","email":"import7@example.com","password":"password123"}` + "\n"
+		code, report = importUsers("?mode=upsert", "application/x-ndjson", ndjson)
+		if code != http.StatusOK || report.Updated != 1 || report.Created != 1 || report.Skipped != 2 {
+			t.Fatalf("upsert 期望更新 1 行创建 1 行跳过 2 行，得到 %d %+v", code, report)
+		}
+		if report.Rows[1].Code != "UNCHANGED" || report.Rows[2].Code != "INVALID_PASSWORD" {
+			t.Errorf("期望未变化和缺少密码的行被跳过，得到 %+v", report.Rows)
+		}
+
+		// fail-fast 遇到无效行时回滚之前写入的行
+		code, report = importUsers("", "text/csv", "name,email,password\n导入八,import8@example.com,password123\n坏,bad,password123\n导入九,import9@example.com,password123\n")
+		if code != http.StatusUnprocessableEntity || !report.Aborted || report.Created != 0 || report.Skipped != 1 || report.Failed != 1 || report.Total != 2 {
+			t.Errorf("fail-fast 期望停止并回滚，得到 %d %+v", code, report)
+		}
+		if row := report.Rows[0]; row.Status != "skipped" || row.Code != "ABORTED" || row.ID != 0 {
+			t.Errorf("已回滚的行应标记为 ABORTED，得到 %+v", row)
+		}
+		var count int64
+		app.DB.GetGormDB().Model(&models.UserModel{}).Where("email = ?", "import8@example.com").Count(&count)
+		if count != 0 {
+			t.Errorf("fail-fast 停止后不应写入任何行，得到 %d 条", count)
+		}
+
+		if code, _ := importUsers("?mode=replace", "text/csv", csv); code != http.StatusBadRequest {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusBadRequest, code)
+		}
+		if code, _ := importUsers("", "application/xml", "<users/>"); code != http.StatusUnsupportedMediaType {
+			t.Errorf("期望状态码 %d，得到 %d", http.StatusUnsupportedMediaType, code)
+		}
+	})
+
+	t.Run("ExportUsers", func(t *testing.T) {
+		exportUsers := func(query string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/api/v1/users/export"+query, nil)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := exportUsers("?name=导入")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") ||
+			!strings.Contains(w.Header().Get("Content-Disposition"), `.csv"`) {
+			t.Errorf("期望 CSV 附件响应头，得到 %v", w.Header())
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if lines[0] != "id,name,email,created_at" || len(lines) < 2 {
+			t.Fatalf("期望默认列表头和数据行，得到 %q", w.Body.String())
+		}
+
+		w = exportUsers("?format=ndjson&columns=email,name&email_domain=example.com&name=导入一")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码 %d，得到 %d", http.StatusOK, w.Code)
+		}
+		if got := strings.TrimSpace(w.Body.String()); got != `{"email":"import1@example.com","name":"导入一改"}` {
+			t.Errorf("期望按列顺序导出筛选结果，得到 %q", got)
+		}
+
+		w = exportUsers("?format=json&name=不存在的用户")
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+			t.Errorf("期望空数组，得到 %d %q", w.Code, w.Body.String())
+		}
+
+		for _, query := range []string{"?columns=password", "?format=xml", "?status=unknown"} {
+			if w := exportUsers(query); w.Code != http.StatusBadRequest {
+				t.Errorf("%s 期望状态码 %d，得到 %d", query, http.StatusBadRequest, w.Code)
+			}
+		}
+	})
+
+	t.Run("DomainEvents", func(t *testing.T) {
+		var received []string
+		unsubscribe := app.EventBus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+			received = append(received, e.EventName())
+			return nil
+		})
+		defer unsubscribe()
+
+		send := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", "Bearer "+testAdminToken)
+			w := httptest.NewRecorder()
+			app.Router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := send("POST", "/api/v1/users", "application/json", `{"name":"事件用户","email":"event@example.com","password":"password123"}`)
+		var response struct{ Data struct{ ID int } }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		path := fmt.Sprintf("/api/v1/users/%d", response.Data.ID)
+
+		send("PATCH", path, "application/merge-patch+json", `{"name":"事件用户二","email":"event2@example.com"}`)
+		send("PATCH", path, "application/merge-patch+json", `{"name":"事件用户二"}`)
+		send("DELETE", path, "", "")
+
+		// 管理接口的恢复和永久删除同样发布事件
+		adminPath := fmt.Sprintf("/api/v1/admin/users/%d", response.Data.ID)
+		if w := send("POST", adminPath+"/restore", "", ""); w.Code != http.StatusOK {
+			t.Fatalf("恢复用户失败: %d %s", w.Code, w.Body.String())
+		}
+		send("DELETE", path, "", "")
+		if w := send("DELETE", adminPath, "", ""); w.Code != http.StatusOK {
+			t.Fatalf("永久删除用户失败: %d %s", w.Code, w.Body.String())
+		}
+
+		want := []string{"user.created", "user.renamed", "user.email_changed", "user.deleted", "user.restored", "user.deleted", "user.purged"}
+		if strings.Join(received, ",") != strings.Join(want, ",") {
+			t.Errorf("期望事件 %v，得到 %v", want, received)
+		}
+	})
+
 	// 测试获取不存在的用户
 	t.Run("GetUser", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/api/v1/users/999", nil)
@@ -97,3 +673,1585 @@ func TestUserAPI(t *testing.T) {
 		}
 	})
 }
+
+func TestOutboxRelay(t *testing.T) {
+	t.Run("无效的中继配置无法启动", func(t *testing.T) {
+		for _, name := range []string{"OUTBOX_BATCH_SIZE", "OUTBOX_POLL_INTERVAL_MS", "OUTBOX_LEASE_SECONDS"} {
+			t.Setenv("OUTBOX_ENABLED", "true")
+			t.Setenv("OUTBOX_SINK", "memory")
+			t.Setenv(name, "0")
+			if _, cleanup, err := wire.InitializeApp(); !errors.Is(err, outbox.ErrInvalidRelayConfig) {
+				if cleanup != nil {
+					cleanup()
+				}
+				t.Errorf("%s=0 应启动失败，得到 %v", name, err)
+			}
+			t.Setenv(name, "1")
+		}
+	})
+
+	outboxFile := filepath.Join(t.TempDir(), "outbox.jsonl")
+	t.Setenv("OUTBOX_ENABLED", "true")
+	t.Setenv("OUTBOX_SINK", "file")
+	t.Setenv("OUTBOX_FILE_PATH", outboxFile)
+
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+	outboxRows := func(aggregateID string) []models.OutboxMessageModel {
+		var rows []models.OutboxMessageModel
+		app.DB.GetGormDB().Where("aggregate_type = ? AND aggregate_id = ?", "user", aggregateID).Order("id").Find(&rows)
+		return rows
+	}
+	drain := func(relay *outbox.Relay) {
+		for {
+			n, err := relay.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("投递失败: %v", err)
+			}
+			if n == 0 {
+				return
+			}
+		}
+	}
+
+	email := fmt.Sprintf("outbox%d@example.com", time.Now().UnixNano())
+	w := send("POST", "/api/v1/users", `{"name":"发件箱","email":"`+email+`","password":"password123"}`, nil)
+	var response struct{ Data struct{ ID int } }
+	json.Unmarshal(w.Body.Bytes(), &response)
+	id := strconv.Itoa(response.Data.ID)
+
+	// 版本冲突回滚的修改不写入发件箱
+	send("PUT", "/api/v1/users/"+id, `{"name":"冲突","email":"`+email+`"}`, map[string]string{"If-Match": `"99"`})
+	rows := outboxRows(id)
+	if len(rows) != 1 || rows[0].EventName != "user.created" || rows[0].Status != models.OutboxStatusPending {
+		t.Fatalf("期望 1 条待投递的 user.created 消息，得到 %+v", rows)
+	}
+
+	drain(app.OutboxRelay)
+	if rows := outboxRows(id); rows[0].Status != models.OutboxStatusDelivered || rows[0].DeliveredAt == nil {
+		t.Errorf("期望消息已投递，得到 %+v", rows[0])
+	}
+	content, _ := os.ReadFile(outboxFile)
+	if !strings.Contains(string(content), `"type":"user.created","aggregate_type":"user","aggregate_id":"`+id+`"`) {
+		t.Errorf("输出文件中缺少消息: %s", content)
+	}
+
+	// 投递目标持续失败，超过最大次数后进入死信
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	t.Setenv("OUTBOX_SINK", "http")
+	t.Setenv("OUTBOX_HTTP_URL", failing.URL)
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "2")
+	t.Setenv("OUTBOX_RETRY_BACKOFF_SECONDS", "0")
+	failingApp, failingCleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer failingCleanup()
+
+	send("DELETE", "/api/v1/users/"+id, "", nil)
+	drain(failingApp.OutboxRelay)
+	rows = outboxRows(id)
+	if len(rows) != 2 || rows[1].EventName != "user.deleted" {
+		t.Fatalf("期望写入 user.deleted 消息，得到 %+v", rows)
+	}
+	if rows[1].Status != models.OutboxStatusDead || rows[1].Attempts != 2 || !strings.Contains(rows[1].LastError, "500") {
+		t.Errorf("期望重试 2 次后进入死信，得到 %+v", rows[1])
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "5")
+	t.Setenv("WEBHOOK_RETRY_BACKOFF_SECONDS", "0")
+	t.Setenv("WEBHOOK_DISABLE_AFTER_FAILURES", "2")
+	t.Setenv("WEBHOOK_ALLOWED_NETWORKS", "127.0.0.0/8")
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	// 接收方校验签名，failing 为 true 时返回 500
+	var mu sync.Mutex
+	var secret string
+	var failing bool
+	var received []*http.Request
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := webhooksig.Verify(r.Header, secret, body, time.Now(), 5*time.Minute); err != nil {
+			t.Errorf("签名校验失败: %v", err)
+		}
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("boom"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+	drain := func() {
+		for {
+			n, err := app.WebhookJob.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("投递失败: %v", err)
+			}
+			if n == 0 {
+				return
+			}
+		}
+	}
+
+	for _, body := range []string{
+		`{"url":"ftp://example.com","events":["user.created"]}`,
+		`{"url":"` + receiver.URL + `","events":["user.unknown"]}`,
+		`{"url":"` + receiver.URL + `","events":["user.created"],"secret":"short"}`,
+		`{"url":"http://169.254.169.254/latest/meta-data/","events":["user.created"]}`,
+		`{"url":"http://[::ffff:10.0.0.1]:8080/hook","events":["user.created"]}`,
+	} {
+		if w := send("POST", "/api/v1/webhooks", body); w.Code != http.StatusBadRequest {
+			t.Errorf("无效订阅 %s 期望状态码 400，得到 %d", body, w.Code)
+		}
+	}
+
+	w := send("POST", "/api/v1/webhooks", `{"url":"`+receiver.URL+`","events":["user.created","user.deleted"],"description":"合作方"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建订阅期望状态码 201，得到 %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID     int    `json:"id"`
+			Secret string `json:"secret"`
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if !strings.HasPrefix(created.Data.Secret, "whsec_") {
+		t.Fatalf("创建响应中缺少签名密钥: %s", w.Body.String())
+	}
+	secret = created.Data.Secret
+	base := "/api/v1/webhooks/" + strconv.Itoa(created.Data.ID)
+	defer send("DELETE", base, "")
+
+	if w := send("GET", base, ""); strings.Contains(w.Body.String(), secret) {
+		t.Errorf("查询订阅不应返回签名密钥: %s", w.Body.String())
+	}
+
+	type deliveryDetail struct {
+		ID       int             `json:"id"`
+		Status   string          `json:"status"`
+		Attempts int             `json:"attempts"`
+		Payload  json.RawMessage `json:"payload"`
+		History  []struct {
+			RequestHeaders map[string]string `json:"request_headers"`
+			ResponseStatus int               `json:"response_status"`
+			ResponseBody   string            `json:"response_body"`
+			Error          string            `json:"error"`
+		} `json:"history"`
+	}
+	getDelivery := func(id int) deliveryDetail {
+		w := send("GET", base+"/deliveries/"+strconv.Itoa(id), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("查询投递详情期望状态码 200，得到 %d: %s", w.Code, w.Body.String())
+		}
+		var response struct{ Data deliveryDetail }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+	listDeliveries := func() []deliveryDetail {
+		var response struct {
+			Total int64            `json:"total"`
+			Data  []deliveryDetail `json:"data"`
+		}
+		json.Unmarshal(send("GET", base+"/deliveries", "").Body.Bytes(), &response)
+		return response.Data
+	}
+
+	// 创建用户：投递签名请求，改名事件未订阅不投递
+	email := fmt.Sprintf("webhook%d@example.com", time.Now().UnixNano())
+	w = send("POST", "/api/v1/users", `{"name":"回调","email":"`+email+`","password":"password123"}`)
+	var user struct{ Data struct{ ID int } }
+	json.Unmarshal(w.Body.Bytes(), &user)
+	userID := strconv.Itoa(user.Data.ID)
+	send("PUT", "/api/v1/users/"+userID, `{"name":"回调改名","email":"`+email+`"}`)
+	drain()
+
+	mu.Lock()
+	if len(received) != 1 || received[0].Header.Get("X-Webhook-Event") != "user.created" {
+		t.Fatalf("期望收到 1 个 user.created 请求，得到 %d 个", len(received))
+	}
+	var payload struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			UserID int    `json:"user_id"`
+			Email  string `json:"email"`
+		} `json:"data"`
+	}
+	json.Unmarshal(bodies[0], &payload)
+	if payload.ID == "" || payload.ID != received[0].Header.Get("X-Webhook-Event-ID") || payload.Type != "user.created" || payload.Data.Email != email {
+		t.Errorf("请求体错误: %s", bodies[0])
+	}
+	mu.Unlock()
+
+	deliveries := listDeliveries()
+	if len(deliveries) != 1 || deliveries[0].Status != "succeeded" || deliveries[0].Attempts != 1 {
+		t.Fatalf("期望 1 条成功的投递，得到 %+v", deliveries)
+	}
+	detail := getDelivery(deliveries[0].ID)
+	if len(detail.History) != 1 || detail.History[0].ResponseStatus != 200 || detail.History[0].ResponseBody != "ok" ||
+		detail.History[0].RequestHeaders[webhooksig.HeaderSignature] == "" || !strings.Contains(string(detail.Payload), email) {
+		t.Errorf("投递详情错误: %+v", detail)
+	}
+
+	// 事务内处理器失败时用户变更与投递记录一起回滚
+	unsubscribe := app.EventBus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		return errors.New("事务内处理失败")
+	}, event.InTransaction())
+	rollbackEmail := "rollback-" + email
+	if w := send("POST", "/api/v1/users", `{"name":"回滚","email":"`+rollbackEmail+`","password":"password123"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("事务内处理器失败时期望状态码 400，得到 %d: %s", w.Code, w.Body.String())
+	}
+	unsubscribe()
+	if w := send("GET", "/api/v1/users?name=回滚", ""); strings.Contains(w.Body.String(), rollbackEmail) {
+		t.Errorf("回滚后不应存在该用户: %s", w.Body.String())
+	}
+	if deliveries := listDeliveries(); len(deliveries) != 1 {
+		t.Errorf("回滚后不应创建投递记录，得到 %+v", deliveries)
+	}
+
+	// 接收方持续失败：连续失败 2 次后订阅自动停用，投递停止重试
+	mu.Lock()
+	failing = true
+	mu.Unlock()
+	send("DELETE", "/api/v1/users/"+userID, "")
+	drain()
+
+	var subscription struct {
+		Data struct {
+			Active              bool   `json:"active"`
+			ConsecutiveFailures int    `json:"consecutive_failures"`
+			DisabledReason      string `json:"disabled_reason"`
+		}
+	}
+	json.Unmarshal(send("GET", base, "").Body.Bytes(), &subscription)
+	if subscription.Data.Active || subscription.Data.ConsecutiveFailures != 2 || subscription.Data.DisabledReason == "" {
+		t.Fatalf("期望订阅已自动停用，得到 %+v", subscription.Data)
+	}
+
+	deliveries = listDeliveries()
+	if len(deliveries) != 2 || deliveries[0].Status != "pending" || deliveries[0].Attempts != 2 {
+		t.Fatalf("期望最新的投递尝试 2 次后暂停，得到 %+v", deliveries)
+	}
+	failed := deliveries[0].ID
+	detail = getDelivery(failed)
+	if len(detail.History) != 2 || detail.History[1].ResponseStatus != 500 || detail.History[1].ResponseBody != "boom" || detail.History[1].Error != "HTTP 500" {
+		t.Errorf("失败投递的历史记录错误: %+v", detail.History)
+	}
+
+	// 停用期间不能重新投递，重新启用后手动重新投递成功
+	if w := send("POST", base+"/deliveries/"+strconv.Itoa(failed)+"/redeliver", ""); w.Code != http.StatusConflict {
+		t.Errorf("订阅停用时重新投递期望状态码 409，得到 %d", w.Code)
+	}
+	json.Unmarshal(send("PATCH", base, `{"active":true}`).Body.Bytes(), &subscription)
+	if !subscription.Data.Active || subscription.Data.ConsecutiveFailures != 0 {
+		t.Fatalf("期望订阅已重新启用，得到 %+v", subscription.Data)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	if w := send("POST", base+"/deliveries/"+strconv.Itoa(failed)+"/redeliver", ""); w.Code != http.StatusAccepted {
+		t.Fatalf("重新投递期望状态码 202，得到 %d: %s", w.Code, w.Body.String())
+	}
+	drain()
+
+	detail = getDelivery(failed)
+	if detail.Status != "succeeded" || detail.Attempts != 3 || len(detail.History) != 3 {
+		t.Errorf("重新投递后期望成功，得到 %+v", detail)
+	}
+	if w := send("GET", base+"/deliveries/999999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的投递期望状态码 404，得到 %d", w.Code)
+	}
+}
+
+// sseFrame 事件流中的一帧，注释行（心跳）的 Comment 为 true
+type sseFrame struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment bool
+}
+
+// readSSE 在后台读取事件流，按帧发送到返回的通道
+func readSSE(t *testing.T, body io.Reader) <-chan sseFrame {
+	frames := make(chan sseFrame, 64)
+	go func() {
+		defer close(frames)
+		scanner := bufio.NewScanner(body)
+		var frame sseFrame
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if frame != (sseFrame{}) {
+					frames <- frame
+				}
+				frame = sseFrame{}
+			case strings.HasPrefix(line, ":"):
+				frame.Comment = true
+			case strings.HasPrefix(line, "id:"):
+				frame.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+			case strings.HasPrefix(line, "event:"):
+				frame.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				frame.Data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+	}()
+	return frames
+}
+
+func TestUserEventStream(t *testing.T) {
+	t.Run("未配置令牌时关闭", func(t *testing.T) {
+		t.Setenv("EVENT_STREAM_TOKENS", "")
+		app, cleanup, err := wire.InitializeApp()
+		if err != nil {
+			t.Fatalf("初始化应用失败: %v", err)
+		}
+		defer cleanup()
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/users/events?access_token=anything", nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("期望状态码 403，得到 %d", w.Code)
+		}
+	})
+
+	t.Setenv("EVENT_STREAM_TOKENS", "dashboard-token:*,audit-token:user.deleted")
+	t.Setenv("EVENT_STREAM_HEARTBEAT_SECONDS", "1")
+
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+	server := httptest.NewServer(app.Router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	open := func(query string, headers map[string]string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/api/v1/users/events"+query, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("连接事件流失败: %v", err)
+		}
+		return resp
+	}
+	next := func(frames <-chan sseFrame, skipHeartbeat bool) sseFrame {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case frame, ok := <-frames:
+				if !ok {
+					t.Fatal("事件流已断开")
+				}
+				if frame.Comment && skipHeartbeat || frame.ID == "" && frame.Event == "" && !frame.Comment {
+					continue
+				}
+				return frame
+			case <-timeout:
+				t.Fatal("等待事件超时")
+			}
+		}
+	}
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Auth", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			query   string
+			headers map[string]string
+			want    int
+		}{
+			{"缺少令牌", "", nil, http.StatusUnauthorized},
+			{"无效令牌", "?access_token=wrong", nil, http.StatusUnauthorized},
+			{"请求无权读取的事件", "?types=user.created", map[string]string{"Authorization": "Bearer audit-token"}, http.StatusForbidden},
+			{"不支持的事件类型", "?types=user.unknown&access_token=dashboard-token", nil, http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp := open(tt.query, tt.headers)
+				resp.Body.Close()
+				if resp.StatusCode != tt.want {
+					t.Errorf("期望状态码 %d，得到 %d", tt.want, resp.StatusCode)
+				}
+			})
+		}
+	})
+
+	dashboard := open("?types=user.created,user.deleted", map[string]string{"Authorization": "Bearer dashboard-token"})
+	defer dashboard.Body.Close()
+	if dashboard.StatusCode != http.StatusOK || dashboard.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("期望事件流响应，得到 %d %s", dashboard.StatusCode, dashboard.Header.Get("Content-Type"))
+	}
+	dashboardFrames := readSSE(t, dashboard.Body)
+	audit := open("?access_token=audit-token", nil)
+	defer audit.Body.Close()
+	auditFrames := readSSE(t, audit.Body)
+
+	// 创建、改名、删除用户：改名未订阅，不推送；审计令牌只能读取删除事件
+	email := fmt.Sprintf("stream%d@example.com", time.Now().UnixNano())
+	w := send("POST", "/api/v1/users", `{"name":"推送","email":"`+email+`","password":"password123"}`)
+	var user struct{ Data struct{ ID int } }
+	json.Unmarshal(w.Body.Bytes(), &user)
+	id := strconv.Itoa(user.Data.ID)
+	send("PUT", "/api/v1/users/"+id, `{"name":"推送改名","email":"`+email+`"}`)
+	send("DELETE", "/api/v1/users/"+id, "")
+
+	created := next(dashboardFrames, true)
+	if created.Event != "user.created" || created.ID == "" || !strings.Contains(created.Data, email) {
+		t.Fatalf("期望收到 user.created，得到 %+v", created)
+	}
+	deleted := next(dashboardFrames, true)
+	if deleted.Event != "user.deleted" || !strings.Contains(deleted.Data, `"user_id":`+id) {
+		t.Fatalf("期望收到 user.deleted，得到 %+v", deleted)
+	}
+	if frame := next(auditFrames, true); frame.Event != "user.deleted" || frame.ID != deleted.ID {
+		t.Errorf("审计令牌期望只收到 user.deleted，得到 %+v", frame)
+	}
+	if frame := next(auditFrames, false); !frame.Comment {
+		t.Errorf("期望收到心跳，得到 %+v", frame)
+	}
+
+	// 携带 Last-Event-ID 重连，补发之后的事件
+	resumed := open("?types=user.created,user.deleted", map[string]string{"Authorization": "Bearer dashboard-token", "Last-Event-ID": created.ID})
+	defer resumed.Body.Close()
+	if frame := next(readSSE(t, resumed.Body), true); frame.Event != "user.deleted" || frame.ID != deleted.ID {
+		t.Errorf("期望补发 user.deleted，得到 %+v", frame)
+	}
+
+	// 无法续传时先发送 reset
+	stale := open("?access_token=dashboard-token&last_event_id=stale-1", nil)
+	defer stale.Body.Close()
+	if frame := next(readSSE(t, stale.Body), true); frame.Event != "reset" {
+		t.Errorf("期望收到 reset，得到 %+v", frame)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Setenv("HTTP_CACHE_STORE", "true")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	do := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/users", []byte(`{"name":"指标用户","email":"metrics@example.com","password":"password123"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	userPath := fmt.Sprintf("/api/v1/users/%d", created.Data.ID)
+
+	// 第一次未命中缓存，第二次命中
+	do("GET", userPath, nil)
+	do("GET", userPath, nil)
+	do("GET", "/api/v1/users/99999999", nil)
+	do("GET", "/no/such/path", nil)
+
+	w = do("GET", "/metrics", nil)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("指标端点响应错误: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	out := w.Body.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"按路由模板统计", `http_requests_total{method="GET",route="/api/v1/users/:id",status="200"} 2`},
+		{"按状态码区分", `http_requests_total{method="GET",route="/api/v1/users/:id",status="404"} 1`},
+		{"未匹配的路由", `http_requests_total{method="GET",route="unmatched",status="404"} 1`},
+		{"请求耗时直方图", `http_request_duration_seconds_count{method="POST",route="/api/v1/users",status="201"} 1`},
+		{"数据库查询耗时", `db_query_duration_seconds_count{operation="create",table="users"}`},
+		{"连接池状态", "db_open_connections "},
+		{"缓存命中", `cache_requests_total{cache="response",result="hit"} 1`},
+		{"缓存未命中", `cache_requests_total{cache="response",result="miss"}`},
+		{"缓存条数", `cache_entries{cache="response"}`},
+		{"运行时指标", "go_goroutines "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("指标中缺少 %q", tt.want)
+			}
+		})
+	}
+	if strings.Contains(out, "/no/such/path") || strings.Contains(out, userPath+`"`) {
+		t.Error("route 标签不应包含原始路径")
+	}
+}
+
+func TestResponseCacheInvalidation(t *testing.T) {
+	t.Setenv("HTTP_CACHE_STORE", "true")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	do := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/users", []byte(`{"name":"缓存用户","email":"cache-event@example.com","password":"password123"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	userPath := fmt.Sprintf("/api/v1/users/%d", created.Data.ID)
+
+	tests := []struct {
+		name  string
+		event event.Event
+	}{
+		{"用户改名", entity.UserRenamed{UserID: created.Data.ID}},
+		{"邮箱变更", entity.UserEmailChanged{UserID: created.Data.ID}},
+		{"用户删除", entity.UserDeleted{UserID: created.Data.ID}},
+		{"用户恢复", entity.UserRestored{UserID: created.Data.ID}},
+		{"永久删除", entity.UserPurged{UserID: created.Data.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{userPath, "/api/v1/users"} {
+				do("GET", path, nil)
+				if got := do("GET", path, nil).Header().Get("X-Cache"); got != "HIT" {
+					t.Fatalf("%s 期望命中缓存，得到 %q", path, got)
+				}
+			}
+
+			// 不经过 HTTP 路由发布的事件同样使缓存失效
+			if err := app.EventBus.Publish(context.Background(), tt.event); err != nil {
+				t.Fatalf("发布事件失败: %v", err)
+			}
+			for _, path := range []string{userPath, "/api/v1/users"} {
+				if got := do("GET", path, nil).Header().Get("X-Cache"); got != "MISS" {
+					t.Errorf("%s 期望事件后缓存失效，得到 %q", path, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	type otlpSpan struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Kind         int    `json:"kind"`
+		Attributes   []struct {
+			Key   string                 `json:"key"`
+			Value map[string]interface{} `json:"value"`
+		} `json:"attributes"`
+	}
+	var (
+		mu    sync.Mutex
+		spans []otlpSpan
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+
+	t.Setenv("TRACING_ENABLED", "true")
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("TRACING_OTLP_ENDPOINT", collector.URL+"/v1/traces")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	do := func(method, path, traceparent string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if traceparent != "" {
+			req.Header.Set("traceparent", traceparent)
+		}
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/users", "00-"+traceID+"-00f067aa0ba902b7-01",
+		[]byte(`{"name":"追踪用户","email":"tracing@example.com","password":"password123"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	// 采样比例为 0，没有上游链路或上游未采样的请求不记录
+	do("GET", "/api/v1/users", "", nil)
+	do("GET", "/api/v1/users", "00-"+strings.Repeat("a", 32)+"-00f067aa0ba902b7-00", nil)
+
+	cleanup()
+
+	mu.Lock()
+	defer mu.Unlock()
+	byName := map[string]otlpSpan{}
+	for _, span := range spans {
+		if span.TraceID != traceID {
+			t.Errorf("不应记录未采样的链路: %s %s", span.TraceID, span.Name)
+		}
+		byName[span.Name] = span
+	}
+
+	server, ok := byName["POST /api/v1/users"]
+	if !ok || server.ParentSpanID != "00f067aa0ba902b7" || server.Kind != 2 {
+		t.Fatalf("server span 应以上游 span 为父 span: %+v", spans)
+	}
+	service, ok := byName["UserService.CreateUser"]
+	if !ok || service.ParentSpanID != server.SpanID {
+		t.Fatalf("缺少服务 span 或父子关系错误: %+v", service)
+	}
+	save, ok := byName["UserRepository.Save"]
+	if !ok || save.ParentSpanID != service.SpanID {
+		t.Fatalf("缺少仓储 span 或父子关系错误: %+v", save)
+	}
+
+	var insert *otlpSpan
+	for i, span := range spans {
+		if strings.HasPrefix(span.Name, "INSERT") && span.ParentSpanID == save.SpanID {
+			insert = &spans[i]
+		}
+	}
+	if insert == nil || insert.Kind != 3 {
+		t.Fatalf("缺少 SQL span: %+v", spans)
+	}
+	var text string
+	for _, attr := range insert.Attributes {
+		if attr.Key == "db.query.text" {
+			text, _ = attr.Value["stringValue"].(string)
+		}
+	}
+	if !strings.Contains(text, "INSERT INTO") || strings.Contains(text, "tracing@example.com") {
+		t.Errorf("SQL 语句缺失或未脱敏: %q", text)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	type report struct {
+		Status string `json:"status"`
+		Checks []struct {
+			Name      string   `json:"name"`
+			Status    string   `json:"status"`
+			LatencyMs *float64 `json:"latency_ms"`
+			Error     string   `json:"error"`
+		} `json:"checks"`
+	}
+	get := func(path string) (int, report) {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		var r report
+		if err := json.Unmarshal(w.Body.Bytes(), &r); err != nil {
+			t.Fatalf("%s 响应不是 JSON: %s", path, w.Body.String())
+		}
+		return w.Code, r
+	}
+
+	// 后台任务运行期间注册心跳
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go app.WebhookJob.Start(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, live := get("/livez?verbose")
+		if len(live.Checks) > 0 && live.Checks[0].Name == "job:webhook_delivery" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("存活检查中没有后台任务心跳: %+v", live)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantCode   int
+		wantChecks []string
+	}{
+		{"存活检查", "/livez", http.StatusOK, nil},
+		{"就绪检查", "/readyz", http.StatusOK, nil},
+		{"旧健康检查路径", "/health", http.StatusOK, nil},
+		{"详细检查结果", "/healthz?verbose", http.StatusOK, []string{"database", "disk", "job:webhook_delivery", "migrations"}},
+		{"详细存活检查只包含心跳", "/livez?verbose=1", http.StatusOK, []string{"job:webhook_delivery"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, r := get(tt.path)
+			if code != tt.wantCode || r.Status != "ok" {
+				t.Fatalf("期望 %d ok，得到 %d %+v", tt.wantCode, code, r)
+			}
+			if tt.wantChecks == nil && r.Checks != nil {
+				t.Errorf("未带 verbose 时不应返回检查详情: %+v", r.Checks)
+			}
+			if tt.wantChecks == nil {
+				return
+			}
+			if len(r.Checks) != len(tt.wantChecks) {
+				t.Fatalf("期望检查 %v，得到 %+v", tt.wantChecks, r.Checks)
+			}
+			for i, name := range tt.wantChecks {
+				check := r.Checks[i]
+				if check.Name != name || check.Status != "ok" || check.LatencyMs == nil {
+					t.Errorf("检查 %s 结果错误: %+v", name, check)
+				}
+			}
+		})
+	}
+
+	// 任务退出后移除心跳
+	stop()
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		if _, live := get("/livez?verbose"); len(live.Checks) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("任务退出后心跳检查未移除")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 关闭期间就绪检查失败，存活检查不受影响
+	app.Health.SetShuttingDown()
+	if code, r := get("/readyz?verbose"); code != http.StatusServiceUnavailable || r.Status != "fail" || r.Checks[0].Name != "shutdown" {
+		t.Errorf("关闭期间就绪检查应返回 503，得到 %d %+v", code, r)
+	}
+	if code, _ := get("/livez"); code != http.StatusOK {
+		t.Errorf("关闭期间存活检查应返回 200，得到 %d", code)
+	}
+
+	// 数据库不可用时就绪检查失败，缓存过期后重新检查
+	app.DB.Close()
+	time.Sleep(time.Duration(app.Config.Health.CacheTTLMs) * time.Millisecond)
+	code, r := get("/healthz?verbose")
+	if code != http.StatusServiceUnavailable || r.Status != "fail" {
+		t.Errorf("数据库关闭后应返回 503，得到 %d %+v", code, r)
+	}
+	// 业务端口不返回错误详情，只写日志
+	for _, check := range r.Checks {
+		if check.Name == "database" && check.Status != "fail" {
+			t.Errorf("数据库检查应失败: %+v", check)
+		}
+		if check.Error != "" {
+			t.Errorf("检查 %s 不应返回错误详情: %s", check.Name, check.Error)
+		}
+	}
+}
+
+func TestAdminServer(t *testing.T) {
+	t.Run("未配置令牌时拒绝非回环地址", func(t *testing.T) {
+		t.Setenv("ADMIN_ENABLED", "true")
+		t.Setenv("ADMIN_ADDR", "0.0.0.0:6060")
+		if _, cleanup, err := wire.InitializeApp(); !errors.Is(err, admin.ErrUnprotected) {
+			if cleanup != nil {
+				cleanup()
+			}
+			t.Fatalf("期望 ErrUnprotected，得到 %v", err)
+		}
+	})
+
+	t.Run("未配置令牌时只接受本机请求", func(t *testing.T) {
+		t.Setenv("ADMIN_ENABLED", "true")
+		app, cleanup, err := wire.InitializeApp()
+		if err != nil {
+			t.Fatalf("初始化应用失败: %v", err)
+		}
+		defer cleanup()
+
+		for _, tt := range []struct {
+			remoteAddr string
+			wantCode   int
+		}{
+			{"127.0.0.1:50000", http.StatusOK},
+			{"[::1]:50000", http.StatusOK},
+			{"192.0.2.10:50000", http.StatusForbidden},
+		} {
+			req := httptest.NewRequest("GET", "/version", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			app.Admin.Handler().ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("%s 期望 %d，得到 %d", tt.remoteAddr, tt.wantCode, w.Code)
+			}
+		}
+	})
+
+	t.Setenv("ADMIN_ENABLED", "true")
+	t.Setenv("ADMIN_ADDR", "0.0.0.0:6060")
+	t.Setenv("ADMIN_TOKEN", "admin-token")
+	t.Setenv("CURSOR_SECRET", "cursor-secret-value")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	adminDo := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		app.Admin.Handler().ServeHTTP(w, req)
+		return w
+	}
+	publicDo := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     string
+		wantCode int
+		contains string
+	}{
+		{"缺少令牌", "GET", "/version", "", "", http.StatusUnauthorized, "无效的管理令牌"},
+		{"错误令牌", "GET", "/version", "wrong", "", http.StatusUnauthorized, "无效的管理令牌"},
+		{"版本信息", "GET", "/version", "admin-token", "", http.StatusOK, `"go_version":"go`},
+		{"路由表", "GET", "/routes", "admin-token", "", http.StatusOK, `"path":"/api/v1/users/:id"`},
+		{"配置脱敏", "GET", "/config", "admin-token", "", http.StatusOK, `"CursorSecret":"[REDACTED]"`},
+		{"pprof", "GET", "/debug/pprof/", "admin-token", "", http.StatusOK, "goroutine"},
+		{"expvar", "GET", "/debug/vars", "admin-token", "", http.StatusOK, "memstats"},
+		{"修改日志级别", "POST", "/log-level", "admin-token", `{"level":"warn"}`, http.StatusOK, `"level":"warn"`},
+		{"查询日志级别", "GET", "/log-level", "admin-token", "", http.StatusOK, `"level":"warn"`},
+		{"无效的日志级别", "POST", "/log-level", "admin-token", `{"level":"verbose"}`, http.StatusBadRequest, "日志级别"},
+		{"缺少 enabled", "POST", "/maintenance", "admin-token", `{}`, http.StatusBadRequest, "请求参数格式错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminDo(tt.method, tt.path, tt.token, tt.body)
+			if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("期望 %d 包含 %q，得到 %d %s", tt.wantCode, tt.contains, w.Code, w.Body.String())
+			}
+		})
+	}
+	if w := adminDo("GET", "/config", "admin-token", ""); strings.Contains(w.Body.String(), "cursor-secret-value") || strings.Contains(w.Body.String(), "admin-token") {
+		t.Error("配置中不应包含密钥原文")
+	}
+	if app.Logger.Level() != logging.LevelWarn {
+		t.Errorf("日志级别应已修改为 warn，得到 %s", app.Logger.Level())
+	}
+
+	t.Run("维护模式", func(t *testing.T) {
+		w := adminDo("POST", "/maintenance", "admin-token", `{"enabled":true,"message":"数据库升级中","retry_after":60}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("开启维护模式失败: %d %s", w.Code, w.Body.String())
+		}
+
+		w = publicDo("/api/v1/users")
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "60" || !strings.Contains(w.Body.String(), "数据库升级中") {
+			t.Errorf("维护模式下业务接口应返回 503，得到 %d %s", w.Code, w.Body.String())
+		}
+		if w := publicDo("/healthz"); w.Code != http.StatusOK {
+			t.Errorf("维护模式不应影响健康检查，得到 %d", w.Code)
+		}
+
+		adminDo("POST", "/maintenance", "admin-token", `{"enabled":false}`)
+		if w := publicDo("/api/v1/users"); w.Code != http.StatusOK {
+			t.Errorf("关闭维护模式后应正常响应，得到 %d", w.Code)
+		}
+	})
+}
+
+func TestAuditTrail(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	do := func(method, path, contentType, body, requestID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("X-Actor", "alice@example.com")
+		req.Header.Set("User-Agent", "audit-test/1.0")
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		req.RemoteAddr = "192.0.2.7:40000"
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	email := fmt.Sprintf("audit-%d@example.com", time.Now().UnixNano())
+	w := do("POST", "/api/v1/users", "application/json", `{"name":"审计用户","email":"`+email+`","password":"password123"}`, "audit-create")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Request-ID") != "audit-create" {
+		t.Errorf("响应应返回请求 ID，得到 %q", w.Header().Get("X-Request-ID"))
+	}
+	var created struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	id := strconv.Itoa(created.Data.ID)
+
+	steps := []struct {
+		method, path, contentType, body string
+	}{
+		{"PUT", "/api/v1/users/" + id, "application/json", `{"name":"审计用户2","email":"` + email + `"}`},
+		{"PATCH", "/api/v1/users/" + id, "application/merge-patch+json", `{"name":"审计用户3"}`},
+		{"DELETE", "/api/v1/users/" + id, "", ""},
+		{"POST", "/api/v1/admin/users/" + id + "/restore", "", ""},
+	}
+	for _, step := range steps {
+		if w := do(step.method, step.path, step.contentType, step.body, ""); w.Code >= 300 {
+			t.Fatalf("%s %s 失败: %d %s", step.method, step.path, w.Code, w.Body.String())
+		}
+	}
+	if w := do("GET", "/api/v1/users/"+id, "", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("只读请求也应正常响应: %d", w.Code)
+	}
+
+	type auditEntry struct {
+		ID          int64                     `json:"id"`
+		Actor       string                    `json:"actor"`
+		ActorSource string                    `json:"actor_source"`
+		Action      string                    `json:"action"`
+		TargetID    string                    `json:"target_id"`
+		Changes     map[string]map[string]any `json:"changes"`
+		RequestID   string                    `json:"request_id"`
+		IP          string                    `json:"ip"`
+		UserAgent   string                    `json:"user_agent"`
+	}
+	list := func(query string) (int, []auditEntry, int64) {
+		w := do("GET", "/api/v1/audit?"+query, "", "", "")
+		var resp struct {
+			Data  []auditEntry `json:"data"`
+			Total int64        `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data, resp.Total
+	}
+
+	code, entries, total := list("target_type=user&target_id=" + id)
+	if code != http.StatusOK || total != 5 {
+		t.Fatalf("期望 5 条审计记录，得到 %d 条（状态码 %d）", total, code)
+	}
+	wantActions := []string{"user.restored", "user.deleted", "user.updated", "user.updated", "user.created"}
+	for i, e := range entries {
+		if e.Action != wantActions[i] {
+			t.Errorf("第 %d 条期望 %s，得到 %s", i, wantActions[i], e.Action)
+		}
+		if e.IP != "192.0.2.7" || e.UserAgent != "audit-test/1.0" || e.RequestID == "" {
+			t.Errorf("来源信息不完整: %+v", e)
+		}
+		// 对端不是可信代理，请求头中的操作者标记为客户端自称；管理接口以令牌认证的身份为准
+		wantActor, wantSource := "alice@example.com", "client"
+		if e.Action == "user.restored" {
+			wantActor, wantSource = "admin", "admin_token"
+		}
+		if e.Actor != wantActor || e.ActorSource != wantSource {
+			t.Errorf("第 %d 条期望操作者 %s（%s），得到 %s（%s）", i, wantActor, wantSource, e.Actor, e.ActorSource)
+		}
+	}
+
+	t.Run("变更内容", func(t *testing.T) {
+		createdEntry, updatedEntry, patchedEntry := entries[4], entries[3], entries[2]
+		if createdEntry.RequestID != "audit-create" {
+			t.Errorf("应记录客户端提供的请求 ID，得到 %q", createdEntry.RequestID)
+		}
+		if createdEntry.Changes["email"]["after"] != email {
+			t.Errorf("新建记录应包含邮箱: %v", createdEntry.Changes)
+		}
+		if password := createdEntry.Changes["password"]; password["redacted"] != true || password["after"] != nil {
+			t.Errorf("密码应脱敏，得到 %v", password)
+		}
+		if len(updatedEntry.Changes) != 1 || updatedEntry.Changes["name"]["before"] != "审计用户" || updatedEntry.Changes["name"]["after"] != "审计用户2" {
+			t.Errorf("更新记录只应包含变化的字段: %v", updatedEntry.Changes)
+		}
+		if patchedEntry.Changes["name"]["after"] != "审计用户3" {
+			t.Errorf("部分更新记录错误: %v", patchedEntry.Changes)
+		}
+		if deleted := entries[1].Changes["deleted_at"]; deleted == nil || deleted["before"] != nil || deleted["after"] == nil {
+			t.Errorf("删除记录应包含删除时间: %v", entries[1].Changes)
+		}
+	})
+
+	t.Run("筛选和分页", func(t *testing.T) {
+		tests := []struct {
+			query     string
+			wantTotal int64
+			wantLen   int
+		}{
+			{"target_id=" + id + "&action=user.updated", 2, 2},
+			{"request_id=audit-create", 1, 1},
+			{"target_id=" + id + "&actor=bob", 0, 0},
+			{"target_id=" + id + "&page=2&page_size=2", 5, 2},
+			{"target_id=" + id + "&from=" + url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339)), 0, 0},
+			// 时间范围按实际时刻比较，与请求中的时区偏移无关
+			{"target_id=" + id + "&from=" + url.QueryEscape(time.Now().Add(-time.Hour).In(time.FixedZone("UTC+14", 14*3600)).Format(time.RFC3339)), 5, 5},
+			{"target_id=" + id + "&to=" + url.QueryEscape(time.Now().Add(time.Hour).In(time.FixedZone("UTC-12", -12*3600)).Format(time.RFC3339)), 5, 5},
+		}
+		for _, tt := range tests {
+			code, entries, total := list(tt.query)
+			if code != http.StatusOK || total != tt.wantTotal || len(entries) != tt.wantLen {
+				t.Errorf("%s 期望 %d/%d 条，得到 %d/%d 条（状态码 %d）", tt.query, tt.wantLen, tt.wantTotal, len(entries), total, code)
+			}
+		}
+		if code, _, _ := list("from=yesterday"); code != http.StatusBadRequest {
+			t.Errorf("无效的时间应返回 400，得到 %d", code)
+		}
+	})
+
+	t.Run("写入失败时回滚", func(t *testing.T) {
+		db := app.DB.GetGormDB()
+		if err := db.Exec(`CREATE TRIGGER audit_logs_test_fail BEFORE INSERT ON audit_logs BEGIN
+			SELECT RAISE(ABORT, 'audit unavailable');
+		END`).Error; err != nil {
+			t.Fatalf("创建触发器失败: %v", err)
+		}
+		defer db.Exec("DROP TRIGGER audit_logs_test_fail")
+
+		if w := do("PUT", "/api/v1/users/"+id, "application/json", `{"name":"审计回滚","email":"`+email+`"}`, ""); w.Code < 400 {
+			t.Fatalf("审计写入失败时修改应失败，得到 %d", w.Code)
+		}
+		var response struct{ Data struct{ Name string } }
+		json.Unmarshal(do("GET", "/api/v1/users/"+id, "", "", "").Body.Bytes(), &response)
+		if response.Data.Name != "审计用户3" {
+			t.Errorf("审计写入失败时用户修改应回滚，得到 %q", response.Data.Name)
+		}
+	})
+
+	t.Run("只能追加", func(t *testing.T) {
+		db := app.DB.GetGormDB()
+		if err := db.Exec("UPDATE audit_logs SET actor = 'mallory' WHERE id = ?", entries[0].ID).Error; err == nil {
+			t.Error("修改审计记录应被拒绝")
+		}
+		if err := db.Exec("DELETE FROM audit_logs WHERE id = ?", entries[0].ID).Error; err == nil {
+			t.Error("删除审计记录应被拒绝")
+		}
+
+		w := do("GET", "/api/v1/audit/verify", "", "", "")
+		var resp struct {
+			Data struct {
+				Valid   bool  `json:"valid"`
+				Checked int64 `json:"checked"`
+				HeadID  int64 `json:"head_id"`
+			} `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || !resp.Data.Valid || resp.Data.HeadID < entries[0].ID || resp.Data.Checked != resp.Data.HeadID {
+			t.Errorf("哈希链应校验通过: %d %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestErrorReporting(t *testing.T) {
+	var mu sync.Mutex
+	var envelopes []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		envelopes = append(envelopes, r.URL.Path+"\n"+string(body))
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	t.Setenv("ERROR_REPORT_DSN", strings.Replace(collector.URL, "http://", "http://public-key@", 1)+"/7")
+	t.Setenv("ERROR_REPORT_ENVIRONMENT", "integration")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	app.Router.GET("/api/v1/panic", func(c *gin.Context) { panic("测试 panic") })
+
+	req, _ := http.NewRequest("GET", "/api/v1/panic", nil)
+	req.Header.Set("X-Request-ID", "panic-request")
+	req.Header.Set("X-Actor", "alice@example.com")
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, req)
+
+	var body struct {
+		ErrorID   string `json:"error_id"`
+		RequestID string `json:"request_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusInternalServerError || body.ErrorID == "" || body.RequestID != "panic-request" {
+		t.Fatalf("期望带错误 ID 的 500 响应，得到 %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" {
+		t.Errorf("期望问题详情响应类型，得到 %s", w.Header().Get("Content-Type"))
+	}
+
+	// 清理时发送队列中剩余的事件
+	cleanup()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(envelopes) != 1 {
+		t.Fatalf("期望上报 1 个事件，得到 %d", len(envelopes))
+	}
+	for _, want := range []string{"/api/7/envelope/", `"error_id":"` + body.ErrorID + `"`, `"request_id":"panic-request"`, `"route":"/api/v1/panic"`, `"id":"alice@example.com"`, `"environment":"integration"`, "测试 panic"} {
+		if !strings.Contains(envelopes[0], want) {
+			t.Errorf("上报内容缺少 %s: %s", want, envelopes[0])
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	t.Run("默认拒绝跨域请求", func(t *testing.T) {
+		app, cleanup, err := wire.InitializeApp()
+		if err != nil {
+			t.Fatalf("初始化应用失败: %v", err)
+		}
+		defer cleanup()
+
+		req, _ := http.NewRequest("OPTIONS", "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("未配置来源时预检请求应返回 403，得到 %d %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+
+	t.Run("无效的配置无法启动", func(t *testing.T) {
+		t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+		t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		if _, cleanup, err := wire.InitializeApp(); err == nil {
+			cleanup()
+			t.Fatal("允许凭据时来源为 * 应启动失败")
+		}
+	})
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.example.com")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_ADMIN_ALLOWED_ORIGINS", "https://ops.example.com")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	do := func(method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/api/v1/users", "https://web.example.com", nil)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://web.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("子域名应被允许并携带凭据，得到 %v", w.Header())
+	}
+	vary := strings.Join(w.Header().Values("Vary"), ", ")
+	if !strings.Contains(vary, "Origin") || !strings.Contains(vary, "Accept") {
+		t.Errorf("缓存的 Vary 不应覆盖 Origin，得到 %q", vary)
+	}
+
+	preflight := func(path, origin string) int {
+		return do("OPTIONS", path, origin, map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "Content-Type, X-Actor, X-Request-ID",
+		}).Code
+	}
+	tests := []struct {
+		name   string
+		path   string
+		origin string
+		want   int
+	}{
+		{"业务接口允许审计操作者请求头", "/api/v1/users", "https://app.example.com", http.StatusNoContent},
+		{"不允许的来源", "/api/v1/users", "https://evil.example.net", http.StatusForbidden},
+		{"管理接口只允许管理来源", "/api/v1/admin/users/purge", "https://app.example.com", http.StatusForbidden},
+		{"管理来源", "/api/v1/audit", "https://ops.example.com", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preflight(tt.path, tt.origin); got != tt.want {
+				t.Errorf("期望 %d，得到 %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSecurityHardening(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("SECURITY_PROFILE", "production")
+	t.Setenv("SECURITY_MAX_BODY_BYTES", "512")
+	t.Setenv("SECURITY_MAX_URL_LENGTH", "256")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	send := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("GET", "/api/v1/users", "", "")
+	for _, name := range []string{"Strict-Transport-Security", "Content-Security-Policy", "X-Content-Type-Options", "Referrer-Policy", "Permissions-Policy", "X-Frame-Options"} {
+		if w.Header().Get(name) == "" {
+			t.Errorf("production 配置档应发送 %s", name)
+		}
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); strings.Contains(csp, "{nonce}") || !strings.Contains(csp, "'nonce-") {
+		t.Errorf("CSP 中的 {nonce} 应替换为随机值，得到 %q", csp)
+	}
+
+	user := `{"name":"安全测试","email":"security@example.com","password":"password123"}`
+	csv := "name,email,password\n" + strings.Repeat("批量,bulk@example.com,password123\n", 32)
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"拒绝未知字段", "POST", "/api/v1/users", "application/json", `{"name":"安全测试","email":"security@example.com","password":"password123","is_admin":true}`, http.StatusBadRequest},
+		{"拒绝非 JSON 请求体", "POST", "/api/v1/users", "text/plain", user, http.StatusUnsupportedMediaType},
+		{"Webhook 同样要求 JSON", "POST", "/api/v1/webhooks", "application/x-www-form-urlencoded", "url=http://example.com", http.StatusUnsupportedMediaType},
+		{"请求体超过全局限制", "POST", "/api/v1/users", "application/json", `{"name":"` + strings.Repeat("a", 512) + `"}`, http.StatusRequestEntityTooLarge},
+		{"补丁请求体同样受全局限制", "PATCH", "/api/v1/users/1", "application/merge-patch+json", `{"name":"` + strings.Repeat("a", 512) + `"}`, http.StatusRequestEntityTooLarge},
+		{"导入接口使用单独的限制", "POST", "/api/v1/users/import?dry_run=true&mode=skip-invalid", "text/csv", csv, http.StatusOK},
+		{"请求地址过长", "GET", "/api/v1/users?q=" + strings.Repeat("a", 256), "", "", http.StatusRequestURITooLong},
+		{"正常创建", "POST", "/api/v1/users", "application/json; charset=utf-8", user, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(tt.method, tt.target, tt.contentType, tt.body); w.Code != tt.want {
+				t.Errorf("期望 %d，得到 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Run("无效的网段无法启动", func(t *testing.T) {
+		t.Setenv("IP_FILTER_ADMIN_ALLOW", "203.0.113.0/33")
+		if _, cleanup, err := wire.InitializeApp(); err == nil {
+			cleanup()
+			t.Fatal("无效的网段应启动失败")
+		}
+	})
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	t.Setenv("IP_FILTER_ADMIN_ALLOW", "203.0.113.0/24")
+	t.Setenv("IP_FILTER_API_DENY", "198.51.100.0/24")
+	t.Setenv("ADMIN_ENABLED", "true")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	do := func(method, path, remoteAddr, forwardedFor, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		remoteAddr   string
+		forwardedFor string
+		want         int
+	}{
+		{"办公网经代理访问管理接口", "/api/v1/audit", "10.0.0.1:5000", "203.0.113.5", http.StatusOK},
+		{"外部地址经代理访问管理接口", "/api/v1/audit", "10.0.0.1:5000", "192.0.2.7", http.StatusForbidden},
+		{"不可信的对端不能伪造地址", "/api/v1/audit", "192.0.2.7:5000", "203.0.113.5", http.StatusForbidden},
+		{"Webhook 属于管理接口", "/api/v1/webhooks", "10.0.0.1:5000", "192.0.2.7", http.StatusForbidden},
+		{"业务接口不受管理列表限制", "/api/v1/users", "10.0.0.1:5000", "192.0.2.7", http.StatusOK},
+		{"业务接口拒绝列表", "/api/v1/users", "10.0.0.1:5000", "198.51.100.7", http.StatusForbidden},
+		{"健康检查不受限制", "/healthz", "10.0.0.1:5000", "198.51.100.7", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do("GET", tt.path, tt.remoteAddr, tt.forwardedFor, ""); w.Code != tt.want {
+				t.Errorf("期望 %d，得到 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("审计记录使用解析后的地址", func(t *testing.T) {
+		w := do("POST", "/api/v1/users", "10.0.0.1:5000", "192.0.2.44, 10.3.3.3", `{"name":"代理用户","email":"proxied@example.com","password":"password123"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+		}
+		w = do("GET", "/api/v1/audit?action=user.created&page_size=100", "10.0.0.1:5000", "203.0.113.5", "")
+		if !strings.Contains(w.Body.String(), `"ip":"192.0.2.44"`) {
+			t.Errorf("审计记录的 IP 应为客户端地址，得到 %s", w.Body.String())
+		}
+	})
+
+	t.Run("通过管理端口热更新", func(t *testing.T) {
+		update := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("PUT", "/ip-rules", strings.NewReader(body))
+			req.RemoteAddr = "127.0.0.1:5000"
+			w := httptest.NewRecorder()
+			app.Admin.Handler().ServeHTTP(w, req)
+			return w
+		}
+		if w := update(`{"trusted_proxies":["10.0.0.0/8"],"groups":{"admin":{"allow":["bad"]}}}`); w.Code != http.StatusBadRequest {
+			t.Errorf("无效的规则应返回 400，得到 %d", w.Code)
+		}
+		if w := do("GET", "/api/v1/audit", "10.0.0.1:5000", "203.0.113.5", ""); w.Code != http.StatusOK {
+			t.Errorf("更新失败时应保留原规则，得到 %d", w.Code)
+		}
+
+		w := update(`{"trusted_proxies":["10.0.0.0/8"],"headers":["X-Forwarded-For"],"groups":{"admin":{"allow":["192.0.2.0/24"]}}}`)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"192.0.2.0/24"`) {
+			t.Fatalf("更新规则失败: %d %s", w.Code, w.Body.String())
+		}
+		if w := do("GET", "/api/v1/audit", "10.0.0.1:5000", "192.0.2.7", ""); w.Code != http.StatusOK {
+			t.Errorf("更新后应允许新的网段，得到 %d", w.Code)
+		}
+		if w := do("GET", "/api/v1/audit", "10.0.0.1:5000", "203.0.113.5", ""); w.Code != http.StatusForbidden {
+			t.Errorf("更新后应拒绝原来的网段，得到 %d", w.Code)
+		}
+		if w := do("GET", "/api/v1/users", "10.0.0.1:5000", "198.51.100.7", ""); w.Code != http.StatusOK {
+			t.Errorf("未提供的分组不再限制，得到 %d", w.Code)
+		}
+
+		// 没有 ADMIN_TOKEN 时去掉管理分组的允许列表，管理接口拒绝所有访问
+		if w := update(`{"trusted_proxies":["10.0.0.0/8"],"headers":["X-Forwarded-For"]}`); w.Code != http.StatusOK {
+			t.Fatalf("更新规则失败: %d %s", w.Code, w.Body.String())
+		}
+		for _, path := range []string{"/api/v1/audit", "/api/v1/admin/users/deleted"} {
+			if w := do("GET", path, "10.0.0.1:5000", "192.0.2.7", ""); w.Code != http.StatusForbidden {
+				t.Errorf("%s 未配置令牌和允许列表时应返回 403，得到 %d", path, w.Code)
+			}
+		}
+	})
+}
+
+func TestCompression(t *testing.T) {
+	t.Run("不支持的编码无法启动", func(t *testing.T) {
+		t.Setenv("COMPRESSION_ENCODINGS", "zstd, gzip")
+		if _, cleanup, err := wire.InitializeApp(); err == nil {
+			cleanup()
+			t.Fatal("未注册的编码应启动失败")
+		}
+	})
+
+	t.Setenv("COMPRESSION_MIN_SIZE", "256")
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	gzipped := func(data string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(data))
+		zw.Close()
+		return buf.String()
+	}
+	do := func(method, target string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+	gunzip := func(t *testing.T, w *httptest.ResponseRecorder) string {
+		t.Helper()
+		if w.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("响应应使用 gzip 压缩，得到 %v", w.Header())
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("响应无法解压: %v", err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("响应无法解压: %v", err)
+		}
+		return string(body)
+	}
+
+	t.Run("gzip 请求体", func(t *testing.T) {
+		headers := map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}
+		for i := 0; i < 5; i++ {
+			body := fmt.Sprintf(`{"name":"压缩用户%d","email":"compress%d@example.com","password":"password123"}`, i, i)
+			if w := do("POST", "/api/v1/users", headers, gzipped(body)); w.Code != http.StatusCreated {
+				t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+			}
+		}
+		bomb := gzipped(`{"name":"` + strings.Repeat("a", 2<<20) + `"}`)
+		if w := do("POST", "/api/v1/users", headers, bomb); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("解压后超过请求体限制应返回 413，得到 %d", w.Code)
+		}
+		if w := do("POST", "/api/v1/users", headers, "not gzip"); w.Code != http.StatusBadRequest {
+			t.Errorf("无效的 gzip 应返回 400，得到 %d", w.Code)
+		}
+	})
+
+	t.Run("用户列表", func(t *testing.T) {
+		w := do("GET", "/api/v1/users?page_size=100", map[string]string{"Accept-Encoding": "gzip, deflate"}, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("查询失败: %d", w.Code)
+		}
+		if body := gunzip(t, w); !strings.Contains(body, "compress4@example.com") {
+			t.Errorf("解压后的列表错误: %s", body)
+		}
+		if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Accept-Encoding") {
+			t.Errorf("Vary 应包含 Accept-Encoding，得到 %v", w.Header().Values("Vary"))
+		}
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("列表应返回 ETag")
+		}
+		w = do("GET", "/api/v1/users?page_size=100", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag}, "")
+		if w.Code != http.StatusNotModified || w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("304 不应压缩，得到 %d %v", w.Code, w.Header())
+		}
+
+		w = do("GET", "/api/v1/users?page_size=100", nil, "")
+		if w.Header().Get("Content-Encoding") != "" || !strings.Contains(w.Body.String(), "compress4@example.com") {
+			t.Errorf("未声明 Accept-Encoding 时不应压缩，得到 %v", w.Header())
+		}
+	})
+
+	t.Run("流式导出", func(t *testing.T) {
+		w := do("GET", "/api/v1/users/export?format=csv", map[string]string{"Accept-Encoding": "gzip"}, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("导出失败: %d %s", w.Code, w.Body.String())
+		}
+		body := gunzip(t, w)
+		for i := 0; i < 5; i++ {
+			if !strings.Contains(body, fmt.Sprintf("compress%d@example.com", i)) {
+				t.Errorf("导出应包含 compress%d@example.com: %s", i, body)
+			}
+		}
+		if w.Header().Get("Content-Length") != "" {
+			t.Errorf("压缩的响应不应带 Content-Length，得到 %q", w.Header().Get("Content-Length"))
+		}
+	})
+}
@@ -0,0 +1,87 @@
+//go:build sqlite_fts5
+
+package integration_test
+
+import (
+	"base-gin/wire"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestFullTextSearchFTS5 以 sqlite_fts5 构建时全文检索必须走 FTS5 索引而不是退化为 LIKE
+func TestFullTextSearchFTS5(t *testing.T) {
+	app, cleanup, err := wire.InitializeApp()
+	if err != nil {
+		t.Fatalf("初始化应用失败: %v", err)
+	}
+	defer cleanup()
+
+	if !app.DB.FullTextEnabled() {
+		t.Fatal("以 sqlite_fts5 构建时应启用 FTS5 全文索引")
+	}
+
+	do := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.Router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/users", []byte(`{"name":"全文索引用户","email":"fts5-index@example.com","password":"password123"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建用户失败: %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	// 触发器应把新用户写入 FTS5 索引
+	var indexed int64
+	if err := app.DB.GetGormDB().Raw("SELECT count(*) FROM users_fts WHERE rowid = ?", created.Data.ID).Scan(&indexed).Error; err != nil {
+		t.Fatalf("查询 FTS5 索引失败: %v", err)
+	}
+	if indexed != 1 {
+		t.Fatalf("期望 FTS5 索引中有 1 条记录，得到 %d", indexed)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"完整词命中", "全文索引用户", true},
+		{"前缀命中", "fts5-ind", true},
+		{"未命中", "不存在的关键字", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do("GET", "/api/v1/users/search?q="+url.QueryEscape(tt.query), nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("期望状态码 %d，得到 %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var response struct {
+				Data []struct {
+					ID int `json:"id"`
+				} `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			found := false
+			for _, item := range response.Data {
+				if item.ID == created.Data.ID {
+					found = true
+				}
+			}
+			if found != tt.want {
+				t.Errorf("查询 %q 期望命中 %v，得到 %s", tt.query, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package user_test
+
+import (
+	"base-gin/internal/domain/audit/entity"
+	"base-gin/internal/interfaces/middleware"
+	"base-gin/pkg/requestmeta"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditDiff(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var notDeleted *time.Time
+
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]entity.Change
+	}{
+		{
+			name:   "新建",
+			before: nil,
+			after:  map[string]any{"name": "张三", "password": "hash", "deleted_at": notDeleted},
+			want: map[string]entity.Change{
+				"name":     {After: "张三"},
+				"password": {Redacted: true},
+			},
+		},
+		{
+			name:   "只记录变化的字段",
+			before: map[string]any{"name": "张三", "email": "a@example.com"},
+			after:  map[string]any{"name": "李四", "email": "a@example.com"},
+			want:   map[string]entity.Change{"name": {Before: "张三", After: "李四"}},
+		},
+		{
+			name:   "敏感字段不记录值",
+			before: map[string]any{"password": "old", "api_token": "t1", "client_secret": "s1"},
+			after:  map[string]any{"password": "new", "api_token": "t2", "client_secret": "s1"},
+			want: map[string]entity.Change{
+				"password":  {Redacted: true},
+				"api_token": {Redacted: true},
+			},
+		},
+		{
+			name:   "指针比较指向的值",
+			before: map[string]any{"deleted_at": notDeleted},
+			after:  map[string]any{"deleted_at": &deletedAt},
+			want:   map[string]entity.Change{"deleted_at": {Before: nil, After: deletedAt}},
+		},
+		{
+			name:   "删除",
+			before: map[string]any{"name": "张三"},
+			after:  nil,
+			want:   map[string]entity.Change{"name": {Before: "张三"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := entity.Diff(tt.before, tt.after)
+			if len(got) != len(tt.want) {
+				t.Fatalf("期望 %v，得到 %v", tt.want, got)
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s 期望 %+v，得到 %+v", key, want, got[key])
+				}
+			}
+		})
+	}
+}
+
+// sealedChain 生成 n 条连续的审计记录
+func sealedChain(t *testing.T, n int) []*entity.Entry {
+	t.Helper()
+	entries := make([]*entity.Entry, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		e := &entity.Entry{
+			Actor:      "alice",
+			Action:     entity.ActionUserUpdated,
+			TargetType: entity.TargetTypeUser,
+			TargetID:   "1",
+			Changes:    map[string]entity.Change{"name": {Before: "a", After: "b"}},
+			CreatedAt:  time.Now(),
+		}
+		if err := e.Seal(int64(i), prevHash); err != nil {
+			t.Fatalf("计算哈希失败: %v", err)
+		}
+		prevHash = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditHashChain(t *testing.T) {
+	verify := func(entries []*entity.Entry) error {
+		var prev *entity.Entry
+		for _, e := range entries {
+			if err := e.Follows(prev); err != nil {
+				return err
+			}
+			prev = e
+		}
+		return nil
+	}
+
+	if err := verify(sealedChain(t, 3)); err != nil {
+		t.Fatalf("未修改的记录应校验通过: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func([]*entity.Entry) []*entity.Entry
+	}{
+		{"修改操作者", func(es []*entity.Entry) []*entity.Entry { es[1].Actor = "mallory"; return es }},
+		{"修改变更内容", func(es []*entity.Entry) []*entity.Entry { es[1].RawChanges = `{"name":{"before":"a","after":"c"}}`; return es }},
+		{"修改时间", func(es []*entity.Entry) []*entity.Entry { es[1].CreatedAt = es[1].CreatedAt.Add(time.Second); return es }},
+		{"删除中间的记录", func(es []*entity.Entry) []*entity.Entry { return []*entity.Entry{es[0], es[2]} }},
+		{"删除第一条记录", func(es []*entity.Entry) []*entity.Entry { return es[1:] }},
+		{"重新计算被修改记录的哈希", func(es []*entity.Entry) []*entity.Entry {
+			es[1].Actor = "mallory"
+			es[1].Hash = es[1].ComputeHash()
+			return es
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(tt.tamper(sealedChain(t, 3)))
+			if !errors.Is(err, entity.ErrChainBroken) {
+				t.Errorf("期望 ErrChainBroken，得到 %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestMetaMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got requestmeta.Metadata
+	rules, err := middleware.NewIPRules(middleware.IPRulesConfig{TrustedProxies: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatalf("创建 IP 规则失败: %v", err)
+	}
+	r := gin.New()
+	r.Use(middleware.RequestMeta("X-Actor", rules))
+	r.GET("/", func(c *gin.Context) { got = requestmeta.FromContext(c.Request.Context()) })
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		headers       map[string]string
+		wantActor     string
+		wantSource    string
+		wantRequestID string
+	}{
+		{"未提供时生成请求 ID", "", nil, "anonymous", "", ""},
+		{"客户端自称的操作者", "", map[string]string{"X-Actor": "alice", "X-Request-ID": "req-1"}, "alice", requestmeta.ActorSourceClient, "req-1"},
+		{"可信代理设置的操作者", "10.0.0.2:4000", map[string]string{"X-Actor": "alice"}, "alice", requestmeta.ActorSourceProxy, ""},
+		{"去掉控制字符", "", map[string]string{"X-Actor": "bob\tadmin", "X-Request-ID": "req\x7f-2"}, "bobadmin", requestmeta.ActorSourceClient, "req-2"},
+		{"超长时截断", "", map[string]string{"X-Request-ID": strings.Repeat("a", 200)}, "anonymous", "", strings.Repeat("a", 128)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got.Actor != tt.wantActor || got.ActorSource != tt.wantSource {
+				t.Errorf("期望操作者 %q（%q），得到 %q（%q）", tt.wantActor, tt.wantSource, got.Actor, got.ActorSource)
+			}
+			if tt.wantRequestID != "" && got.RequestID != tt.wantRequestID {
+				t.Errorf("期望请求 ID %q，得到 %q", tt.wantRequestID, got.RequestID)
+			}
+			if got.RequestID == "" || w.Header().Get(middleware.HeaderRequestID) != got.RequestID {
+				t.Errorf("响应应返回请求 ID，得到 %q", w.Header().Get(middleware.HeaderRequestID))
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package user_test
+
+import (
+	"base-gin/pkg/clientip"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := clientip.NewResolver(
+		[]string{"10.0.0.0/8", "2001:db8:ffff::/48", "192.0.2.1"},
+		[]string{"X-Forwarded-For", "Forwarded", "X-Real-IP"},
+	)
+	if err != nil {
+		t.Fatalf("创建解析器失败: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{"没有转发请求头", "10.0.0.1:5000", nil, "10.0.0.1"},
+		{"不可信的对端不读取请求头", "198.51.100.7:5000", map[string][]string{"X-Forwarded-For": {"203.0.113.5"}}, "198.51.100.7"},
+		{"跳过可信代理", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"203.0.113.5, 10.1.2.3"}}, "203.0.113.5"},
+		{"不能伪造最左侧的地址", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"1.1.1.1, 203.0.113.5"}}, "203.0.113.5"},
+		{"合并多个请求头", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"203.0.113.5", "10.1.2.3"}}, "203.0.113.5"},
+		{"全部可信时取最左侧", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"10.9.9.9, 10.1.2.3"}}, "10.9.9.9"},
+		{"带端口的地址", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"203.0.113.5:4711"}}, "203.0.113.5"},
+		{"无效地址时尝试下一个请求头", "10.0.0.1:5000", map[string][]string{"X-Forwarded-For": {"unknown"}, "X-Real-Ip": {"203.0.113.9"}}, "203.0.113.9"},
+		{"Forwarded", "10.0.0.1:5000", map[string][]string{"Forwarded": {`for=203.0.113.5;proto=https, for="[2001:db8:ffff::1]:4711"`}}, "203.0.113.5"},
+		{"Forwarded IPv6", "[2001:db8:ffff::2]:5000", map[string][]string{"Forwarded": {`for="[2001:db8:cafe::17]:4711"`}}, "2001:db8:cafe::17"},
+		{"Forwarded 匿名标识不可用", "10.0.0.1:5000", map[string][]string{"Forwarded": {"for=_hidden"}}, "10.0.0.1"},
+		{"单个 IP 的可信代理", "192.0.2.1:5000", map[string][]string{"X-Real-Ip": {"203.0.113.9"}}, "203.0.113.9"},
+		{"IPv4 映射的 IPv6 对端", "[::ffff:10.0.0.1]:5000", map[string][]string{"X-Real-Ip": {"203.0.113.9"}}, "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, values := range tt.headers {
+				req.Header[name] = values
+			}
+			if got := resolver.ClientIP(req).String(); got != tt.want {
+				t.Errorf("期望 %s，得到 %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientIPResolverInvalidConfig(t *testing.T) {
+	if _, err := clientip.NewResolver([]string{"10.0.0.0/33"}, nil); err == nil {
+		t.Error("无效的网段应返回错误")
+	}
+	if _, err := clientip.NewResolver(nil, []string{"X-Client-IP"}); !errors.Is(err, clientip.ErrUnsupportedHeader) {
+		t.Errorf("期望 ErrUnsupportedHeader，得到 %v", err)
+	}
+}
+
+func TestClientIPFilter(t *testing.T) {
+	filter, err := clientip.NewFilter([]string{"203.0.113.0/24", "2001:db8::/32"}, []string{"203.0.113.66"})
+	if err != nil {
+		t.Fatalf("创建过滤器失败: %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"203.0.113.5", true},
+		{"203.0.113.66", false},
+		{"198.51.100.7", false},
+		{"2001:db8::1", true},
+		{"::ffff:203.0.113.5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := filter.Allowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("期望 %v，得到 %v", tt.want, got)
+			}
+		})
+	}
+	if filter.Allowed(netip.Addr{}) {
+		t.Error("配置了列表时无效地址不应被允许")
+	}
+
+	denyOnly, _ := clientip.NewFilter(nil, []string{"198.51.100.0/24"})
+	if !denyOnly.Allowed(netip.MustParseAddr("203.0.113.5")) || denyOnly.Allowed(netip.MustParseAddr("198.51.100.7")) {
+		t.Error("只有拒绝列表时应允许其他地址")
+	}
+	if !filter.Restricted() || denyOnly.Restricted() {
+		t.Error("只有配置了允许列表时才视为受限")
+	}
+}
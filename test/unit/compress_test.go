@@ -0,0 +1,344 @@
+package user_test
+
+import (
+	"base-gin/internal/interfaces/middleware"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCompressRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	compressor, err := middleware.NewCompressor(middleware.CompressionOptions{
+		Codecs:       []middleware.Codec{middleware.GzipCodec(gzip.DefaultCompression), middleware.DeflateCodec(gzip.DefaultCompression)},
+		MinSize:      64,
+		ContentTypes: []string{"application/json", "text/*"},
+	})
+	if err != nil {
+		t.Fatalf("创建压缩配置失败: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Compress(compressor))
+	return r
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	r := newCompressRouter(t)
+	large := strings.Repeat("用户列表", 100)
+	r.GET("/large", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": large}) })
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{"gzip", "gzip", "gzip"},
+		{"deflate", "deflate", "deflate"},
+		{"权重相同时按服务端偏好", "deflate, gzip", "gzip"},
+		{"按权重选择", "gzip;q=0.5, deflate", "deflate"},
+		{"通配符", "br, *", "gzip"},
+		{"q=0 排除编码", "*, gzip;q=0", "deflate"},
+		{"不支持的编码", "br, zstd", ""},
+		{"未声明", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/large", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Header().Get("Content-Encoding"); got != tt.want {
+				t.Fatalf("期望编码 %q，得到 %q", tt.want, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary 期望 Accept-Encoding，得到 %q", got)
+			}
+
+			var body io.Reader = w.Body
+			switch tt.want {
+			case "gzip":
+				body, _ = gzip.NewReader(w.Body)
+			case "deflate":
+				body, _ = zlib.NewReader(w.Body)
+			}
+			decoded, err := io.ReadAll(body)
+			if err != nil || !strings.Contains(string(decoded), large) {
+				t.Errorf("解压后的响应体错误: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	r := newCompressRouter(t)
+	large := strings.Repeat("x", 1024)
+	r.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"data": "ok"}) })
+	r.GET("/image", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+	r.GET("/no-transform", func(c *gin.Context) {
+		c.Header("Cache-Control", "no-transform")
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/not-modified", func(c *gin.Context) { c.Status(http.StatusNotModified) })
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		wantBody bool
+	}{
+		{"小于最小长度", "GET", "/small", true},
+		{"类型不在允许列表", "GET", "/image", true},
+		{"no-transform", "GET", "/no-transform", true},
+		{"已编码的响应", "GET", "/encoded", true},
+		{"304", "GET", "/not-modified", false},
+		{"HEAD", "HEAD", "/small", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if got := w.Header().Get("Content-Encoding"); got == "gzip" {
+				t.Errorf("不应压缩，得到 Content-Encoding %q", got)
+			}
+			if tt.wantBody && w.Body.Len() == 0 {
+				t.Error("响应体不应丢失")
+			}
+		})
+	}
+
+	t.Run("压缩时删除 Content-Length", func(t *testing.T) {
+		r.GET("/sized", func(c *gin.Context) {
+			c.Header("Content-Length", "1024")
+			c.String(http.StatusOK, large)
+		})
+		req := httptest.NewRequest("GET", "/sized", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" {
+			t.Errorf("期望压缩且没有 Content-Length，得到 %v", w.Header())
+		}
+	})
+}
+
+func TestCompressStreaming(t *testing.T) {
+	r := newCompressRouter(t)
+	var flushed []byte
+	var w *httptest.ResponseRecorder
+	r.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("data: first\n\n")
+		c.Writer.Flush()
+		flushed = append([]byte{}, w.Body.Bytes()...)
+		c.Writer.WriteString("data: second\n\n")
+		c.Writer.Flush()
+	})
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" || !w.Flushed {
+		t.Fatalf("流式响应应压缩并刷新，得到 %v", w.Header())
+	}
+	// 第一次 Flush 之后已发送的部分可以单独解压
+	reader, err := gzip.NewReader(bytes.NewReader(flushed))
+	if err != nil {
+		t.Fatalf("Flush 后的数据无法解压: %v", err)
+	}
+	first := make([]byte, len("data: first\n\n"))
+	if _, err := io.ReadFull(reader, first); err != nil || string(first) != "data: first\n\n" {
+		t.Errorf("Flush 后的数据错误: %q %v", first, err)
+	}
+
+	reader, _ = gzip.NewReader(w.Body)
+	all, err := io.ReadAll(reader)
+	if err != nil || string(all) != "data: first\n\ndata: second\n\n" {
+		t.Errorf("完整响应错误: %q %v", all, err)
+	}
+}
+
+func TestCompressETag(t *testing.T) {
+	r := newCompressRouter(t)
+	large := strings.Repeat("x", 1024)
+	r.GET("/strong", func(c *gin.Context) {
+		c.Header("ETag", `"3"`)
+		c.Header("X-If-Match", c.GetHeader("If-Match"))
+		if c.GetHeader("If-None-Match") == `"3"` {
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/weak", func(c *gin.Context) {
+		c.Header("ETag", `W/"3"`)
+		c.String(http.StatusOK, large)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		header         map[string]string
+		wantStatus     int
+		wantETag       string
+	}{
+		{"压缩时强 ETag 加编码后缀", "/strong", "gzip", nil, http.StatusOK, `"3-gzip"`},
+		{"不同编码的后缀不同", "/strong", "deflate", nil, http.StatusOK, `"3-deflate"`},
+		{"未压缩时保持原样", "/strong", "", nil, http.StatusOK, `"3"`},
+		{"弱 ETag 不变", "/weak", "gzip", nil, http.StatusOK, `W/"3"`},
+		{"条件请求去掉后缀后比较", "/strong", "gzip", map[string]string{"If-None-Match": `"3-gzip"`}, http.StatusNotModified, `"3-gzip"`},
+		{"If-Match 同样去掉后缀", "/strong", "gzip", map[string]string{"If-Match": `"1", "3-gzip"`}, http.StatusOK, `"3-gzip"`},
+		{"未压缩的 ETag 条件请求", "/strong", "gzip", map[string]string{"If-None-Match": `"3"`}, http.StatusNotModified, `"3"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus || w.Header().Get("ETag") != tt.wantETag {
+				t.Errorf("期望 %d %s，得到 %d %s", tt.wantStatus, tt.wantETag, w.Code, w.Header().Get("ETag"))
+			}
+			if tt.header["If-Match"] != "" && w.Header().Get("X-If-Match") != `"1", "3"` {
+				t.Errorf("处理函数收到的 If-Match 应去掉后缀，得到 %q", w.Header().Get("X-If-Match"))
+			}
+		})
+	}
+}
+
+func TestCompressAbort(t *testing.T) {
+	r := newCompressRouter(t)
+	large := strings.Repeat("x", 1024)
+	r.GET("/abort", func(c *gin.Context) {
+		c.String(http.StatusOK, large)
+		c.Writer.Flush()
+		panic(http.ErrAbortHandler)
+	})
+	r.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+
+	do := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil && recovered != http.ErrAbortHandler {
+					panic(recovered)
+				}
+			}()
+			r.ServeHTTP(w, req)
+		}()
+		return w
+	}
+
+	// 中断的连接不写出压缩流的结尾，客户端能发现响应不完整
+	reader, err := gzip.NewReader(do("/abort").Body)
+	if err != nil {
+		t.Fatalf("已发送的部分应能解压: %v", err)
+	}
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Error("中断的压缩流不应完整结束")
+	}
+
+	// 编码器重置后放回池中，后续请求正常压缩
+	reader, err = gzip.NewReader(do("/large").Body)
+	if err != nil {
+		t.Fatalf("后续响应无法解压: %v", err)
+	}
+	if body, err := io.ReadAll(reader); err != nil || string(body) != large {
+		t.Errorf("后续响应错误: %v", err)
+	}
+}
+
+func TestCompressorInvalidConfig(t *testing.T) {
+	if _, err := middleware.NewCompressor(middleware.CompressionOptions{Codecs: []middleware.Codec{middleware.GzipCodec(42)}}); err == nil {
+		t.Error("无效的压缩级别应返回错误")
+	}
+	if _, err := middleware.BuiltinCodec("zstd", gzip.DefaultCompression); !errors.Is(err, middleware.ErrUnsupportedEncoding) {
+		t.Errorf("期望 ErrUnsupportedEncoding，得到 %v", err)
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.DecompressRequest(64))
+	r.POST("/", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "请求体过大"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.String(http.StatusOK, string(body))
+	})
+
+	compress := func(data string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(data))
+		zw.Close()
+		return buf.String()
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     string
+		want     int
+		wantBody string
+	}{
+		{"gzip", "gzip", compress(`{"name":"张三"}`), http.StatusOK, `{"name":"张三"}`},
+		{"未压缩", "", `{"name":"张三"}`, http.StatusOK, `{"name":"张三"}`},
+		{"identity", "identity", "plain", http.StatusOK, "plain"},
+		{"解压后超过上限", "gzip", compress(strings.Repeat("a", 1000)), http.StatusRequestEntityTooLarge, ""},
+		{"无效的 gzip", "gzip", "not gzip", http.StatusBadRequest, ""},
+		{"不支持的编码", "br", "data", http.StatusUnsupportedMediaType, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("期望 %d，得到 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("期望 %q，得到 %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package user_test
+
+import (
+	"base-gin/internal/interfaces/middleware"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCORSRouter(t *testing.T, policy middleware.CORSPolicy, overrides ...middleware.CORSOverride) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	rules, err := middleware.NewCORS(policy, overrides...)
+	if err != nil {
+		t.Fatalf("创建跨域策略失败: %v", err)
+	}
+	r := gin.New()
+	r.Use(middleware.CORS(rules))
+	r.GET("/api/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/api/admin/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestCORSOrigins(t *testing.T) {
+	r := newCORSRouter(t, middleware.CORSPolicy{
+		AllowedOrigins: []string{
+			"https://app.example.com",
+			"https://*.example.org",
+			`regex:https://pr-[0-9]+\.preview\.example\.net`,
+		},
+		AllowedMethods:   []string{"GET", "POST"},
+		ExposedHeaders:   []string{"ETag", "X-Request-ID"},
+		AllowCredentials: true,
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"https://a.example.org:8443", false},
+		{"https://pr-42.preview.example.net", true},
+		{"https://pr-x.preview.example.net", false},
+		{"https://pr-42.preview.example.net.evil.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/users", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.want && (got != tt.origin || w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Access-Control-Expose-Headers") != "ETag, X-Request-ID") {
+				t.Errorf("应允许并返回来源和凭据，得到 %q", got)
+			}
+			if !tt.want && got != "" {
+				t.Errorf("不应允许，得到 %q", got)
+			}
+			if w.Code != http.StatusOK {
+				t.Errorf("非预检请求应交给处理器，得到 %d", w.Code)
+			}
+			if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Origin") {
+				t.Errorf("响应应包含 Vary: Origin，得到 %v", w.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSRouter(t, middleware.CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST", "PATCH"},
+		AllowedHeaders: []string{"Content-Type", "If-Match"},
+		MaxAge:         10 * time.Minute,
+	}, middleware.CORSOverride{
+		PathPrefix: "/api/admin",
+		Policy: middleware.CORSPolicy{
+			AllowedOrigins: []string{"https://ops.example.com"},
+			AllowedMethods: []string{"GET"},
+			AllowedHeaders: []string{"*"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		origin   string
+		method   string
+		headers  string
+		wantCode int
+	}{
+		{"允许的预检", "/api/users", "https://app.example.com", "PATCH", "content-type, if-match", http.StatusNoContent},
+		{"不允许的来源", "/api/users", "https://evil.com", "GET", "", http.StatusForbidden},
+		{"不允许的方法", "/api/users", "https://app.example.com", "DELETE", "", http.StatusForbidden},
+		{"不允许的请求头", "/api/users", "https://app.example.com", "POST", "Content-Type, X-Custom", http.StatusForbidden},
+		{"分组策略的来源", "/api/admin/users", "https://ops.example.com", "GET", "X-Anything", http.StatusNoContent},
+		{"分组策略不继承默认来源", "/api/admin/users", "https://app.example.com", "GET", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("期望 %d，得到 %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode == http.StatusForbidden && w.Header().Get("Access-Control-Allow-Origin") != "" {
+				t.Error("拒绝的预检不应返回 Access-Control-Allow-Origin")
+			}
+		})
+	}
+
+	req := httptest.NewRequest("OPTIONS", "/api/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PATCH")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://app.example.com",
+		"Access-Control-Allow-Methods": "GET, POST, PATCH",
+		"Access-Control-Allow-Headers": "Content-Type, If-Match",
+		"Access-Control-Max-Age":       "600",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s 期望 %q，得到 %q", header, want, got)
+		}
+	}
+}
+
+func TestCORSWildcard(t *testing.T) {
+	r := newCORSRouter(t, middleware.CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+	req := httptest.NewRequest("GET", "/api/users", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || len(w.Header().Values("Vary")) != 0 {
+		t.Errorf("允许任意来源时应返回 * 且不需要 Vary，得到 %q %v", w.Header().Get("Access-Control-Allow-Origin"), w.Header().Values("Vary"))
+	}
+
+	invalid := []struct {
+		name    string
+		origins []string
+		creds   bool
+		wantErr error
+	}{
+		{"凭据与通配来源", []string{"*"}, true, middleware.ErrCORSWildcardCredentials},
+		{"无效的正则表达式", []string{"regex:(unclosed"}, false, nil},
+		{"无效的通配位置", []string{"https://app.*.com"}, false, nil},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := middleware.NewCORS(middleware.CORSPolicy{AllowedOrigins: tt.origins, AllowCredentials: tt.creds})
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("期望错误 %v，得到 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package user_test
+
+import (
+	"base-gin/pkg/dataformat"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, reader dataformat.Reader) []*dataformat.Record {
+	t.Helper()
+	var records []*dataformat.Record
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("读取失败: %v", err)
+		}
+		records = append(records, record)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	input := "\ufeffName, EMAIL,password\n" +
+		"张三, zhangsan@example.com ,password123\n" +
+		"\"李,四\",lisi@example.com\n" +
+		"\"王五\",\"wang\nwu@example.com\",secret1\n" +
+		"赵六,zhaoliu@example.com,password456\n"
+
+	records := readAll(t, dataformat.NewCSVReader(strings.NewReader(input)))
+	if len(records) != 4 {
+		t.Fatalf("期望 4 行记录，得到 %d", len(records))
+	}
+
+	want := map[string]string{"name": "张三", "email": "zhangsan@example.com", "password": "password123"}
+	if records[0].Line != 2 || !reflect.DeepEqual(records[0].Fields, want) {
+		t.Errorf("第一行解析错误: %d %v", records[0].Line, records[0].Fields)
+	}
+	if records[1].Err == nil || records[1].Line != 3 {
+		t.Errorf("字段数不一致时应返回行错误，得到 %d %v", records[1].Line, records[1].Err)
+	}
+	if records[2].Err != nil || records[2].Fields["email"] != "wang\nwu@example.com" {
+		t.Errorf("引号内换行应保留，得到 %v %v", records[2].Fields, records[2].Err)
+	}
+	if records[3].Line != 6 || records[3].Fields["name"] != "赵六" {
+		t.Errorf("行错误后应继续读取，得到 %d %v", records[3].Line, records[3].Fields)
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := `{"Name":"张三","email":"zhangsan@example.com"}` + "\n" +
+		"\n" +
+		`{"name":"李四","email":null}` + "\n" +
+		`{"name":1}` + "\n" +
+		`not json` + "\n"
+
+	records := readAll(t, dataformat.NewNDJSONReader(strings.NewReader(input)))
+	if len(records) != 4 {
+		t.Fatalf("期望 4 行记录（空行忽略），得到 %d", len(records))
+	}
+
+	tests := []struct {
+		line    int
+		wantErr bool
+		fields  map[string]string
+	}{
+		{1, false, map[string]string{"name": "张三", "email": "zhangsan@example.com"}},
+		{3, false, map[string]string{"name": "李四"}},
+		{4, true, nil},
+		{5, true, nil},
+	}
+	for i, tt := range tests {
+		record := records[i]
+		if record.Line != tt.line || (record.Err != nil) != tt.wantErr {
+			t.Errorf("第 %d 条记录期望行号 %d 错误 %v，得到 %d %v", i, tt.line, tt.wantErr, record.Line, record.Err)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(record.Fields, tt.fields) {
+			t.Errorf("第 %d 条记录期望 %v，得到 %v", i, tt.fields, record.Fields)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	if _, err := dataformat.NewReader(dataformat.FormatJSON, strings.NewReader("")); !errors.Is(err, dataformat.ErrUnsupportedFormat) {
+		t.Errorf("导入不支持 JSON 数组，期望 ErrUnsupportedFormat，得到 %v", err)
+	}
+	if got := dataformat.FormatFromContentType("application/x-ndjson"); got != dataformat.FormatNDJSON {
+		t.Errorf("期望 ndjson，得到 %q", got)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	created := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rows := [][]interface{}{
+		{1, "张三", created, (*time.Time)(nil)},
+		{2, "李,四", created, &created},
+	}
+	columns := []string{"id", "name", "created_at", "deleted_at"}
+
+	tests := []struct {
+		format string
+		rows   [][]interface{}
+		want   string
+	}{
+		{dataformat.FormatCSV, rows, "id,name,created_at,deleted_at\n" +
+			"1,张三,2024-06-01T12:00:00Z,\n" +
+			"2,\"李,四\",2024-06-01T12:00:00Z,2024-06-01T12:00:00Z\n"},
+		{dataformat.FormatNDJSON, rows, `{"id":1,"name":"张三","created_at":"2024-06-01T12:00:00Z","deleted_at":null}` + "\n" +
+			`{"id":2,"name":"李,四","created_at":"2024-06-01T12:00:00Z","deleted_at":"2024-06-01T12:00:00Z"}` + "\n"},
+		{dataformat.FormatJSON, rows[:1], "[\n" + `{"id":1,"name":"张三","created_at":"2024-06-01T12:00:00Z","deleted_at":null}` + "\n]\n"},
+		{dataformat.FormatJSON, nil, "[]\n"},
+		{dataformat.FormatCSV, nil, "id,name,created_at,deleted_at\n"},
+	}
+
+	for _, tt := range tests {
+		var buf strings.Builder
+		writer, err := dataformat.NewWriter(tt.format, &buf, columns)
+		if err != nil {
+			t.Fatalf("创建 %s 写入器失败: %v", tt.format, err)
+		}
+		for _, row := range tt.rows {
+			if err := writer.Write(row); err != nil {
+				t.Fatalf("写入 %s 失败: %v", tt.format, err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("关闭 %s 写入器失败: %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s 期望:\n%s\n得到:\n%s", tt.format, tt.want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package user_test
+
+import (
+	"base-gin/configs"
+	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/interfaces/middleware"
+	"base-gin/pkg/errreport"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubReporter 记录收到的事件
+type stubReporter struct {
+	mu     sync.Mutex
+	events []*errreport.Event
+}
+
+func (r *stubReporter) Report(event *errreport.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *stubReporter) Shutdown(ctx context.Context) error { return nil }
+
+func panicHandler(c *gin.Context) {
+	var values []int
+	_ = values[len(c.Query("n"))+3]
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(&configs.Config{Log: configs.LogConfig{Level: "error"}})
+	reporter := &stubReporter{}
+	r := gin.New()
+	r.Use(middleware.RequestMeta("X-Actor", nil), middleware.Recovery(logger, reporter))
+	r.GET("/users/:id", panicHandler)
+	r.GET("/string", func(c *gin.Context) { panic("出错了") })
+	r.GET("/written", func(c *gin.Context) {
+		c.String(http.StatusOK, "部分响应")
+		panic(errors.New("写入后出错"))
+	})
+	r.GET("/abort", func(c *gin.Context) { panic(http.ErrAbortHandler) })
+
+	req := httptest.NewRequest("GET", "/users/7?access_token=secret-token", nil)
+	req.Header.Set("X-Actor", "alice")
+	req.Header.Set("X-Request-ID", "req-panic")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+json") {
+		t.Fatalf("期望 500 问题详情，得到 %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var body struct {
+		Status    int    `json:"status"`
+		Error     string `json:"error"`
+		ErrorID   string `json:"error_id"`
+		RequestID string `json:"request_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Status != 500 || body.Error == "" || body.ErrorID == "" || body.RequestID != "req-panic" {
+		t.Errorf("响应体缺少字段: %s", w.Body.String())
+	}
+
+	if len(reporter.events) != 1 {
+		t.Fatalf("期望上报 1 个事件，得到 %d", len(reporter.events))
+	}
+	event := reporter.events[0]
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"错误 ID 与响应一致", event.ID, body.ErrorID},
+		{"错误类型", event.Type, "runtime.boundsError"},
+		{"路由", event.Request.Route, "/users/:id"},
+		{"隐藏访问令牌", event.Request.URL, "/users/7?access_token=REDACTED"},
+		{"请求 ID", event.Request.RequestID, "req-panic"},
+		{"操作者", event.User, "alice"},
+		{"出错位置", event.Stack[len(event.Stack)-1].Function, "panicHandler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("期望 %q，得到 %q", tt.want, tt.got)
+			}
+		})
+	}
+	if !event.Stack[len(event.Stack)-1].InApp {
+		t.Error("出错位置应标记为项目代码")
+	}
+
+	t.Run("非 error 类型的 panic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/string", nil))
+		if w.Code != http.StatusInternalServerError || reporter.events[1].Message != "出错了" {
+			t.Errorf("字符串 panic 应被处理和上报，得到 %d", w.Code)
+		}
+	})
+
+	t.Run("响应已写入时不再写入错误", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+		if w.Body.String() != "部分响应" || len(reporter.events) != 3 {
+			t.Errorf("不应追加错误响应，得到 %q", w.Body.String())
+		}
+	})
+
+	t.Run("ErrAbortHandler 交给 net/http", func(t *testing.T) {
+		defer func() {
+			if recovered := recover(); recovered != http.ErrAbortHandler {
+				t.Errorf("期望继续 panic ErrAbortHandler，得到 %v", recovered)
+			}
+		}()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	})
+}
+
+// sentryStub 记录收到的 envelope
+type sentryStub struct {
+	mu        sync.Mutex
+	auth      []string
+	envelopes [][]map[string]any
+	status    int
+}
+
+func (s *sentryStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var line map[string]any
+		json.Unmarshal(scanner.Bytes(), &line)
+		lines = append(lines, line)
+	}
+	s.auth = append(s.auth, r.URL.Path+" "+r.Header.Get("X-Sentry-Auth"))
+	s.envelopes = append(s.envelopes, lines)
+	if s.status != 0 {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(s.status)
+	}
+}
+
+func newTestEvent(message string) *errreport.Event {
+	return &errreport.Event{
+		ID:        "5d0f6c1e-8a4b-4f3e-9a51-0c2d7e8b1f4a",
+		Timestamp: time.Now(),
+		Level:     errreport.LevelError,
+		Type:      "*errors.errorString",
+		Message:   message,
+		Stack:     errreport.Stack(0),
+		Request:   &errreport.Request{Method: "GET", URL: "/users/1", RequestID: "req-1", IP: "192.0.2.1"},
+		User:      "alice",
+	}
+}
+
+func TestSentryReporter(t *testing.T) {
+	stub := &sentryStub{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	dsn := strings.Replace(server.URL, "http://", "http://public-key@", 1) + "/42"
+
+	reporter, err := errreport.NewSentryReporter(errreport.SentryOptions{
+		DSN:           dsn,
+		Environment:   "test",
+		RateLimit:     4,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("创建上报失败: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		reporter.Report(newTestEvent("相同的错误"))
+	}
+	reporter.Report(newTestEvent("另一个错误"))
+	reporter.Report(newTestEvent("超过速率限制"))
+	if err := reporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("关闭上报失败: %v", err)
+	}
+
+	if reporter.Dropped() != 1 {
+		t.Errorf("超过速率限制的事件应被丢弃，丢弃数 %d", reporter.Dropped())
+	}
+	if len(stub.envelopes) != 2 {
+		t.Fatalf("同一批次中相同的错误应合并，期望 2 个请求，得到 %d", len(stub.envelopes))
+	}
+	if want := "/api/42/envelope/ Sentry sentry_version=7"; !strings.HasPrefix(stub.auth[0], want) || !strings.Contains(stub.auth[0], "sentry_key=public-key") {
+		t.Errorf("请求地址或认证头错误: %s", stub.auth[0])
+	}
+
+	envelope := stub.envelopes[0]
+	if len(envelope) != 3 || envelope[1]["type"] != "event" {
+		t.Fatalf("envelope 应包含头部、条目头部和事件: %v", envelope)
+	}
+	event := envelope[2]
+	tags := event["tags"].(map[string]any)
+	exception := event["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	checks := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"事件 ID 不含连字符", event["event_id"], "5d0f6c1e8a4b4f3e9a510c2d7e8b1f4a"},
+		{"环境", event["environment"], "test"},
+		{"错误消息", exception["value"], "相同的错误"},
+		{"出现次数", event["extra"].(map[string]any)["occurrences"], float64(3)},
+		{"错误 ID", tags["error_id"], "5d0f6c1e-8a4b-4f3e-9a51-0c2d7e8b1f4a"},
+		{"请求 ID", tags["request_id"], "req-1"},
+		{"用户", event["user"].(map[string]any)["id"], "alice"},
+	}
+	for _, tt := range checks {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("期望 %v，得到 %v", tt.want, tt.got)
+			}
+		})
+	}
+	if frames := exception["stacktrace"].(map[string]any)["frames"].([]any); len(frames) == 0 {
+		t.Error("应包含调用栈")
+	}
+}
+
+func TestSentryReporterBackoff(t *testing.T) {
+	stub := &sentryStub{status: http.StatusTooManyRequests}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	reporter, err := errreport.NewSentryReporter(errreport.SentryOptions{
+		DSN:           strings.Replace(server.URL, "http://", "http://key@", 1) + "/1",
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("创建上报失败: %v", err)
+	}
+	reporter.Report(newTestEvent("第一个错误"))
+	reporter.Report(newTestEvent("第二个错误"))
+	reporter.Shutdown(context.Background())
+
+	if len(stub.envelopes) != 1 || reporter.Dropped() != 1 {
+		t.Errorf("服务端限流后应暂停发送，请求数 %d，丢弃数 %d", len(stub.envelopes), reporter.Dropped())
+	}
+}
+
+func TestSentryDSN(t *testing.T) {
+	for _, dsn := range []string{"", "not a url", "https://sentry.example.com/1", "https://key@sentry.example.com", "ftp://key@host/1"} {
+		if _, err := errreport.NewSentryReporter(errreport.SentryOptions{DSN: dsn}); !errors.Is(err, errreport.ErrInvalidDSN) {
+			t.Errorf("%q 期望 ErrInvalidDSN，得到 %v", dsn, err)
+		}
+	}
+}
+
+func TestLoggerErrorWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogger(&configs.Config{})
+	output, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	}()
+
+	logger.ErrorWith("panic recovered", logging.Fields{"error_id": "abc", "stack": "line1\nline2"})
+	line := strings.TrimSpace(buf.String())
+	if strings.Count(line, "\n") != 0 || !strings.Contains(line, `"error_id":"abc"`) {
+		t.Errorf("期望单行 JSON 字段，得到 %q", line)
+	}
+}
+
+func TestLoggerRedactsAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &buf
+	defer func() { gin.DefaultWriter = defaultWriter }()
+
+	logger := logging.NewLogger(&configs.Config{Log: configs.LogConfig{Level: "info"}})
+	r := gin.New()
+	r.Use(middleware.Logger(logger))
+	r.GET("/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events?types=user.created&access_token=secret-token", nil))
+	line := buf.String()
+	if strings.Contains(line, "secret-token") || !strings.Contains(line, "access_token=REDACTED") || !strings.Contains(line, "types=user.created") {
+		t.Errorf("请求日志应隐藏访问令牌并保留其他参数，得到 %q", line)
+	}
+}
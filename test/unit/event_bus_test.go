@@ -0,0 +1,159 @@
+package user_test
+
+import (
+	"base-gin/internal/domain/event"
+	"base-gin/internal/domain/user/entity"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventBusTypedSubscribers(t *testing.T) {
+	bus := event.NewBus()
+	defer bus.Close()
+
+	var created []int
+	var all []string
+	event.Subscribe(bus, func(ctx context.Context, e entity.UserCreated) error {
+		created = append(created, e.UserID)
+		return nil
+	})
+	unsubscribe := bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		all = append(all, e.EventName())
+		return nil
+	})
+
+	err := bus.Publish(context.Background(),
+		entity.UserCreated{UserID: 1},
+		entity.UserDeleted{UserID: 1},
+		entity.UserCreated{UserID: 2},
+	)
+	if err != nil {
+		t.Fatalf("发布事件失败: %v", err)
+	}
+	if len(created) != 2 || created[0] != 1 || created[1] != 2 {
+		t.Errorf("期望收到用户 1、2 的创建事件，得到 %v", created)
+	}
+	want := []string{entity.EventUserCreated, entity.EventUserDeleted, entity.EventUserCreated}
+	if strings.Join(all, ",") != strings.Join(want, ",") {
+		t.Errorf("期望按顺序收到 %v，得到 %v", want, all)
+	}
+
+	unsubscribe()
+	bus.Publish(context.Background(), entity.UserDeleted{UserID: 3})
+	if len(all) != 3 {
+		t.Errorf("取消订阅后不应再收到事件，得到 %v", all)
+	}
+}
+
+func TestEventBusFailures(t *testing.T) {
+	bus := event.NewBus()
+	defer bus.Close()
+
+	var flakyCalls, afterPanic int
+	bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		panic("boom")
+	}, event.WithName("panicky"))
+	bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		flakyCalls++
+		if flakyCalls < 3 {
+			return errors.New("暂时失败")
+		}
+		return nil
+	}, event.WithRetry(3, time.Millisecond))
+	bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		afterPanic++
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), entity.UserDeleted{UserID: 1})
+	if err == nil || !strings.Contains(err.Error(), "panicky") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("期望返回 panic 处理器的错误，得到 %v", err)
+	}
+	if flakyCalls != 3 {
+		t.Errorf("期望重试到第 3 次成功，实际执行 %d 次", flakyCalls)
+	}
+	if afterPanic != 1 {
+		t.Errorf("panic 不应影响其他处理器，实际执行 %d 次", afterPanic)
+	}
+}
+
+func TestEventBusAsync(t *testing.T) {
+	bus := event.NewBus()
+
+	var handled atomic.Int32
+	release := make(chan struct{})
+	event.Subscribe(bus, func(ctx context.Context, e entity.UserCreated) error {
+		<-release
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		handled.Add(1)
+		return nil
+	}, event.Async())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := bus.Publish(ctx, entity.UserCreated{UserID: 1}); err != nil {
+		t.Fatalf("发布事件失败: %v", err)
+	}
+	// 请求结束后上下文被取消，异步处理器仍应执行
+	cancel()
+	if handled.Load() != 0 {
+		t.Fatal("Publish 不应等待异步处理器")
+	}
+
+	close(release)
+	bus.Close()
+	if handled.Load() != 1 {
+		t.Errorf("期望 Close 等待异步处理器执行完毕，实际执行 %d 次", handled.Load())
+	}
+
+	// 关闭后改为同步执行
+	bus.Publish(context.Background(), entity.UserCreated{UserID: 2})
+	if handled.Load() != 2 {
+		t.Errorf("期望关闭后同步执行，实际执行 %d 次", handled.Load())
+	}
+}
+
+func TestEventBusInTransaction(t *testing.T) {
+	bus := event.NewBus()
+	defer bus.Close()
+
+	var inTx, afterCommit []string
+	bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		inTx = append(inTx, e.EventName())
+		if e.EventName() == entity.EventUserDeleted {
+			return errors.New("写入失败")
+		}
+		return nil
+	}, event.WithName("webhook"), event.InTransaction())
+	bus.SubscribeAll(func(ctx context.Context, e event.Event) error {
+		afterCommit = append(afterCommit, e.EventName())
+		return nil
+	})
+
+	if err := bus.PublishInTransaction(context.Background(), entity.UserCreated{UserID: 1}); err != nil {
+		t.Fatalf("事务内发布失败: %v", err)
+	}
+	if len(inTx) != 1 || len(afterCommit) != 0 {
+		t.Errorf("PublishInTransaction 只应调用事务内处理器，得到 %v %v", inTx, afterCommit)
+	}
+
+	err := bus.PublishInTransaction(context.Background(), entity.UserDeleted{UserID: 1}, entity.UserCreated{UserID: 2})
+	if err == nil || !strings.Contains(err.Error(), "webhook") {
+		t.Errorf("期望返回事务内处理器的错误，得到 %v", err)
+	}
+	if len(inTx) != 2 {
+		t.Errorf("遇到错误后应停止处理后续事件，得到 %v", inTx)
+	}
+
+	if err := bus.Publish(context.Background(), entity.UserCreated{UserID: 3}); err != nil {
+		t.Fatalf("发布事件失败: %v", err)
+	}
+	if len(inTx) != 2 || len(afterCommit) != 1 {
+		t.Errorf("Publish 不应调用事务内处理器，得到 %v %v", inTx, afterCommit)
+	}
+}
@@ -0,0 +1,91 @@
+package user_test
+
+import (
+	"base-gin/internal/infrastructure/eventstream"
+	"strings"
+	"testing"
+)
+
+func TestEventStreamBroker(t *testing.T) {
+	broker := eventstream.NewMemoryBroker(3, 2)
+	defer broker.Close()
+
+	first, _, resumed := broker.Subscribe("", nil)
+	if !resumed {
+		t.Fatal("不带 Last-Event-ID 订阅应视为可续传")
+	}
+	defer first.Close()
+
+	for i, eventType := range []string{"user.created", "user.renamed", "user.deleted", "user.created"} {
+		broker.Publish(eventType, []byte(`{}`))
+		if i == 1 {
+			// 读走前两条，避免缓冲写满
+			<-first.C
+			<-first.C
+		}
+	}
+	m1, m2 := <-first.C, <-first.C
+	if m1.Type != "user.deleted" || m2.Type != "user.created" || !strings.HasSuffix(m1.ID, "-3") || !strings.HasSuffix(m2.ID, "-4") {
+		t.Fatalf("事件顺序错误: %+v %+v", m1, m2)
+	}
+
+	// 缓冲保留最近 3 条（序号 2 到 4）
+	epoch, _, _ := strings.Cut(m1.ID, "-")
+	onlyCreated := func(eventType string) bool { return eventType == "user.created" }
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		filter      func(string) bool
+		wantResumed bool
+		wantTypes   []string
+	}{
+		{"缓冲范围内续传", epoch + "-2", nil, true, []string{"user.deleted", "user.created"}},
+		{"续传并筛选类型", epoch + "-1", onlyCreated, true, []string{"user.created"}},
+		{"已是最新", epoch + "-4", nil, true, nil},
+		{"超出缓冲范围", epoch + "-0", nil, false, nil},
+		{"其他启动周期的 ID", "abc-2", nil, false, nil},
+		{"未来的序号", epoch + "-9", nil, false, nil},
+		{"无法识别的 ID", "garbage", nil, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay, resumed := broker.Subscribe(tt.lastEventID, tt.filter)
+			defer sub.Close()
+			if resumed != tt.wantResumed {
+				t.Fatalf("期望 resumed=%v，得到 %v", tt.wantResumed, resumed)
+			}
+			var types []string
+			for _, msg := range replay {
+				types = append(types, msg.Type)
+			}
+			if strings.Join(types, ",") != strings.Join(tt.wantTypes, ",") {
+				t.Errorf("期望补发 %v，得到 %v", tt.wantTypes, types)
+			}
+		})
+	}
+
+	// 消费过慢的连接被断开
+	slow, _, _ := broker.Subscribe("", onlyCreated)
+	for i := 0; i < 3; i++ {
+		broker.Publish("user.created", []byte(`{}`))
+	}
+	count := 0
+	for range slow.C {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("期望缓冲写满后断开，收到 %d 条", count)
+	}
+
+	// 关闭后所有连接断开，不再接受订阅
+	last, _, _ := broker.Subscribe("", nil)
+	broker.Close()
+	if _, ok := <-last.C; ok {
+		t.Error("关闭后连接应断开")
+	}
+	if sub, _, _ := broker.Subscribe("", nil); sub != nil {
+		t.Error("关闭后不应接受订阅")
+	}
+}
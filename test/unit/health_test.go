@@ -0,0 +1,215 @@
+package user_test
+
+import (
+	"base-gin/pkg/health"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker(t *testing.T) {
+	failing := errors.New("连接被拒绝")
+
+	tests := []struct {
+		name       string
+		register   func(c *health.Checker)
+		wantReady  string
+		wantLive   string
+		wantChecks int
+	}{
+		{
+			name:       "没有检查",
+			register:   func(c *health.Checker) {},
+			wantReady:  health.StatusOK,
+			wantLive:   health.StatusOK,
+			wantChecks: 0,
+		},
+		{
+			name: "必需检查失败",
+			register: func(c *health.Checker) {
+				c.Register("database", func(ctx context.Context) error { return failing })
+			},
+			wantReady:  health.StatusFail,
+			wantLive:   health.StatusOK,
+			wantChecks: 1,
+		},
+		{
+			name: "可选检查失败只降级",
+			register: func(c *health.Checker) {
+				c.Register("database", func(ctx context.Context) error { return nil })
+				c.Register("redis", func(ctx context.Context) error { return failing }, health.Optional())
+			},
+			wantReady:  health.StatusDegraded,
+			wantLive:   health.StatusOK,
+			wantChecks: 2,
+		},
+		{
+			name: "存活检查失败",
+			register: func(c *health.Checker) {
+				c.Register("worker", func(ctx context.Context) error { return failing }, health.Liveness())
+			},
+			wantReady:  health.StatusFail,
+			wantLive:   health.StatusFail,
+			wantChecks: 1,
+		},
+		{
+			name: "检查超时",
+			register: func(c *health.Checker) {
+				c.Register("slow", func(ctx context.Context) error {
+					time.Sleep(time.Second)
+					return nil
+				}, health.WithTimeout(20*time.Millisecond))
+			},
+			wantReady:  health.StatusFail,
+			wantLive:   health.StatusOK,
+			wantChecks: 1,
+		},
+		{
+			name: "检查 panic",
+			register: func(c *health.Checker) {
+				c.Register("panic", func(ctx context.Context) error { panic("boom") })
+			},
+			wantReady:  health.StatusFail,
+			wantLive:   health.StatusOK,
+			wantChecks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second, 0, time.Minute)
+			tt.register(checker)
+
+			start := time.Now()
+			ready := checker.Ready(context.Background())
+			if time.Since(start) > 500*time.Millisecond {
+				t.Errorf("超时未生效，耗时 %s", time.Since(start))
+			}
+			if ready.Status != tt.wantReady {
+				t.Errorf("就绪状态期望 %s，得到 %s", tt.wantReady, ready.Status)
+			}
+			if len(ready.Checks) != tt.wantChecks {
+				t.Errorf("期望 %d 项检查，得到 %d", tt.wantChecks, len(ready.Checks))
+			}
+			if live := checker.Live(context.Background()); live.Status != tt.wantLive {
+				t.Errorf("存活状态期望 %s，得到 %s", tt.wantLive, live.Status)
+			}
+			for _, result := range ready.Checks {
+				if result.Status == health.StatusFail && result.Error == "" {
+					t.Errorf("检查 %s 失败时应包含错误信息", result.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthCheckerCache(t *testing.T) {
+	var calls atomic.Int32
+	checker := health.NewChecker(time.Second, time.Hour, time.Minute)
+	checker.Register("database", func(ctx context.Context) error {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	// 并发请求共享同一次执行
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Ready(context.Background())
+		}()
+	}
+	wg.Wait()
+	if got := calls.Load(); got != 1 {
+		t.Errorf("缓存期内检查应只执行 1 次，执行了 %d 次", got)
+	}
+
+	report := checker.Health(context.Background())
+	if !report.Checks[0].Cached {
+		t.Error("缓存期内的结果应标记为 cached")
+	}
+
+	// 不缓存的检查每次都执行
+	var uncached atomic.Int32
+	checker.Register("disk", func(ctx context.Context) error {
+		uncached.Add(1)
+		return nil
+	}, health.WithCacheTTL(0))
+	checker.Ready(context.Background())
+	checker.Ready(context.Background())
+	if got := uncached.Load(); got != 2 {
+		t.Errorf("不缓存的检查应执行 2 次，执行了 %d 次", got)
+	}
+}
+
+func TestHealthCheckerShutdown(t *testing.T) {
+	checker := health.NewChecker(time.Second, 0, time.Minute)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+
+	checker.SetShuttingDown()
+
+	ready := checker.Ready(context.Background())
+	if ready.Status != health.StatusFail || ready.Checks[0].Name != "shutdown" {
+		t.Errorf("关闭期间就绪检查应失败，得到 %s", ready.Status)
+	}
+	if live := checker.Live(context.Background()); live.Status != health.StatusOK {
+		t.Errorf("关闭期间存活检查不应失败，得到 %s", live.Status)
+	}
+	if all := checker.Health(context.Background()); all.Status != health.StatusOK {
+		t.Errorf("关闭状态不应影响依赖检查汇总，得到 %s", all.Status)
+	}
+}
+
+func TestHealthHeartbeat(t *testing.T) {
+	// 心跳超时为 3 倍间隔与最短超时中的较大值
+	checker := health.NewChecker(time.Second, time.Hour, 30*time.Millisecond)
+	heartbeat := checker.Heartbeat("job:test", 5*time.Millisecond)
+
+	if live := checker.Live(context.Background()); live.Status != health.StatusOK {
+		t.Fatalf("注册后应立即有一次心跳，得到 %s", live.Status)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	live := checker.Live(context.Background())
+	if live.Status != health.StatusFail {
+		t.Fatalf("心跳超时后存活检查应失败，得到 %s", live.Status)
+	}
+	if live.Checks[0].Cached {
+		t.Error("心跳检查不应缓存")
+	}
+
+	heartbeat.Beat()
+	if live := checker.Live(context.Background()); live.Status != health.StatusOK {
+		t.Errorf("恢复心跳后存活检查应通过，得到 %s", live.Status)
+	}
+
+	heartbeat.Stop()
+	if live := checker.Live(context.Background()); len(live.Checks) != 0 {
+		t.Errorf("任务退出后应移除心跳检查，仍有 %d 项", len(live.Checks))
+	}
+
+	// 未启用健康检查时心跳为 nil，调用不应 panic
+	var disabled *health.Checker
+	hb := disabled.Heartbeat("job:none", time.Second)
+	hb.Beat()
+	hb.Stop()
+}
+
+func TestHealthDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := health.DiskSpace(dir, 1)(context.Background()); err != nil {
+		t.Errorf("可用空间充足时不应失败: %v", err)
+	}
+	if err := health.DiskSpace(dir, 1<<62)(context.Background()); err == nil {
+		t.Error("可用空间不足时应失败")
+	}
+	if err := health.DiskSpace(dir+"/missing", 1)(context.Background()); err == nil {
+		t.Error("目录不存在时应失败")
+	}
+}
@@ -0,0 +1,140 @@
+package user_test
+
+import (
+	"base-gin/internal/infrastructure/cache"
+	"base-gin/internal/interfaces/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := cache.NewMemoryStore(2)
+
+	store.Set("a", []byte("1"), time.Minute, "list", "user:1")
+	store.Set("b", []byte("2"), time.Minute, "user:2")
+	store.Set("c", []byte("3"), time.Minute)
+	if _, ok := store.Get("c"); ok {
+		t.Error("缓存已满时不应写入")
+	}
+
+	store.InvalidateTags("user:1")
+	if _, ok := store.Get("a"); ok {
+		t.Error("按标签失效后不应命中")
+	}
+	if value, ok := store.Get("b"); !ok || string(value) != "2" {
+		t.Errorf("其他标签的缓存不应失效，得到 %q", value)
+	}
+
+	store.Set("d", []byte("4"), -time.Second)
+	if _, ok := store.Get("d"); ok {
+		t.Error("过期的缓存不应命中")
+	}
+	if store.Len() != 1 {
+		t.Errorf("期望剩余 1 条缓存，得到 %d", store.Len())
+	}
+}
+
+func TestHTTPCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemoryStore(0)
+	calls := 0
+
+	r := gin.New()
+	tags := func(c *gin.Context) []string { return []string{"items"} }
+	r.Use(middleware.InvalidateCache(store, tags))
+	policy := middleware.CachePolicy{Vary: "Accept", Store: store, TTL: time.Minute, Tags: tags}
+	r.GET("/items", middleware.HTTPCache(policy), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"data": []int{1, 2, 3}})
+	})
+	r.GET("/items/1", middleware.HTTPCache(middleware.CachePolicy{MaxAge: 60}), func(c *gin.Context) {
+		c.Header("ETag", `"3"`)
+		c.Header("Last-Modified", "Sat, 01 Jun 2024 12:00:00 GMT")
+		c.JSON(http.StatusOK, gin.H{"data": 1})
+	})
+	r.GET("/missing", middleware.HTTPCache(policy), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusNotFound, gin.H{"error": "不存在"})
+	})
+	r.POST("/items", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	r.POST("/items/import", func(c *gin.Context) {
+		middleware.MarkModified(c)
+		c.Status(http.StatusUnprocessableEntity)
+	})
+
+	do := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/items", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != `{"data":[1,2,3]}` {
+		t.Fatalf("期望 200 和完整响应，得到 %d %s", w.Code, w.Body.String())
+	}
+	if len(etag) < 3 || etag[:2] != "W/" {
+		t.Errorf("期望弱 ETag，得到 %q", etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("期望 Cache-Control private, no-cache，得到 %q", got)
+	}
+	if got := w.Header().Get("X-Cache"); got != "MISS" {
+		t.Errorf("期望 X-Cache MISS，得到 %q", got)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		wantStatus int
+		wantCache  string
+		wantCalls  int
+	}{
+		{"缓存命中", "GET", "/items", nil, http.StatusOK, "HIT", 1},
+		{"条件请求命中", "GET", "/items", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "HIT", 1},
+		{"Vary 头不同", "GET", "/items", map[string]string{"Accept": "text/plain"}, http.StatusOK, "MISS", 2},
+		{"客户端要求不使用缓存", "GET", "/items", map[string]string{"Cache-Control": "no-cache"}, http.StatusOK, "MISS", 3},
+		{"修改后失效", "POST", "/items", nil, http.StatusCreated, "", 3},
+		{"失效后重新获取", "GET", "/items", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "MISS", 4},
+		{"部分修改已提交的失败请求同样失效", "POST", "/items/import", nil, http.StatusUnprocessableEntity, "", 4},
+		{"部分提交后重新获取", "GET", "/items", nil, http.StatusOK, "MISS", 5},
+		{"错误响应不缓存", "GET", "/missing", nil, http.StatusNotFound, "", 6},
+		{"错误响应不缓存", "GET", "/missing", nil, http.StatusNotFound, "", 7},
+		{"强 ETag 弱比较", "GET", "/items/1", map[string]string{"If-None-Match": `W/"3"`}, http.StatusNotModified, "", 7},
+		{"ETag 不匹配", "GET", "/items/1", map[string]string{"If-None-Match": `"2"`}, http.StatusOK, "", 7},
+		{"未修改", "GET", "/items/1", map[string]string{"If-Modified-Since": "Sat, 01 Jun 2024 12:00:00 GMT"}, http.StatusNotModified, "", 7},
+		{"已修改", "GET", "/items/1", map[string]string{"If-Modified-Since": "Sat, 01 Jun 2024 11:59:59 GMT"}, http.StatusOK, "", 7},
+	}
+
+	for _, tt := range tests {
+		w := do(tt.method, tt.path, tt.header)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: 期望状态码 %d，得到 %d", tt.name, tt.wantStatus, w.Code)
+		}
+		if got := w.Header().Get("X-Cache"); got != tt.wantCache {
+			t.Errorf("%s: 期望 X-Cache %q，得到 %q", tt.name, tt.wantCache, got)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s: 期望处理器调用 %d 次，得到 %d", tt.name, tt.wantCalls, calls)
+		}
+		if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("%s: 304 响应不应有响应体", tt.name)
+		}
+	}
+
+	if got := do("GET", "/items/1", nil).Header().Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("期望 Cache-Control private, max-age=60，得到 %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package user_test
+
+import (
+	"base-gin/pkg/jsonpatch"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("解析 JSON 失败: %v", err)
+	}
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396 附录 A 中的部分示例
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		doc := decodeJSON(t, tt.doc)
+		got, err := jsonpatch.MergePatch(doc, []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("MergePatch(%s, %s) 错误: %v", tt.doc, tt.patch, err)
+		}
+		if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("MergePatch(%s, %s) 期望 %v，得到 %v", tt.doc, tt.patch, want, got)
+		}
+		if original := decodeJSON(t, tt.doc); !reflect.DeepEqual(doc, original) {
+			t.Errorf("原文档被修改: %v", doc)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+		wantErr                error
+	}{
+		{name: "replace", doc: `{"name":"a"}`, patch: `[{"op":"replace","path":"/name","value":"b"}]`, want: `{"name":"b"}`},
+		{name: "add array", doc: `{"tags":["a","c"]}`, patch: `[{"op":"add","path":"/tags/1","value":"b"},{"op":"add","path":"/tags/-","value":"d"}]`, want: `{"tags":["a","b","c","d"]}`},
+		{name: "remove array", doc: `{"tags":["a","b"]}`, patch: `[{"op":"remove","path":"/tags/0"}]`, want: `{"tags":["b"]}`},
+		{name: "move", doc: `{"a":{"b":1},"c":{}}`, patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`, want: `{"a":{},"c":{"d":1}}`},
+		{name: "copy", doc: `{"a":[1]}`, patch: `[{"op":"copy","from":"/a","path":"/b"}]`, want: `{"a":[1],"b":[1]}`},
+		{name: "escaped pointer", doc: `{"a/b":1,"m~n":2}`, patch: `[{"op":"test","path":"/a~1b","value":1},{"op":"remove","path":"/m~0n"}]`, want: `{"a/b":1}`},
+		{name: "replace with null", doc: `{"name":"a"}`, patch: `[{"op":"replace","path":"/name","value":null}]`, want: `{"name":null}`},
+		{name: "add null", doc: `{}`, patch: `[{"op":"add","path":"/a","value":null}]`, want: `{"a":null}`},
+		{name: "test null", doc: `{"a":null}`, patch: `[{"op":"test","path":"/a","value":null},{"op":"remove","path":"/a"}]`, want: `{}`},
+		{name: "test null failed", doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":null}]`, wantErr: jsonpatch.ErrTestFailed},
+		{name: "missing value", doc: `{"name":"a"}`, patch: `[{"op":"replace","path":"/name"}]`, wantErr: jsonpatch.ErrInvalidPatch},
+		{name: "test failed", doc: `{"name":"a"}`, patch: `[{"op":"test","path":"/name","value":"x"}]`, wantErr: jsonpatch.ErrTestFailed},
+		{name: "missing path", doc: `{}`, patch: `[{"op":"replace","path":"/name","value":"x"}]`, wantErr: jsonpatch.ErrInvalidPatch},
+		{name: "unknown op", doc: `{}`, patch: `[{"op":"merge","path":"/a","value":1}]`, wantErr: jsonpatch.ErrInvalidPatch},
+		{name: "leading zero", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/01"}]`, wantErr: jsonpatch.ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decodeJSON(t, tt.doc)
+			got, err := jsonpatch.Apply(doc, []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("期望错误 %v，得到 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望错误，但得到错误: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("期望 %v，得到 %v", want, got)
+			}
+			if original := decodeJSON(t, tt.doc); !reflect.DeepEqual(doc, original) {
+				t.Errorf("原文档被修改: %v", doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package user_test
+
+import (
+	"base-gin/internal/interfaces/middleware"
+	"base-gin/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	counter := registry.NewCounterVec("jobs_total", "Jobs run.\nBy result.", "result")
+	counter.With("ok").Add(2)
+	counter.With(`bad "x"\y`).Inc()
+
+	gauge := registry.NewGauge("queue_depth", "Queue depth.")
+	gauge.Set(3)
+	gauge.Dec()
+
+	histogram := registry.NewHistogram("job_seconds", "Job latency.", []float64{0.1, 1})
+	for _, v := range []float64{0.05, 0.5, 0.5, 3} {
+		histogram.Observe(v)
+	}
+
+	registry.NewGaugeFunc("answer", "", func() float64 { return 42 })
+	registry.NewCounterVec("unused_total", "Never incremented.", "x")
+
+	var b strings.Builder
+	if _, err := registry.WriteTo(&b); err != nil {
+		t.Fatalf("输出指标失败: %v", err)
+	}
+	out := b.String()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"HELP 转义换行", `# HELP jobs_total Jobs run.\nBy result.`},
+		{"TYPE 行", "# TYPE jobs_total counter"},
+		{"带标签的计数器", `jobs_total{result="ok"} 2`},
+		{"标签值转义", `jobs_total{result="bad \"x\"\\y"} 1`},
+		{"仪表盘", "queue_depth 2"},
+		{"直方图桶累计", `job_seconds_bucket{le="0.1"} 1`},
+		{"直方图上一个桶", `job_seconds_bucket{le="1"} 3`},
+		{"直方图 +Inf 桶", `job_seconds_bucket{le="+Inf"} 4`},
+		{"直方图总和", "job_seconds_sum 4.05"},
+		{"直方图次数", "job_seconds_count 4"},
+		{"函数仪表盘", "answer 42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.line+"\n") {
+				t.Errorf("输出中缺少 %q:\n%s", tt.line, out)
+			}
+		})
+	}
+
+	if strings.Contains(out, "unused_total") {
+		t.Error("没有样本的指标不应输出")
+	}
+	if strings.Contains(out, "# HELP answer") {
+		t.Error("帮助文本为空时不应输出 HELP 行")
+	}
+	if strings.Index(out, "answer") > strings.Index(out, "jobs_total") {
+		t.Error("指标应按名称排序")
+	}
+
+	// 注册校验
+	if err := registry.Register(metrics.CollectorFunc(func() []metrics.Family {
+		return []metrics.Family{{Name: "queue_depth", Type: metrics.TypeGauge}}
+	})); err == nil {
+		t.Error("重复的指标名称应注册失败")
+	}
+	if err := registry.Register(metrics.CollectorFunc(func() []metrics.Family {
+		return []metrics.Family{{Name: "bad-name", Type: metrics.TypeGauge}}
+	})); err == nil {
+		t.Error("无效的指标名称应注册失败")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("标签值个数不一致时应 panic")
+			}
+		}()
+		counter.With("a", "b")
+	}()
+
+	// HTTP 处理器
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Header().Get("Content-Type") != metrics.ContentType || !strings.Contains(rec.Body.String(), "queue_depth 2") {
+		t.Errorf("处理器响应错误: %s %s", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestGoCollector(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.NewGoCollector())
+
+	var b strings.Builder
+	registry.WriteTo(&b)
+	for _, name := range []string{"go_goroutines ", "go_info{version=", "go_memstats_alloc_bytes ", "go_gc_cycles_total ", "process_start_time_seconds "} {
+		if !strings.Contains(b.String(), name) {
+			t.Errorf("缺少运行时指标 %s", name)
+		}
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := metrics.NewRegistry()
+	r := gin.New()
+	r.Use(middleware.Metrics(registry))
+	r.Any("/items", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/abort", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		panic(http.ErrAbortHandler)
+	})
+
+	do := func(method, path string) {
+		defer func() {
+			if recovered := recover(); recovered != nil && recovered != http.ErrAbortHandler {
+				panic(recovered)
+			}
+		}()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+	}
+	do("GET", "/items")
+	do("PURGE", "/items")
+	do("X-CUSTOM-1", "/items")
+	do("GET", "/abort")
+
+	var b strings.Builder
+	registry.WriteTo(&b)
+	out := b.String()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"标准方法", `http_requests_total{method="GET",route="/items",status="204"} 1`},
+		{"非标准方法归为 OTHER", `http_requests_total{method="OTHER",route="unmatched",status="404"} 2`},
+		{"中断连接的请求同样统计", `http_requests_total{method="GET",route="/abort",status="200"} 1`},
+		{"处理中请求数归零", "http_requests_in_flight 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.line+"\n") {
+				t.Errorf("输出中缺少 %q:\n%s", tt.line, out)
+			}
+		})
+	}
+	if strings.Contains(out, "PURGE") || strings.Contains(out, "X-CUSTOM-1") {
+		t.Error("非标准方法不应作为标签值")
+	}
+}
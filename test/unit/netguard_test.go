@@ -0,0 +1,82 @@
+package user_test
+
+import (
+	"base-gin/pkg/netguard"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestNetguardCheck(t *testing.T) {
+	guard, err := netguard.NewGuard([]string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatalf("创建校验失败: %v", err)
+	}
+
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"203.0.113.5", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"10.1.2.3", true},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := guard.Check(netip.MustParseAddr(tt.addr))
+			if (err == nil) != tt.allowed {
+				t.Errorf("期望允许 %v，得到 %v", tt.allowed, err)
+			}
+		})
+	}
+	if err := guard.CheckHost("localhost"); !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("localhost 应被拒绝，得到 %v", err)
+	}
+	if err := guard.CheckHost("example.com"); err != nil {
+		t.Errorf("域名在连接时校验，得到 %v", err)
+	}
+	if _, err := netguard.NewGuard([]string{"bad"}); err == nil {
+		t.Error("无效的网段应返回错误")
+	}
+}
+
+func TestNetguardClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	blocked, _ := netguard.NewGuard(nil)
+	if _, err := blocked.Client(time.Second).Get(server.URL); !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("连接回环地址应返回 ErrForbiddenAddress，得到 %v", err)
+	}
+
+	loopback, _ := netguard.NewGuard([]string{"127.0.0.0/8"})
+	resp, err := loopback.Client(time.Second).Get(server.URL + "/redirect")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("不应跟随重定向，期望 302，得到 %d", resp.StatusCode)
+	}
+}
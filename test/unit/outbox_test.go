@@ -0,0 +1,177 @@
+package user_test
+
+import (
+	"base-gin/internal/infrastructure/outbox"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOutboxMessage(id string) *outbox.Message {
+	return &outbox.Message{
+		ID:            id,
+		Type:          "user.created",
+		AggregateType: "user",
+		AggregateID:   "1",
+		OccurredAt:    time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Data:          json.RawMessage(`{"user_id":1}`),
+		Attempt:       2,
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "outbox.jsonl")
+	sink, err := outbox.NewFileSink(path)
+	if err != nil {
+		t.Fatalf("创建文件投递目标失败: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := sink.Publish(context.Background(), testOutboxMessage(id)); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+	}
+	sink.Close()
+
+	content, _ := os.ReadFile(path)
+	want := `{"id":"a","type":"user.created","aggregate_type":"user","aggregate_id":"1","occurred_at":"2024-06-01T12:00:00Z","data":{"user_id":1}}` + "\n" +
+		`{"id":"b","type":"user.created","aggregate_type":"user","aggregate_id":"1","occurred_at":"2024-06-01T12:00:00Z","data":{"user_id":1}}` + "\n"
+	if string(content) != want {
+		t.Errorf("期望:\n%s得到:\n%s", want, content)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	status := http.StatusAccepted
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		fmt.Fprint(w, "down for maintenance")
+	}))
+	defer server.Close()
+
+	sink := outbox.NewHTTPSink(server.URL, time.Second)
+	if err := sink.Publish(context.Background(), testOutboxMessage("a")); err != nil {
+		t.Fatalf("期望 2xx 视为成功，得到 %v", err)
+	}
+	if headers.Get("X-Event-ID") != "a" || headers.Get("X-Event-Type") != "user.created" || headers.Get("X-Delivery-Attempt") != "2" {
+		t.Errorf("请求头错误: %v", headers)
+	}
+	if !strings.Contains(string(body), `"data":{"user_id":1}`) {
+		t.Errorf("请求体错误: %s", body)
+	}
+
+	status = http.StatusServiceUnavailable
+	err := sink.Publish(context.Background(), testOutboxMessage("a"))
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "down for maintenance") {
+		t.Errorf("期望返回状态码和响应片段，得到 %v", err)
+	}
+}
+
+// natsStandIn 只实现 INFO/CONNECT/PUB/PING 的 NATS 服务端替身，
+// 收到的消息写入 published；subject 以 fail 结尾时返回 -ERR
+type natsStandIn struct {
+	listener  net.Listener
+	published chan string
+	connects  chan string
+}
+
+func newNATSStandIn(t *testing.T) *natsStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	s := &natsStandIn{listener: listener, published: make(chan string, 10), connects: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *natsStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprint(conn, "INFO {\"server_id\":\"stand-in\"}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "CONNECT":
+			s.connects <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+		case fields[0] == "PUB" && len(fields) == 3:
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			if strings.HasSuffix(fields[1], "fail") {
+				fmt.Fprint(conn, "-ERR 'Permissions Violation'\r\n")
+				return
+			}
+			s.published <- fields[1] + " " + string(payload[:size])
+		case fields[0] == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	server := newNATSStandIn(t)
+	sink, err := outbox.NewNATSSink("nats://app:secret@"+server.listener.Addr().String(), "events", time.Second)
+	if err != nil {
+		t.Fatalf("创建 NATS 投递目标失败: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Publish(context.Background(), testOutboxMessage("a")); err != nil {
+		t.Fatalf("投递失败: %v", err)
+	}
+	if connect := <-server.connects; !strings.Contains(connect, `"user":"app"`) || !strings.Contains(connect, `"pass":"secret"`) {
+		t.Errorf("CONNECT 缺少认证信息: %s", connect)
+	}
+	if got := <-server.published; !strings.HasPrefix(got, `events.user.created {"id":"a"`) {
+		t.Errorf("期望发布到 events.user.created，得到 %s", got)
+	}
+
+	// 服务端返回 -ERR 时投递失败并断开，下次发布重新连接
+	failing := testOutboxMessage("b")
+	failing.Type = "user.fail"
+	if err := sink.Publish(context.Background(), failing); err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Errorf("期望返回服务端错误，得到 %v", err)
+	}
+	if err := sink.Publish(context.Background(), testOutboxMessage("c")); err != nil {
+		t.Fatalf("重连后投递失败: %v", err)
+	}
+	<-server.connects
+	if got := <-server.published; !strings.Contains(got, `"id":"c"`) {
+		t.Errorf("期望重连后收到消息 c，得到 %s", got)
+	}
+
+	if _, err := outbox.NewNATSSink("http://localhost:4222", "events", time.Second); err == nil {
+		t.Error("期望拒绝非 nats:// 地址")
+	}
+}
@@ -0,0 +1,203 @@
+package user_test
+
+import (
+	"base-gin/pkg/pagination"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type cursorItem struct {
+	ID    int
+	Score int
+	Name  string
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec := pagination.NewCursorCodec([]byte("secret"))
+	keys := []pagination.SortKey{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 123456789, time.FixedZone("CST", 8*3600))
+
+	cursor, err := codec.Encode(keys, []interface{}{now, 42})
+	if err != nil {
+		t.Fatalf("编码游标失败: %v", err)
+	}
+
+	values, err := codec.Decode(keys, cursor)
+	if err != nil {
+		t.Fatalf("解析游标失败: %v", err)
+	}
+	if got, ok := values[0].(time.Time); !ok || !got.Equal(now) {
+		t.Errorf("期望时间 %v，得到 %v", now, values[0])
+	}
+	if got, ok := values[1].(int64); !ok || got != 42 {
+		t.Errorf("期望 id 42，得到 %v", values[1])
+	}
+
+	if _, err := codec.Decode(keys, cursor+"x"); err == nil {
+		t.Error("期望篡改后的游标校验失败")
+	}
+	if _, err := pagination.NewCursorCodec([]byte("other")).Decode(keys, cursor); err == nil {
+		t.Error("期望不同密钥签名的游标校验失败")
+	}
+	if _, err := codec.Decode([]pagination.SortKey{{Column: "id"}}, cursor); err == nil {
+		t.Error("期望排序键不同的游标校验失败")
+	}
+}
+
+func TestCursorScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.AutoMigrate(&cursorItem{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	for i := 1; i <= 7; i++ {
+		db.Create(&cursorItem{ID: i, Score: i % 3, Name: string(rune('a' + i))})
+	}
+
+	codec := pagination.NewCursorCodec([]byte("secret"))
+	keyOf := func(item cursorItem) []interface{} { return []interface{}{item.Score, item.ID} }
+
+	tests := []struct {
+		name string
+		keys []pagination.SortKey
+		want string
+	}{
+		{name: "uniform", keys: []pagination.SortKey{{Column: "score"}, {Column: "id"}}, want: "3,6,1,4,7,2,5"},
+		{name: "mixed", keys: []pagination.SortKey{{Column: "score", Desc: true}, {Column: "id"}}, want: "2,5,1,4,7,3,6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 向后翻到底
+			var ids []string
+			var pages []pagination.CursorMeta
+			req := &pagination.CursorRequest{Limit: 2}
+			for {
+				q, err := codec.NewCursorQuery(req, tt.keys)
+				if err != nil {
+					t.Fatalf("创建游标查询失败: %v", err)
+				}
+				var rows []cursorItem
+				db.Scopes(q.Scope()).Find(&rows)
+				rows, meta, err := pagination.Paginate(q, rows, keyOf)
+				if err != nil {
+					t.Fatalf("分页失败: %v", err)
+				}
+				for _, r := range rows {
+					ids = append(ids, strconv.Itoa(r.ID))
+				}
+				pages = append(pages, meta)
+				if !meta.HasMore {
+					break
+				}
+				req = &pagination.CursorRequest{After: meta.NextCursor, Limit: 2}
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("期望顺序 %s，得到 %s", tt.want, got)
+			}
+
+			// 从最后一页往回翻一页
+			last := pages[len(pages)-1]
+			q, err := codec.NewCursorQuery(&pagination.CursorRequest{Before: last.PrevCursor, Limit: 2}, tt.keys)
+			if err != nil {
+				t.Fatalf("创建游标查询失败: %v", err)
+			}
+			var rows []cursorItem
+			db.Scopes(q.Scope()).Find(&rows)
+			rows, _, _ = pagination.Paginate(q, rows, keyOf)
+			want := strings.Split(tt.want, ",")[4:6]
+			if got := []string{strconv.Itoa(rows[0].ID), strconv.Itoa(rows[1].ID)}; strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("期望上一页 %v，得到 %v", want, got)
+			}
+		})
+	}
+}
+
+func TestCursorScopeTimeOffsets(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	type timedItem struct {
+		ID        int
+		CreatedAt time.Time
+	}
+	if err := db.AutoMigrate(&timedItem{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	// SQLite 保存写入时的偏移，文本顺序为 5,4,2,3,1，实际时刻顺序为 5,3,1,4,2（1 与 4 同一时刻）
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	newYork := time.FixedZone("UTC-5", -5*3600)
+	db.Create(&timedItem{ID: 1, CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, shanghai)})
+	db.Create(&timedItem{ID: 2, CreatedAt: time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)})
+	db.Create(&timedItem{ID: 3, CreatedAt: time.Date(2024, 6, 1, 9, 30, 0, 0, shanghai)})
+	db.Create(&timedItem{ID: 4, CreatedAt: time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)})
+	db.Create(&timedItem{ID: 5, CreatedAt: time.Date(2024, 5, 31, 20, 0, 0, 0, newYork)})
+
+	codec := pagination.NewCursorCodec([]byte("secret"))
+	keyOf := func(item timedItem) []interface{} { return []interface{}{item.CreatedAt, item.ID} }
+
+	tests := []struct {
+		name string
+		keys []pagination.SortKey
+		want string
+	}{
+		{name: "升序", keys: []pagination.SortKey{{Column: "created_at", Time: true}, {Column: "id"}}, want: "5,3,1,4,2"},
+		{name: "降序", keys: []pagination.SortKey{{Column: "created_at", Desc: true, Time: true}, {Column: "id", Desc: true}}, want: "2,4,1,3,5"},
+		{name: "方向混合", keys: []pagination.SortKey{{Column: "created_at", Desc: true, Time: true}, {Column: "id"}}, want: "2,1,4,3,5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			req := &pagination.CursorRequest{Limit: 2}
+			for {
+				q, err := codec.NewCursorQuery(req, tt.keys)
+				if err != nil {
+					t.Fatalf("创建游标查询失败: %v", err)
+				}
+				var rows []timedItem
+				if err := db.Scopes(q.Scope()).Find(&rows).Error; err != nil {
+					t.Fatalf("查询失败: %v", err)
+				}
+				rows, meta, err := pagination.Paginate(q, rows, keyOf)
+				if err != nil {
+					t.Fatalf("分页失败: %v", err)
+				}
+				for _, r := range rows {
+					ids = append(ids, strconv.Itoa(r.ID))
+				}
+				if !meta.HasMore {
+					break
+				}
+				req = &pagination.CursorRequest{After: meta.NextCursor, Limit: 2}
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("期望按实际时刻的顺序 %s，得到 %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPageResponseLinkHeader(t *testing.T) {
+	u, _ := url.Parse("/api/v1/users?page=2&page_size=10&sort=name")
+	link := pagination.NewPageResponse(35, 2, 10, nil).LinkHeader(u)
+
+	for _, want := range []string{
+		`</api/v1/users?page=1&page_size=10&sort=name>; rel="first"`,
+		`</api/v1/users?page=1&page_size=10&sort=name>; rel="prev"`,
+		`</api/v1/users?page=3&page_size=10&sort=name>; rel="next"`,
+		`</api/v1/users?page=4&page_size=10&sort=name>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Link 头缺少 %s，得到 %s", want, link)
+		}
+	}
+}
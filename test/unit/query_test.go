@@ -0,0 +1,154 @@
+package user_test
+
+import (
+	"base-gin/pkg/query"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var testSchema = query.NewSchema(
+	query.Field{Name: "id", Type: query.TypeInt, Sortable: true},
+	query.Field{Name: "name", Type: query.TypeString, Sortable: true},
+	query.Field{Name: "created_at", Type: query.TypeTime, Sortable: true},
+	query.Field{Name: "deleted", Column: "deleted_at", Type: query.TypeTime, Operators: []query.Operator{query.OpIsNull}},
+)
+
+func TestQueryParse(t *testing.T) {
+	values, _ := url.ParseQuery("filter=name:like:张&filter=created_at:gte:2024-01-01T08:00:00Z&filter=id:in:1|2|3&sort=-created_at,name")
+	q, err := testSchema.Parse(values)
+	if err != nil {
+		t.Fatalf("不期望错误，但得到错误: %v", err)
+	}
+
+	if len(q.Filters) != 3 {
+		t.Fatalf("期望 3 个筛选条件，得到 %d", len(q.Filters))
+	}
+	if q.Filters[1].Operator != query.OpGte {
+		t.Errorf("期望操作符 gte，得到 %s", q.Filters[1].Operator)
+	}
+	if len(q.Sorts) != 2 || !q.Sorts[0].Desc || q.Sorts[1].Desc {
+		t.Errorf("排序解析错误: %+v", q.Sorts)
+	}
+
+	keys := q.SortKeys("id")
+	if len(keys) != 3 || keys[2].Column != "id" || !keys[2].Desc {
+		t.Errorf("期望追加 id 降序作为最后排序键，得到 %+v", keys)
+	}
+	if !keys[0].Time || keys[1].Time || keys[2].Time {
+		t.Errorf("只有时间字段的排序键应标记为时间，得到 %+v", keys)
+	}
+}
+
+func TestQueryParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		codes []string
+	}{
+		{name: "syntax", raw: "filter=name", codes: []string{query.CodeInvalidSyntax}},
+		{name: "unknown field", raw: "filter=password:eq:x", codes: []string{query.CodeUnknownField}},
+		{name: "bad operator", raw: "filter=name:gt:x", codes: []string{query.CodeUnsupportedOperator}},
+		{name: "bad value", raw: "filter=id:eq:abc", codes: []string{query.CodeInvalidValue}},
+		{name: "not sortable", raw: "sort=deleted", codes: []string{query.CodeNotSortable}},
+		{name: "collect all", raw: "filter=id:eq:x&sort=password", codes: []string{query.CodeInvalidValue, query.CodeUnknownField}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.raw)
+			_, err := testSchema.Parse(values)
+
+			var errs query.Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("期望 query.Errors，得到 %v", err)
+			}
+			if len(errs) != len(tt.codes) {
+				t.Fatalf("期望 %d 个错误，得到 %v", len(tt.codes), errs)
+			}
+			for i, code := range tt.codes {
+				if errs[i].Code != code {
+					t.Errorf("期望错误码 %s，得到 %s", code, errs[i].Code)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryScope(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+
+	values, _ := url.ParseQuery("filter=name:like:50%25_off&filter=deleted:isnull:true&filter=id:in:1|2")
+	q, err := testSchema.Parse(values)
+	if err != nil {
+		t.Fatalf("不期望错误，但得到错误: %v", err)
+	}
+
+	stmt := db.Table("users").Scopes(q.Scope()).Find(&[]map[string]interface{}{}).Statement
+	sql := stmt.SQL.String()
+
+	for _, want := range []string{"LOWER(`name`) LIKE ? ESCAPE", "`deleted_at` IS NULL", "`id` IN (?,?)"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL 缺少 %q: %s", want, sql)
+		}
+	}
+	if stmt.Vars[0] != `%50\%\_off%` {
+		t.Errorf("期望 LIKE 通配符被转义，得到 %v", stmt.Vars[0])
+	}
+}
+
+func TestQueryTimeFilter(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	type event struct {
+		ID        int
+		CreatedAt time.Time
+	}
+	if err := db.AutoMigrate(&event{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	// 写入时带 +08:00 偏移，SQLite 中保存为带偏移的文本
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	db.Create(&event{ID: 1, CreatedAt: time.Date(2024, 5, 31, 1, 30, 0, 0, shanghai)})
+	db.Create(&event{ID: 2, CreatedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.Local)})
+
+	tests := []struct {
+		name   string
+		filter string
+		want   []int
+	}{
+		{"不同偏移按实际时刻比较", "created_at:gte:2024-05-30T18:00:00Z", []int{2}},
+		{"不同偏移的上界", "created_at:lt:2024-05-30T18:00:00Z", []int{1}},
+		{"精确时刻相等", "created_at:eq:2024-05-30T17:30:00Z", []int{1}},
+		{"只有日期时 eq 匹配当天", "created_at:eq:2024-06-01", []int{2}},
+		{"只有日期时 gt 从次日开始", "created_at:gt:2024-06-01", nil},
+		{"只有日期时 lte 包含当天", "created_at:lte:2024-06-01", []int{1, 2}},
+		{"只有日期时 lt 不含当天", "created_at:lt:2024-06-01", []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := testSchema.Parse(url.Values{"filter": {tt.filter}})
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			var ids []int
+			if err := db.Model(&event{}).Scopes(q.Scope()).Order("id").Pluck("id", &ids).Error; err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("期望 %v，得到 %v", tt.want, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package user_test
+
+import (
+	"base-gin/internal/interfaces/middleware"
+	"base-gin/internal/interfaces/validation"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Secure(middleware.SecurityHeaders{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: "default-src 'none'; script-src 'nonce-{nonce}'",
+		ReferrerPolicy:        "no-referrer",
+		PermissionsPolicy:     "camera=()",
+		FrameOptions:          "DENY",
+		NoSniff:               true,
+	}))
+	var nonce string
+	r.GET("/", func(c *gin.Context) {
+		nonce = middleware.CSPNonce(c)
+		c.Status(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w
+	}
+	w := serve()
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"Content-Security-Policy":   "default-src 'none'; script-src 'nonce-" + nonce + "'",
+		"Referrer-Policy":           "no-referrer",
+		"Permissions-Policy":        "camera=()",
+		"X-Frame-Options":           "DENY",
+		"X-Content-Type-Options":    "nosniff",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s 期望 %q，得到 %q", name, value, got)
+		}
+	}
+	if len(nonce) < 20 {
+		t.Fatalf("nonce 长度不足: %q", nonce)
+	}
+
+	first := nonce
+	serve()
+	if nonce == first {
+		t.Error("每个请求的 nonce 应不同")
+	}
+}
+
+func TestSecureHeadersReportOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.Secure(middleware.SecurityHeaders{ContentSecurityPolicy: "default-src 'none'", CSPReportOnly: true}))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Header().Get("Content-Security-Policy-Report-Only") != "default-src 'none'" || w.Header().Get("Content-Security-Policy") != "" {
+		t.Errorf("只报告模式应使用 Report-Only 响应头，得到 %v", w.Header())
+	}
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTSMaxAge 为 0 时不应发送 HSTS")
+	}
+}
+
+func TestLimitRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.LimitRequest(middleware.RequestLimits{MaxURLLength: 64, MaxHeaderBytes: 256}))
+	r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   int
+	}{
+		{"正常请求", "/users?page=1", "", http.StatusOK},
+		{"请求地址过长", "/users?q=" + strings.Repeat("a", 64), "", http.StatusRequestURITooLong},
+		{"请求头过大", "/users", strings.Repeat("a", 256), http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Padding", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("期望 %d，得到 %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.MaxBodySize(16))
+	read := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.POST("/default", read)
+	r.POST("/large", middleware.MaxBodySize(64), read)
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"未超过全局限制", "/default", 16, false, http.StatusOK},
+		{"超过全局限制", "/default", 17, false, http.StatusRequestEntityTooLarge},
+		{"未声明长度时读取超过限制", "/default", 17, true, http.StatusRequestEntityTooLarge},
+		{"路由放宽限制", "/large", 64, false, http.StatusOK},
+		{"路由放宽后未声明长度", "/large", 64, true, http.StatusOK},
+		{"超过路由限制", "/large", 65, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("期望 %d，得到 %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	type request struct {
+		Name string `json:"name" binding:"required"`
+	}
+	newRouter := func(rejectUnknown bool) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(middleware.MaxBodySize(64))
+		r.POST("/", middleware.RequireJSON(rejectUnknown), func(c *gin.Context) {
+			var req request
+			if err := validation.BindJSON(c, &req); err != nil {
+				status, message := validation.BindError(err)
+				c.JSON(status, gin.H{"error": message})
+				return
+			}
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+	strict, lenient := newRouter(true), newRouter(false)
+
+	tests := []struct {
+		name        string
+		router      *gin.Engine
+		contentType string
+		body        string
+		want        int
+		wantError   string
+	}{
+		{"JSON 请求", strict, "application/json; charset=utf-8", `{"name":"张三"}`, http.StatusOK, ""},
+		{"缺少 Content-Type", strict, "", `{"name":"张三"}`, http.StatusUnsupportedMediaType, ""},
+		{"表单请求", strict, "application/x-www-form-urlencoded", `name=a`, http.StatusUnsupportedMediaType, ""},
+		{"严格模式拒绝未知字段", strict, "application/json", `{"name":"张三","role":"admin"}`, http.StatusBadRequest, "role"},
+		{"严格模式拒绝多余内容", strict, "application/json", `{"name":"张三"} {}`, http.StatusBadRequest, ""},
+		{"非严格模式忽略未知字段", lenient, "application/json", `{"name":"张三","role":"admin"}`, http.StatusOK, ""},
+		{"仍执行 binding 校验", lenient, "application/json", `{}`, http.StatusBadRequest, ""},
+		{"请求体过大", lenient, "application/json", `{"name":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.ContentLength = -1
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			tt.router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("期望 %d，得到 %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.wantError != "" && !strings.Contains(w.Body.String(), tt.wantError) {
+				t.Errorf("错误信息应包含 %s，得到 %s", tt.wantError, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package user_test
+
+import (
+	"base-gin/pkg/textsearch"
+	"strings"
+	"testing"
+)
+
+func TestTextSearchTokens(t *testing.T) {
+	doc := textsearch.Document("张三丰", "ZhangSan.Feng@Example.com")
+	for _, want := range []string{"张", "张三", "三丰", "丰", "zhangsan", "feng", "example", "com"} {
+		if !strings.Contains(" "+doc+" ", " "+want+" ") {
+			t.Errorf("索引文档缺少词元 %q: %s", want, doc)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "张三丰", want: "张三,三丰"},
+		{query: "张", want: "张"},
+		{query: "Zhang 张三", want: "zhang,张三"},
+		{query: "a@b.com", want: "a,b,com"},
+		{query: `" OR 1=1 --`, want: "or,1"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(textsearch.QueryTokens(tt.query), ","); got != tt.want {
+			t.Errorf("QueryTokens(%q) 期望 %s，得到 %s", tt.query, tt.want, got)
+		}
+	}
+
+	if got := textsearch.FTS5Query([]string{"张三", "fe"}); got != `"张三" "fe"*` {
+		t.Errorf("FTS5 查询不符合预期: %s", got)
+	}
+	if got := textsearch.TSQuery([]string{"张三", "fe"}); got != "张三 & fe:*" {
+		t.Errorf("tsquery 不符合预期: %s", got)
+	}
+}
+
+func TestTextSearchHighlight(t *testing.T) {
+	tests := []struct {
+		text   string
+		tokens []string
+		want   string
+	}{
+		{text: "张三丰", tokens: []string{"张三", "三丰"}, want: "[张三丰]"},
+		{text: "ZhangSan@example.com", tokens: []string{"zhang"}, want: "[Zhang]San@example.com"},
+		{text: "<b>李四</b>", tokens: []string{"李四"}, want: "&lt;b&gt;[李四]&lt;/b&gt;"},
+	}
+	for _, tt := range tests {
+		if got := textsearch.Highlight(tt.text, tt.tokens, "[", "]"); got != tt.want {
+			t.Errorf("Highlight(%q) 期望 %s，得到 %s", tt.text, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package user_test
+
+import (
+	"base-gin/pkg/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		wantErr     bool
+		wantSampled bool
+	}{
+		{"采样", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, true},
+		{"未采样", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", false, false},
+		{"未来版本带扩展字段", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, true},
+		{"00 版本不能带扩展字段", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, false},
+		{"ff 版本", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, false},
+		{"全零链路 ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", true, false},
+		{"全零 span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", true, false},
+		{"大写十六进制", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", true, false},
+		{"长度不足", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", true, false},
+		{"空", "", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := tracing.ParseTraceparent(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("期望错误 %v，得到 %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+				t.Errorf("ID 解析错误: %s %s", sc.TraceID, sc.SpanID)
+			}
+			if sc.Sampled != tt.wantSampled || !sc.Remote {
+				t.Errorf("期望 sampled=%v remote=true，得到 %+v", tt.wantSampled, sc)
+			}
+			if tt.header[:2] == "00" && sc.Traceparent() != tt.header {
+				t.Errorf("格式化结果不一致: %s", sc.Traceparent())
+			}
+		})
+	}
+}
+
+func TestSamplers(t *testing.T) {
+	var low, high tracing.TraceID
+	high[8] = 0xff
+	sampled := tracing.SpanContext{TraceID: high, SpanID: tracing.SpanID{1}, Sampled: true}
+	notSampled := tracing.SpanContext{TraceID: high, SpanID: tracing.SpanID{1}}
+
+	tests := []struct {
+		name    string
+		sampler tracing.Sampler
+		parent  tracing.SpanContext
+		traceID tracing.TraceID
+		want    bool
+	}{
+		{"比例采样命中", tracing.TraceIDRatio(0.5), tracing.SpanContext{}, low, true},
+		{"比例采样未命中", tracing.TraceIDRatio(0.5), tracing.SpanContext{}, high, false},
+		{"比例为 0", tracing.TraceIDRatio(0), tracing.SpanContext{}, low, false},
+		{"比例为 1", tracing.TraceIDRatio(1), tracing.SpanContext{}, high, true},
+		{"沿用父 span 的采样", tracing.ParentBased(tracing.NeverSample()), sampled, high, true},
+		{"沿用父 span 的不采样", tracing.ParentBased(tracing.AlwaysSample()), notSampled, high, false},
+		{"没有父 span 时使用根采样器", tracing.ParentBased(tracing.AlwaysSample()), tracing.SpanContext{}, high, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sampler.ShouldSample(tt.parent, tt.traceID); got != tt.want {
+				t.Errorf("期望 %v，得到 %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// memoryExporter 保存导出的 span
+type memoryExporter struct {
+	mu       sync.Mutex
+	spans    []tracing.SpanData
+	resource []tracing.Attribute
+	shutdown int
+}
+
+func (e *memoryExporter) Export(ctx context.Context, resource []tracing.Attribute, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resource = resource
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Shutdown(ctx context.Context) error {
+	e.shutdown++
+	return nil
+}
+
+func TestTracerSpans(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "test", Exporter: exporter, BatchSize: 2, BatchTimeout: time.Hour})
+
+	remote, _ := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := tracing.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, server := tracer.Start(ctx, "GET /users/:id", tracing.WithKind(tracing.KindServer))
+	childCtx, child := tracer.Start(ctx, "UserService.GetUser", tracing.WithAttributes(tracing.Int("user.id", 1)))
+	_, grandchild := tracer.Start(childCtx, "UserRepository.FindByID")
+	grandchild.RecordError(errors.New("用户不存在"))
+	grandchild.End()
+	child.SetAttributes(tracing.Int("user.id", 2))
+	child.End()
+	child.End()
+	server.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	tracer.Shutdown(context.Background())
+	if exporter.shutdown != 1 {
+		t.Errorf("Exporter 应只关闭一次，实际 %d 次", exporter.shutdown)
+	}
+
+	if len(exporter.spans) != 3 {
+		t.Fatalf("期望导出 3 个 span，得到 %d", len(exporter.spans))
+	}
+	byName := map[string]tracing.SpanData{}
+	for _, span := range exporter.spans {
+		byName[span.Name] = span
+		if span.SpanContext.TraceID != remote.TraceID {
+			t.Errorf("%s 应沿用上游链路 ID", span.Name)
+		}
+	}
+	s, c, g := byName["GET /users/:id"], byName["UserService.GetUser"], byName["UserRepository.FindByID"]
+	if s.ParentSpanID != remote.SpanID || c.ParentSpanID != s.SpanContext.SpanID || g.ParentSpanID != c.SpanContext.SpanID {
+		t.Error("父子关系错误")
+	}
+	if s.Kind != tracing.KindServer || c.Kind != tracing.KindInternal {
+		t.Errorf("span 类型错误: %d %d", s.Kind, c.Kind)
+	}
+	if len(c.Attributes) != 1 || c.Attributes[0].Value != int64(2) {
+		t.Errorf("同名属性应覆盖: %+v", c.Attributes)
+	}
+	if g.StatusCode != tracing.StatusError || len(g.Events) != 1 || g.Events[0].Name != "exception" {
+		t.Errorf("错误记录不正确: %+v", g)
+	}
+	if exporter.resource[0] != tracing.String("service.name", "test") {
+		t.Errorf("资源属性错误: %+v", exporter.resource)
+	}
+
+	// 上游未采样时不记录，但仍传播链路
+	unsampled, _ := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	tracer2 := tracing.NewTracer(tracing.Options{Sampler: tracing.ParentBased(tracing.AlwaysSample()), Exporter: &memoryExporter{}})
+	defer tracer2.Shutdown(context.Background())
+	_, span := tracer2.Start(tracing.ContextWithRemoteSpanContext(context.Background(), unsampled), "x")
+	if span.IsRecording() || span.SpanContext().TraceID != unsampled.TraceID || span.SpanContext().Sampled {
+		t.Errorf("未采样的 span 应沿用链路且不记录: %+v", span.SpanContext())
+	}
+
+	// 未启用追踪时 nil 安全
+	var nilTracer *tracing.Tracer
+	nilCtx, nilSpan := nilTracer.Start(context.Background(), "x")
+	nilSpan.SetAttributes(tracing.String("k", "v"))
+	nilSpan.RecordError(errors.New("x"))
+	nilSpan.End()
+	if nilCtx != context.Background() || nilSpan.SpanContext().IsValid() {
+		t.Error("nil 追踪器应原样返回 context")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		contentType, auth string
+		body              map[string]interface{}
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, auth = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{}`))
+	}))
+	defer collector.Close()
+
+	exporter := tracing.NewOTLPExporter(collector.URL+"/v1/traces", map[string]string{"Authorization": "Bearer t"}, time.Second)
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "svc", Exporter: exporter})
+	_, span := tracer.Start(context.Background(), "op", tracing.WithKind(tracing.KindClient),
+		tracing.WithAttributes(tracing.String("s", "v"), tracing.Int("n", 7), tracing.Bool("b", true), tracing.Float64("f", 1.5)))
+	span.RecordError(errors.New("失败"))
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	if contentType != "application/json" || auth != "Bearer t" {
+		t.Fatalf("请求头错误: %s %s", contentType, auth)
+	}
+
+	encoded, _ := json.Marshal(body)
+	for _, want := range []string{
+		`"key":"service.name","value":{"stringValue":"svc"}`,
+		`"traceId":"` + span.SpanContext().TraceID.String() + `"`,
+		`"spanId":"` + span.SpanContext().SpanID.String() + `"`,
+		`"kind":3`,
+		`"key":"n","value":{"intValue":"7"}`,
+		`"key":"b","value":{"boolValue":true}`,
+		`"key":"f","value":{"doubleValue":1.5}`,
+		`"status":{"code":2,"message":"失败"}`,
+		`"name":"exception"`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("OTLP 请求中缺少 %s:\n%s", want, encoded)
+		}
+	}
+
+	// 接收方返回错误
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		http.Error(w, "bad", http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	err := tracing.NewOTLPExporter(failing.URL, nil, time.Second).Export(context.Background(), nil, []tracing.SpanData{{Name: "x"}})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("期望返回状态码错误，得到 %v", err)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	exporter, err := tracing.NewFileExporter(path)
+	if err != nil {
+		t.Fatalf("创建文件导出失败: %v", err)
+	}
+	tracer := tracing.NewTracer(tracing.Options{ServiceName: "svc", Exporter: exporter})
+	for _, name := range []string{"a", "b"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	tracer.Shutdown(context.Background())
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("期望一批写入一行，得到 %d 行", len(lines))
+	}
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &req); err != nil || len(req.ResourceSpans[0].ScopeSpans[0].Spans) != 2 {
+		t.Errorf("文件内容不是 OTLP/JSON: %v %s", err, lines[0])
+	}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"字符串字面量", "SELECT * FROM users WHERE email = 'a@b.com'", "SELECT * FROM users WHERE email = ?"},
+		{"转义的单引号", "SELECT 'it''s' AS x", "SELECT ? AS x"},
+		{"数字字面量", "SELECT * FROM users WHERE id = 42 LIMIT 10 OFFSET 2.5e3", "SELECT * FROM users WHERE id = ? LIMIT ? OFFSET ?"},
+		{"占位符保持不变", "SELECT * FROM users WHERE id = $1 AND name = ?", "SELECT * FROM users WHERE id = $1 AND name = ?"},
+		{"标识符中的数字", `SELECT col1, "users"."name2" FROM t2`, `SELECT col1, "users"."name2" FROM t2`},
+		{"反引号标识符", "SELECT `order` FROM `t` WHERE x = 'y'", "SELECT `order` FROM `t` WHERE x = ?"},
+		{"IN 列表", "DELETE FROM users WHERE id IN (1,2,3)", "DELETE FROM users WHERE id IN (?,?,?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracing.SanitizeSQL(tt.sql); got != tt.want {
+				t.Errorf("期望 %q，得到 %q", tt.want, got)
+			}
+		})
+	}
+}
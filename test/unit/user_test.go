@@ -2,6 +2,7 @@ package user_test
 
 import (
 	"base-gin/internal/domain/user/entity"
+	"base-gin/internal/domain/user/vo"
 	"testing"
 	"time"
 )
@@ -60,15 +61,15 @@ func TestNewUser(t *testing.T) {
 				return
 			}
 
-			if user.Name != tt.userName {
+			if user.Name.String() != tt.userName {
 				t.Errorf("期望用户名 %s，得到 %s", tt.userName, user.Name)
 			}
 
-			if user.Email != tt.email {
+			if user.Email.String() != tt.email {
 				t.Errorf("期望邮箱 %s，得到 %s", tt.email, user.Email)
 			}
 
-			if user.Password != tt.password {
+			if user.Password.Value() != tt.password {
 				t.Errorf("期望密码 %s，得到 %s", tt.password, user.Password)
 			}
 		})
@@ -78,9 +79,9 @@ func TestNewUser(t *testing.T) {
 func TestUserValidate(t *testing.T) {
 	user := &entity.User{
 		ID:        1,
-		Name:      "张三",
-		Email:     "zhangsan@example.com",
-		Password:  "password123",
+		Name:      vo.RestoreUserName("张三"),
+		Email:     vo.RestoreEmail("zhangsan@example.com"),
+		Password:  vo.RestorePassword("password123"),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -90,8 +91,58 @@ func TestUserValidate(t *testing.T) {
 	}
 
 	// 测试无效邮箱
-	user.Email = "invalid-email"
+	if err := user.UpdateEmail("invalid-email"); err == nil {
+		t.Error("期望邮箱验证失败，但验证通过")
+	}
+
+	// 测试缺少邮箱
+	user.Email = vo.Email{}
 	if err := user.Validate(); err == nil {
 		t.Error("期望邮箱验证失败，但验证通过")
 	}
 }
+
+func TestUserEvents(t *testing.T) {
+	user, err := entity.NewUser("张三", "zhangsan@example.com", "password123")
+	if err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	// 模拟仓储保存后分配 ID
+	user.ID = 7
+	events := user.PullEvents()
+	if len(events) != 1 {
+		t.Fatalf("期望 1 个事件，得到 %d", len(events))
+	}
+	created, ok := events[0].(entity.UserCreated)
+	if !ok || created.UserID != 7 || created.Email != "zhangsan@example.com" {
+		t.Errorf("期望补全 ID 的 UserCreated 事件，得到 %+v", events[0])
+	}
+	if len(user.PullEvents()) != 0 {
+		t.Error("期望取出后事件被清空")
+	}
+
+	// 值未变化时不记录事件
+	user.UpdateName("张三")
+	user.UpdateEmail("ZhangSan@Example.com")
+	user.UpdateName("李四")
+	user.UpdateEmail("lisi@example.com")
+	user.MarkDeleted()
+
+	wantNames := []string{entity.EventUserRenamed, entity.EventUserEmailChanged, entity.EventUserDeleted}
+	events = user.PullEvents()
+	if len(events) != len(wantNames) {
+		t.Fatalf("期望 %d 个事件，得到 %d: %+v", len(wantNames), len(events), events)
+	}
+	for i, e := range events {
+		if e.EventName() != wantNames[i] || e.OccurredAt().IsZero() {
+			t.Errorf("第 %d 个事件期望 %s，得到 %+v", i, wantNames[i], e)
+		}
+	}
+	if renamed := events[0].(entity.UserRenamed); renamed.OldName != "张三" || renamed.NewName != "李四" || renamed.UserID != 7 {
+		t.Errorf("UserRenamed 内容错误: %+v", renamed)
+	}
+	if user.DeletedAt == nil {
+		t.Error("期望 MarkDeleted 设置删除时间")
+	}
+}
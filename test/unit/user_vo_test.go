@@ -0,0 +1,97 @@
+package user_test
+
+import (
+	"base-gin/internal/domain/user/vo"
+	"testing"
+)
+
+func TestNewEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		want      string
+		wantError bool
+	}{
+		{name: "lowercase", raw: "zhangsan@example.com", want: "zhangsan@example.com"},
+		{name: "normalize case and spaces", raw: "  ZhangSan@Example.COM ", want: "zhangsan@example.com"},
+		{name: "empty", raw: "   ", wantError: true},
+		{name: "missing domain", raw: "zhangsan@", wantError: true},
+		{name: "missing tld", raw: "zhangsan@example", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := vo.NewEmail(tt.raw)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("期望错误，但没有得到错误")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("不期望错误，但得到错误: %v", err)
+			}
+			if email.String() != tt.want {
+				t.Errorf("期望邮箱 %s，得到 %s", tt.want, email.String())
+			}
+		})
+	}
+
+	a, _ := vo.NewEmail("A@x.com")
+	b, _ := vo.NewEmail("a@X.com")
+	if !a.Equals(b) {
+		t.Errorf("期望 %s 与 %s 相等", a, b)
+	}
+	if a.Domain() != "x.com" {
+		t.Errorf("期望域名 x.com，得到 %s", a.Domain())
+	}
+}
+
+func TestNewUserName(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		want      string
+		wantError bool
+	}{
+		{name: "chinese", raw: "张三", want: "张三"},
+		{name: "trim", raw: "  李四 ", want: "李四"},
+		{name: "nfkc fullwidth", raw: "ＡＢＣ", want: "ABC"},
+		{name: "too short", raw: "张", wantError: true},
+		{name: "empty", raw: " ", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := vo.NewUserName(tt.raw)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("期望错误，但没有得到错误")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("不期望错误，但得到错误: %v", err)
+			}
+			if name.String() != tt.want {
+				t.Errorf("期望用户名 %s，得到 %s", tt.want, name.String())
+			}
+		})
+	}
+}
+
+func TestNewPassword(t *testing.T) {
+	if _, err := vo.NewPassword("12345"); err == nil {
+		t.Error("期望密码长度校验失败，但验证通过")
+	}
+
+	password, err := vo.NewPassword("password123")
+	if err != nil {
+		t.Fatalf("不期望错误，但得到错误: %v", err)
+	}
+	if password.String() == "password123" {
+		t.Error("密码不应以明文输出")
+	}
+}
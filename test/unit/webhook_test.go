@@ -0,0 +1,186 @@
+package user_test
+
+import (
+	"base-gin/internal/domain/webhook/entity"
+	"base-gin/pkg/webhooksig"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	secret := "whsec_0123456789abcdef"
+	body := []byte(`{"id":"1","type":"user.created"}`)
+	now := time.Unix(1700000000, 0)
+
+	signed := http.Header{}
+	webhooksig.SetHeaders(signed, secret, now, body)
+	if got := signed.Get(webhooksig.HeaderTimestamp); got != "1700000000" {
+		t.Fatalf("时间戳头错误: %s", got)
+	}
+	if got := signed.Get(webhooksig.HeaderSignature); !strings.HasPrefix(got, "sha256=") || len(got) != len("sha256=")+64 {
+		t.Fatalf("签名头格式错误: %s", got)
+	}
+
+	withHeaders := func(timestamp, signature string) http.Header {
+		h := http.Header{}
+		if timestamp != "" {
+			h.Set(webhooksig.HeaderTimestamp, timestamp)
+		}
+		if signature != "" {
+			h.Set(webhooksig.HeaderSignature, signature)
+		}
+		return h
+	}
+	valid := signed.Get(webhooksig.HeaderSignature)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		secret string
+		body   []byte
+		now    time.Time
+		want   error
+	}{
+		{"有效签名", signed, secret, body, now, nil},
+		{"容差内的时间差", signed, secret, body, now.Add(4 * time.Minute), nil},
+		{"多个签名任一匹配", withHeaders("1700000000", "sha256=00, "+valid), secret, body, now, nil},
+		{"密钥错误", signed, "whsec_other_secret_value", body, now, webhooksig.ErrInvalidSignature},
+		{"请求体被篡改", signed, secret, []byte(`{"id":"2"}`), now, webhooksig.ErrInvalidSignature},
+		{"时间戳过期", signed, secret, body, now.Add(10 * time.Minute), webhooksig.ErrInvalidTimestamp},
+		{"时间戳格式错误", withHeaders("abc", valid), secret, body, now, webhooksig.ErrInvalidTimestamp},
+		{"缺少签名", withHeaders("1700000000", ""), secret, body, now, webhooksig.ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhooksig.Verify(tt.header, tt.secret, tt.body, tt.now, 5*time.Minute)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("期望 %v，得到 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWebhookSubscription(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		events []string
+		secret string
+		want   error
+	}{
+		{"自动生成密钥", "https://example.com/hook", []string{"user.created"}, "", nil},
+		{"自定义密钥", "http://localhost:9000/hook", []string{"*"}, "0123456789abcdef", nil},
+		{"密钥过短", "https://example.com/hook", []string{"user.created"}, "short", entity.ErrSecretTooShort},
+		{"非 http 地址", "ftp://example.com/hook", []string{"user.created"}, "", entity.ErrInvalidURL},
+		{"相对地址", "/hook", []string{"user.created"}, "", entity.ErrInvalidURL},
+		{"没有事件", "https://example.com/hook", []string{" ", ""}, "", entity.ErrEventsRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := entity.NewSubscription(tt.url, tt.events, tt.secret, "")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("期望 %v，得到 %v", tt.want, err)
+			}
+			if err == nil && (!s.Active || len(s.Secret) < 16) {
+				t.Errorf("订阅状态错误: %+v", s)
+			}
+		})
+	}
+
+	s, _ := entity.NewSubscription("https://example.com/hook", []string{"user.created", " user.deleted ", "user.created"}, "", "")
+	if strings.Join(s.Events, ",") != "user.created,user.deleted" {
+		t.Errorf("事件未去重: %v", s.Events)
+	}
+	if !strings.HasPrefix(s.Secret, "whsec_") {
+		t.Errorf("自动生成的密钥缺少前缀: %s", s.Secret)
+	}
+	if !s.Matches("user.deleted") || s.Matches("user.renamed") {
+		t.Errorf("事件匹配错误: %v", s.Events)
+	}
+
+	wildcard, _ := entity.NewSubscription("https://example.com/hook", []string{entity.EventWildcard}, "", "")
+	if !wildcard.Matches("user.renamed") {
+		t.Error("通配订阅应匹配所有事件")
+	}
+
+	s.ConsecutiveFailures = 5
+	s.Disable(entity.AutoDisableReason(5))
+	if s.Active || s.DisabledAt == nil || s.DisabledReason == "" {
+		t.Errorf("停用后状态错误: %+v", s)
+	}
+	s.Enable()
+	if !s.Active || s.ConsecutiveFailures != 0 || s.DisabledAt != nil || s.DisabledReason != "" {
+		t.Errorf("重新启用后状态错误: %+v", s)
+	}
+}
+
+func TestWebhookRetryPolicy(t *testing.T) {
+	policy := entity.RetryPolicy{MaxAttempts: 5, Base: 10 * time.Second, Max: time.Minute}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 5 * time.Second, 10 * time.Second},
+		{2, 10 * time.Second, 20 * time.Second},
+		{3, 20 * time.Second, 40 * time.Second},
+		{4, 30 * time.Second, time.Minute},
+		{50, 30 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := policy.Delay(tt.attempt); d < tt.min || d > tt.max {
+				t.Fatalf("第 %d 次失败后的间隔 %v 不在 [%v, %v] 内", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+
+	if d := (entity.RetryPolicy{}).Delay(3); d != 0 {
+		t.Errorf("未配置间隔时应立即重试，得到 %v", d)
+	}
+}
+
+func TestWebhookDeliveryRecordAttempt(t *testing.T) {
+	policy := entity.RetryPolicy{MaxAttempts: 3, Base: time.Second, Max: time.Minute}
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		attempt    entity.DeliveryAttempt
+		wantStatus string
+		wantNext   bool
+	}{
+		{"2xx 成功", entity.DeliveryAttempt{Attempt: 1, ResponseStatus: 204, CreatedAt: now}, entity.DeliverySucceeded, false},
+		{"5xx 等待重试", entity.DeliveryAttempt{Attempt: 1, ResponseStatus: 500, Error: "HTTP 500", CreatedAt: now}, entity.DeliveryPending, true},
+		{"网络错误等待重试", entity.DeliveryAttempt{Attempt: 2, Error: "connection refused", CreatedAt: now}, entity.DeliveryPending, true},
+		{"达到最大次数", entity.DeliveryAttempt{Attempt: 3, ResponseStatus: 410, Error: "HTTP 410", CreatedAt: now}, entity.DeliveryFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &entity.Delivery{Status: entity.DeliveryPending}
+			d.RecordAttempt(&tt.attempt, policy)
+			if d.Status != tt.wantStatus || d.Attempts != tt.attempt.Attempt || d.LastStatusCode != tt.attempt.ResponseStatus {
+				t.Errorf("投递状态错误: %+v", d)
+			}
+			if (d.NextAttemptAt != nil) != tt.wantNext {
+				t.Errorf("下次尝试时间错误: %v", d.NextAttemptAt)
+			}
+			if tt.wantNext && d.NextAttemptAt.Before(now) {
+				t.Errorf("下次尝试时间早于本次尝试: %v", d.NextAttemptAt)
+			}
+		})
+	}
+
+	d := &entity.Delivery{Status: entity.DeliveryFailed, Attempts: 3}
+	d.Redeliver()
+	if d.Status != entity.DeliveryPending || d.NextAttemptAt == nil || d.Attempts != 3 {
+		t.Errorf("重新投递后状态错误: %+v", d)
+	}
+}
@@ -2,44 +2,152 @@ package wire
 
 import (
 	"base-gin/configs"
+	auditService "base-gin/internal/app/audit/service"
+	"base-gin/internal/app/user/job"
 	"base-gin/internal/app/user/service"
-	"base-gin/internal/domain/user/repository"
+	webhookJob "base-gin/internal/app/webhook/job"
+	webhookService "base-gin/internal/app/webhook/service"
+	auditRepository "base-gin/internal/domain/audit/repository"
+	"base-gin/internal/domain/event"
 	domainService "base-gin/internal/domain/user/service"
+	webhookRepository "base-gin/internal/domain/webhook/repository"
 	"base-gin/internal/infrastructure/cache"
 	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/eventstream"
 	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/infrastructure/outbox"
+	"base-gin/internal/infrastructure/repository/audit_impl"
 	"base-gin/internal/infrastructure/repository/user_impl"
+	"base-gin/internal/infrastructure/repository/webhook_impl"
+	"base-gin/internal/infrastructure/telemetry"
+	"base-gin/internal/interfaces/admin"
+	auditHandler "base-gin/internal/interfaces/handler/audit"
+	healthHandler "base-gin/internal/interfaces/handler/health"
 	"base-gin/internal/interfaces/handler/user"
+	"base-gin/internal/interfaces/handler/webhook"
+	"base-gin/internal/interfaces/middleware"
 	"base-gin/internal/interfaces/router"
 	"base-gin/internal/interfaces/validation"
+	"base-gin/pkg/health"
+	"base-gin/pkg/metrics"
+	"base-gin/pkg/pagination"
+	"crypto/rand"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/wire"
 )
 
 // 基础设施层依赖
 var InfraSet = wire.NewSet(
-	configs.LoadConfig,   // 提供 *configs.Config
-	database.NewDB,       // 需要 *configs.Config，提供 *database.DB
-	cache.NewRedisClient, // 需要 *configs.Config，提供 *cache.RedisClient
-	logging.NewLogger,    // 需要 *configs.Config，提供 *logging.Logger
+	configs.LoadConfig,         // 提供 *configs.Config
+	logging.NewLogger,          // 需要 *configs.Config，提供 *logging.Logger
+	NewMetricsRegistry,         // 提供 *metrics.Registry
+	telemetry.NewTracer,        // 需要 *configs.Config，未启用追踪时提供 nil，清理时导出剩余的 span
+	telemetry.NewErrorReporter, // 需要 *configs.Config，未配置 DSN 时提供 nil，清理时发送剩余的事件
+	database.NewDB,             // 需要 *configs.Config、*logging.Logger、*metrics.Registry 和 *tracing.Tracer，提供 *database.DB
+	cache.NewRedisClient,       // 需要 *configs.Config，提供 *cache.RedisClient
+	cache.NewResponseStore,     // 需要 *configs.Config 和 *metrics.Registry，提供 cache.Store
+	wire.Bind(new(service.Transactor), new(*database.DB)),
+)
+
+// NewMetricsRegistry 创建指标注册表并注册 Go 运行时指标，其他模块通过它定义自己的指标
+func NewMetricsRegistry() *metrics.Registry {
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.NewGoCollector())
+	return registry
+}
+
+// 健康检查依赖
+var HealthSet = wire.NewSet(
+	NewHealthChecker, // 需要 *configs.Config、*database.DB 和 *cache.RedisClient，后台任务启动时注册心跳
+)
+
+// NewHealthChecker 创建健康检查并注册数据库、数据迁移、磁盘空间和 Redis 检查。
+// 数据库不可用时就绪检查失败；迁移未完成、Redis 不可用只降级
+func NewHealthChecker(config *configs.Config, db *database.DB, redis *cache.RedisClient) *health.Checker {
+	cfg := config.Health
+	checker := health.NewChecker(
+		time.Duration(cfg.CheckTimeoutMs)*time.Millisecond,
+		time.Duration(cfg.CacheTTLMs)*time.Millisecond,
+		time.Duration(cfg.HeartbeatTimeoutSeconds)*time.Second,
+	)
+	checker.Register("database", db.Ping)
+	checker.Register("migrations", db.CheckMigrations, health.Optional())
+	if path := db.FilePath(); path != "" && cfg.MinFreeDiskMB > 0 {
+		checker.Register("disk", health.DiskSpace(filepath.Dir(path), uint64(cfg.MinFreeDiskMB)<<20))
+	}
+	if cfg.RedisCheckEnabled {
+		checker.Register("redis", redis.Ping, health.Optional())
+	}
+	return checker
+}
+
+// 发件箱依赖
+var OutboxSet = wire.NewSet(
+	outbox.NewStore, // 需要 *configs.Config 和 *database.DB，未启用时提供 nil
+	outbox.NewSink,  // 需要 *configs.Config，未启用时提供 nil，清理时关闭文件或连接
+	outbox.NewRelay, // 需要 *outbox.Store、outbox.Sink 和 *health.Checker
 )
 
 // 仓储层依赖
 var RepositorySet = wire.NewSet(
-	user_impl.NewGormUserRepository, // 需要 *database.DB，提供 *user_impl.GormUserRepository
-	wire.Bind(
-		new(repository.UserRepository),
-		new(*user_impl.GormUserRepository)),
-) // 接口绑定
+	user_impl.NewGormUserRepository,   // 需要 *database.DB 和 *outbox.Store，提供 *user_impl.GormUserRepository
+	user_impl.NewTracedUserRepository, // 需要 *user_impl.GormUserRepository 和 *tracing.Tracer，提供 repository.UserRepository
+)
 
 // 领域服务依赖
 var DomainServiceSet = wire.NewSet(
 	domainService.NewUserDomainService, // 需要 repository.UserRepository，提供 *UserDomainService
 )
 
+// 领域事件依赖
+var EventSet = wire.NewSet(
+	NewEventBus,
+	wire.Bind(new(event.Publisher), new(*event.Bus)),
+)
+
+// 事件推送依赖
+var EventStreamSet = wire.NewSet(
+	eventstream.NewBroker, // 需要 *configs.Config 和 *event.Bus，清理时断开所有 SSE 连接
+)
+
+// NewEventBus 创建进程内事件总线，清理时等待异步处理器执行完毕
+func NewEventBus() (*event.Bus, func()) {
+	bus := event.NewBus()
+	return bus, bus.Close
+}
+
+// 审计依赖
+var AuditSet = wire.NewSet(
+	audit_impl.NewGormAuditRepository, // 需要 *database.DB
+	wire.Bind(
+		new(auditRepository.AuditRepository),
+		new(*audit_impl.GormAuditRepository)),
+	auditService.NewAuditService, // 需要 auditRepository.AuditRepository
+)
+
 // 应用服务依赖
 var ServiceSet = wire.NewSet(
-	service.NewUserService, // 需要 repository.UserRepository 和 *UserDomainService
+	service.NewUserService, // 需要 repository.UserRepository、*UserDomainService、event.Publisher、service.Transactor、*auditService.AuditService 和 *tracing.Tracer
+)
+
+// 定时任务依赖
+var JobSet = wire.NewSet(
+	job.NewPurgeJob, // 需要 *configs.Config、*service.UserService 和 *health.Checker
+)
+
+// Webhook 依赖
+var WebhookSet = wire.NewSet(
+	webhook_impl.NewGormWebhookRepository, // 需要 *database.DB
+	wire.Bind(
+		new(webhookRepository.WebhookRepository),
+		new(*webhook_impl.GormWebhookRepository)),
+	webhookService.NewWebhookService, // 需要 *configs.Config、webhookRepository.WebhookRepository 和 *event.Bus，创建时以事务内处理器订阅所有事件，WEBHOOK_ALLOWED_NETWORKS 无效时返回错误
+	webhookJob.NewDeliveryJob,        // 需要 *configs.Config、*webhookService.WebhookService 和 *health.Checker
 )
 
 // 验证器依赖
@@ -47,12 +155,119 @@ var ValidationSet = wire.NewSet(
 	validation.NewValidator,
 )
 
+// 分页依赖
+var PaginationSet = wire.NewSet(
+	NewCursorCodec,
+)
+
+// NewCursorCodec 根据配置创建分页游标编解码器
+func NewCursorCodec(config *configs.Config) *pagination.CursorCodec {
+	secret := []byte(config.Paging.CursorSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("生成游标密钥失败: %v", err)
+		}
+		log.Println("未配置 CURSOR_SECRET，使用随机密钥，重启后旧游标将失效")
+	}
+	return pagination.NewCursorCodec(secret)
+}
+
 // 控制器依赖
 var HandlerSet = wire.NewSet(
 	user.NewUserHandler,
+	user.NewEventStreamHandler,
+	webhook.NewWebhookHandler,
+	auditHandler.NewAuditHandler,
+	healthHandler.NewHealthHandler,
 )
 
 // 路由依赖
 var RouterSet = wire.NewSet(
+	middleware.NewMaintenanceMode, // 提供 *middleware.MaintenanceMode，由管理端口切换
+	NewCORSRules,                  // 需要 *configs.Config，来源配置无效时返回错误
+	NewIPRules,                    // 需要 *configs.Config，CIDR 或请求头无效时返回错误，运行时由管理端口修改
+	NewCompressor,                 // 需要 *configs.Config，未启用时提供 nil
 	router.NewRouter,
 )
+
+// adminPathPrefixes 使用 CORS_ADMIN_ALLOWED_ORIGINS 的管理接口
+var adminPathPrefixes = []string{"/api/v1/admin", "/api/v1/audit", "/api/v1/webhooks"}
+
+// NewCORSRules 根据配置创建跨域策略，审计操作者请求头自动加入允许的请求头；
+// 配置了 CORS_ADMIN_ALLOWED_ORIGINS 时管理接口只允许这些来源
+func NewCORSRules(config *configs.Config) (*middleware.CORSRules, error) {
+	cfg := config.CORS
+	policy := middleware.CORSPolicy{
+		AllowedOrigins:   splitList(cfg.AllowedOrigins),
+		AllowedMethods:   splitList(cfg.AllowedMethods),
+		AllowedHeaders:   splitList(cfg.AllowedHeaders),
+		ExposedHeaders:   splitList(cfg.ExposedHeaders),
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAgeSeconds) * time.Second,
+	}
+	if actor := config.Audit.ActorHeader; actor != "" && !slices.ContainsFunc(policy.AllowedHeaders, func(h string) bool { return strings.EqualFold(h, actor) }) {
+		policy.AllowedHeaders = append(policy.AllowedHeaders, actor)
+	}
+
+	var overrides []middleware.CORSOverride
+	if cfg.AdminAllowedOrigins != "" {
+		admin := policy
+		admin.AllowedOrigins = splitList(cfg.AdminAllowedOrigins)
+		for _, prefix := range adminPathPrefixes {
+			overrides = append(overrides, middleware.CORSOverride{PathPrefix: prefix, Policy: admin})
+		}
+	}
+	return middleware.NewCORS(policy, overrides...)
+}
+
+// NewIPRules 根据配置创建客户端 IP 规则，分组名与路由中 IPFilter 使用的一致
+func NewIPRules(config *configs.Config) (*middleware.IPRules, error) {
+	cfg := config.ClientIP
+	return middleware.NewIPRules(middleware.IPRulesConfig{
+		TrustedProxies: splitList(cfg.TrustedProxies),
+		Headers:        splitList(cfg.Headers),
+		Groups: map[string]middleware.IPFilterRules{
+			router.IPGroupAPI:     {Allow: splitList(cfg.APIAllow), Deny: splitList(cfg.APIDeny)},
+			router.IPGroupAdmin:   {Allow: splitList(cfg.AdminAllow), Deny: splitList(cfg.AdminDeny)},
+			router.IPGroupMetrics: {Allow: splitList(cfg.MetricsAllow), Deny: splitList(cfg.MetricsDeny)},
+		},
+	})
+}
+
+// NewCompressor 根据配置创建响应压缩，未启用时返回 nil；编码不受支持或压缩级别无效时返回错误
+func NewCompressor(config *configs.Config) (*middleware.Compressor, error) {
+	cfg := config.Compression
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	var codecs []middleware.Codec
+	for _, name := range splitList(cfg.Encodings) {
+		codec, err := middleware.BuiltinCodec(name, cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		codecs = append(codecs, codec)
+	}
+	return middleware.NewCompressor(middleware.CompressionOptions{
+		Codecs:       codecs,
+		MinSize:      cfg.MinSize,
+		ContentTypes: splitList(cfg.ContentTypes),
+	})
+}
+
+// splitList 按逗号拆分配置并去掉空白和空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 管理端口依赖
+var AdminSet = wire.NewSet(
+	admin.NewServer, // 需要 *configs.Config、*gin.Engine、*logging.Logger、*middleware.MaintenanceMode 和 *middleware.IPRules，未启用时提供 nil
+)
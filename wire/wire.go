@@ -4,9 +4,18 @@
 package wire
 
 import (
+	"base-gin/configs"
+	"base-gin/internal/app/user/job"
+	"base-gin/internal/app/user/service"
+	webhookJob "base-gin/internal/app/webhook/job"
+	"base-gin/internal/domain/event"
 	"base-gin/internal/infrastructure/cache"
 	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/eventstream"
 	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/infrastructure/outbox"
+	"base-gin/internal/interfaces/admin"
+	"base-gin/pkg/health"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
@@ -14,24 +23,55 @@ import (
 
 // App 应用结构
 type App struct {
+	Config *configs.Config
 	Router *gin.Engine
 	DB     *database.DB
 	Cache  *cache.RedisClient
 	Logger *logging.Logger
+	// PurgeJob 已删除用户清理任务，由 main 启动
+	PurgeJob *job.PurgeJob
+	// EventBus 领域事件总线，其他模块在此订阅用户事件
+	EventBus *event.Bus
+	// OutboxRelay 发件箱中继，由 main 启动
+	OutboxRelay *outbox.Relay
+	// WebhookJob Webhook 投递任务，由 main 启动
+	WebhookJob *webhookJob.DeliveryJob
+	// Health 健康检查，main 在关闭前将就绪状态置为失败
+	Health *health.Checker
+	// Admin 管理端口，未启用时为 nil，由 main 启动
+	Admin *admin.Server
+	// EventStream SSE 推送，main 关闭服务器时先断开所有连接，长连接不会拖到关闭超时
+	EventStream *eventstream.Broker
 }
 
 // NewApp 创建应用实例
 func NewApp(
+	config *configs.Config,
 	router *gin.Engine,
 	db *database.DB,
 	cache *cache.RedisClient,
 	logger *logging.Logger,
+	purgeJob *job.PurgeJob,
+	eventBus *event.Bus,
+	outboxRelay *outbox.Relay,
+	webhookDeliveryJob *webhookJob.DeliveryJob,
+	healthChecker *health.Checker,
+	adminServer *admin.Server,
+	eventStream *eventstream.Broker,
 ) *App {
 	return &App{
-		Router: router,
-		DB:     db,
-		Cache:  cache,
-		Logger: logger,
+		Config:      config,
+		Router:      router,
+		DB:          db,
+		Cache:       cache,
+		Logger:      logger,
+		PurgeJob:    purgeJob,
+		EventBus:    eventBus,
+		OutboxRelay: outboxRelay,
+		WebhookJob:  webhookDeliveryJob,
+		Health:      healthChecker,
+		Admin:       adminServer,
+		EventStream: eventStream,
 	}
 }
 
@@ -39,12 +79,34 @@ func NewApp(
 func InitializeApp() (*App, func(), error) {
 	panic(wire.Build(
 		InfraSet,         // 基础设施层
+		HealthSet,        // 健康检查
+		OutboxSet,        // 发件箱
 		RepositorySet,    // 仓储层
 		DomainServiceSet, // 领域服务层
+		EventSet,         // 领域事件
+		EventStreamSet,   // 事件推送
+		AuditSet,         // 审计
 		ServiceSet,       // 应用服务层
+		JobSet,           // 定时任务
+		WebhookSet,       // Webhook
 		ValidationSet,    // 验证层
+		PaginationSet,    // 分页
 		HandlerSet,       // 控制器层
 		RouterSet,        // 路由层
+		AdminSet,         // 管理端口
 		NewApp,           // 应用构造函数
 	))
 }
+
+// InitializeUserService 初始化用户应用服务，供不启动 HTTP 服务的命令行工具使用
+func InitializeUserService() (*service.UserService, func(), error) {
+	panic(wire.Build(
+		InfraSet,
+		OutboxSet,
+		RepositorySet,
+		DomainServiceSet,
+		EventSet,
+		AuditSet,
+		ServiceSet,
+	))
+}
@@ -8,15 +8,31 @@ package wire
 
 import (
 	"base-gin/configs"
-	service2 "base-gin/internal/app/user/service"
+	service2 "base-gin/internal/app/audit/service"
+	"base-gin/internal/app/user/job"
+	service3 "base-gin/internal/app/user/service"
+	job2 "base-gin/internal/app/webhook/job"
+	service4 "base-gin/internal/app/webhook/service"
+	"base-gin/internal/domain/event"
 	"base-gin/internal/domain/user/service"
 	"base-gin/internal/infrastructure/cache"
 	"base-gin/internal/infrastructure/database"
+	"base-gin/internal/infrastructure/eventstream"
 	"base-gin/internal/infrastructure/logging"
+	"base-gin/internal/infrastructure/outbox"
+	"base-gin/internal/infrastructure/repository/audit_impl"
 	"base-gin/internal/infrastructure/repository/user_impl"
+	"base-gin/internal/infrastructure/repository/webhook_impl"
+	"base-gin/internal/infrastructure/telemetry"
+	"base-gin/internal/interfaces/admin"
+	"base-gin/internal/interfaces/handler/audit"
+	"base-gin/internal/interfaces/handler/health"
 	"base-gin/internal/interfaces/handler/user"
+	"base-gin/internal/interfaces/handler/webhook"
+	"base-gin/internal/interfaces/middleware"
 	"base-gin/internal/interfaces/router"
 	"base-gin/internal/interfaces/validation"
+	health2 "base-gin/pkg/health"
 	"github.com/gin-gonic/gin"
 )
 
@@ -25,17 +41,132 @@ import (
 // InitializeApp 初始化应用
 func InitializeApp() (*App, func(), error) {
 	config := configs.LoadConfig()
-	db := database.NewDB(config)
-	gormUserRepository := user_impl.NewGormUserRepository(db)
-	userDomainService := service.NewUserDomainService(gormUserRepository)
-	userService := service2.NewUserService(gormUserRepository, userDomainService)
+	logger := logging.NewLogger(config)
+	registry := NewMetricsRegistry()
+	tracer, cleanup, err := telemetry.NewTracer(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := database.NewDB(config, logger, registry, tracer)
+	store := outbox.NewStore(config, db)
+	gormUserRepository := user_impl.NewGormUserRepository(db, store)
+	userRepository := user_impl.NewTracedUserRepository(gormUserRepository, tracer)
+	userDomainService := service.NewUserDomainService(userRepository)
+	bus, cleanup2 := NewEventBus()
+	gormAuditRepository := audit_impl.NewGormAuditRepository(db)
+	auditService := service2.NewAuditService(gormAuditRepository)
+	userService := service3.NewUserService(userRepository, userDomainService, bus, db, auditService, tracer)
 	validator := validation.NewValidator()
-	userHandler := user.NewUserHandler(userService, validator)
-	engine := router.NewRouter(userHandler)
+	cursorCodec := NewCursorCodec(config)
+	userHandler := user.NewUserHandler(userService, validator, cursorCodec)
+	broker, cleanup3 := eventstream.NewBroker(config, bus)
+	eventStreamHandler := user.NewEventStreamHandler(config, broker)
+	gormWebhookRepository := webhook_impl.NewGormWebhookRepository(db)
+	webhookService, err := service4.NewWebhookService(config, gormWebhookRepository, bus)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	webhookHandler := webhook.NewWebhookHandler(webhookService)
+	auditHandler := audit.NewAuditHandler(auditService)
 	redisClient := cache.NewRedisClient(config)
-	logger := logging.NewLogger(config)
-	app := NewApp(engine, db, redisClient, logger)
+	checker := NewHealthChecker(config, db, redisClient)
+	healthHandler := health.NewHealthHandler(checker, logger)
+	cacheStore := cache.NewResponseStore(config, registry)
+	errorReporter, cleanup4, err := telemetry.NewErrorReporter(config)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	maintenanceMode := middleware.NewMaintenanceMode()
+	corsRules, err := NewCORSRules(config)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	ipRules, err := NewIPRules(config)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	compressor, err := NewCompressor(config)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	engine := router.NewRouter(config, userHandler, eventStreamHandler, webhookHandler, auditHandler, healthHandler, cacheStore, registry, tracer, errorReporter, logger, maintenanceMode, corsRules, ipRules, compressor, bus)
+	purgeJob := job.NewPurgeJob(config, userService, checker)
+	sink, cleanup5, err := outbox.NewSink(config)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	relay, err := outbox.NewRelay(config, store, sink, checker)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	deliveryJob := job2.NewDeliveryJob(config, webhookService, checker)
+	server, err := admin.NewServer(config, engine, logger, maintenanceMode, ipRules)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	app := NewApp(config, engine, db, redisClient, logger, purgeJob, bus, relay, deliveryJob, checker, server, broker)
 	return app, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+// InitializeUserService 初始化用户应用服务，供不启动 HTTP 服务的命令行工具使用
+func InitializeUserService() (*service3.UserService, func(), error) {
+	config := configs.LoadConfig()
+	logger := logging.NewLogger(config)
+	registry := NewMetricsRegistry()
+	tracer, cleanup, err := telemetry.NewTracer(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	db := database.NewDB(config, logger, registry, tracer)
+	store := outbox.NewStore(config, db)
+	gormUserRepository := user_impl.NewGormUserRepository(db, store)
+	userRepository := user_impl.NewTracedUserRepository(gormUserRepository, tracer)
+	userDomainService := service.NewUserDomainService(userRepository)
+	bus, cleanup2 := NewEventBus()
+	gormAuditRepository := audit_impl.NewGormAuditRepository(db)
+	auditService := service2.NewAuditService(gormAuditRepository)
+	userService := service3.NewUserService(userRepository, userDomainService, bus, db, auditService, tracer)
+	return userService, func() {
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
@@ -43,21 +174,52 @@ func InitializeApp() (*App, func(), error) {
 
 // App 应用结构
 type App struct {
+	Config *configs.Config
 	Router *gin.Engine
 	DB     *database.DB
 	Cache  *cache.RedisClient
 	Logger *logging.Logger
+	// PurgeJob 已删除用户清理任务，由 main 启动
+	PurgeJob *job.PurgeJob
+	// EventBus 领域事件总线，其他模块在此订阅用户事件
+	EventBus *event.Bus
+	// OutboxRelay 发件箱中继，由 main 启动
+	OutboxRelay *outbox.Relay
+	// WebhookJob Webhook 投递任务，由 main 启动
+	WebhookJob *job2.DeliveryJob
+	// Health 健康检查，main 在关闭前将就绪状态置为失败
+	Health *health2.Checker
+	// Admin 管理端口，未启用时为 nil，由 main 启动
+	Admin *admin.Server
+	// EventStream SSE 推送，main 关闭服务器时先断开所有连接，长连接不会拖到关闭超时
+	EventStream *eventstream.Broker
 }
 
 // NewApp 创建应用实例
-func NewApp(router2 *gin.Engine,
+func NewApp(
+	config *configs.Config, router2 *gin.Engine,
 	db *database.DB, cache2 *cache.RedisClient,
 	logger *logging.Logger,
+	purgeJob *job.PurgeJob,
+	eventBus *event.Bus,
+	outboxRelay *outbox.Relay,
+	webhookDeliveryJob *job2.DeliveryJob,
+	healthChecker *health2.Checker,
+	adminServer *admin.Server,
+	eventStream *eventstream.Broker,
 ) *App {
 	return &App{
-		Router: router2,
-		DB:     db,
-		Cache:  cache2,
-		Logger: logger,
+		Config:      config,
+		Router:      router2,
+		DB:          db,
+		Cache:       cache2,
+		Logger:      logger,
+		PurgeJob:    purgeJob,
+		EventBus:    eventBus,
+		OutboxRelay: outboxRelay,
+		WebhookJob:  webhookDeliveryJob,
+		Health:      healthChecker,
+		Admin:       adminServer,
+		EventStream: eventStream,
 	}
 }